@@ -3,84 +3,130 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
-	"regexp"
 	"strings"
 	"time"
-
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
 )
 
-// AgentLLMClient handles LLM interactions for the agent
+// maxToolResultLen limits how much of a tool's output is sent back to the LLM
+const maxToolResultLen = 4000
+
+// maxToolCallAttempts is how many times the LLM is asked to call a tool before giving up
+const maxToolCallAttempts = 3
+
+// AgentLLMClient handles LLM interactions for the agent. It keeps the whole
+// conversation: the initial request, each tool call and each tool result.
 type AgentLLMClient struct {
-	client *anthropic.Client
+	provider LLMProvider
+	messages []LLMMessage
+	skipped  []LLMToolCall // Extra tool calls from the last turn that were not executed
 }
 
 // AgentResponse represents the LLM's decision for the next action
 type AgentResponse struct {
-	Thought string // The agent's reasoning
-	Action  string // Tool to use: aws_cli, shell, file_edit, terraform_apply, complete
-	Command string // The exact command to execute
+	Thought string          // The agent's reasoning
+	Action  string          // Tool to use: aws_cli, shell, file_edit, terraform_plan, terraform_apply, web_search, complete
+	Command string          // Human-readable form of the tool input
+	Input   *agentToolInput // Structured tool arguments
+	call    LLMToolCall
 }
 
 // NewAgentLLMClient creates a new LLM client for the agent
 func NewAgentLLMClient() (*AgentLLMClient, error) {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("ANTHROPIC_API_KEY not set")
+	provider, err := NewLLMProvider(LLMFeatureAgent)
+	if err != nil {
+		return nil, err
 	}
 
-	client := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
-	)
-
 	return &AgentLLMClient{
-		client: &client,
+		provider: provider,
 	}, nil
 }
 
-// GetNextAction asks the LLM to decide the next action based on context and history
-func (c *AgentLLMClient) GetNextAction(ctx context.Context, agentCtx *AgentContext, history string) (*AgentResponse, error) {
-	prompt := c.buildPrompt(agentCtx, history)
-
-	// Create a fresh context with timeout for this LLM call
-	// Use background context to avoid inheriting cancelled state from previous iterations
-	// But also check if the parent context is already cancelled (user stopped agent)
+// GetNextAction asks the LLM to decide the next action based on context and the conversation so far
+func (c *AgentLLMClient) GetNextAction(ctx context.Context, agentCtx *AgentContext) (*AgentResponse, error) {
+	// Check if the parent context is already cancelled (user stopped agent)
 	select {
 	case <-ctx.Done():
 		return nil, fmt.Errorf("agent cancelled: %w", ctx.Err())
 	default:
 	}
 
-	// Create fresh timeout context from background (60 seconds for complex prompts)
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	if len(c.messages) == 0 {
+		c.messages = append(c.messages, LLMMessage{Role: "user", Content: "This is your first action. Start by investigating the error."})
+	}
 
-	message, err := c.client.Messages.New(timeoutCtx, anthropic.MessageNewParams{
-		Model:     "claude-sonnet-4-5-20250929", // Latest Claude Sonnet 4.5 - best for coding and agents
+	req := LLMRequest{
+		System:    c.buildPrompt(agentCtx),
+		Tools:     agentTools,
 		MaxTokens: 2048,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
-	})
+		Timeout:   60 * time.Second,
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("LLM API call failed: %w", err)
+	for attempt := 0; attempt < maxToolCallAttempts; attempt++ {
+		req.Messages = c.messages
+
+		// Fresh context from background to avoid inheriting cancelled state from previous iterations
+		message, err := c.provider.Complete(context.Background(), req)
+		if err != nil {
+			return nil, fmt.Errorf("LLM API call failed: %w", err)
+		}
+		c.messages = append(c.messages, LLMMessage{Role: "assistant", Content: message.Text, ToolCalls: message.ToolCalls})
+
+		if len(message.ToolCalls) == 0 {
+			c.messages = append(c.messages, LLMMessage{Role: "user", Content: "Call one of the tools to take the next step."})
+			continue
+		}
+
+		call := message.ToolCalls[0]
+		c.skipped = message.ToolCalls[1:]
+
+		input, err := parseAgentToolCall(call)
+		if err != nil {
+			c.messages = append(c.messages, toolResult(call, err.Error(), true))
+			c.answerSkipped()
+			continue
+		}
+
+		thought := strings.TrimSpace(message.Text)
+		if thought == "" {
+			thought = fmt.Sprintf("Using %s", call.Name)
+		}
+
+		return &AgentResponse{
+			Thought: thought,
+			Action:  call.Name,
+			Command: input.display(call.Name),
+			Input:   input,
+			call:    call,
+		}, nil
 	}
 
-	if len(message.Content) == 0 {
-		return nil, fmt.Errorf("empty response from LLM")
+	return nil, fmt.Errorf("LLM did not make a valid tool call after %d attempts", maxToolCallAttempts)
+}
+
+// AddObservation sends the result of executing response's tool call back to the LLM
+func (c *AgentLLMClient) AddObservation(response *AgentResponse, output string, execErr error) {
+	content := truncateOutput(output, maxToolResultLen)
+	if execErr != nil {
+		content = fmt.Sprintf("ERROR: %v\n%s", execErr, content)
 	}
+	c.messages = append(c.messages, toolResult(response.call, content, execErr != nil))
+	c.answerSkipped()
+}
 
-	responseText := message.Content[0].Text
+// Usage returns the tokens, latency and cost of the calls made so far
+func (c *AgentLLMClient) Usage() LLMUsage {
+	return llmUsageOf(c.provider)
+}
 
-	// Parse the response
-	return c.parseResponse(responseText)
+// answerSkipped tells the LLM that extra tool calls in its last turn were not run
+func (c *AgentLLMClient) answerSkipped() {
+	c.messages = append(c.messages, skippedToolResults(c.skipped)...)
+	c.skipped = nil
 }
 
 // buildPrompt constructs the ReAct-style prompt
-func (c *AgentLLMClient) buildPrompt(agentCtx *AgentContext, history string) string {
+func (c *AgentLLMClient) buildPrompt(agentCtx *AgentContext) string {
 	systemContext := fmt.Sprintf(`You are an autonomous AWS infrastructure troubleshooting agent for the Meroku platform. Your goal is to analyze and fix infrastructure deployment errors.
 
 ═══════════════════════════════════════════════════════════════════
@@ -206,7 +252,7 @@ ALL TOOLS AUTOMATICALLY SET: AWS_PROFILE=%s, AWS_REGION=%s
    Example: cat dev.yaml | grep service_name
 
 3. file_edit - Edit configuration files
-   Format: FILE:path|OLD:old_text|NEW:new_text
+   Input: path, old_text, new_text
 
 4. terraform_plan - Preview terraform changes (credentials auto-configured)
    Example: terraform plan
@@ -219,7 +265,7 @@ ALL TOOLS AUTOMATICALLY SET: AWS_PROFILE=%s, AWS_REGION=%s
    Example: AWS ECS service deployment troubleshooting
 
 7. complete - Mark problem as solved
-   Example: Success: Service is now running
+   Example summary: Service is now running
 
 CRITICAL:
 - ALWAYS use terraform_apply tool for terraform operations (not shell)
@@ -246,9 +292,7 @@ SYSTEMATIC DEBUGGING APPROACH
 
 CRITICAL NOTES:
 - Step 7 (REGENERATE) is NOT OPTIONAL - always required after YAML edits
-- For step 9, ALWAYS use terraform_apply tool:
-  ACTION: terraform_apply
-  COMMAND: terraform apply -auto-approve
+- For step 9, ALWAYS use the terraform_apply tool with: terraform apply -auto-approve
 - DO NOT use shell tool with "cd env/{env} && terraform apply" - credentials won't work!
 
 ═══════════════════════════════════════════════════════════════════
@@ -303,67 +347,21 @@ COMMON ISSUES IN MEROKU:
 TASK
 ═══════════════════════════════════════════════════════════════════
 
-Analyze the situation and decide on ONE action to take next
-
-Respond in this EXACT format:
-THOUGHT: [Your reasoning about what to investigate or fix next]
-ACTION: [One of: aws_cli, shell, file_edit, terraform_plan, terraform_apply, web_search, complete]
-COMMAND: [Exact command to run or search query]
-
-EXAMPLES:
-
-Example 1 - Checking ECS service:
-THOUGHT: The error mentions ECS service deployment failure. I should check the service status first to see if tasks are running and what errors might be present. I'll use the region from the context.
-ACTION: aws_cli
-COMMAND: aws ecs describe-services --cluster %s_cluster_%s --services %s_service_%s --region %s
-
-Example 2 - Extracting Terraform errors:
-THOUGHT: The terraform apply failed. I need to extract the actual error message from the verbose terraform output to understand what went wrong. I'll use grep to find "Error:" and get the context. AWS credentials are automatically exported so I don't need to set them.
-ACTION: shell
-COMMAND: cd env/%s && terraform plan 2>&1 | grep -A 10 "Error:"
-
-Example 3 - Checking ECS task failures:
-THOUGHT: The service shows no running tasks. I need to check recent task failures to see why tasks aren't starting.
-ACTION: aws_cli
-COMMAND: aws ecs describe-tasks --cluster %s_cluster_%s --tasks $(aws ecs list-tasks --cluster %s_cluster_%s --service %s_service_%s --desired-status STOPPED --region %s --query 'taskArns[0]' --output text) --region %s
-
-Example 4 - Editing YAML config:
-THOUGHT: The task definition is missing an IAM role configuration. I need to add the IAM role to the YAML configuration and regenerate terraform.
-ACTION: file_edit
-COMMAND: FILE:%s.yaml|OLD:enable_ecs_task_role: false|NEW:enable_ecs_task_role: true
-
-Example 5 - Regenerating after YAML edit (CRITICAL STEP):
-THOUGHT: I've updated the YAML configuration. Now I MUST regenerate the terraform files - this is critical because YAML changes don't take effect until regenerated. Without this step, my changes won't be applied!
-ACTION: shell
-COMMAND: cd %s && ./meroku --generate --env %s
+Analyze the situation and call ONE tool to take the next step.
 
-Example 6 - Searching for documentation:
-THOUGHT: The error message mentions "InvalidParameterException" but I'm not sure what parameter is invalid. Let me search for AWS documentation on this error.
-ACTION: web_search
-COMMAND: AWS ECS InvalidParameterException task definition
-
-Example 7 - Applying fix:
-THOUGHT: I've updated and regenerated the terraform configuration. Now I need to apply the changes to AWS. I'll use the terraform_apply tool which automatically sets AWS credentials.
-ACTION: terraform_apply
-COMMAND: terraform apply -auto-approve
-
-Example 8 - Problem solved:
-THOUGHT: The ECS service is now running with 1 healthy task. The deployment error has been resolved.
-ACTION: complete
-COMMAND: Success: ECS service is healthy with running tasks
+Before each tool call, briefly explain your reasoning in plain text: what you
+learned from the previous result and why this is the right next step.
 
 IMPORTANT:
-- Only take ONE action per iteration
-- Always explain your reasoning in THOUGHT
+- Call exactly one tool per step; its result is returned to you before the next step
 - Use specific resource names from the context
-- For file edits, use the exact format: FILE:path|OLD:old_text|NEW:new_text
 - ⚠️  CRITICAL: After editing ANY YAML file, ALWAYS regenerate!
   Command: ./meroku --generate --env {environment}
   Why: YAML changes don't take effect until Terraform is regenerated
   Consequence: Skipping this = your fix won't work!
 - After regeneration, verify env/{environment}/main.tf was updated
-- Mark as complete only when you've verified the fix worked
-- If stuck, try a different approach or ask for more information`,
+- Call complete only when you've verified the fix worked
+- If stuck, try a different approach or search the web for the error`,
 		agentCtx.Operation,
 		agentCtx.Environment,
 		agentCtx.AWSProfile,
@@ -385,116 +383,18 @@ IMPORTANT:
 		agentCtx.Environment, // CORRECT FORMAT: cd env/%s
 		agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, // EXAMPLE COMMANDS (3x cd env/%s)
 		agentCtx.AWSProfile, // NOTE: AWS_PROFILE=%s
-		// Example 1 - Checking ECS service
-		agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, agentCtx.AWSRegion,
-		// Example 2 - Extracting Terraform errors
-		agentCtx.Environment, // cd env/%s
-		// Example 3 - Checking ECS task failures
-		agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, agentCtx.Environment, agentCtx.AWSRegion, agentCtx.AWSRegion,
-		// Example 4 - Editing YAML config
-		agentCtx.Environment,
-		// Example 5 - Regenerating after YAML edit
-		agentCtx.WorkingDir, agentCtx.Environment,
 	)
 
-	if history != "" {
-		systemContext += fmt.Sprintf("\n\nPREVIOUS ACTIONS:\n%s", history)
-	} else {
-		systemContext += "\n\nThis is your first action. Start by investigating the error."
+	switch agentCtx.Policy {
+	case AgentPolicyReadOnly:
+		systemContext += "\n\nSESSION POLICY: read-only. Mutating actions (terraform_apply, file_edit, mutating aws or shell commands) are blocked. Diagnose the problem with read-only calls, then call complete with the fix you recommend."
+	case AgentPolicyApprove:
+		systemContext += "\n\nSESSION POLICY: the user approves each mutating action (terraform_apply, file_edit, mutating aws or shell commands) after seeing a preview. If an action is rejected, do not retry it; suggest an alternative."
 	}
 
-	return systemContext
-}
-
-// parseResponse extracts THOUGHT, ACTION, and COMMAND from the LLM response
-func (c *AgentLLMClient) parseResponse(response string) (*AgentResponse, error) {
-	// Use regex to extract sections
-	thoughtRegex := regexp.MustCompile(`(?i)THOUGHT:\s*(.+?)(?:\n|$)`)
-	actionRegex := regexp.MustCompile(`(?i)ACTION:\s*(\w+)`)
-	commandRegex := regexp.MustCompile(`(?i)COMMAND:\s*(.+?)(?:\n\n|$)`)
-
-	thoughtMatch := thoughtRegex.FindStringSubmatch(response)
-	actionMatch := actionRegex.FindStringSubmatch(response)
-	commandMatch := commandRegex.FindStringSubmatch(response)
-
-	if len(thoughtMatch) < 2 || len(actionMatch) < 2 || len(commandMatch) < 2 {
-		// Fallback: try line-by-line parsing
-		return c.parseResponseFallback(response)
+	if agentCtx.ReadOnlyAWS {
+		systemContext += "\n\nAWS CREDENTIALS: read-only for this session. Mutating AWS and terraform commands are blocked until the user grants write access; recommend such changes instead of retrying them."
 	}
 
-	thought := strings.TrimSpace(thoughtMatch[1])
-	action := strings.ToLower(strings.TrimSpace(actionMatch[1]))
-	command := strings.TrimSpace(commandMatch[1])
-
-	// Validate action
-	validActions := map[string]bool{
-		"aws_cli":         true,
-		"shell":           true,
-		"file_edit":       true,
-		"terraform_plan":  true,
-		"terraform_apply": true,
-		"web_search":      true,
-		"complete":        true,
-	}
-
-	if !validActions[action] {
-		return nil, fmt.Errorf("invalid action: %s (must be one of: aws_cli, shell, file_edit, terraform_plan, terraform_apply, web_search, complete)", action)
-	}
-
-	return &AgentResponse{
-		Thought: thought,
-		Action:  action,
-		Command: command,
-	}, nil
-}
-
-// parseResponseFallback uses line-by-line parsing as a backup
-func (c *AgentLLMClient) parseResponseFallback(response string) (*AgentResponse, error) {
-	lines := strings.Split(response, "\n")
-	var thought, action, command string
-	var currentSection string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(strings.ToUpper(line), "THOUGHT:") {
-			currentSection = "thought"
-			thought = strings.TrimSpace(strings.TrimPrefix(line, "THOUGHT:"))
-			continue
-		}
-
-		if strings.HasPrefix(strings.ToUpper(line), "ACTION:") {
-			currentSection = "action"
-			action = strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(line), "ACTION:"))
-			action = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "ACTION:")))
-			continue
-		}
-
-		if strings.HasPrefix(strings.ToUpper(line), "COMMAND:") {
-			currentSection = "command"
-			// Remove case-insensitive prefix
-			upper := strings.ToUpper(line)
-			if strings.HasPrefix(upper, "COMMAND:") {
-				command = strings.TrimSpace(line[8:]) // Skip "COMMAND:" (8 chars)
-			}
-			continue
-		}
-
-		// Append to current section if we're in multi-line content
-		if currentSection == "thought" && line != "" && !strings.Contains(line, "ACTION:") {
-			thought += " " + line
-		} else if currentSection == "command" && line != "" && !strings.Contains(line, "THOUGHT:") {
-			command += "\n" + line
-		}
-	}
-
-	if thought == "" || action == "" || command == "" {
-		return nil, fmt.Errorf("failed to parse LLM response - missing required fields. Response: %s", response)
-	}
-
-	return &AgentResponse{
-		Thought: strings.TrimSpace(thought),
-		Action:  strings.TrimSpace(action),
-		Command: strings.TrimSpace(command),
-	}, nil
+	return systemContext
 }
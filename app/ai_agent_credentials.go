@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"gopkg.in/yaml.v2"
+)
+
+// agentReadOnlyPolicyARN is the default session policy for the agent's read credentials
+const agentReadOnlyPolicyARN = "arn:aws:iam::aws:policy/ReadOnlyAccess"
+
+// Credential modes, resolved on first use
+const (
+	agentCredentialsRole        = "role"         // AssumeRole on the configured role
+	agentCredentialsFederation  = "federation"   // GetFederationToken for IAM users
+	agentCredentialsSessionRole = "session-role" // AssumeRole on the role behind an assumed-role or SSO session
+	agentCredentialsProfile     = "profile"      // The user's profile, unscoped; only with agent.allow_unscoped_profile
+	agentCredentialsUnavailable = "unavailable"  // No way to scope the credentials, AWS commands are refused
+)
+
+// agentCredentialEnvVars are removed from a command's environment when it runs
+// with scoped credentials, so the AWS CLI and terraform can't fall back to the profile
+var agentCredentialEnvVars = []string{
+	"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN", "AWS_SECURITY_TOKEN", "AWS_CREDENTIAL_EXPIRATION",
+}
+
+var stsSessionNameUnsafe = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// AgentCredentials are temporary AWS credentials for the agent's commands
+type AgentCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// agentSTSAPI is the part of the STS client the broker uses
+type agentSTSAPI interface {
+	AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+	GetFederationToken(ctx context.Context, params *sts.GetFederationTokenInput, optFns ...func(*sts.Options)) (*sts.GetFederationTokenOutput, error)
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// agentCredentialBroker hands out short-lived credentials for the agent's AWS
+// commands. Reads use the role (or the user's IAM identity) down-scoped by a
+// read-only session policy; writes are only possible after the user grants
+// write access for the session.
+type agentCredentialBroker struct {
+	config      AgentCredentialsConfig
+	profile     string
+	region      string
+	sessionName string
+	newClient   func(ctx context.Context) (agentSTSAPI, error)
+
+	mu          sync.Mutex
+	client      agentSTSAPI
+	mode        string
+	modeReason  string // Why the credentials can't be scoped
+	sessionRole string // Role ARN behind the caller's assumed-role session
+	writeAccess bool
+	cached      map[bool]*AgentCredentials // Keyed by write
+}
+
+// newAgentCredentialBroker reads the agent settings from <env>.yaml in the working directory
+func newAgentCredentialBroker(agentCtx *AgentContext) *agentCredentialBroker {
+	var env Env
+	if data, err := os.ReadFile(filepath.Join(agentCtx.WorkingDir, agentCtx.Environment+".yaml")); err == nil {
+		yaml.Unmarshal(data, &env)
+	}
+
+	broker := &agentCredentialBroker{
+		config:      env.Agent,
+		profile:     agentCtx.AWSProfile,
+		region:      agentCtx.AWSRegion,
+		sessionName: stsSessionNameUnsafe.ReplaceAllString(fmt.Sprintf("meroku-agent-%s-%d", agentCtx.Environment, time.Now().Unix()), "-"),
+		cached:      map[bool]*AgentCredentials{},
+	}
+	broker.newClient = func(ctx context.Context) (agentSTSAPI, error) {
+		var opts []func(*config.LoadOptions) error
+		if broker.region != "" {
+			opts = append(opts, config.WithRegion(broker.region))
+		}
+		cfg, err := loadAWSConfigForProfile(ctx, broker.profile, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		return sts.NewFromConfig(cfg), nil
+	}
+	return broker
+}
+
+// SetWriteAccess grants or revokes write credentials for the rest of the session
+func (b *agentCredentialBroker) SetWriteAccess(granted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writeAccess = granted
+}
+
+// WriteAccess reports whether the user granted write access
+func (b *agentCredentialBroker) WriteAccess() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writeAccess
+}
+
+// ReadOnly reports whether AWS writes would be denied by the session's credentials
+func (b *agentCredentialBroker) ReadOnly(ctx context.Context) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.writeAccess && b.resolveMode(ctx) != agentCredentialsProfile
+}
+
+// Description is a short label for the TUI header, e.g. "read-only role meroku-agent"
+func (b *agentCredentialBroker) Description() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	access := "read-only"
+	if b.writeAccess {
+		access = "write"
+	}
+	switch {
+	case b.config.RoleARN != "":
+		role := b.config.RoleARN
+		if b.writeAccess && b.config.WriteRoleARN != "" {
+			role = b.config.WriteRoleARN
+		}
+		return fmt.Sprintf("%s role %s", access, role[strings.LastIndex(role, "/")+1:])
+	case b.mode == agentCredentialsFederation && !b.writeAccess:
+		return "read-only federation token"
+	case b.mode == agentCredentialsSessionRole && !b.writeAccess:
+		return "read-only session role " + b.sessionRole[strings.LastIndex(b.sessionRole, "/")+1:]
+	case b.mode == agentCredentialsUnavailable:
+		return "no read-only credentials (" + b.modeReason + ")"
+	case b.mode == agentCredentialsProfile && b.modeReason != "":
+		return "profile (unscoped: " + b.modeReason + ")"
+	default:
+		return "profile"
+	}
+}
+
+// Credentials returns credentials for a command, or nil to use the profile.
+// Write credentials are only returned once the user granted write access, and
+// the profile is only used for reads when agent.allow_unscoped_profile is set.
+func (b *agentCredentialBroker) Credentials(ctx context.Context, write bool) (*AgentCredentials, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	write = write && b.writeAccess
+	mode := b.resolveMode(ctx)
+	switch {
+	case mode == agentCredentialsUnavailable:
+		return nil, b.unscopedError()
+	case mode == agentCredentialsProfile:
+		return nil, nil
+	case mode != agentCredentialsRole && write:
+		// Without a configured write role, writes the user granted use the profile
+		return nil, nil
+	}
+
+	if creds := b.cached[write]; creds != nil && time.Until(creds.Expiration) > 5*time.Minute {
+		return creds, nil
+	}
+
+	client, err := b.stsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stsCreds *ststypes.Credentials
+	switch mode {
+	case agentCredentialsRole:
+		input := &sts.AssumeRoleInput{
+			RoleArn:         aws.String(b.config.RoleARN),
+			RoleSessionName: aws.String(b.sessionName),
+		}
+		if write {
+			if b.config.WriteRoleARN != "" {
+				input.RoleArn = aws.String(b.config.WriteRoleARN)
+			}
+		} else {
+			input.PolicyArns = b.readPolicies()
+		}
+		if b.config.ExternalID != "" {
+			input.ExternalId = aws.String(b.config.ExternalID)
+		}
+		if b.config.SessionDuration > 0 {
+			input.DurationSeconds = aws.Int32(int32(b.config.SessionDuration))
+		}
+		out, err := client.AssumeRole(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assume %s: %w", aws.ToString(input.RoleArn), err)
+		}
+		stsCreds = out.Credentials
+	case agentCredentialsSessionRole:
+		input := &sts.AssumeRoleInput{
+			RoleArn:         aws.String(b.sessionRole),
+			RoleSessionName: aws.String(b.sessionName),
+			PolicyArns:      b.readPolicies(),
+		}
+		if b.config.SessionDuration > 0 {
+			input.DurationSeconds = aws.Int32(int32(b.config.SessionDuration))
+		}
+		out, err := client.AssumeRole(ctx, input)
+		if err != nil {
+			// SSO permission set roles only trust the identity provider
+			b.modeReason = "cannot assume " + b.sessionRole[strings.LastIndex(b.sessionRole, "/")+1:]
+			if b.fallbackMode() == agentCredentialsProfile {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%w: %v", b.unscopedError(), err)
+		}
+		stsCreds = out.Credentials
+	default:
+		input := &sts.GetFederationTokenInput{
+			Name:       aws.String("meroku-agent"),
+			PolicyArns: b.readPolicies(),
+		}
+		if b.config.SessionDuration > 0 {
+			input.DurationSeconds = aws.Int32(int32(b.config.SessionDuration))
+		}
+		out, err := client.GetFederationToken(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get a read-only federation token: %w", err)
+		}
+		stsCreds = out.Credentials
+	}
+	if stsCreds == nil {
+		return nil, fmt.Errorf("STS returned no credentials")
+	}
+
+	creds := &AgentCredentials{
+		AccessKeyID:     aws.ToString(stsCreds.AccessKeyId),
+		SecretAccessKey: aws.ToString(stsCreds.SecretAccessKey),
+		SessionToken:    aws.ToString(stsCreds.SessionToken),
+		Expiration:      aws.ToTime(stsCreds.Expiration),
+	}
+	b.cached[write] = creds
+	return creds, nil
+}
+
+// resolveMode picks how credentials are scoped. Without a configured role, IAM
+// users get a federation token and assumed-role sessions re-assume their role,
+// both with the read-only session policy. When neither works the profile is
+// only used if agent.allow_unscoped_profile is set. Callers hold b.mu.
+func (b *agentCredentialBroker) resolveMode(ctx context.Context) string {
+	if b.config.RoleARN != "" {
+		return agentCredentialsRole
+	}
+	if b.mode != "" {
+		return b.mode
+	}
+
+	client, err := b.stsClient(ctx)
+	if err != nil {
+		b.modeReason = "no AWS config"
+		return b.fallbackMode()
+	}
+	identity, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		b.modeReason = "caller identity unknown"
+		return b.fallbackMode()
+	}
+
+	arn := aws.ToString(identity.Arn)
+	switch {
+	case strings.Contains(arn, ":user/"):
+		b.mode = agentCredentialsFederation
+	case strings.Contains(arn, ":assumed-role/"):
+		b.mode = agentCredentialsSessionRole
+		b.sessionRole = roleARNFromSession(arn)
+	default:
+		b.modeReason = "cannot scope " + arn
+		return b.fallbackMode()
+	}
+	return b.mode
+}
+
+// fallbackMode is used when the credentials can't be scoped. Callers hold b.mu.
+func (b *agentCredentialBroker) fallbackMode() string {
+	b.mode = agentCredentialsUnavailable
+	if b.config.AllowUnscopedProfile {
+		b.mode = agentCredentialsProfile
+	}
+	return b.mode
+}
+
+func (b *agentCredentialBroker) unscopedError() error {
+	return fmt.Errorf("no read-only AWS credentials for the agent (%s): set agent.role_arn to a role it can assume, "+
+		"or agent.allow_unscoped_profile: true to run with profile %q unscoped", b.modeReason, b.profile)
+}
+
+// roleARNFromSession turns arn:aws:sts::<account>:assumed-role/<role>/<session>
+// into arn:aws:iam::<account>:role/<role>
+func roleARNFromSession(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 {
+		return arn
+	}
+	resource := strings.Split(parts[5], "/")
+	if len(resource) < 2 {
+		return arn
+	}
+	return fmt.Sprintf("%s:%s:iam::%s:role/%s", parts[0], parts[1], parts[4], resource[1])
+}
+
+func (b *agentCredentialBroker) stsClient(ctx context.Context) (agentSTSAPI, error) {
+	if b.client == nil {
+		client, err := b.newClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b.client = client
+	}
+	return b.client, nil
+}
+
+func (b *agentCredentialBroker) readPolicies() []ststypes.PolicyDescriptorType {
+	arns := b.config.ReadPolicyARNs
+	if len(arns) == 0 {
+		arns = []string{agentReadOnlyPolicyARN}
+	}
+	policies := make([]ststypes.PolicyDescriptorType, 0, len(arns))
+	for _, arn := range arns {
+		policies = append(policies, ststypes.PolicyDescriptorType{Arn: aws.String(arn)})
+	}
+	return policies
+}
+
+// withAgentCredentials replaces any profile or static credentials in env with creds
+func withAgentCredentials(env []string, creds *AgentCredentials) []string {
+	result := make([]string, 0, len(env)+3)
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		drop := false
+		for _, v := range agentCredentialEnvVars {
+			if name == v {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			result = append(result, kv)
+		}
+	}
+	return append(result,
+		"AWS_ACCESS_KEY_ID="+creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY="+creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN="+creds.SessionToken,
+	)
+}
+
+// needsAWSWrite reports whether a mutating action changes AWS resources, as
+// opposed to only local files
+func needsAWSWrite(risk AgentActionRisk) bool {
+	for _, reason := range risk.Reasons {
+		if strings.HasPrefix(reason, "aws ") || strings.HasPrefix(reason, "terraform ") {
+			return true
+		}
+	}
+	return false
+}
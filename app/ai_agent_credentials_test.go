@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// fakeSTS records the STS calls made by the broker
+type fakeSTS struct {
+	callerARN  string
+	assumeErr  error
+	assumed    []*sts.AssumeRoleInput
+	federated  []*sts.GetFederationTokenInput
+	expiration time.Time
+}
+
+func (f *fakeSTS) credentials(id string) *ststypes.Credentials {
+	expiration := f.expiration
+	if expiration.IsZero() {
+		expiration = time.Now().Add(time.Hour)
+	}
+	return &ststypes.Credentials{
+		AccessKeyId:     aws.String(id),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("token"),
+		Expiration:      aws.Time(expiration),
+	}
+}
+
+func (f *fakeSTS) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	f.assumed = append(f.assumed, params)
+	if f.assumeErr != nil {
+		return nil, f.assumeErr
+	}
+	return &sts.AssumeRoleOutput{Credentials: f.credentials("ASIAROLE")}, nil
+}
+
+func (f *fakeSTS) GetFederationToken(ctx context.Context, params *sts.GetFederationTokenInput, optFns ...func(*sts.Options)) (*sts.GetFederationTokenOutput, error) {
+	f.federated = append(f.federated, params)
+	return &sts.GetFederationTokenOutput{Credentials: f.credentials("ASIAFEDERATED")}, nil
+}
+
+func (f *fakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Arn: aws.String(f.callerARN)}, nil
+}
+
+func newTestCredentialBroker(t *testing.T, config string, client *fakeSTS) *agentCredentialBroker {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("project: demo\nenv: dev\n"+config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	broker := newAgentCredentialBroker(&AgentContext{Environment: "dev", WorkingDir: dir, AWSProfile: "demo-admin"})
+	broker.newClient = func(ctx context.Context) (agentSTSAPI, error) { return client, nil }
+	return broker
+}
+
+func TestAgentCredentialBrokerRole(t *testing.T) {
+	client := &fakeSTS{}
+	broker := newTestCredentialBroker(t, `agent:
+  role_arn: arn:aws:iam::123456789012:role/meroku-agent
+  write_role_arn: arn:aws:iam::123456789012:role/meroku-agent-write
+  external_id: ext-1
+`, client)
+	ctx := context.Background()
+
+	// Writes are denied until the user opts in
+	for _, write := range []bool{false, true} {
+		creds, err := broker.Credentials(ctx, write)
+		if err != nil || creds == nil || creds.AccessKeyID != "ASIAROLE" {
+			t.Fatalf("Credentials(%v) = %+v, %v", write, creds, err)
+		}
+	}
+	if len(client.assumed) != 1 {
+		t.Fatalf("AssumeRole called %d times, want 1 (cached)", len(client.assumed))
+	}
+	read := client.assumed[0]
+	if aws.ToString(read.RoleArn) != "arn:aws:iam::123456789012:role/meroku-agent" || aws.ToString(read.ExternalId) != "ext-1" ||
+		len(read.PolicyArns) != 1 || aws.ToString(read.PolicyArns[0].Arn) != agentReadOnlyPolicyARN {
+		t.Errorf("unexpected read AssumeRole input: %+v", read)
+	}
+	if !broker.ReadOnly(ctx) || broker.Description() != "read-only role meroku-agent" {
+		t.Errorf("ReadOnly() = %v, Description() = %q", broker.ReadOnly(ctx), broker.Description())
+	}
+
+	broker.SetWriteAccess(true)
+	if _, err := broker.Credentials(ctx, true); err != nil {
+		t.Fatalf("Credentials(write) error = %v", err)
+	}
+	write := client.assumed[len(client.assumed)-1]
+	if aws.ToString(write.RoleArn) != "arn:aws:iam::123456789012:role/meroku-agent-write" || len(write.PolicyArns) != 0 {
+		t.Errorf("unexpected write AssumeRole input: %+v", write)
+	}
+	if broker.ReadOnly(ctx) || broker.Description() != "write role meroku-agent-write" {
+		t.Errorf("ReadOnly() = %v, Description() = %q", broker.ReadOnly(ctx), broker.Description())
+	}
+}
+
+func TestAgentCredentialBrokerRefreshesExpiringCredentials(t *testing.T) {
+	client := &fakeSTS{expiration: time.Now().Add(2 * time.Minute)}
+	broker := newTestCredentialBroker(t, "agent:\n  role_arn: arn:aws:iam::123456789012:role/meroku-agent\n", client)
+
+	for i := 0; i < 2; i++ {
+		if _, err := broker.Credentials(context.Background(), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(client.assumed) != 2 {
+		t.Errorf("AssumeRole called %d times, want 2", len(client.assumed))
+	}
+}
+
+func TestAgentCredentialBrokerWithoutRole(t *testing.T) {
+	t.Run("IAM user gets a federation token", func(t *testing.T) {
+		client := &fakeSTS{callerARN: "arn:aws:iam::123456789012:user/jo"}
+		broker := newTestCredentialBroker(t, "", client)
+
+		creds, err := broker.Credentials(context.Background(), false)
+		if err != nil || creds == nil || creds.AccessKeyID != "ASIAFEDERATED" {
+			t.Fatalf("Credentials() = %+v, %v", creds, err)
+		}
+		if len(client.federated) != 1 || aws.ToString(client.federated[0].PolicyArns[0].Arn) != agentReadOnlyPolicyARN {
+			t.Errorf("unexpected federation input: %+v", client.federated)
+		}
+
+		// Federation tokens can't call IAM, so granted writes use the profile
+		broker.SetWriteAccess(true)
+		if creds, err := broker.Credentials(context.Background(), true); err != nil || creds != nil {
+			t.Errorf("Credentials(write) = %+v, %v; want the profile", creds, err)
+		}
+	})
+
+	t.Run("assumed-role session re-assumes its role read-only", func(t *testing.T) {
+		client := &fakeSTS{callerARN: "arn:aws:sts::123456789012:assumed-role/deployer/jo"}
+		broker := newTestCredentialBroker(t, "", client)
+
+		creds, err := broker.Credentials(context.Background(), false)
+		if err != nil || creds == nil || creds.AccessKeyID != "ASIAROLE" {
+			t.Fatalf("Credentials() = %+v, %v", creds, err)
+		}
+		if len(client.assumed) != 1 || aws.ToString(client.assumed[0].RoleArn) != "arn:aws:iam::123456789012:role/deployer" ||
+			len(client.assumed[0].PolicyArns) != 1 || aws.ToString(client.assumed[0].PolicyArns[0].Arn) != agentReadOnlyPolicyARN {
+			t.Errorf("unexpected AssumeRole input: %+v", client.assumed)
+		}
+		if !broker.ReadOnly(context.Background()) || broker.Description() != "read-only session role deployer" {
+			t.Errorf("ReadOnly() = %v, Description() = %q", broker.ReadOnly(context.Background()), broker.Description())
+		}
+	})
+
+	t.Run("SSO session that can't be scoped fails closed", func(t *testing.T) {
+		client := &fakeSTS{callerARN: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_0123/jo", assumeErr: errors.New("AccessDenied")}
+		broker := newTestCredentialBroker(t, "", client)
+
+		if creds, err := broker.Credentials(context.Background(), false); err == nil || creds != nil || !strings.Contains(err.Error(), "allow_unscoped_profile") {
+			t.Fatalf("Credentials() = %+v, %v; want an error", creds, err)
+		}
+		if !strings.Contains(broker.Description(), "no read-only credentials") {
+			t.Errorf("Description() = %q", broker.Description())
+		}
+	})
+
+	t.Run("SSO session uses the profile when explicitly allowed", func(t *testing.T) {
+		client := &fakeSTS{callerARN: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_0123/jo", assumeErr: errors.New("AccessDenied")}
+		broker := newTestCredentialBroker(t, "agent:\n  allow_unscoped_profile: true\n", client)
+
+		if creds, err := broker.Credentials(context.Background(), false); err != nil || creds != nil {
+			t.Fatalf("Credentials() = %+v, %v; want the profile", creds, err)
+		}
+		if broker.ReadOnly(context.Background()) || !strings.Contains(broker.Description(), "unscoped") {
+			t.Errorf("ReadOnly() = %v, Description() = %q", broker.ReadOnly(context.Background()), broker.Description())
+		}
+	})
+}
+
+func TestAgentExecutorUsesScopedCredentials(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "demo-admin")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "long-term")
+
+	broker := newTestCredentialBroker(t, "agent:\n  role_arn: arn:aws:iam::123456789012:role/meroku-agent\n", &fakeSTS{})
+	executor := &AgentExecutor{context: &AgentContext{AWSProfile: "demo-admin", AWSRegion: "eu-west-1"}, credentials: broker}
+
+	env, err := executor.awsEnv(context.Background(), false)
+	if err != nil {
+		t.Fatalf("awsEnv() error = %v", err)
+	}
+	for _, want := range []string{"AWS_ACCESS_KEY_ID=ASIAROLE", "AWS_SESSION_TOKEN=token", "AWS_REGION=eu-west-1"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("env is missing %s", want)
+		}
+	}
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "AWS_PROFILE=") || kv == "AWS_SECRET_ACCESS_KEY=long-term" {
+			t.Errorf("env still contains %s", kv)
+		}
+	}
+}
+
+func TestAIAgentBlocksAWSWritesWithReadOnlyCredentials(t *testing.T) {
+	agent := newPolicyTestAgent(t, AgentPolicyAutonomous)
+	agent.executor.credentials = newTestCredentialBroker(t, "agent:\n  role_arn: arn:aws:iam::123456789012:role/meroku-agent\n", &fakeSTS{})
+
+	update := &AgentResponse{Action: "aws_cli", Input: &agentToolInput{Command: "aws ecs update-service --cluster c --service s --force-new-deployment"}}
+	iter := &AgentIteration{}
+	if err := agent.authorize(iter, update); err == nil || iter.Status != "blocked" || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("authorize() = %v, status %q", err, iter.Status)
+	}
+
+	// Local edits don't need AWS credentials
+	if err := agent.authorize(&AgentIteration{}, fileEditResponse()); err != nil {
+		t.Errorf("authorize(file_edit) = %v", err)
+	}
+
+	agent.SetWriteAccess(true)
+	if err := agent.authorize(&AgentIteration{}, update); err != nil {
+		t.Errorf("authorize() after granting write access = %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AgentScenario is a recorded troubleshooting session that replays the agent
+// offline: the initial error, scripted LLM responses and fake outputs for
+// every command the agent runs. validateCommand and the session policy still
+// run for real, so prompt and security changes can be regression-tested.
+type AgentScenario struct {
+	Name           string              `json:"name"`
+	Description    string              `json:"description,omitempty"`
+	Error          ErrorContext        `json:"error"`
+	Policy         AgentPolicy         `json:"policy,omitempty"`          // Defaults to autonomous
+	Approvals      []bool              `json:"approvals,omitempty"`       // Answers to approval prompts, in order
+	IterationLimit int                 `json:"iteration_limit,omitempty"` // Defaults to the agent's limit
+	Files          map[string]string   `json:"files,omitempty"`           // Project files, relative to the working directory
+	LLM            []LLMExchange       `json:"llm"`
+	Commands       []ScenarioCommand   `json:"commands,omitempty"`
+	Expect         ScenarioExpectation `json:"expect"`
+}
+
+// ScenarioCommand is the fake result for commands (or web search queries)
+// containing Match. The first matching entry wins.
+type ScenarioCommand struct {
+	Match  string `json:"match"`
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"` // Makes the command fail with this message
+}
+
+// ScenarioExpectation is what a scenario asserts once the agent stops
+type ScenarioExpectation struct {
+	Actions        []string          `json:"actions,omitempty"`         // Tool names in order, including complete
+	SecurityBlocks []string          `json:"security_blocks,omitempty"` // Substrings of commands rejected by validateCommand
+	PolicyBlocks   []string          `json:"policy_blocks,omitempty"`   // Substrings of commands blocked by the policy or the user
+	Outcome        string            `json:"outcome"`                   // success, failed, cancelled or error
+	Files          map[string]string `json:"files,omitempty"`           // Substrings each file must contain afterwards
+}
+
+// AgentScenarioResult is what happened when a scenario was replayed
+type AgentScenarioResult struct {
+	Name           string
+	Outcome        string
+	Actions        []string
+	SecurityBlocks []string
+	PolicyBlocks   []string
+	Unmatched      []string // Commands with no recorded output
+	Iterations     []AgentIteration
+	Duration       time.Duration
+	Failures       []string // Expectations that were not met
+}
+
+// Passed reports whether every expectation was met
+func (r *AgentScenarioResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// LoadAgentScenario reads a scenario from a JSON file
+func LoadAgentScenario(path string) (*AgentScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scenario %s: %w", path, err)
+	}
+	var scenario AgentScenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("error parsing scenario %s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &scenario, nil
+}
+
+// LoadAgentScenarios loads a scenario file, or every *.json file in a directory
+func LoadAgentScenarios(path string) ([]*AgentScenario, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	var scenarios []*AgentScenario
+	for _, file := range files {
+		scenario, err := LoadAgentScenario(file)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
+
+// scenarioRunner replaces the executor's command runner with recorded outputs
+type scenarioRunner struct {
+	mu        sync.Mutex
+	commands  []ScenarioCommand
+	unmatched []string
+}
+
+func (r *scenarioRunner) RunCommand(ctx context.Context, dir string, env []string, command string) (string, error) {
+	return r.lookup(command)
+}
+
+func (r *scenarioRunner) WebSearch(ctx context.Context, query string) (string, error) {
+	return r.lookup(query)
+}
+
+func (r *scenarioRunner) lookup(command string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.commands {
+		if strings.Contains(command, c.Match) {
+			if c.Error != "" {
+				return c.Output, errors.New(c.Error)
+			}
+			return c.Output, nil
+		}
+	}
+	r.unmatched = append(r.unmatched, command)
+	return "", fmt.Errorf("no recorded output for %q", command)
+}
+
+// RunAgentScenario replays a scenario through AIAgent.Start in a temporary
+// project directory and checks the expectations
+func RunAgentScenario(scenario *AgentScenario) (*AgentScenarioResult, error) {
+	workingDir, err := os.MkdirTemp("", "meroku-agent-eval-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scenario directory: %w", err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	// Terraform tools require the environment directory to exist
+	if err := os.MkdirAll(filepath.Join(workingDir, "env", scenario.Error.Environment), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create scenario directory: %w", err)
+	}
+	for name, content := range scenario.Files {
+		path := filepath.Join(workingDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to write scenario file %s: %w", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write scenario file %s: %w", name, err)
+		}
+	}
+
+	agentContext := newAgentContext(scenario.Error, workingDir)
+	agentContext.Policy = scenario.Policy
+	if agentContext.Policy == "" {
+		agentContext.Policy = AgentPolicyAutonomous
+	}
+
+	provider := newFixtureProvider(scenario.LLM...)
+	updateChan := make(chan AgentUpdate, 10)
+	agent := newAIAgent(agentContext, updateChan, &AgentLLMClient{provider: provider})
+	agent.session = nil // Scenarios don't leave transcripts behind
+	runner := &scenarioRunner{commands: scenario.Commands}
+	agent.executor.runner = runner
+	agent.executor.credentials = nil // Recorded commands never reach AWS
+	if scenario.IterationLimit > 0 {
+		agent.state.IterationLimit = scenario.IterationLimit
+	}
+
+	result := &AgentScenarioResult{Name: scenario.Name}
+
+	// Answer approval prompts from the script; unscripted prompts are rejected
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		approvals := scenario.Approvals
+		for {
+			select {
+			case update := <-updateChan:
+				if update.Type != "approval_required" {
+					continue
+				}
+				approved := false
+				if len(approvals) > 0 {
+					approved, approvals = approvals[0], approvals[1:]
+				} else {
+					result.Failures = append(result.Failures, fmt.Sprintf("unexpected approval request for %q", update.Iteration.Command))
+				}
+				agent.Approve(approved)
+			case <-agent.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	runErr := agent.Start()
+	result.Duration = time.Since(start)
+	agent.Stop()
+	<-done
+
+	state := agent.GetState()
+	result.Iterations = state.Iterations
+	result.Outcome = state.FinalOutcome
+	if result.Outcome == "" {
+		result.Outcome = "error"
+	}
+	for _, iter := range state.Iterations {
+		result.Actions = append(result.Actions, iter.Action)
+		switch {
+		case iter.Status == "blocked":
+			result.PolicyBlocks = append(result.PolicyBlocks, iter.Command)
+		case strings.Contains(iter.ErrorDetail, "command validation failed"):
+			result.SecurityBlocks = append(result.SecurityBlocks, iter.Command)
+		}
+	}
+	result.Unmatched = runner.unmatched
+
+	result.Failures = append(result.Failures, scenario.check(result, workingDir)...)
+	if remaining := provider.remaining(); remaining > 0 && result.Outcome != "error" {
+		result.Failures = append(result.Failures, fmt.Sprintf("%d scripted LLM responses were not used", remaining))
+	}
+	if result.Outcome == "error" && scenario.Expect.Outcome != "error" && runErr != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("agent error: %v", runErr))
+	}
+
+	return result, nil
+}
+
+// check compares a replay with the scenario's expectations
+func (s *AgentScenario) check(result *AgentScenarioResult, workingDir string) []string {
+	var failures []string
+	expect := s.Expect
+
+	if expect.Outcome != "" && result.Outcome != expect.Outcome {
+		failures = append(failures, fmt.Sprintf("outcome = %s, want %s", result.Outcome, expect.Outcome))
+	}
+	if expect.Actions != nil && strings.Join(result.Actions, ",") != strings.Join(expect.Actions, ",") {
+		failures = append(failures, fmt.Sprintf("actions = %v, want %v", result.Actions, expect.Actions))
+	}
+	failures = append(failures, matchCommands("security blocks", result.SecurityBlocks, expect.SecurityBlocks)...)
+	failures = append(failures, matchCommands("policy blocks", result.PolicyBlocks, expect.PolicyBlocks)...)
+	for _, command := range result.Unmatched {
+		failures = append(failures, fmt.Sprintf("no recorded output for command %q", command))
+	}
+
+	for name, want := range expect.Files {
+		content, err := os.ReadFile(filepath.Join(workingDir, name))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("file %s: %v", name, err))
+		} else if !strings.Contains(string(content), want) {
+			failures = append(failures, fmt.Sprintf("file %s does not contain %q", name, want))
+		}
+	}
+	return failures
+}
+
+// matchCommands checks that each actual command contains the expected substring at the same position
+func matchCommands(kind string, got, want []string) []string {
+	if len(got) != len(want) {
+		return []string{fmt.Sprintf("%s = %q, want %q", kind, got, want)}
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			return []string{fmt.Sprintf("%s = %q, want %q", kind, got, want)}
+		}
+	}
+	return nil
+}
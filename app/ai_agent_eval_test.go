@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestAgentScenarios replays every recorded scenario offline
+func TestAgentScenarios(t *testing.T) {
+	scenarios, err := LoadAgentScenarios("testdata/agent_scenarios")
+	if err != nil {
+		t.Fatalf("LoadAgentScenarios() error = %v", err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatal("no scenarios found")
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			result, err := RunAgentScenario(scenario)
+			if err != nil {
+				t.Fatalf("RunAgentScenario() error = %v", err)
+			}
+			for _, failure := range result.Failures {
+				t.Error(failure)
+			}
+		})
+	}
+}
+
+func TestRunAgentScenarioReportsMismatches(t *testing.T) {
+	scenario := &AgentScenario{
+		Name:  "mismatch",
+		Error: ErrorContext{Environment: "dev", Errors: []string{"boom"}},
+		LLM: []LLMExchange{
+			{Response: LLMResponse{ToolCalls: []LLMToolCall{{ID: "call_1", Name: "aws_cli", Input: json.RawMessage(`{"command":"aws ecs list-clusters"}`)}}}},
+			{Response: LLMResponse{ToolCalls: []LLMToolCall{{ID: "call_2", Name: "complete", Input: json.RawMessage(`{}`)}}}},
+		},
+		Expect: ScenarioExpectation{
+			Actions:        []string{"complete"},
+			SecurityBlocks: []string{"rm -rf"},
+			Outcome:        "failed",
+		},
+	}
+
+	result, err := RunAgentScenario(scenario)
+	if err != nil {
+		t.Fatalf("RunAgentScenario() error = %v", err)
+	}
+	if result.Passed() {
+		t.Fatal("expected the scenario to fail")
+	}
+
+	failures := strings.Join(result.Failures, "\n")
+	for _, want := range []string{"outcome = success, want failed", "actions = [aws_cli complete]", "security blocks", `no recorded output for command "aws ecs list-clusters"`} {
+		if !strings.Contains(failures, want) {
+			t.Errorf("failures missing %q:\n%s", want, failures)
+		}
+	}
+}
@@ -13,14 +13,80 @@ import (
 
 // AgentExecutor handles the execution of different tool types
 type AgentExecutor struct {
-	context *AgentContext
+	context     *AgentContext
+	runner      agentCommandRunner
+	credentials *agentCredentialBroker // Scoped AWS credentials; nil uses the profile
+}
+
+// agentCommandRunner runs validated commands and web searches for the executor.
+// Evaluation scenarios replace it with recorded outputs.
+type agentCommandRunner interface {
+	RunCommand(ctx context.Context, dir string, env []string, command string) (string, error)
+	WebSearch(ctx context.Context, query string) (string, error)
+}
+
+// hostCommandRunner runs commands with sh -c on the local machine
+type hostCommandRunner struct{}
+
+func (hostCommandRunner) RunCommand(ctx context.Context, dir string, env []string, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	output := stdout.String()
+	if stderr.Len() > 0 {
+		// Include stderr in output
+		if output != "" {
+			output += "\n--- STDERR ---\n"
+		}
+		output += stderr.String()
+	}
+	return output, err
+}
+
+func (hostCommandRunner) WebSearch(ctx context.Context, query string) (string, error) {
+	return ExecuteWebSearch(ctx, query)
 }
 
 // NewAgentExecutor creates a new executor
 func NewAgentExecutor(ctx *AgentContext) *AgentExecutor {
 	return &AgentExecutor{
-		context: ctx,
+		context:     ctx,
+		runner:      hostCommandRunner{},
+		credentials: newAgentCredentialBroker(ctx),
+	}
+}
+
+// awsEnv returns the environment for a command that may call AWS. Commands run
+// with the broker's scoped credentials; write credentials are only used for
+// mutating commands, once the user has granted write access.
+func (e *AgentExecutor) awsEnv(ctx context.Context, write bool) ([]string, error) {
+	env := os.Environ()
+
+	var creds *AgentCredentials
+	if e.credentials != nil {
+		var err error
+		if creds, err = e.credentials.Credentials(ctx, write); err != nil {
+			return nil, fmt.Errorf("failed to get scoped AWS credentials: %w", err)
+		}
+	}
+	if creds != nil {
+		env = withAgentCredentials(env, creds)
+	} else if e.context.AWSProfile != "" {
+		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", e.context.AWSProfile))
+	}
+
+	if e.context.AWSRegion != "" {
+		env = append(env, fmt.Sprintf("AWS_REGION=%s", e.context.AWSRegion))
+		env = append(env, fmt.Sprintf("AWS_DEFAULT_REGION=%s", e.context.AWSRegion))
 	}
+	return env, nil
 }
 
 // ExecuteAWSCLI runs AWS CLI commands
@@ -34,37 +100,16 @@ func (e *AgentExecutor) ExecuteAWSCLI(ctx context.Context, command string) (stri
 	}
 
 	// Set AWS environment variables
-	env := os.Environ()
-	if e.context.AWSProfile != "" {
-		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", e.context.AWSProfile))
-	}
-	if e.context.AWSRegion != "" {
-		env = append(env, fmt.Sprintf("AWS_REGION=%s", e.context.AWSRegion))
-		env = append(env, fmt.Sprintf("AWS_DEFAULT_REGION=%s", e.context.AWSRegion))
+	env, err := e.awsEnv(ctx, classifyShellCommand(command).Mutating)
+	if err != nil {
+		return "", err
 	}
 
 	// Create command with timeout
 	cmdCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, "sh", "-c", command)
-	cmd.Dir = e.context.WorkingDir
-	cmd.Env = env
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		// Include stderr in output
-		if output != "" {
-			output += "\n--- STDERR ---\n"
-		}
-		output += stderr.String()
-	}
+	output, err := e.runner.RunCommand(cmdCtx, e.context.WorkingDir, env, command)
 
 	if err != nil {
 		return output, fmt.Errorf("AWS CLI command failed: %w", err)
@@ -81,35 +126,16 @@ func (e *AgentExecutor) ExecuteShell(ctx context.Context, command string) (strin
 	}
 
 	// Set environment variables
-	env := os.Environ()
-	if e.context.AWSProfile != "" {
-		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", e.context.AWSProfile))
-	}
-	if e.context.AWSRegion != "" {
-		env = append(env, fmt.Sprintf("AWS_REGION=%s", e.context.AWSRegion))
+	env, err := e.awsEnv(ctx, classifyShellCommand(command).Mutating)
+	if err != nil {
+		return "", err
 	}
 
 	// Create command with timeout
 	cmdCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, "sh", "-c", command)
-	cmd.Dir = e.context.WorkingDir
-	cmd.Env = env
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		if output != "" {
-			output += "\n--- STDERR ---\n"
-		}
-		output += stderr.String()
-	}
+	output, err := e.runner.RunCommand(cmdCtx, e.context.WorkingDir, env, command)
 
 	if err != nil {
 		return output, fmt.Errorf("shell command failed: %w", err)
@@ -118,28 +144,8 @@ func (e *AgentExecutor) ExecuteShell(ctx context.Context, command string) (strin
 	return output, nil
 }
 
-// ExecuteFileEdit performs file editing operations
-// Command format: "FILE:/path/to/file|OLD:old text|NEW:new text"
-func (e *AgentExecutor) ExecuteFileEdit(ctx context.Context, command string) (string, error) {
-	// Parse the command
-	parts := strings.Split(command, "|")
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid file_edit command format, expected: FILE:path|OLD:text|NEW:text")
-	}
-
-	var filePath, oldText, newText string
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if strings.HasPrefix(part, "FILE:") {
-			filePath = strings.TrimPrefix(part, "FILE:")
-		} else if strings.HasPrefix(part, "OLD:") {
-			oldText = strings.TrimPrefix(part, "OLD:")
-		} else if strings.HasPrefix(part, "NEW:") {
-			newText = strings.TrimPrefix(part, "NEW:")
-		}
-	}
-
+// ExecuteFileEdit replaces oldText with newText in filePath (relative to the working directory)
+func (e *AgentExecutor) ExecuteFileEdit(ctx context.Context, filePath, oldText, newText string) (string, error) {
 	if filePath == "" {
 		return "", fmt.Errorf("file path not specified")
 	}
@@ -235,32 +241,13 @@ func (e *AgentExecutor) ExecuteTerraformApply(ctx context.Context, command strin
 		return "", fmt.Errorf("command validation failed: %w", err)
 	}
 
-	cmd := exec.CommandContext(cmdCtx, "sh", "-c", command)
-	cmd.Dir = tfDir
-
 	// Set AWS environment
-	cmdEnv := os.Environ()
-	if e.context.AWSProfile != "" {
-		cmdEnv = append(cmdEnv, fmt.Sprintf("AWS_PROFILE=%s", e.context.AWSProfile))
-	}
-	if e.context.AWSRegion != "" {
-		cmdEnv = append(cmdEnv, fmt.Sprintf("AWS_REGION=%s", e.context.AWSRegion))
+	cmdEnv, err := e.awsEnv(ctx, true)
+	if err != nil {
+		return "", err
 	}
-	cmd.Env = cmdEnv
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		if output != "" {
-			output += "\n--- STDERR ---\n"
-		}
-		output += stderr.String()
-	}
+	output, err := e.runner.RunCommand(cmdCtx, tfDir, cmdEnv, command)
 
 	if err != nil {
 		return output, fmt.Errorf("terraform apply failed: %w", err)
@@ -282,32 +269,13 @@ func (e *AgentExecutor) ExecuteTerraformPlan(ctx context.Context, command string
 	cmdCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, "sh", "-c", command)
-	cmd.Dir = tfDir
-
 	// Set AWS environment
-	cmdEnv := os.Environ()
-	if e.context.AWSProfile != "" {
-		cmdEnv = append(cmdEnv, fmt.Sprintf("AWS_PROFILE=%s", e.context.AWSProfile))
-	}
-	if e.context.AWSRegion != "" {
-		cmdEnv = append(cmdEnv, fmt.Sprintf("AWS_REGION=%s", e.context.AWSRegion))
+	cmdEnv, err := e.awsEnv(ctx, false)
+	if err != nil {
+		return "", err
 	}
-	cmd.Env = cmdEnv
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
 
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		if output != "" {
-			output += "\n--- STDERR ---\n"
-		}
-		output += stderr.String()
-	}
+	output, err := e.runner.RunCommand(cmdCtx, tfDir, cmdEnv, command)
 
 	if err != nil {
 		return output, fmt.Errorf("terraform plan failed: %w", err)
@@ -323,7 +291,7 @@ func (e *AgentExecutor) ExecuteWebSearch(ctx context.Context, query string) (str
 	defer cancel()
 
 	// Execute search
-	output, err := ExecuteWebSearch(searchCtx, query)
+	output, err := e.runner.WebSearch(searchCtx, query)
 	if err != nil {
 		return "", fmt.Errorf("web search failed: %w", err)
 	}
@@ -10,10 +10,9 @@ import (
 // offerAIAgentHelp offers the new ReAct-based AI agent for error recovery
 // This replaces the simple AI helper with an autonomous agent
 func offerAIAgentHelp(ctx ErrorContext) error {
-	if !isAIHelperAvailable() {
-		fmt.Println("\n⚠️  AI Agent requires ANTHROPIC_API_KEY to be set")
-		fmt.Println("   Set it with: export ANTHROPIC_API_KEY=your_key_here")
-		return fmt.Errorf("ANTHROPIC_API_KEY not set")
+	if err := llmConfigError(); err != nil {
+		printLLMSetupHelp("AI Agent")
+		return err
 	}
 
 	// Ask user if they want to use the AI agent
@@ -27,6 +26,24 @@ func offerAIAgentHelp(ctx ErrorContext) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
+	agentContext := newAgentContext(ctx, wd)
+
+	// Run the AI Agent TUI
+	fmt.Println("\n🚀 Starting AI Agent...")
+	fmt.Println("   The agent will analyze the problem and attempt to fix it autonomously.")
+	fmt.Println()
+
+	err = RunAIAgentTUI(agentContext)
+	if err != nil {
+		return fmt.Errorf("AI agent failed: %w", err)
+	}
+
+	return nil
+}
+
+// newAgentContext converts an ErrorContext into the agent's context, adding a
+// structured JSON representation of the errors if none was provided
+func newAgentContext(ctx ErrorContext, workingDir string) *AgentContext {
 	// Create structured JSON representation of errors if not provided
 	structuredJSON := ctx.StructuredErrorsJSON
 	if structuredJSON == "" && len(ctx.Errors) > 0 {
@@ -48,24 +65,14 @@ func offerAIAgentHelp(ctx ErrorContext) error {
 		Environment:          ctx.Environment,
 		AWSProfile:           ctx.AWSProfile,
 		AWSRegion:            ctx.AWSRegion,
-		WorkingDir:           wd,
+		WorkingDir:           workingDir,
 		InitialError:         strings.Join(ctx.Errors, "\n\n"),
 		ResourceErrors:       ctx.Errors,
 		StructuredErrorsJSON: structuredJSON,
 		AdditionalInfo:       make(map[string]string),
 	}
 
-	// Run the AI Agent TUI
-	fmt.Println("\n🚀 Starting AI Agent...")
-	fmt.Println("   The agent will analyze the problem and attempt to fix it autonomously.")
-	fmt.Println()
-
-	err = RunAIAgentTUI(agentContext)
-	if err != nil {
-		return fmt.Errorf("AI agent failed: %w", err)
-	}
-
-	return nil
+	return agentContext
 }
 
 // promptForAIAgent asks the user if they want to use the autonomous AI agent
@@ -95,13 +102,12 @@ func promptForAIAgent() bool {
 // offerAIAgentFromMenu allows running the AI agent from the main menu
 // This is useful for when users want to proactively troubleshoot
 func offerAIAgentFromMenu() error {
-	if !isAIHelperAvailable() {
-		fmt.Println("\n⚠️  AI Agent requires ANTHROPIC_API_KEY to be set")
-		fmt.Println("   Set it with: export ANTHROPIC_API_KEY=your_key_here")
+	if err := llmConfigError(); err != nil {
+		printLLMSetupHelp("AI Agent")
 		fmt.Println()
 		fmt.Print("Press Enter to continue...")
 		fmt.Scanln()
-		return fmt.Errorf("ANTHROPIC_API_KEY not set")
+		return err
 	}
 
 	// Get environment
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// AgentPolicy controls which actions the troubleshooting agent may run without asking
+type AgentPolicy string
+
+const (
+	AgentPolicyReadOnly   AgentPolicy = "read-only"  // Only reads: describe/list/get AWS calls, terraform plan, viewing files
+	AgentPolicyApprove    AgentPolicy = "approve"    // Every mutating action waits for the user's approval
+	AgentPolicyAutonomous AgentPolicy = "autonomous" // Runs everything that passes validateCommand
+)
+
+// agentPolicies lists the policies in the order the TUI cycles through them
+var agentPolicies = []AgentPolicy{AgentPolicyReadOnly, AgentPolicyApprove, AgentPolicyAutonomous}
+
+// parseAgentPolicy validates a policy name
+func parseAgentPolicy(value string) (AgentPolicy, error) {
+	policy := AgentPolicy(strings.ToLower(strings.TrimSpace(value)))
+	for _, p := range agentPolicies {
+		if p == policy {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown agent policy %q (expected read-only, approve or autonomous)", value)
+}
+
+// defaultAgentPolicy returns MEROKU_AGENT_POLICY when set, otherwise approve
+func defaultAgentPolicy() AgentPolicy {
+	if value := os.Getenv("MEROKU_AGENT_POLICY"); value != "" {
+		if policy, err := parseAgentPolicy(value); err == nil {
+			return policy
+		}
+	}
+	return AgentPolicyApprove
+}
+
+// Description returns a short human-readable label for the policy
+func (p AgentPolicy) Description() string {
+	switch p {
+	case AgentPolicyReadOnly:
+		return "read-only"
+	case AgentPolicyAutonomous:
+		return "autonomous"
+	default:
+		return "approve each change"
+	}
+}
+
+// AgentActionRisk describes whether an action changes infrastructure or files
+type AgentActionRisk struct {
+	Mutating bool
+	Reasons  []string // What makes the action mutating, e.g. "aws ecs update-service"
+}
+
+// awsReadOnlyPrefixes are AWS CLI operation prefixes that never change resources
+var awsReadOnlyPrefixes = []string{
+	"describe-", "list-", "get-", "head-", "batch-get-", "batch-describe-",
+	"lookup-", "search-", "filter-", "validate-", "estimate-", "simulate-", "test-metric-filter",
+}
+
+// awsReadOnlyOperations are complete AWS CLI operations that never change resources
+var awsReadOnlyOperations = map[string]bool{
+	"ls":          true, // s3 ls
+	"presign":     true, // s3 presign
+	"tail":        true, // logs tail
+	"wait":        true,
+	"help":        true,
+	"scan":        true, // dynamodb
+	"query":       true, // dynamodb
+	"start-query": true, // logs insights queries only read
+	"stop-query":  true,
+}
+
+// awsGlobalOptionsWithValue are AWS CLI options that consume the following token
+var awsGlobalOptionsWithValue = map[string]bool{
+	"--region": true, "--profile": true, "--output": true, "--query": true,
+	"--endpoint-url": true, "--cli-read-timeout": true, "--cli-connect-timeout": true, "--color": true,
+}
+
+// terraformReadOnlyCommands are terraform subcommands that don't change infrastructure.
+// init only touches the local .terraform directory.
+var terraformReadOnlyCommands = map[string]bool{
+	"plan": true, "show": true, "output": true, "validate": true, "version": true,
+	"providers": true, "graph": true, "init": true,
+}
+
+// readOnlyShellCommands are shell commands that only read files
+var readOnlyShellCommands = map[string]bool{
+	"cat": true, "grep": true, "ls": true, "echo": true, "head": true, "tail": true,
+	"wc": true, "diff": true, "pwd": true, "cd": true, "jq": true, "uniq": true,
+}
+
+var (
+	shellSeparators     = regexp.MustCompile(`&&|\|\||[;&|\n\r\x60]|\$\(|\)`)
+	harmlessRedirection = regexp.MustCompile(`\d?>&\d|\d?>\s*/dev/null`)
+	// commandListSeparators run several commands in sequence or in the background
+	commandListSeparators = regexp.MustCompile(`[;\n\r]|(^|[^&])&([^&]|$)`)
+)
+
+// classifyAgentAction reports whether a tool call would change infrastructure or files
+func classifyAgentAction(action string, input *agentToolInput) AgentActionRisk {
+	switch action {
+	case "terraform_apply":
+		return AgentActionRisk{Mutating: true, Reasons: []string{"terraform apply"}}
+	case "file_edit":
+		return AgentActionRisk{Mutating: true, Reasons: []string{"edits " + input.Path}}
+	case "aws_cli", "shell", "terraform_plan":
+		return classifyShellCommand(input.Command)
+	default:
+		return AgentActionRisk{}
+	}
+}
+
+// classifyShellCommand checks every command in a pipeline or command list,
+// including $(...) substitutions, and reports the mutating ones
+func classifyShellCommand(command string) AgentActionRisk {
+	risk := AgentActionRisk{}
+	add := func(reason string) {
+		risk.Mutating = true
+		risk.Reasons = append(risk.Reasons, reason)
+	}
+
+	command = harmlessRedirection.ReplaceAllString(command, "")
+	if strings.Contains(command, ">") {
+		add("writes output to a file")
+	}
+
+	for _, segment := range shellSeparators.Split(command, -1) {
+		fields := strings.Fields(segment)
+		// Skip leading VAR=value assignments
+		for len(fields) > 0 && strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		base := filepath.Base(fields[0])
+		switch {
+		case base == "aws":
+			if operation, mutating := classifyAWSCommand(fields[1:]); mutating {
+				add("aws " + operation)
+			}
+		case base == "terraform":
+			if sub := terraformSubcommand(fields[1:]); !terraformReadOnlyCommands[sub] && !isTerraformStateRead(fields[1:]) {
+				add("terraform " + sub)
+			}
+		case base == "git":
+			if len(fields) < 2 || !map[string]bool{"status": true, "log": true, "diff": true, "show": true}[fields[1]] {
+				add(strings.Join(fields[:min(2, len(fields))], " "))
+			}
+		case base == "find":
+			if strings.Contains(segment, "-delete") || strings.Contains(segment, "-exec") {
+				add("find with -delete/-exec")
+			}
+		case readOnlyShellCommands[base]:
+		default:
+			add(base)
+		}
+	}
+	return risk
+}
+
+// isShellCommandList reports whether a command runs several commands with ;,
+// newlines or a backgrounding &. Pipelines and &&/|| chains are still allowed.
+func isShellCommandList(command string) bool {
+	return commandListSeparators.MatchString(harmlessRedirection.ReplaceAllString(command, ""))
+}
+
+// classifyAWSCommand returns "<service> <operation>" and whether the operation can change resources
+func classifyAWSCommand(args []string) (string, bool) {
+	var positional []string
+	for i := 0; i < len(args) && len(positional) < 2; i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			if awsGlobalOptionsWithValue[arg] {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	if len(positional) < 2 {
+		// "aws --version" and "aws help" don't touch resources
+		return strings.Join(positional, " "), false
+	}
+
+	service, operation := positional[0], positional[1]
+	name := service + " " + operation
+	if awsReadOnlyOperations[operation] {
+		return name, false
+	}
+	for _, prefix := range awsReadOnlyPrefixes {
+		if strings.HasPrefix(operation, prefix) {
+			return name, false
+		}
+	}
+	if service == "configure" && (operation == "list" || operation == "get") {
+		return name, false
+	}
+	return name, true
+}
+
+func terraformSubcommand(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+func isTerraformStateRead(args []string) bool {
+	var positional []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+	return len(positional) >= 2 && positional[0] == "state" && (positional[1] == "list" || positional[1] == "show" || positional[1] == "pull")
+}
+
+// previewAgentAction describes what an approved action would change
+func (a *AIAgent) previewAgentAction(ctx context.Context, response *AgentResponse, risk AgentActionRisk) string {
+	input := response.Input
+	switch response.Action {
+	case "file_edit":
+		return previewFileEdit(a.state.Context.WorkingDir, input)
+	case "terraform_apply":
+		output, err := a.executor.ExecuteTerraformPlan(ctx, "terraform plan -no-color -input=false")
+		summary := summarizeTerraformPlan(output)
+		if err != nil {
+			summary = fmt.Sprintf("terraform plan failed: %v\n%s", err, truncateOutput(output, 1500))
+		}
+		return "terraform apply in env/" + a.state.Context.Environment + "\n\n" + summary
+	default:
+		return fmt.Sprintf("%s\n\nChanges: %s", input.Command, strings.Join(risk.Reasons, ", "))
+	}
+}
+
+// previewFileEdit renders the replacement as a diff with the number of matches
+func previewFileEdit(workingDir string, input *agentToolInput) string {
+	path := input.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	var b strings.Builder
+	if content, err := os.ReadFile(path); err != nil {
+		b.WriteString(fmt.Sprintf("%s (cannot read: %v)\n", input.Path, err))
+	} else {
+		b.WriteString(fmt.Sprintf("%s (%d match(es))\n", input.Path, strings.Count(string(content), input.OldText)))
+	}
+	for _, line := range strings.Split(input.OldText, "\n") {
+		b.WriteString("- " + line + "\n")
+	}
+	for _, line := range strings.Split(input.NewText, "\n") {
+		b.WriteString("+ " + line + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+var terraformPlanChange = regexp.MustCompile(`^\s*# .* (will be|must be)`)
+
+// summarizeTerraformPlan keeps the per-resource change lines and the Plan: totals
+func summarizeTerraformPlan(output string) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if terraformPlanChange.MatchString(line) || strings.HasPrefix(trimmed, "Plan:") || strings.HasPrefix(trimmed, "No changes.") {
+			lines = append(lines, trimmed)
+		}
+	}
+	if len(lines) == 0 {
+		return truncateOutput(output, 1500)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyAgentAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		input    agentToolInput
+		mutating bool
+		reason   string
+	}{
+		{name: "describe", action: "aws_cli", input: agentToolInput{Command: "aws ecs describe-services --cluster c --services s"}},
+		{name: "global options before service", action: "aws_cli", input: agentToolInput{Command: "aws --region us-east-1 --output json ecs list-tasks --cluster c"}},
+		{name: "caller identity", action: "aws_cli", input: agentToolInput{Command: "aws sts get-caller-identity"}},
+		{name: "s3 ls", action: "aws_cli", input: agentToolInput{Command: "aws s3 ls s3://bucket"}},
+		{name: "logs tail", action: "aws_cli", input: agentToolInput{Command: "aws logs tail /ecs/app --since 1h"}},
+		{name: "version", action: "aws_cli", input: agentToolInput{Command: "aws --version"}},
+		{name: "update service", action: "aws_cli", input: agentToolInput{Command: "aws ecs update-service --cluster c --service s --force-new-deployment"}, mutating: true, reason: "aws ecs update-service"},
+		{name: "s3 rm", action: "aws_cli", input: agentToolInput{Command: "aws s3 rm s3://bucket/key"}, mutating: true, reason: "aws s3 rm"},
+		{name: "mutation in substitution", action: "aws_cli", input: agentToolInput{Command: "aws ecs describe-tasks --tasks $(aws ecs stop-task --task t --query x)"}, mutating: true, reason: "aws ecs stop-task"},
+		{name: "read pipeline", action: "shell", input: agentToolInput{Command: "cat dev.yaml | grep -A 5 workload 2>&1"}},
+		{name: "terraform plan in shell", action: "shell", input: agentToolInput{Command: "cd env/dev && terraform plan 2>&1 | grep -A 10 Error:"}},
+		{name: "terraform state list", action: "shell", input: agentToolInput{Command: "terraform state list"}},
+		{name: "terraform destroy in shell", action: "shell", input: agentToolInput{Command: "cd env/dev && terraform destroy"}, mutating: true, reason: "terraform destroy"},
+		{name: "regenerate", action: "shell", input: agentToolInput{Command: "./meroku --generate --env dev"}, mutating: true, reason: "meroku"},
+		{name: "redirect to file", action: "shell", input: agentToolInput{Command: "echo x > dev.yaml"}, mutating: true, reason: "writes output to a file"},
+		{name: "null redirect", action: "shell", input: agentToolInput{Command: "ls env 2>/dev/null"}},
+		{name: "git commit", action: "shell", input: agentToolInput{Command: "git commit -am fix"}, mutating: true, reason: "git commit"},
+		{name: "background job", action: "shell", input: agentToolInput{Command: "cat x & aws ec2 terminate-instances --instance-ids i-1"}, mutating: true, reason: "aws ec2 terminate-instances"},
+		{name: "newline list", action: "shell", input: agentToolInput{Command: "cat x\naws ec2 terminate-instances --instance-ids i-1"}, mutating: true, reason: "aws ec2 terminate-instances"},
+		{name: "carriage return list", action: "shell", input: agentToolInput{Command: "cat x\raws ec2 terminate-instances --instance-ids i-1"}, mutating: true, reason: "aws ec2 terminate-instances"},
+		{name: "stderr redirect before and", action: "shell", input: agentToolInput{Command: "ls env 2>&1 && cat dev.yaml"}},
+		{name: "sort writes files", action: "shell", input: agentToolInput{Command: "sort -o dev.yaml dev.yaml"}, mutating: true, reason: "sort"},
+		{name: "terraform_plan tool", action: "terraform_plan", input: agentToolInput{Command: "terraform plan"}},
+		{name: "terraform_apply tool", action: "terraform_apply", input: agentToolInput{Command: "terraform apply"}, mutating: true, reason: "terraform apply"},
+		{name: "file edit", action: "file_edit", input: agentToolInput{Path: "dev.yaml"}, mutating: true, reason: "edits dev.yaml"},
+		{name: "web search", action: "web_search", input: agentToolInput{Query: "ecs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk := classifyAgentAction(tt.action, &tt.input)
+			if risk.Mutating != tt.mutating {
+				t.Fatalf("classifyAgentAction() mutating = %v, want %v (reasons %v)", risk.Mutating, tt.mutating, risk.Reasons)
+			}
+			if tt.reason != "" && !strings.Contains(strings.Join(risk.Reasons, ", "), tt.reason) {
+				t.Errorf("classifyAgentAction() reasons = %v, want %q", risk.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestIsShellCommandList(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"cat dev.yaml | grep -A 5 workload 2>&1", false},
+		{"cd env/dev && terraform plan || true", false},
+		{"ls env >/dev/null 2>&1", false},
+		{"cat x & aws ec2 terminate-instances --instance-ids i-1", true},
+		{"aws ec2 terminate-instances --instance-ids i-1 &", true},
+		{"cat x &>out", true},
+		{"cat x; aws ecs list-clusters", true},
+		{"cat x\naws ecs list-clusters", true},
+		{"cat x\raws ecs list-clusters", true},
+	}
+	for _, tt := range tests {
+		if got := isShellCommandList(tt.command); got != tt.want {
+			t.Errorf("isShellCommandList(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestParseAgentPolicy(t *testing.T) {
+	if policy, err := parseAgentPolicy(" Read-Only "); err != nil || policy != AgentPolicyReadOnly {
+		t.Errorf("parseAgentPolicy() = %q, %v", policy, err)
+	}
+	if _, err := parseAgentPolicy("yolo"); err == nil {
+		t.Error("parseAgentPolicy() expected error for unknown policy")
+	}
+
+	t.Setenv("MEROKU_AGENT_POLICY", "autonomous")
+	if got := defaultAgentPolicy(); got != AgentPolicyAutonomous {
+		t.Errorf("defaultAgentPolicy() = %q", got)
+	}
+	t.Setenv("MEROKU_AGENT_POLICY", "")
+	if got := defaultAgentPolicy(); got != AgentPolicyApprove {
+		t.Errorf("defaultAgentPolicy() = %q, want approve", got)
+	}
+}
+
+func newPolicyTestAgent(t *testing.T, policy AgentPolicy) *AIAgent {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("enable_ecs_task_role: false\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	agentCtx := &AgentContext{Environment: "dev", WorkingDir: dir, Policy: policy}
+	executor := NewAgentExecutor(agentCtx)
+	executor.credentials = nil // Tests never call AWS
+	return &AIAgent{
+		state:      &AgentState{Context: agentCtx},
+		updateChan: make(chan AgentUpdate, 10),
+		executor:   executor,
+		ctx:        ctx,
+		cancelFunc: cancel,
+		approvals:  make(chan bool, 1),
+		policy:     policy,
+	}
+}
+
+func fileEditResponse() *AgentResponse {
+	return &AgentResponse{
+		Action: "file_edit",
+		Input:  &agentToolInput{Path: "dev.yaml", OldText: "enable_ecs_task_role: false", NewText: "enable_ecs_task_role: true"},
+	}
+}
+
+func TestAIAgentAuthorize(t *testing.T) {
+	t.Run("read-only blocks mutations", func(t *testing.T) {
+		agent := newPolicyTestAgent(t, AgentPolicyReadOnly)
+		iter := &AgentIteration{}
+		if err := agent.authorize(iter, fileEditResponse()); err == nil || iter.Status != "blocked" {
+			t.Fatalf("authorize() = %v, status %q", err, iter.Status)
+		}
+
+		read := &AgentResponse{Action: "aws_cli", Input: &agentToolInput{Command: "aws ecs list-clusters"}}
+		if err := agent.authorize(&AgentIteration{}, read); err != nil {
+			t.Errorf("authorize() read-only call = %v", err)
+		}
+	})
+
+	for _, policy := range []AgentPolicy{AgentPolicyReadOnly, AgentPolicyApprove} {
+		t.Run(string(policy)+" rejects command lists", func(t *testing.T) {
+			agent := newPolicyTestAgent(t, policy)
+			for _, command := range []string{
+				"cat x & aws ec2 terminate-instances --instance-ids i-1",
+				"cat x\naws ec2 terminate-instances --instance-ids i-1",
+				"aws ecs list-clusters; aws ecs list-services",
+			} {
+				iter := &AgentIteration{}
+				response := &AgentResponse{Action: "shell", Input: &agentToolInput{Command: command}}
+				if err := agent.authorize(iter, response); err == nil || iter.Status != "blocked" {
+					t.Errorf("authorize(%q) = %v, status %q", command, err, iter.Status)
+				}
+			}
+		})
+	}
+
+	t.Run("autonomous allows mutations", func(t *testing.T) {
+		agent := newPolicyTestAgent(t, AgentPolicyAutonomous)
+		if err := agent.authorize(&AgentIteration{}, fileEditResponse()); err != nil {
+			t.Errorf("authorize() = %v", err)
+		}
+	})
+
+	for _, approved := range []bool{true, false} {
+		agent := newPolicyTestAgent(t, AgentPolicyApprove)
+		iter := &AgentIteration{}
+		done := make(chan error)
+		go func() { done <- agent.authorize(iter, fileEditResponse()) }()
+
+		update := <-agent.updateChan
+		if update.Type != "approval_required" || !strings.Contains(update.Iteration.Preview, "dev.yaml (1 match(es))") ||
+			!strings.Contains(update.Iteration.Preview, "+ enable_ecs_task_role: true") {
+			t.Fatalf("unexpected approval update: %+v", update)
+		}
+		agent.Approve(approved)
+
+		err := <-done
+		if approved && err != nil {
+			t.Errorf("authorize() after approval = %v", err)
+		}
+		if !approved && (err == nil || iter.Status != "blocked") {
+			t.Errorf("authorize() after rejection = %v, status %q", err, iter.Status)
+		}
+	}
+}
+
+func TestSummarizeTerraformPlan(t *testing.T) {
+	output := `Refreshing state...
+
+  # aws_ecs_service.backend will be updated in-place
+  ~ resource "aws_ecs_service" "backend" {
+    }
+
+  # aws_db_instance.main must be replaced
+-/+ resource "aws_db_instance" "main" {
+    }
+
+Plan: 0 to add, 1 to change, 0 to destroy.`
+
+	want := "# aws_ecs_service.backend will be updated in-place\n# aws_db_instance.main must be replaced\nPlan: 0 to add, 1 to change, 0 to destroy."
+	if got := summarizeTerraformPlan(output); got != want {
+		t.Errorf("summarizeTerraformPlan() = %q, want %q", got, want)
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,7 +16,8 @@ type AgentIteration struct {
 	Action      string        `json:"action"`       // Tool type: aws_cli, shell, file_edit, terraform_apply
 	Command     string        `json:"command"`      // Exact command or operation
 	Output      string        `json:"output"`       // Command result/observation
-	Status      string        `json:"status"`       // running, success, failed
+	Preview     string        `json:"preview,omitempty"` // What a mutating action would change, shown for approval
+	Status      string        `json:"status"`       // running, awaiting_approval, success, failed, blocked
 	Duration    time.Duration `json:"duration"`
 	Timestamp   time.Time     `json:"timestamp"`
 	ErrorDetail string        `json:"error_detail"` // Detailed error if failed
@@ -24,9 +28,10 @@ type AgentState struct {
 	Iterations       []AgentIteration `json:"iterations"`
 	CurrentThinking  bool             `json:"current_thinking"`
 	IsComplete       bool             `json:"is_complete"`
-	FinalOutcome     string           `json:"final_outcome"`      // "success" or "failed"
+	FinalOutcome     string           `json:"final_outcome"`      // "success", "failed", "cancelled" or "budget_exceeded"
 	TotalDuration    time.Duration    `json:"total_duration"`
 	IterationLimit   int              `json:"iteration_limit"`    // Maximum iterations to prevent infinite loops
+	Usage            LLMUsage         `json:"usage"`              // Tokens, latency and cost of the session's LLM calls
 	Context          *AgentContext    `json:"context"`
 }
 
@@ -41,11 +46,13 @@ type AgentContext struct {
 	ResourceErrors       []string          `json:"resource_errors"`        // Multiple error messages if available
 	StructuredErrorsJSON string            `json:"structured_errors_json"` // JSON-formatted error data
 	AdditionalInfo       map[string]string `json:"additional_info"`        // Extra context (e.g., cluster name, service name)
+	Policy               AgentPolicy       `json:"policy,omitempty"`       // Which actions need approval; defaults to approve
+	ReadOnlyAWS          bool              `json:"read_only_aws,omitempty"` // The session's AWS credentials can't change resources
 }
 
 // AgentUpdate is sent to the TUI to update the display
 type AgentUpdate struct {
-	Type       string          `json:"type"` // "thinking", "action_start", "action_complete", "finished", "error"
+	Type       string          `json:"type"` // "thinking", "action_start", "approval_required", "action_complete", "finished", "error"
 	Iteration  *AgentIteration `json:"iteration,omitempty"`
 	Message    string          `json:"message,omitempty"`
 	IsComplete bool            `json:"is_complete"`
@@ -60,22 +67,38 @@ type AIAgent struct {
 	llmClient   *AgentLLMClient
 	ctx         context.Context
 	cancelFunc  context.CancelFunc
+	approvals   chan bool // User decisions for actions awaiting approval
+	session     *AgentSession
+
+	sessionMu  sync.Mutex
+	sessionErr error // Last error saving the session, reported when the TUI exits
+
+	policyMu sync.Mutex
+	policy   AgentPolicy
 }
 
 // NewAIAgent creates a new autonomous agent
 func NewAIAgent(agentContext *AgentContext, updateChan chan AgentUpdate) (*AIAgent, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-
 	// Create LLM client
 	llmClient, err := NewAgentLLMClient()
 	if err != nil {
-		cancel()
 		return nil, fmt.Errorf("failed to create LLM client: %w", err)
 	}
 
+	return newAIAgent(agentContext, updateChan, llmClient), nil
+}
+
+// newAIAgent creates an agent around an existing LLM client
+func newAIAgent(agentContext *AgentContext, updateChan chan AgentUpdate, llmClient *AgentLLMClient) *AIAgent {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// Create executor
 	executor := NewAgentExecutor(agentContext)
 
+	if agentContext.Policy == "" {
+		agentContext.Policy = defaultAgentPolicy()
+	}
+
 	agent := &AIAgent{
 		state: &AgentState{
 			Iterations:       []AgentIteration{},
@@ -90,8 +113,41 @@ func NewAIAgent(agentContext *AgentContext, updateChan chan AgentUpdate) (*AIAge
 		llmClient:  llmClient,
 		ctx:        ctx,
 		cancelFunc: cancel,
+		approvals:  make(chan bool, 1),
+		policy:     agentContext.Policy,
+	}
+	agent.session = &AgentSession{
+		ID:        newAgentSessionID(agentContext.Environment),
+		CreatedAt: time.Now(),
+		RunNumber: 1,
+		State:     agent.state,
 	}
+	setLLMUsageSession(llmClient.provider, agent.session.ID, LLMUsage{})
 
+	return agent
+}
+
+// ResumeAIAgent continues a saved session with its iterations and LLM conversation
+func ResumeAIAgent(session *AgentSession, updateChan chan AgentUpdate) (*AIAgent, error) {
+	agentContext := session.State.Context
+	agent, err := NewAIAgent(agentContext, updateChan)
+	if err != nil {
+		return nil, err
+	}
+
+	state := session.State
+	state.CurrentThinking = false
+	state.IsComplete = false
+	state.FinalOutcome = ""
+	if state.IterationLimit == 0 {
+		state.IterationLimit = agent.state.IterationLimit
+	}
+	agent.state = state
+	agent.llmClient.messages = resumeConversation(session.Messages)
+	setLLMUsageSession(agent.llmClient.provider, session.ID, state.Usage)
+
+	session.RunNumber++
+	agent.session = session
 	return agent, nil
 }
 
@@ -99,8 +155,10 @@ func NewAIAgent(agentContext *AgentContext, updateChan chan AgentUpdate) (*AIAge
 func (a *AIAgent) Start() error {
 	startTime := time.Now()
 	defer func() {
-		a.state.TotalDuration = time.Since(startTime)
+		a.state.TotalDuration += time.Since(startTime)
+		a.saveSession()
 	}()
+	a.saveSession()
 
 	// Send initial update
 	a.sendUpdate(AgentUpdate{
@@ -108,8 +166,9 @@ func (a *AIAgent) Start() error {
 		Message: "AI Agent started - analyzing problem...",
 	})
 
-	// Main ReAct loop
-	for iteration := 1; iteration <= a.state.IterationLimit; iteration++ {
+	// Main ReAct loop. A resumed session continues numbering and gets a fresh iteration budget.
+	first := len(a.state.Iterations) + 1
+	for iteration := first; iteration < first+a.state.IterationLimit; iteration++ {
 		// Check if cancelled
 		select {
 		case <-a.ctx.Done():
@@ -139,7 +198,20 @@ func (a *AIAgent) Start() error {
 			Message: fmt.Sprintf("Iteration %d: Analyzing situation...", iteration),
 		})
 
-		thought, action, command, err := a.think()
+		response, err := a.think()
+		var budgetErr *LLMBudgetError
+		if errors.As(err, &budgetErr) {
+			// Spend cap reached: stop without counting it as a failed iteration
+			a.state.IsComplete = true
+			a.state.FinalOutcome = "budget_exceeded"
+			a.sendUpdate(AgentUpdate{
+				Type:       "finished",
+				IsComplete: true,
+				Success:    false,
+				Message:    fmt.Sprintf("Stopped: %v. Raise the cap in llm.yaml and run 'meroku agent resume %s' to continue", budgetErr, a.SessionID()),
+			})
+			return nil
+		}
 		if err != nil {
 			iter.Status = "failed"
 			iter.ErrorDetail = fmt.Sprintf("Thinking failed: %v", err)
@@ -152,12 +224,12 @@ func (a *AIAgent) Start() error {
 			return fmt.Errorf("thinking failed: %w", err)
 		}
 
-		iter.Thought = thought
-		iter.Action = action
-		iter.Command = command
+		iter.Thought = response.Thought
+		iter.Action = response.Action
+		iter.Command = response.Command
 
 		// Check if agent thinks it's done
-		if action == "complete" {
+		if response.Action == "complete" {
 			iter.Status = "success"
 			iter.Output = "Problem solved!"
 			a.state.Iterations = append(a.state.Iterations, iter)
@@ -178,14 +250,20 @@ func (a *AIAgent) Start() error {
 		a.sendUpdate(AgentUpdate{
 			Type:      "action_start",
 			Iteration: &iter,
-			Message:   fmt.Sprintf("Executing: %s", command),
+			Message:   fmt.Sprintf("Executing: %s", response.Command),
 		})
 
 		actionStart := time.Now()
-		output, err := a.act(action, command)
+		var output string
+		if err = a.authorize(&iter, response); err == nil {
+			output, err = a.act(response)
+		}
 		iter.Duration = time.Since(actionStart)
+		a.llmClient.AddObservation(response, output, err)
 
-		if err != nil {
+		if iter.Status == "blocked" {
+			iter.ErrorDetail = err.Error()
+		} else if err != nil {
 			iter.Status = "failed"
 			iter.Output = output // May contain partial output
 			iter.ErrorDetail = err.Error()
@@ -196,6 +274,7 @@ func (a *AIAgent) Start() error {
 
 		// Step 3: Observe - Add iteration to history
 		a.state.Iterations = append(a.state.Iterations, iter)
+		a.saveSession()
 		a.sendUpdate(AgentUpdate{
 			Type:      "action_complete",
 			Iteration: &iter,
@@ -203,7 +282,7 @@ func (a *AIAgent) Start() error {
 		})
 
 		// If action failed but agent wants to continue, allow it
-		// The LLM will see the failure in the next iteration's context
+		// The LLM sees the failure as the tool result
 	}
 
 	// Reached iteration limit
@@ -220,61 +299,140 @@ func (a *AIAgent) Start() error {
 }
 
 // think uses the LLM to decide the next action
-func (a *AIAgent) think() (thought, action, command string, err error) {
-	// Build conversation history for context
-	history := a.buildHistory()
+func (a *AIAgent) think() (*AgentResponse, error) {
+	// The prompt tells the LLM which policy is in effect
+	a.state.Context.Policy = a.Policy()
+	a.state.Context.ReadOnlyAWS = a.executor.credentials != nil && a.executor.credentials.ReadOnly(a.ctx)
+	response, err := a.llmClient.GetNextAction(a.ctx, a.state.Context)
+	a.state.Usage = a.llmClient.Usage()
+	return response, err
+}
 
-	// Call LLM
-	response, err := a.llmClient.GetNextAction(a.ctx, a.state.Context, history)
-	if err != nil {
-		return "", "", "", err
+// authorize applies the session policy to a tool call. Mutating actions are
+// blocked in read-only mode and wait for the user's decision in approve mode.
+func (a *AIAgent) authorize(iter *AgentIteration, response *AgentResponse) error {
+	// Command lists and background jobs are never needed for troubleshooting
+	// and make the classification easy to get wrong, so only autonomous runs them
+	if a.Policy() != AgentPolicyAutonomous && response.Input != nil && isShellCommandList(response.Input.Command) {
+		iter.Status = "blocked"
+		return fmt.Errorf("blocked by %s policy: run one command at a time without ;, newlines or a trailing &", a.Policy())
+	}
+
+	risk := classifyAgentAction(response.Action, response.Input)
+	if !risk.Mutating {
+		return nil
+	}
+	reasons := strings.Join(risk.Reasons, ", ")
+
+	// Scoped read-only credentials would make the action fail with AccessDenied
+	if needsAWSWrite(risk) && a.executor.credentials != nil && a.executor.credentials.ReadOnly(a.ctx) {
+		iter.Status = "blocked"
+		return fmt.Errorf("blocked: the session's AWS credentials are read-only (%s); ask the user to grant write access or recommend the change", reasons)
+	}
+
+	switch a.Policy() {
+	case AgentPolicyAutonomous:
+		return nil
+	case AgentPolicyReadOnly:
+		iter.Status = "blocked"
+		return fmt.Errorf("blocked by read-only policy (%s); recommend the change to the user instead", reasons)
+	}
+
+	// Drop any stale decision before asking
+	select {
+	case <-a.approvals:
+	default:
+	}
+
+	iter.Status = "awaiting_approval"
+	iter.Preview = a.previewAgentAction(a.ctx, response, risk)
+	a.sendUpdate(AgentUpdate{
+		Type:      "approval_required",
+		Iteration: iter,
+		Message:   fmt.Sprintf("Approval required: %s", reasons),
+	})
+
+	select {
+	case approved := <-a.approvals:
+		if !approved {
+			iter.Status = "blocked"
+			return fmt.Errorf("rejected by the user (%s); do not retry it, suggest an alternative", reasons)
+		}
+		iter.Status = "running"
+		a.sendUpdate(AgentUpdate{
+			Type:      "action_start",
+			Iteration: iter,
+			Message:   fmt.Sprintf("Approved, executing: %s", response.Command),
+		})
+		return nil
+	case <-a.ctx.Done():
+		return fmt.Errorf("cancelled by user")
+	}
+}
+
+// Approve answers the action currently awaiting approval
+func (a *AIAgent) Approve(approved bool) {
+	select {
+	case a.approvals <- approved:
+	default:
 	}
+}
+
+// Policy returns the session's current policy
+func (a *AIAgent) Policy() AgentPolicy {
+	a.policyMu.Lock()
+	defer a.policyMu.Unlock()
+	return a.policy
+}
 
-	return response.Thought, response.Action, response.Command, nil
+// SetPolicy changes the policy for the following actions
+func (a *AIAgent) SetPolicy(policy AgentPolicy) {
+	a.policyMu.Lock()
+	defer a.policyMu.Unlock()
+	a.policy = policy
+}
+
+// WriteAccess reports whether the user granted AWS write access for the session
+func (a *AIAgent) WriteAccess() bool {
+	return a.executor.credentials != nil && a.executor.credentials.WriteAccess()
+}
+
+// SetWriteAccess grants or revokes AWS write credentials for the following actions
+func (a *AIAgent) SetWriteAccess(granted bool) {
+	if a.executor.credentials != nil {
+		a.executor.credentials.SetWriteAccess(granted)
+	}
 }
 
-// act executes the chosen action
-func (a *AIAgent) act(action, command string) (string, error) {
-	switch action {
+// CredentialsDescription describes the AWS credentials commands run with
+func (a *AIAgent) CredentialsDescription() string {
+	if a.executor.credentials == nil {
+		return "profile"
+	}
+	return a.executor.credentials.Description()
+}
+
+// act executes the chosen tool call
+func (a *AIAgent) act(response *AgentResponse) (string, error) {
+	input := response.Input
+	switch response.Action {
 	case "aws_cli":
-		return a.executor.ExecuteAWSCLI(a.ctx, command)
+		return a.executor.ExecuteAWSCLI(a.ctx, input.Command)
 	case "shell":
-		return a.executor.ExecuteShell(a.ctx, command)
+		return a.executor.ExecuteShell(a.ctx, input.Command)
 	case "file_edit":
-		return a.executor.ExecuteFileEdit(a.ctx, command)
+		return a.executor.ExecuteFileEdit(a.ctx, input.Path, input.OldText, input.NewText)
 	case "terraform_apply":
-		return a.executor.ExecuteTerraformApply(a.ctx, command)
+		return a.executor.ExecuteTerraformApply(a.ctx, input.Command)
 	case "terraform_plan":
-		return a.executor.ExecuteTerraformPlan(a.ctx, command)
+		return a.executor.ExecuteTerraformPlan(a.ctx, input.Command)
 	case "web_search":
-		return a.executor.ExecuteWebSearch(a.ctx, command)
+		return a.executor.ExecuteWebSearch(a.ctx, input.Query)
 	default:
-		return "", fmt.Errorf("unknown action type: %s", action)
+		return "", fmt.Errorf("unknown action type: %s", response.Action)
 	}
 }
 
-// buildHistory creates a string representation of previous iterations for LLM context
-func (a *AIAgent) buildHistory() string {
-	if len(a.state.Iterations) == 0 {
-		return "No previous actions taken."
-	}
-
-	var history string
-	for _, iter := range a.state.Iterations {
-		history += fmt.Sprintf("\n--- Iteration %d ---\n", iter.Number)
-		history += fmt.Sprintf("THOUGHT: %s\n", iter.Thought)
-		history += fmt.Sprintf("ACTION: %s\n", iter.Action)
-		history += fmt.Sprintf("COMMAND: %s\n", iter.Command)
-		history += fmt.Sprintf("OUTPUT: %s\n", truncateOutput(iter.Output, 500))
-		if iter.Status == "failed" {
-			history += fmt.Sprintf("ERROR: %s\n", iter.ErrorDetail)
-		}
-		history += fmt.Sprintf("STATUS: %s\n", iter.Status)
-	}
-
-	return history
-}
-
 // sendUpdate sends an update to the TUI
 func (a *AIAgent) sendUpdate(update AgentUpdate) {
 	select {
@@ -284,6 +442,40 @@ func (a *AIAgent) sendUpdate(update AgentUpdate) {
 	}
 }
 
+// saveSession persists the iterations and conversation so far
+func (a *AIAgent) saveSession() {
+	if a.session == nil {
+		return
+	}
+	a.session.State = a.state
+	a.session.Messages = a.llmClient.messages
+	err := SaveAgentSession(a.state.Context.WorkingDir, a.session)
+
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	a.sessionErr = err
+}
+
+// SessionError returns the error from the last attempt to save the session
+func (a *AIAgent) SessionError() error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.sessionErr
+}
+
+// Usage returns the tokens, latency and cost of the session's LLM calls so far
+func (a *AIAgent) Usage() LLMUsage {
+	return a.llmClient.Usage()
+}
+
+// SessionID returns the ID used to resume or export this run
+func (a *AIAgent) SessionID() string {
+	if a.session == nil {
+		return ""
+	}
+	return a.session.ID
+}
+
 // Stop cancels the agent execution
 func (a *AIAgent) Stop() {
 	a.cancelFunc()
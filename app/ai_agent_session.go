@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	AgentSessionVersion = "1.0"
+	AgentSessionDir     = ".meroku/agent-sessions" // Relative to the project root
+)
+
+// AgentSession is a persisted troubleshooting agent run: the iterations shown
+// in the TUI plus the LLM conversation needed to continue it later
+type AgentSession struct {
+	Version   string       `json:"version"`
+	ID        string       `json:"id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	RunNumber int          `json:"run_number"` // Incremented on each resume
+	State     *AgentState  `json:"state"`
+	Messages  []LLMMessage `json:"messages"`
+}
+
+// newAgentSessionID generates a sortable session ID like 20261018-142501-dev
+func newAgentSessionID(env string) string {
+	if env == "" {
+		env = "agent"
+	}
+	return fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), env)
+}
+
+// agentSessionPath returns the session file path for an ID
+func agentSessionPath(projectDir, id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid session ID: %q", id)
+	}
+	return filepath.Join(projectDir, AgentSessionDir, id+".json"), nil
+}
+
+// SaveAgentSession writes the session under the project. The file is replaced
+// atomically so a crash mid-write never corrupts an earlier save.
+func SaveAgentSession(projectDir string, session *AgentSession) error {
+	path, err := agentSessionPath(projectDir, session.ID)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	// Transcripts contain command output, keep them out of git
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0600)
+	}
+
+	session.Version = AgentSessionVersion
+	session.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
+
+// LoadAgentSession reads a saved session by ID
+func LoadAgentSession(projectDir, id string) (*AgentSession, error) {
+	path, err := agentSessionPath(projectDir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("session %s not found (run 'meroku agent list')", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session AgentSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	if session.Version != AgentSessionVersion {
+		return nil, fmt.Errorf("incompatible session version: %s (expected %s)", session.Version, AgentSessionVersion)
+	}
+	if session.State == nil || session.State.Context == nil {
+		return nil, fmt.Errorf("session %s has no agent state", id)
+	}
+	return &session, nil
+}
+
+// ListAgentSessions returns the project's sessions, most recently updated first
+func ListAgentSessions(projectDir string) ([]*AgentSession, error) {
+	matches, err := filepath.Glob(filepath.Join(projectDir, AgentSessionDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var sessions []*AgentSession
+	for _, match := range matches {
+		session, err := LoadAgentSession(projectDir, strings.TrimSuffix(filepath.Base(match), ".json"))
+		if err != nil {
+			continue // Skip unreadable or incompatible files
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Outcome returns the final outcome, or "interrupted" for unfinished sessions
+func (s *AgentSession) Outcome() string {
+	if s.State.IsComplete && s.State.FinalOutcome != "" {
+		return s.State.FinalOutcome
+	}
+	return "interrupted"
+}
+
+// resumeConversation prepares a saved conversation for another run. Tool
+// calls that were interrupted before their result was recorded are answered
+// first, since every tool call needs a result.
+func resumeConversation(messages []LLMMessage) []LLMMessage {
+	if len(messages) == 0 {
+		return messages
+	}
+	if last := messages[len(messages)-1]; last.Role == "assistant" {
+		for _, call := range last.ToolCalls {
+			messages = append(messages, toolResult(call, "Not executed: the session was interrupted before this call ran.", true))
+		}
+	}
+	return append(messages, LLMMessage{
+		Role:    "user",
+		Content: "The session was resumed. Review what you found so far and continue the investigation; call complete if the problem is already resolved.",
+	})
+}
+
+// ExportAgentSession renders a session as "md" or "json"
+func ExportAgentSession(session *AgentSession, format string) (string, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(session, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal session: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "md", "markdown":
+		return agentSessionMarkdown(session), nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (expected md or json)", format)
+	}
+}
+
+// agentSessionMarkdown renders a transcript suitable for an incident ticket
+func agentSessionMarkdown(session *AgentSession) string {
+	state := session.State
+	agentCtx := state.Context
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# AI agent session %s\n\n", session.ID)
+	fmt.Fprintf(&b, "| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Environment | %s |\n", agentCtx.Environment)
+	fmt.Fprintf(&b, "| Operation | %s |\n", agentCtx.Operation)
+	fmt.Fprintf(&b, "| AWS profile | %s |\n", agentCtx.AWSProfile)
+	fmt.Fprintf(&b, "| AWS region | %s |\n", agentCtx.AWSRegion)
+	fmt.Fprintf(&b, "| Policy | %s |\n", agentCtx.Policy.Description())
+	fmt.Fprintf(&b, "| Started | %s |\n", session.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "| Last updated | %s |\n", session.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "| Runs | %d |\n", session.RunNumber)
+	fmt.Fprintf(&b, "| Iterations | %d |\n", len(state.Iterations))
+	fmt.Fprintf(&b, "| Duration | %v |\n", state.TotalDuration.Round(time.Second))
+	fmt.Fprintf(&b, "| Outcome | %s |\n\n", session.Outcome())
+
+	b.WriteString("## Initial error\n\n")
+	writeFenced(&b, agentCtx.InitialError)
+
+	b.WriteString("## Iterations\n\n")
+	for _, iter := range state.Iterations {
+		fmt.Fprintf(&b, "### %d. %s: %s (%v)\n\n", iter.Number, iter.Action, iter.Status, iter.Duration.Round(time.Millisecond))
+		if iter.Thought != "" {
+			fmt.Fprintf(&b, "%s\n\n", iter.Thought)
+		}
+		if iter.Command != "" {
+			writeFenced(&b, iter.Command)
+		}
+		if iter.Preview != "" {
+			b.WriteString("**Preview shown for approval:**\n\n")
+			writeFenced(&b, iter.Preview)
+		}
+		if iter.Output != "" {
+			b.WriteString("**Output:**\n\n")
+			writeFenced(&b, iter.Output)
+		}
+		if iter.ErrorDetail != "" {
+			fmt.Fprintf(&b, "**Error:** %s\n\n", iter.ErrorDetail)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// writeFenced writes text as a code block, using a fence longer than any backtick run inside it
+func writeFenced(b *strings.Builder, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s\n%s\n%s\n\n", fence, strings.TrimRight(text, "\n"), fence)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAgentSession(id string) *AgentSession {
+	return &AgentSession{
+		ID:        id,
+		CreatedAt: time.Date(2026, 10, 18, 14, 25, 1, 0, time.UTC),
+		RunNumber: 1,
+		State: &AgentState{
+			IterationLimit: 20,
+			Context:        &AgentContext{Environment: "dev", Operation: "terraform_apply", InitialError: "task failed to start\nmore detail"},
+			Iterations: []AgentIteration{
+				{Number: 1, Thought: "Check the service.", Action: "aws_cli", Command: "aws ecs describe-services", Output: "```json\n{}\n```", Status: "success"},
+				{Number: 2, Action: "file_edit", Command: "dev.yaml", Status: "blocked", ErrorDetail: "rejected by the user"},
+			},
+		},
+		Messages: []LLMMessage{{Role: "user", Content: "This is your first action."}},
+	}
+}
+
+func TestAgentSessionSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	session := testAgentSession("20261018-142501-dev")
+	if err := SaveAgentSession(dir, session); err != nil {
+		t.Fatalf("SaveAgentSession() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, AgentSessionDir, ".gitignore")); err != nil {
+		t.Errorf("expected the session directory to be gitignored: %v", err)
+	}
+
+	loaded, err := LoadAgentSession(dir, session.ID)
+	if err != nil {
+		t.Fatalf("LoadAgentSession() error = %v", err)
+	}
+	if len(loaded.State.Iterations) != 2 || loaded.State.Context.Environment != "dev" || len(loaded.Messages) != 1 {
+		t.Errorf("LoadAgentSession() = %+v", loaded)
+	}
+	if loaded.Outcome() != "interrupted" {
+		t.Errorf("Outcome() = %q, want interrupted", loaded.Outcome())
+	}
+
+	older := testAgentSession("20261017-090000-prod")
+	if err := SaveAgentSession(dir, older); err != nil {
+		t.Fatal(err)
+	}
+	session.State.IsComplete = true
+	session.State.FinalOutcome = "success"
+	if err := SaveAgentSession(dir, session); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := ListAgentSessions(dir)
+	if err != nil {
+		t.Fatalf("ListAgentSessions() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != session.ID || sessions[0].Outcome() != "success" {
+		t.Errorf("ListAgentSessions() returned %d sessions, first %+v", len(sessions), sessions[0])
+	}
+
+	for _, id := range []string{"", "../dev", ".hidden"} {
+		if _, err := LoadAgentSession(dir, id); err == nil {
+			t.Errorf("LoadAgentSession(%q) expected an error", id)
+		}
+	}
+	if _, err := LoadAgentSession(dir, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("LoadAgentSession(missing) error = %v", err)
+	}
+}
+
+func TestResumeConversation(t *testing.T) {
+	messages := []LLMMessage{
+		{Role: "user", Content: "This is your first action."},
+		{Role: "assistant", ToolCalls: []LLMToolCall{{ID: "call_1", Name: "shell"}, {ID: "call_2", Name: "aws_cli"}}},
+	}
+
+	resumed := resumeConversation(messages)
+	if len(resumed) != 5 {
+		t.Fatalf("resumeConversation() returned %d messages, want 5", len(resumed))
+	}
+	if resumed[2].ToolCallID != "call_1" || resumed[3].ToolCallID != "call_2" || !resumed[2].IsError {
+		t.Errorf("interrupted tool calls not answered: %+v", resumed[2:4])
+	}
+	if last := resumed[4]; last.Role != "user" || !strings.Contains(last.Content, "resumed") {
+		t.Errorf("unexpected resume message: %+v", last)
+	}
+}
+
+func TestExportAgentSession(t *testing.T) {
+	session := testAgentSession("20261018-142501-dev")
+
+	md, err := ExportAgentSession(session, "md")
+	if err != nil {
+		t.Fatalf("ExportAgentSession(md) error = %v", err)
+	}
+	for _, want := range []string{
+		"# AI agent session 20261018-142501-dev",
+		"| Environment | dev |",
+		"| Outcome | interrupted |",
+		"### 1. aws_cli: success",
+		"Check the service.",
+		"````\n```json\n{}\n```\n````", // Output containing a fence gets a longer one
+		"**Error:** rejected by the user",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown export missing %q:\n%s", want, md)
+		}
+	}
+
+	raw, err := ExportAgentSession(session, "json")
+	if err != nil {
+		t.Fatalf("ExportAgentSession(json) error = %v", err)
+	}
+	var decoded AgentSession
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil || decoded.ID != session.ID {
+		t.Errorf("json export did not round-trip: %v", err)
+	}
+
+	if _, err := ExportAgentSession(session, "pdf"); err == nil {
+		t.Error("ExportAgentSession(pdf) expected an error")
+	}
+}
+
+func TestAIAgentSavesSessionEachIteration(t *testing.T) {
+	agent := newPolicyTestAgent(t, AgentPolicyApprove)
+	agent.state.IterationLimit = 5
+	agent.llmClient = &AgentLLMClient{provider: newFixtureProvider(
+		LLMExchange{Response: LLMResponse{Text: "List files.", ToolCalls: []LLMToolCall{
+			{ID: "call_1", Name: "shell", Input: json.RawMessage(`{"command":"ls"}`)},
+		}}},
+		LLMExchange{Match: "dev.yaml", Response: LLMResponse{Text: "Done.", ToolCalls: []LLMToolCall{
+			{ID: "call_2", Name: "complete", Input: json.RawMessage(`{"summary":"nothing to fix"}`)},
+		}}},
+	)}
+	agent.session = &AgentSession{ID: "20261018-142501-dev", CreatedAt: time.Now(), RunNumber: 1}
+	go func() {
+		for range agent.updateChan {
+		}
+	}()
+
+	if err := agent.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := agent.SessionError(); err != nil {
+		t.Fatalf("SessionError() = %v", err)
+	}
+
+	saved, err := LoadAgentSession(agent.state.Context.WorkingDir, agent.SessionID())
+	if err != nil {
+		t.Fatalf("LoadAgentSession() error = %v", err)
+	}
+	if len(saved.State.Iterations) != 2 || saved.Outcome() != "success" {
+		t.Errorf("saved session has %d iterations, outcome %q", len(saved.State.Iterations), saved.Outcome())
+	}
+	// first prompt, ls call, ls result, complete call
+	if len(saved.Messages) != 4 || saved.Messages[2].ToolCallID != "call_1" {
+		t.Errorf("saved conversation = %+v", saved.Messages)
+	}
+}
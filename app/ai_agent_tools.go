@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// agentTools are the tools the troubleshooting agent can call. Every tool
+// runs through AgentExecutor, which applies the security validation and sets
+// AWS_PROFILE/AWS_REGION.
+var agentTools = []LLMTool{
+	{
+		Name:        "aws_cli",
+		Description: "Run an AWS CLI command. AWS_PROFILE and AWS_REGION are already set.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProperty("Full command starting with 'aws', e.g. aws ecs describe-services --cluster name --services svc"),
+		}, "command"),
+	},
+	{
+		Name:        "shell",
+		Description: "Run a shell command from the project root, e.g. to read YAML or generated Terraform files or to regenerate Terraform with ./meroku --generate --env <env>.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProperty("Shell command to run"),
+		}, "command"),
+	},
+	{
+		Name:        "file_edit",
+		Description: "Replace text in a project file, usually <env>.yaml. A backup is created first. Never edit generated env/*/*.tf files.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"path":     stringProperty("File path relative to the project root"),
+			"old_text": stringProperty("Exact text to replace"),
+			"new_text": stringProperty("Replacement text"),
+		}, "path", "old_text", "new_text"),
+	},
+	{
+		Name:        "terraform_plan",
+		Description: "Run terraform plan in env/<env> with AWS credentials configured.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProperty("Terraform command, e.g. terraform plan"),
+		}, "command"),
+	},
+	{
+		Name:        "terraform_apply",
+		Description: "Run terraform apply in env/<env> with AWS credentials configured. Always use this instead of running terraform through shell.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProperty("Terraform command, e.g. terraform apply -auto-approve"),
+		}, "command"),
+	},
+	{
+		Name:        "web_search",
+		Description: "Search the web for documentation about an error.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"query": stringProperty("Search query"),
+		}, "query"),
+	},
+	{
+		Name:        "complete",
+		Description: "Finish once the fix has been applied and verified.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"summary": stringProperty("What was wrong and how it was fixed"),
+		}, "summary"),
+	},
+}
+
+// agentToolInput holds the arguments of any agent tool
+type agentToolInput struct {
+	Command string `json:"command,omitempty"`
+	Query   string `json:"query,omitempty"`
+	Path    string `json:"path,omitempty"`
+	OldText string `json:"old_text,omitempty"`
+	NewText string `json:"new_text,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// parseAgentToolCall validates a tool call and decodes its arguments
+func parseAgentToolCall(call LLMToolCall) (*agentToolInput, error) {
+	known := false
+	for _, tool := range agentTools {
+		if tool.Name == call.Name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown tool: %s", call.Name)
+	}
+
+	input := &agentToolInput{}
+	if len(call.Input) > 0 {
+		if err := json.Unmarshal(call.Input, input); err != nil {
+			return nil, fmt.Errorf("invalid %s arguments: %w", call.Name, err)
+		}
+	}
+
+	switch call.Name {
+	case "file_edit":
+		if input.Path == "" {
+			return nil, fmt.Errorf("file_edit requires a path")
+		}
+	case "web_search":
+		if input.Query == "" {
+			return nil, fmt.Errorf("web_search requires a query")
+		}
+	case "complete":
+	default:
+		if input.Command == "" {
+			return nil, fmt.Errorf("%s requires a command", call.Name)
+		}
+	}
+	return input, nil
+}
+
+// display returns the human-readable form of the tool input shown in the TUI
+func (in *agentToolInput) display(tool string) string {
+	switch tool {
+	case "file_edit":
+		return fmt.Sprintf("%s\n- %s\n+ %s", in.Path, in.OldText, in.NewText)
+	case "web_search":
+		return in.Query
+	case "complete":
+		return in.Summary
+	default:
+		return in.Command
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseAgentToolCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    LLMToolCall
+		want    string
+		wantErr string
+	}{
+		{
+			name: "aws_cli",
+			call: LLMToolCall{Name: "aws_cli", Input: json.RawMessage(`{"command":"aws ecs list-clusters"}`)},
+			want: "aws ecs list-clusters",
+		},
+		{
+			name: "file_edit",
+			call: LLMToolCall{Name: "file_edit", Input: json.RawMessage(`{"path":"dev.yaml","old_text":"a: 1","new_text":"a: 2"}`)},
+			want: "dev.yaml\n- a: 1\n+ a: 2",
+		},
+		{
+			name: "complete without arguments",
+			call: LLMToolCall{Name: "complete"},
+		},
+		{
+			name:    "unknown tool",
+			call:    LLMToolCall{Name: "rm_rf", Input: json.RawMessage(`{}`)},
+			wantErr: "unknown tool",
+		},
+		{
+			name:    "missing command",
+			call:    LLMToolCall{Name: "shell", Input: json.RawMessage(`{}`)},
+			wantErr: "requires a command",
+		},
+		{
+			name:    "invalid arguments",
+			call:    LLMToolCall{Name: "web_search", Input: json.RawMessage(`{"query":1}`)},
+			wantErr: "invalid web_search arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := parseAgentToolCall(tt.call)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseAgentToolCall() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAgentToolCall() error = %v", err)
+			}
+			if got := input.display(tt.call.Name); got != tt.want {
+				t.Errorf("display() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgentLLMClientConversation(t *testing.T) {
+	provider := newFixtureProvider(
+		LLMExchange{Response: LLMResponse{Text: "Let me think about this."}},
+		LLMExchange{
+			Match: "Call one of the tools",
+			Response: LLMResponse{
+				Text: "The task role is disabled.",
+				ToolCalls: []LLMToolCall{
+					{ID: "call_1", Name: "file_edit", Input: json.RawMessage(`{"path":"dev.yaml","old_text":"enable_ecs_task_role: false","new_text":"enable_ecs_task_role: true"}`)},
+					{ID: "call_2", Name: "shell", Input: json.RawMessage(`{"command":"./meroku --generate --env dev"}`)},
+				},
+			},
+		},
+	)
+	client := &AgentLLMClient{provider: provider}
+	agentCtx := &AgentContext{Environment: "dev", InitialError: "task failed"}
+
+	response, err := client.GetNextAction(context.Background(), agentCtx)
+	if err != nil {
+		t.Fatalf("GetNextAction() error = %v", err)
+	}
+	if response.Action != "file_edit" || response.Thought != "The task role is disabled." || response.Input.Path != "dev.yaml" {
+		t.Errorf("GetNextAction() = %+v", response)
+	}
+
+	client.AddObservation(response, "partial", errors.New("backup failed"))
+
+	// first prompt, text-only reply, nudge, tool calls, result, skipped result
+	if len(client.messages) != 6 {
+		t.Fatalf("got %d messages, want 6: %+v", len(client.messages), client.messages)
+	}
+	result := client.messages[4]
+	if result.Role != "tool" || result.ToolCallID != "call_1" || !result.IsError || !strings.Contains(result.Content, "backup failed") {
+		t.Errorf("unexpected tool result: %+v", result)
+	}
+	if skipped := client.messages[5]; skipped.ToolCallID != "call_2" || !strings.Contains(skipped.Content, "Not executed") {
+		t.Errorf("unexpected skipped result: %+v", skipped)
+	}
+	if provider.remaining() != 0 {
+		t.Errorf("%d fixture responses unused", provider.remaining())
+	}
+}
+
+func TestAgentLLMClientGivesUpWithoutToolCall(t *testing.T) {
+	var exchanges []LLMExchange
+	for i := 0; i < maxToolCallAttempts; i++ {
+		exchanges = append(exchanges, LLMExchange{Response: LLMResponse{Text: "I am not sure."}})
+	}
+	client := &AgentLLMClient{provider: newFixtureProvider(exchanges...)}
+
+	if _, err := client.GetNextAction(context.Background(), &AgentContext{}); err == nil {
+		t.Fatal("GetNextAction() expected an error when the LLM never calls a tool")
+	}
+}
+
+func TestSSOAgentToolCalls(t *testing.T) {
+	// getNextAction appends to a debug log named after the profile
+	t.Cleanup(func() { os.Remove("/tmp/meroku_sso_debug_test-tools.log") })
+	provider := newFixtureProvider(LLMExchange{Response: LLMResponse{
+		Text: "Need the start URL.",
+		ToolCalls: []LLMToolCall{{
+			ID:    "call_1",
+			Name:  "ask_input",
+			Input: json.RawMessage(`{"question":"What is your SSO start URL?","validator":"url"}`),
+		}},
+	}})
+	agent := &SSOAgent{provider: provider}
+	agentCtx := &SSOAgentContext{ProfileName: "test-tools"}
+
+	action, input, err := agent.getNextAction(context.Background(), agentCtx)
+	if err != nil {
+		t.Fatalf("getNextAction() error = %v", err)
+	}
+	if action.Type != "ask_input" || action.ToolCallID != "call_1" || input.Question != "What is your SSO start URL?" || input.Validator != "url" {
+		t.Errorf("getNextAction() = %+v, %+v", action, input)
+	}
+
+	action.Result = "User entered: https://example.awsapps.com/start"
+	agent.addToolResult(agentCtx, action)
+
+	last := agentCtx.Conversation[len(agentCtx.Conversation)-1]
+	if last.Role != "tool" || last.ToolCallID != "call_1" || last.Content != action.Result {
+		t.Errorf("unexpected tool result: %+v", last)
+	}
+}
@@ -26,6 +26,7 @@ type aiAgentModel struct {
 	height         int
 	err            error
 	autoScroll     bool // Auto-scroll to bottom when new messages arrive
+	pending        *AgentIteration // Mutating action waiting for approval
 }
 
 // Messages for TUI updates
@@ -44,16 +45,35 @@ func NewAIAgentTUI(agentContext *AgentContext) (*aiAgentModel, error) {
 		return nil, err
 	}
 
+	return newAIAgentModel(agent, updateChan, "Initializing AI agent..."), nil
+}
+
+// ResumeAIAgentTUI creates the TUI for a saved session, showing its earlier iterations
+func ResumeAIAgentTUI(session *AgentSession) (*aiAgentModel, error) {
+	updateChan := make(chan AgentUpdate, 10)
+
+	agent, err := ResumeAIAgent(session, updateChan)
+	if err != nil {
+		return nil, err
+	}
+
+	status := fmt.Sprintf("Resuming session %s after %d iterations...", session.ID, len(session.State.Iterations))
+	return newAIAgentModel(agent, updateChan, status), nil
+}
+
+func newAIAgentModel(agent *AIAgent, updateChan chan AgentUpdate, status string) *aiAgentModel {
+	iterations := append([]AgentIteration{}, agent.state.Iterations...)
 	return &aiAgentModel{
 		agent:         agent,
 		updateChan:    updateChan,
-		iterations:    []AgentIteration{},
-		currentStatus: "Initializing AI agent...",
+		iterations:    iterations,
+		selectedIndex: max(0, len(iterations)-1),
+		currentStatus: status,
 		isThinking:    false,
 		isComplete:    false,
 		viewport:      viewport.New(80, 20),
 		autoScroll:    true, // Start with auto-scroll enabled
-	}, nil
+	}
 }
 
 // Init implements tea.Model
@@ -78,6 +98,39 @@ func (m aiAgentModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, tea.Quit
 
+		case "y":
+			if m.pending != nil {
+				m.agent.Approve(true)
+				m.pending = nil
+				m.currentStatus = "Approved"
+			}
+
+		case "n":
+			if m.pending != nil {
+				m.agent.Approve(false)
+				m.pending = nil
+				m.currentStatus = "Rejected - the agent will look for an alternative"
+			}
+
+		case "p":
+			// Cycle the session policy: read-only → approve → autonomous
+			if m.agent != nil && !m.isComplete {
+				current := m.agent.Policy()
+				next := agentPolicies[0]
+				for i, policy := range agentPolicies {
+					if policy == current {
+						next = agentPolicies[(i+1)%len(agentPolicies)]
+					}
+				}
+				m.agent.SetPolicy(next)
+			}
+
+		case "w":
+			// Grant or revoke AWS write credentials for the rest of the session
+			if m.agent != nil && !m.isComplete {
+				m.agent.SetWriteAccess(!m.agent.WriteAccess())
+			}
+
 		case "up", "k":
 			// Disable auto-scroll when user manually scrolls up
 			m.autoScroll = false
@@ -124,6 +177,15 @@ func (m aiAgentModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.isThinking = true
 			m.currentStatus = update.Message
 
+		case "approval_required":
+			m.isThinking = false
+			if update.Iteration != nil {
+				m.updateIteration(update.Iteration)
+				pending := *update.Iteration
+				m.pending = &pending
+				m.currentStatus = update.Message
+			}
+
 		case "action_start":
 			m.isThinking = false
 			if update.Iteration != nil {
@@ -220,10 +282,21 @@ func (m aiAgentModel) View() string {
 	header += statusStyle.Render(statusLine) + "\n"
 
 	// Progress summary
-	header += m.renderSummary() + "\n"
+	header += m.renderSummary()
+	if m.agent != nil {
+		header += statusStyle.Render("  •  Cost: " + m.agent.Usage().String() + "  •  Policy: " + m.agent.Policy().Description() + "  •  AWS: " + m.agent.CredentialsDescription() + "  •  Session: " + m.agent.SessionID())
+	}
+	header += "\n"
 
 	// Main content (viewport with iterations)
 	content := m.viewport.View()
+	if m.pending != nil {
+		// Shrink the iteration list to make room for the approval preview
+		approval := m.renderApproval()
+		viewport := m.viewport
+		viewport.Height = max(3, viewport.Height-lipgloss.Height(approval)-1)
+		content = viewport.View() + "\n" + approval
+	}
 
 	// Footer
 	footer := m.renderFooter()
@@ -295,6 +368,12 @@ func (m aiAgentModel) renderIteration(iter AgentIteration, selected bool) string
 	case "failed":
 		statusIcon = "✗"
 		statusColor = lipgloss.Color("196")
+	case "awaiting_approval":
+		statusIcon = "?"
+		statusColor = lipgloss.Color("214")
+	case "blocked":
+		statusIcon = "⊘"
+		statusColor = lipgloss.Color("208")
 	default:
 		statusIcon = "•"
 		statusColor = lipgloss.Color("243")
@@ -617,6 +696,9 @@ func (m aiAgentModel) renderCompletionSummary() string {
 	summary.WriteString(fmt.Sprintf("  • Successful Actions: %d\n", successCount))
 	summary.WriteString(fmt.Sprintf("  • Failed Actions: %d\n", failedCount))
 	summary.WriteString(fmt.Sprintf("  • Total Duration: %v\n", totalDuration.Round(time.Second)))
+	if m.agent != nil {
+		summary.WriteString(fmt.Sprintf("  • LLM Cost: %s\n", m.agent.Usage()))
+	}
 
 	if m.currentStatus != "" {
 		summary.WriteString("\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Render(m.currentStatus))
@@ -625,6 +707,28 @@ func (m aiAgentModel) renderCompletionSummary() string {
 	return boxStyle.Render(summary.String())
 }
 
+// renderApproval shows the preview of the action waiting for approval
+func (m aiAgentModel) renderApproval() string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(0, 1).
+		Width(100)
+
+	preview := m.pending.Preview
+	lines := strings.Split(preview, "\n")
+	if len(lines) > 15 {
+		preview = strings.Join(lines[:15], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-15)
+	}
+
+	var content strings.Builder
+	content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).Render(
+		fmt.Sprintf("Approve %s?", m.pending.Action)) + "\n\n")
+	content.WriteString(preview)
+
+	return boxStyle.Render(content.String())
+}
+
 // renderFooter shows help text
 func (m aiAgentModel) renderFooter() string {
 	footerStyle := lipgloss.NewStyle().
@@ -641,7 +745,11 @@ func (m aiAgentModel) renderFooter() string {
 		return footerStyle.Render("[q] Quit and return to menu" + scrollIndicator)
 	}
 
-	return footerStyle.Render("[↑/↓] Navigate | [Enter] Details | [q] Stop agent" + scrollIndicator)
+	if m.pending != nil {
+		return footerStyle.Render("[y] Approve | [n] Reject | [p] Policy | [w] AWS writes | [q] Stop agent" + scrollIndicator)
+	}
+
+	return footerStyle.Render("[↑/↓] Navigate | [Enter] Details | [p] Policy | [w] AWS writes | [q] Stop agent" + scrollIndicator)
 }
 
 // Helper methods
@@ -723,10 +831,35 @@ func RunAIAgentTUI(agentContext *AgentContext) error {
 		return fmt.Errorf("failed to create AI agent TUI: %w", err)
 	}
 
+	return runAIAgentProgram(model)
+}
+
+// RunResumedAIAgentTUI continues a saved session in the TUI
+func RunResumedAIAgentTUI(session *AgentSession) error {
+	model, err := ResumeAIAgentTUI(session)
+	if err != nil {
+		return fmt.Errorf("failed to create AI agent TUI: %w", err)
+	}
+
+	return runAIAgentProgram(model)
+}
+
+func runAIAgentProgram(model *aiAgentModel) error {
 	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		return fmt.Errorf("TUI error: %w", err)
+	_, err := p.Run()
+
+	// Report where the transcript is, even if the TUI crashed
+	id := model.agent.SessionID()
+	if saveErr := model.agent.SessionError(); saveErr != nil {
+		fmt.Printf("\n⚠️  Failed to save agent session: %v\n", saveErr)
+	} else {
+		fmt.Printf("\n💾 Agent session saved: %s\n", id)
+		fmt.Printf("   Resume: meroku agent resume %s\n", id)
+		fmt.Printf("   Export: meroku agent export %s --format md\n", id)
 	}
 
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
 	return nil
 }
@@ -3,29 +3,26 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/charmbracelet/lipgloss"
 )
 
 // ErrorContext contains information about the error for AI analysis
 type ErrorContext struct {
-	Operation            string   // "apply" or "destroy"
-	Environment          string   // "dev", "prod", etc.
-	AWSProfile           string   // Current AWS profile
-	AWSRegion            string   // Current AWS region
-	Errors               []string // Terraform error messages
-	StructuredErrorsJSON string   // JSON-formatted error data
-	WorkingDir           string   // Current directory
+	Operation            string   `json:"operation"`                        // "apply" or "destroy"
+	Environment          string   `json:"environment"`                      // "dev", "prod", etc.
+	AWSProfile           string   `json:"aws_profile,omitempty"`            // Current AWS profile
+	AWSRegion            string   `json:"aws_region,omitempty"`             // Current AWS region
+	Errors               []string `json:"errors"`                           // Terraform error messages
+	StructuredErrorsJSON string   `json:"structured_errors_json,omitempty"` // JSON-formatted error data
+	WorkingDir           string   `json:"working_dir,omitempty"`            // Current directory
 }
 
-// isAIHelperAvailable checks if the ANTHROPIC_API_KEY is set
+// isAIHelperAvailable checks if the configured LLM provider is usable
 func isAIHelperAvailable() bool {
-	return os.Getenv("ANTHROPIC_API_KEY") != ""
+	return isLLMConfigured()
 }
 
 // promptForAIHelp asks the user if they want AI assistance
@@ -43,17 +40,13 @@ func promptForAIHelp() bool {
 	return response == "y" || response == "yes"
 }
 
-// getAIErrorSuggestions calls the Anthropic API to get error fix suggestions
+// getAIErrorSuggestions calls the configured LLM provider to get error fix suggestions
 func getAIErrorSuggestions(ctx ErrorContext) (string, []string, error) {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return "", nil, fmt.Errorf("ANTHROPIC_API_KEY not set")
+	provider, err := NewLLMProvider(LLMFeatureErrorHelper)
+	if err != nil {
+		return "", nil, err
 	}
 
-	client := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
-	)
-
 	// Build the error context
 	errorText := strings.Join(ctx.Errors, "\n\n")
 
@@ -104,27 +97,12 @@ Important:
 		Render("🔍 Analyzing errors with AI..."))
 
 	// Call the API
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	message, err := client.Messages.New(timeoutCtx, anthropic.MessageNewParams{
-		Model:     "claude-sonnet-4-5-20250929", // Latest Claude Sonnet 4.5 - best for coding and agents
-		MaxTokens: 1024,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
-	})
-
+	message, err := provider.Complete(context.Background(), userPrompt(prompt, 1024, 30*time.Second))
 	if err != nil {
 		return "", nil, fmt.Errorf("API call failed: %w", err)
 	}
 
-	// Extract the response
-	if len(message.Content) == 0 {
-		return "", nil, fmt.Errorf("empty response from API")
-	}
-
-	responseText := message.Content[0].Text
+	responseText := message.Text
 
 	// Parse the response
 	problem, commands := parseAIResponse(responseText)
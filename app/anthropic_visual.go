@@ -1,13 +1,9 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -52,34 +48,10 @@ CRITICAL: Make it easy to scan quickly but also include ALL the technical detail
 
 Output ONLY the complete HTML code with all CSS and JavaScript inline. Start with <!DOCTYPE html> and create a single, self-contained file.`
 
-type anthropicStreamRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	Messages  []anthropicMessage `json:"messages"`
-	Stream    bool               `json:"stream"`
-}
-
-type anthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type streamEvent struct {
-	Type  string `json:"type"`
-	Delta struct {
-		Text string `json:"text"`
-	} `json:"delta,omitempty"`
-	Message struct {
-		Content []struct {
-			Text string `json:"text"`
-		} `json:"content"`
-	} `json:"message,omitempty"`
-}
-
 func callAnthropicForVisualizationWithProgress(planData interface{}) error {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return fmt.Errorf("ANTHROPIC_API_KEY environment variable not set. Set it with: export ANTHROPIC_API_KEY=your_key_here")
+	provider, err := NewLLMProvider(LLMFeatureVisualization)
+	if err != nil {
+		return fmt.Errorf("AI provider not configured: %w", err)
 	}
 
 	// Create context with cancellation
@@ -151,103 +123,30 @@ func callAnthropicForVisualizationWithProgress(planData interface{}) error {
 
 	fmt.Println("\n🎨 Generating visualization...")
 
-	// Create the request with streaming enabled
-	reqBody := anthropicStreamRequest{
-		Model:     "claude-sonnet-4-20250514",
-		MaxTokens: 8192,
-		Stream:    true,
-		Messages: []anthropicMessage{
-			{
-				Role:    "user",
-				Content: fmt.Sprintf("%s\n\nHere is the Terraform plan data to visualize:\n\n%s", visualizationPrompt, string(planJSON)),
-			},
-		},
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	// Make the API request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	client := &http.Client{Timeout: 300 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-			return fmt.Errorf("authentication failed (status %d): Invalid API key. Please check your ANTHROPIC_API_KEY environment variable. Get your API key from: https://console.anthropic.com/settings/keys\nError details: %s", resp.StatusCode, string(body))
-		}
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	// Stream the response so progress can be shown while the HTML is generated
+	req := userPrompt(fmt.Sprintf("%s\n\nHere is the Terraform plan data to visualize:\n\n%s", visualizationPrompt, string(planJSON)), 8192, 300*time.Second)
 
-	// Process the streaming response
 	var htmlContent strings.Builder
-	scanner := bufio.NewScanner(resp.Body)
 	progressChars := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	charIndex := 0
 	charCount := 0
 
 	fmt.Print("\n")
 
-	for scanner.Scan() {
-		// Check if context was cancelled
-		select {
-		case <-ctx.Done():
+	_, err = provider.Stream(ctx, req, func(delta string) {
+		htmlContent.WriteString(delta)
+		charCount += len(delta)
+
+		// Show progress
+		fmt.Printf("\r%s Generating HTML... (%d characters) [Press Ctrl+C to cancel]", progressChars[charIndex], charCount)
+		charIndex = (charIndex + 1) % len(progressChars)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
 			fmt.Println("\n\n❌ AI visualization generation cancelled")
 			return fmt.Errorf("cancelled by user")
-		default:
-			// Continue processing
-		}
-
-		line := scanner.Text()
-
-		// Skip empty lines
-		if line == "" {
-			continue
 		}
-
-		// Parse SSE data
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-
-			// Skip the [DONE] message
-			if data == "[DONE]" {
-				continue
-			}
-
-			var event streamEvent
-			if err := json.Unmarshal([]byte(data), &event); err != nil {
-				continue
-			}
-
-			// Handle content delta
-			if event.Type == "content_block_delta" && event.Delta.Text != "" {
-				htmlContent.WriteString(event.Delta.Text)
-				charCount += len(event.Delta.Text)
-
-				// Show progress
-				fmt.Printf("\r%s Generating HTML... (%d characters) [Press Ctrl+C to cancel]", progressChars[charIndex], charCount)
-				charIndex = (charIndex + 1) % len(progressChars)
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading stream: %w", err)
+		return fmt.Errorf("error generating visualization: %w", err)
 	}
 
 	// Check if we were cancelled
@@ -196,9 +196,7 @@ func getCurrentAccount(w http.ResponseWriter, r *http.Request) {
 	// Get AWS account ID and region using the selected profile
 	if selectedAWSProfile != "" {
 		ctx := context.Background()
-		cfg, err := config.LoadDefaultConfig(ctx,
-			config.WithSharedConfigProfile(selectedAWSProfile),
-		)
+		cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 		if err == nil {
 			stsClient := sts.NewFromConfig(cfg)
 			identity, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
@@ -1012,10 +1010,7 @@ func checkECRTrustPolicyDeployedInAWS(w http.ResponseWriter, r *http.Request) {
 	var cfg aws.Config
 
 	if sourceEnv.AWSProfile != "" {
-		cfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(sourceEnv.Region),
-			config.WithSharedConfigProfile(sourceEnv.AWSProfile),
-		)
+		cfg, err = loadAWSConfigForProfile(ctx, sourceEnv.AWSProfile, config.WithRegion(sourceEnv.Region))
 	} else {
 		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(sourceEnv.Region),
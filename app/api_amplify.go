@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/amplify"
 	"github.com/aws/aws-sdk-go-v2/service/amplify/types"
 )
@@ -203,13 +202,7 @@ func hasEnvironmentTag(tags map[string]string, env string) bool {
 
 // createAWSConfig creates an AWS config with optional profile
 func createAWSConfig(ctx context.Context, profile string) (aws.Config, error) {
-	var optFns []func(*config.LoadOptions) error
-	
-	if profile != "" {
-		optFns = append(optFns, config.WithSharedConfigProfile(profile))
-	}
-	
-	return config.LoadDefaultConfig(ctx, optFns...)
+	return loadAWSConfigForProfile(ctx, profile)
 }
 
 // getAmplifyBuildLogs handles GET /api/amplify/build-logs
@@ -8,7 +8,6 @@ import (
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
 	apigwtypes "github.com/aws/aws-sdk-go-v2/service/apigatewayv2/types"
 	"gopkg.in/yaml.v2"
@@ -69,9 +68,7 @@ func getAPIGatewayInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Get AWS config
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(envConfig.AWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, envConfig.AWSProfile)
 	if err != nil {
 		info.Error = fmt.Sprintf("Failed to load AWS config: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// GET /api/certificates?env=<env>
+func getCertificates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	envName := r.URL.Query().Get("env")
+	if envName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env parameter is required"})
+		return
+	}
+
+	env, err := loadEnv(envName)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	report, err := buildCertificateReport(context.Background(), env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// POST /api/certificates/validation-records
+// Creates the missing DNS validation records of the environment's certificates in the managed zones
+func createCertificateValidationRecords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Env string `json:"env"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Env == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env is required"})
+		return
+	}
+
+	env, err := loadEnv(req.Env)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	ctx := context.Background()
+	report, err := buildCertificateReport(ctx, env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := struct {
+		Created   []CertificateValidationRecord `json:"created"`
+		Unmanaged []CertificateValidationRecord `json:"unmanaged"` // Records outside the managed zones, to add manually
+	}{Created: []CertificateValidationRecord{}, Unmanaged: []CertificateValidationRecord{}}
+
+	var creatable []CertificateValidationRecord
+	for _, record := range report.MissingValidationRecords() {
+		if record.Zone == "" {
+			resp.Unmanaged = append(resp.Unmanaged, record)
+		} else {
+			creatable = append(creatable, record)
+		}
+	}
+	if len(creatable) > 0 {
+		created, err := createValidationRecords(ctx, env, creatable)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		resp.Created = created
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
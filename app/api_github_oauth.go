@@ -11,7 +11,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -345,9 +344,7 @@ func pollForAccessToken(session *DeviceFlowSession) {
 // Store the access token in SSM Parameter Store
 func storeTokenInSSM(session *DeviceFlowSession) error {
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		return fmt.Errorf("failed to load AWS config: %w", err)
 	}
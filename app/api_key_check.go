@@ -2,18 +2,19 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-// CheckAnthropicAPIKey checks if the ANTHROPIC_API_KEY is set
-// Returns true if key is present, false otherwise
+// CheckAnthropicAPIKey checks if the configured LLM provider is usable
+// (ANTHROPIC_API_KEY by default, or the provider configured in llm.yaml)
+// Returns true if AI features can be used, false otherwise
 func CheckAnthropicAPIKey() bool {
-	return os.Getenv("ANTHROPIC_API_KEY") != ""
+	return isLLMConfigured()
 }
 
-// ShowAPIKeyRequiredScreen displays a friendly message when API key is missing
+// ShowAPIKeyRequiredScreen displays a friendly message when the LLM provider is not configured
 func ShowAPIKeyRequiredScreen() {
 	// Define colors and styles
 	titleStyle := lipgloss.NewStyle().
@@ -58,6 +59,13 @@ func ShowAPIKeyRequiredScreen() {
 
 	fmt.Println(titleStyle.Render(art))
 
+	// Steps for the provider configured in llm.yaml (Anthropic by default)
+	problem, steps := llmSetupSteps()
+	var stepLines []string
+	for i, step := range steps {
+		stepLines = append(stepLines, fmt.Sprintf("%s\n   %s", highlightStyle.Render(fmt.Sprintf("Step %d:", i+1)), linkStyle.Render(step)))
+	}
+
 	// Information box
 	infoContent := fmt.Sprintf(`%s
 
@@ -81,29 +89,21 @@ Meroku includes powerful AI capabilities powered by Claude:
 
 
 %s
-To unlock these features, get an Anthropic API key.
-
-%s Get your API key:
+To unlock these features, configure the AI provider:
    %s
 
-%s Set it as an environment variable:
-   export ANTHROPIC_API_KEY=your_key_here
-
-   Or add to your shell profile (~/.bashrc, ~/.zshrc):
-   echo 'export ANTHROPIC_API_KEY=your_key_here' >> ~/.zshrc
-
+%s
 
-%s Meroku works without an API key, but AI features
+%s Meroku works without an AI provider, but AI features
 will be unavailable. Press Enter to continue...`,
-		warningStyle.Render("⚠️  ANTHROPIC_API_KEY NOT FOUND"),
+		warningStyle.Render("⚠️  AI PROVIDER NOT CONFIGURED"),
 		highlightStyle.Render("🔍"),
 		highlightStyle.Render("💡"),
 		highlightStyle.Render("🛠️"),
 		highlightStyle.Render("📊"),
 		titleStyle.Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"),
-		highlightStyle.Render("Step 1:"),
-		linkStyle.Render("https://console.anthropic.com/settings/keys"),
-		highlightStyle.Render("Step 2:"),
+		problem,
+		strings.Join(stepLines, "\n\n"),
 		warningStyle.Render("Note:"),
 	)
 
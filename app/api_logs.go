@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -44,10 +43,14 @@ func constructLogGroupName(envConfig Env, serviceName string) string {
 
 // LogEntry represents a single log entry
 type LogEntry struct {
-	Timestamp string `json:"timestamp"`
-	Message   string `json:"message"`
-	Level     string `json:"level"`
-	Stream    string `json:"stream"`
+	Timestamp string                 `json:"timestamp"`
+	Message   string                 `json:"message"`
+	Level     string                 `json:"level"`
+	Stream    string                 `json:"stream"`
+	Source    string                 `json:"source,omitempty"` // stdout or stderr, when the log router records it
+	TraceID   string                 `json:"traceId,omitempty"`
+	RequestID string                 `json:"requestId,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"` // All fields of a JSON line
 }
 
 // LogsResponse represents the response for logs endpoint
@@ -66,7 +69,11 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// getServiceLogs retrieves recent logs for a service
+// logsFilterMaxPages caps the pages read to fill a page of filtered logs
+const logsFilterMaxPages = 20
+
+// getServiceLogs retrieves recent logs for a service,
+// optionally only some levels (?level=error,warning) and fields (?field=name=value)
 func getServiceLogs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -84,6 +91,13 @@ func getServiceLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	// Default limit
 	logLimit := int32(100)
 	if limit != "" {
@@ -111,6 +125,7 @@ func getServiceLogs(w http.ResponseWriter, r *http.Request) {
 
 	// Construct log group name based on service type
 	logGroupName := constructLogGroupName(envConfig, serviceName)
+	mapping := logFieldMapping(envConfig, serviceName)
 
 	// Get AWS config
 	ctx := context.Background()
@@ -162,35 +177,26 @@ func getServiceLogs(w http.ResponseWriter, r *http.Request) {
 			filterInput.NextToken = aws.String(nextToken)
 		}
 
-		filterResult, err := cwClient.FilterLogEvents(ctx, filterInput)
-		if err == nil {
+		// With a filter, pages are read until the page of logs is full
+		for pages := 0; pages < logsFilterMaxPages; pages++ {
+			filterResult, err := cwClient.FilterLogEvents(ctx, filterInput)
+			if err != nil {
+				break
+			}
 			for _, event := range filterResult.Events {
 				if event.Message != nil && event.Timestamp != nil {
-					// Parse log level from message
-					level := "info"
-					message := *event.Message
-					messageLower := strings.ToLower(message)
-					
-					if strings.Contains(messageLower, "error") || strings.Contains(messageLower, "exception") {
-						level = "error"
-					} else if strings.Contains(messageLower, "warn") {
-						level = "warning"
-					} else if strings.Contains(messageLower, "debug") {
-						level = "debug"
+					entry := parseLogEntry(mapping, aws.ToString(event.LogStreamName), *event.Timestamp, *event.Message)
+					if filter.Matches(entry) {
+						logs = append(logs, entry)
 					}
-
-					logs = append(logs, LogEntry{
-						Timestamp: time.Unix(*event.Timestamp/1000, 0).Format(time.RFC3339),
-						Message:   strings.TrimSpace(message),
-						Level:     level,
-						Stream:    *event.LogStreamName,
-					})
 				}
 			}
 
-			if filterResult.NextToken != nil {
-				outputNextToken = *filterResult.NextToken
+			outputNextToken = aws.ToString(filterResult.NextToken)
+			if filter.IsEmpty() || outputNextToken == "" || len(logs) >= int(logLimit) {
+				break
 			}
+			filterInput.NextToken = filterResult.NextToken
 		}
 	}
 
@@ -218,6 +224,11 @@ func streamServiceLogs(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "env and service parameters are required", http.StatusBadRequest)
 		return
 	}
+	filter, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -243,6 +254,7 @@ func streamServiceLogs(w http.ResponseWriter, r *http.Request) {
 
 	// Construct log group name based on service type
 	logGroupName := constructLogGroupName(envConfig, serviceName)
+	mapping := logFieldMapping(envConfig, serviceName)
 
 	// Get AWS config
 	ctx := context.Background()
@@ -329,25 +341,10 @@ func streamServiceLogs(w http.ResponseWriter, r *http.Request) {
 								lastTimestamp = *event.Timestamp
 							}
 
-							// Parse log level
-							level := "info"
-							message := *event.Message
-							messageLower := strings.ToLower(message)
-							
-							if strings.Contains(messageLower, "error") || strings.Contains(messageLower, "exception") {
-								level = "error"
-							} else if strings.Contains(messageLower, "warn") {
-								level = "warning"
-							} else if strings.Contains(messageLower, "debug") {
-								level = "debug"
+							entry := parseLogEntry(mapping, aws.ToString(event.LogStreamName), *event.Timestamp, *event.Message)
+							if filter.Matches(entry) {
+								newLogs = append(newLogs, entry)
 							}
-
-							newLogs = append(newLogs, LogEntry{
-								Timestamp: time.Unix(*event.Timestamp/1000, 0).Format(time.RFC3339),
-								Message:   strings.TrimSpace(message),
-								Level:     level,
-								Stream:    *event.LogStreamName,
-							})
 						}
 					}
 
@@ -365,4 +362,119 @@ func streamServiceLogs(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+// POST /api/logs/insights
+// Runs a Logs Insights query over the environment's log groups and waits for the result
+func runLogsInsightsQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Env      string   `json:"env"`
+		Query    string   `json:"query"`
+		Saved    string   `json:"saved"`    // Name of a saved query, used if query is empty
+		Services []string `json:"services"` // Default: all services and tasks of the environment
+		Since    string   `json:"since"`    // Default: 1h
+		Limit    int32    `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Env == "" || (req.Query == "" && req.Saved == "") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env and query (or saved) are required"})
+		return
+	}
+	if req.Query == "" {
+		saved, err := findSavedLogQuery(req.Saved)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		req.Query = saved.Query
+		if len(req.Services) == 0 {
+			req.Services = saved.Services
+		}
+	}
+	if req.Since == "" {
+		req.Since = "1h"
+	}
+	now := time.Now()
+	start, err := parseLogsSince(req.Since, now)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	env, err := loadEnv(req.Env)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	// The query is stopped if the client goes away
+	ctx := r.Context()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
+		return
+	}
+
+	result, err := runInsightsQuery(ctx, cloudwatchlogs.NewFromConfig(cfg), env, InsightsQuery{
+		Query:    req.Query,
+		Services: req.Services,
+		Start:    start,
+		End:      now,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GET/POST/DELETE /api/logs/insights/queries
+// Lists, saves (by name, replacing an existing one) or deletes (?name=<name>) the project's saved queries
+func handleSavedLogQueries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var query SavedLogQuery
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body"})
+			return
+		}
+		if err := saveLogQuery(query); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+	case http.MethodDelete:
+		if err := deleteLogQuery(r.URL.Query().Get("name")); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	queries, err := loadSavedLogQueries()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queries)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingpkg "madappgang.com/meroku/pricing"
 )
 
 // PricingResponse represents the pricing data for all services
@@ -244,11 +245,27 @@ func getPricing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 13. VPC pricing (for endpoints if used)
-	vpcPricing := calculateVPCPricing(region)
+	vpcPricing := calculateVPCPricing(region, env.Network)
 	if vpcPricing != nil {
 		response.Nodes["vpc"] = *vpcPricing
 	}
 
+	// 14. NAT pricing if private subnets are enabled
+	if env.Network.PrivateSubnets && !env.UseDefaultVPC {
+		natPricing := calculateNATPricing(region, env.Network)
+		if natPricing != nil {
+			response.Nodes["nat"] = *natPricing
+		}
+	}
+
+	// 15. WAF pricing if enabled (attached to the ALB)
+	if env.WAF.Enabled && env.ALB.Enabled {
+		wafPricing := calculateWAFPricing(region, env.WAF)
+		if wafPricing != nil {
+			response.Nodes["waf"] = *wafPricing
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -617,22 +634,189 @@ func calculateECRPricing(ctx context.Context, client *pricing.Client, region str
 	return nodePricing
 }
 
-func calculateVPCPricing(region string) *NodePricing {
+func calculateVPCPricing(region string, network Network) *NodePricing {
 	nodePricing := &NodePricing{
 		ServiceName: "VPC",
 		ServiceType: "networking",
 		Levels:      make(map[string]LevelPrice),
 	}
 
-	// VPC is free, but we'll show it with $0 pricing
+	// VPC itself is free, interface endpoints cost $0.01/hour per AZ
+	// (gateway endpoints for S3 and DynamoDB are free)
+	interfaceEndpoints := 0
+	if network.PrivateSubnets {
+		for _, endpoint := range network.VPCEndpoints {
+			interfaceEndpoints += interfaceEndpointServiceCount[endpoint]
+		}
+	}
+	hourlyPrice := float64(interfaceEndpoints*vpcAZCount) * 0.01
+
+	subnets := vpcAZCount
+	if network.PrivateSubnets {
+		subnets *= 2
+	}
+
 	for level := range WorkloadSpecs {
+		details := map[string]string{
+			"subnets":        fmt.Sprintf("%d", subnets),
+			"securityGroups": "Multiple",
+			"cost":           "Free",
+		}
+		if interfaceEndpoints > 0 {
+			details["cost"] = "Interface endpoints"
+			details["interfaceEndpoints"] = fmt.Sprintf("%d x %d AZ", interfaceEndpoints, vpcAZCount)
+		}
+
+		nodePricing.Levels[level] = LevelPrice{
+			HourlyPrice:  hourlyPrice,
+			MonthlyPrice: hourlyPrice * 730,
+			Details:      details,
+		}
+	}
+
+	return nodePricing
+}
+
+// vpcAZCount is the number of AZs the VPC module creates subnets in
+const vpcAZCount = 2
+
+// interfaceEndpointServiceCount maps network.vpc_endpoints entries to the number
+// of interface endpoints the VPC module creates for them (0 for gateway endpoints)
+var interfaceEndpointServiceCount = map[string]int{
+	"ecr":            2, // ecr.api + ecr.dkr
+	"logs":           1,
+	"ssm":            3, // ssm + ssmmessages + ec2messages
+	"secretsmanager": 1,
+	"sts":            1,
+}
+
+// natInstanceHourlyPrices holds on-demand prices for common NAT instance types.
+// They are us-east-1 prices, so estimates for other regions are marked as such.
+var natInstanceHourlyPrices = map[string]float64{
+	"t4g.nano":  0.0042,
+	"t4g.micro": 0.0084,
+	"t4g.small": 0.0168,
+	"t3.nano":   0.0052,
+	"t3.micro":  0.0104,
+	"t3.small":  0.0208,
+}
+
+func calculateNATPricing(region string, network Network) *NodePricing {
+	nodePricing := &NodePricing{
+		ServiceName: "NAT",
+		ServiceType: "networking",
+		Levels:      make(map[string]LevelPrice),
+	}
+
+	if network.NATMode == NATModeInstance {
+		instanceType := network.NATInstanceType
+		if instanceType == "" {
+			instanceType = "t4g.nano"
+		}
+		hourlyPrice, ok := natInstanceHourlyPrices[instanceType]
+		if !ok {
+			hourlyPrice = natInstanceHourlyPrices["t4g.nano"]
+		}
+
+		// NAT instances have no per-GB processing charge
+		for level, specs := range WorkloadSpecs {
+			nodePricing.Levels[level] = LevelPrice{
+				HourlyPrice:  hourlyPrice,
+				MonthlyPrice: hourlyPrice * 730,
+				Details: map[string]string{
+					"mode":          "NAT instance",
+					"instanceType":  instanceType,
+					"dataProcessed": fmt.Sprintf("%d GB/month", specs["nat_gateway_gb"].(int)),
+					"priceRegion":   "us-east-1 (estimate)",
+				},
+			}
+		}
+		return nodePricing
+	}
+
+	// Use cached rates from the pricing service, fall back to us-east-1 list price
+	rates := &pricingpkg.PriceRates{
+		NATGateway: pricingpkg.NATGatewayPricing{HourlyPrice: 0.045, DataPerGBMonth: 0.045},
+	}
+	priceRegion := "us-east-1 (estimate)"
+	if globalPricingService != nil {
+		if cached, err := globalPricingService.GetRates(region); err == nil {
+			rates = cached
+			priceRegion = region
+		}
+	}
+
+	gatewayCount := 1
+	mode := "Single NAT gateway"
+	if network.NATMode == NATModePerAZ {
+		gatewayCount = vpcAZCount
+		mode = "NAT gateway per AZ"
+	}
+
+	for level, specs := range WorkloadSpecs {
+		dataGB := specs["nat_gateway_gb"].(int)
+		monthlyPrice := pricingpkg.CalculateNATGatewayPrice(pricingpkg.NATGatewayConfig{
+			Count:           gatewayCount,
+			DataProcessedGB: float64(dataGB),
+		}, rates)
+
 		nodePricing.Levels[level] = LevelPrice{
-			HourlyPrice:  0,
-			MonthlyPrice: 0,
+			HourlyPrice:  monthlyPrice / 730,
+			MonthlyPrice: monthlyPrice,
 			Details: map[string]string{
-				"subnets":         "6",
-				"securityGroups":  "Multiple",
-				"cost":            "Free",
+				"mode":          mode,
+				"gateways":      fmt.Sprintf("%d", gatewayCount),
+				"dataProcessed": fmt.Sprintf("%d GB/month", dataGB),
+				"priceRegion":   priceRegion,
+			},
+		}
+	}
+
+	return nodePricing
+}
+
+func calculateWAFPricing(region string, waf WAF) *NodePricing {
+	nodePricing := &NodePricing{
+		ServiceName: "AWS WAF",
+		ServiceType: "security",
+		Levels:      make(map[string]LevelPrice),
+	}
+
+	// Use cached rates from the pricing service, fall back to us-east-1 list price
+	rates := &pricingpkg.PriceRates{
+		WAF: pricingpkg.WAFPricing{WebACLPerMonth: 5.00, RulePerMonth: 1.00, RequestsPerMillion: 0.60},
+	}
+	if globalPricingService != nil {
+		if cached, err := globalPricingService.GetRates(region); err == nil {
+			rates = cached
+		}
+	}
+
+	// Each managed rule group, the rate limit rule and each IP list is billed as one rule
+	rules := len(waf.enabledManagedRules())
+	if waf.RateLimit > 0 {
+		rules++
+	}
+	if len(waf.AllowIPs) > 0 {
+		rules++
+	}
+	if len(waf.DenyIPs) > 0 {
+		rules++
+	}
+
+	for level, specs := range WorkloadSpecs {
+		requests := specs["alb_requests"].(int)
+		monthlyPrice := pricingpkg.CalculateWAFPrice(pricingpkg.WAFConfig{
+			Rules:           rules,
+			MonthlyRequests: requests,
+		}, rates)
+
+		nodePricing.Levels[level] = LevelPrice{
+			HourlyPrice:  monthlyPrice / 730,
+			MonthlyPrice: monthlyPrice,
+			Details: map[string]string{
+				"rules":    fmt.Sprintf("%d", rules),
+				"requests": fmt.Sprintf("%d/month", requests),
 			},
 		}
 	}
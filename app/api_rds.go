@@ -4,9 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"sync"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/aws"
 )
@@ -38,17 +39,27 @@ func getDatabaseEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
 		return
 	}
 
-	rdsClient := rds.NewFromConfig(cfg)
+	dbInfo, err := lookupDatabaseEndpoint(ctx, rds.NewFromConfig(cfg), project, env, isAurora)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
 	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dbInfo)
+}
+
+// lookupDatabaseEndpoint finds the RDS instance or Aurora cluster created for an environment,
+// trying both naming conventions
+func lookupDatabaseEndpoint(ctx context.Context, rdsClient *rds.Client, project, env string, isAurora bool) (*DatabaseInfo, error) {
 	var dbInfo DatabaseInfo
 	
 	if isAurora {
@@ -67,9 +78,7 @@ func getDatabaseEndpoint(w http.ResponseWriter, r *http.Request) {
 			})
 			
 			if err != nil {
-				w.WriteHeader(http.StatusNotFound)
-				json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Aurora cluster not found: %v", err)})
-				return
+				return nil, fmt.Errorf("Aurora cluster not found: %v", err)
 			}
 		}
 		
@@ -100,9 +109,7 @@ func getDatabaseEndpoint(w http.ResponseWriter, r *http.Request) {
 			})
 			
 			if err != nil {
-				w.WriteHeader(http.StatusNotFound)
-				json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("RDS instance not found: %v", err)})
-				return
+				return nil, fmt.Errorf("RDS instance not found: %v", err)
 			}
 		}
 		
@@ -119,8 +126,8 @@ func getDatabaseEndpoint(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dbInfo)
+	
+	return &dbInfo, nil
 }
 
 // GET /api/rds/info?project=<project>&env=<env>
@@ -141,9 +148,7 @@ func getDatabaseInfo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
@@ -209,4 +214,202 @@ func getDatabaseInfo(w http.ResponseWriter, r *http.Request) {
 	// Neither found
 	w.WriteHeader(http.StatusNotFound)
 	json.NewEncoder(w).Encode(ErrorResponse{Error: "No RDS instance or Aurora cluster found for this project/environment"})
-}
\ No newline at end of file
+}
+// GET /api/rds/snapshots?env=<env>
+// Lists automated and manual snapshots of the environment's database, newest first
+func listRDSSnapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	envName := r.URL.Query().Get("env")
+	if envName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env parameter is required"})
+		return
+	}
+
+	env, err := loadEnv(envName)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
+		return
+	}
+
+	snapshots, err := listDBSnapshots(ctx, rds.NewFromConfig(cfg), env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+// POST /api/rds/snapshots/create
+// Body: {"env": "dev", "snapshotId": "optional-id"}
+func createRDSSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Env        string `json:"env"`
+		SnapshotID string `json:"snapshotId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Env == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env is required"})
+		return
+	}
+
+	env, err := loadEnv(req.Env)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
+		return
+	}
+
+	snapshot, err := createDBSnapshot(ctx, rds.NewFromConfig(cfg), env, req.SnapshotID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// dbRestoreJobs tracks restores started from the API so the UI can poll them,
+// keyed by "<env>/<targetId>"
+var (
+	dbRestoreJobsMu sync.Mutex
+	dbRestoreJobs   = map[string]DBRestoreResult{}
+)
+
+func setDBRestoreJob(envName string, result DBRestoreResult) {
+	dbRestoreJobsMu.Lock()
+	defer dbRestoreJobsMu.Unlock()
+	dbRestoreJobs[envName+"/"+result.TargetID] = result
+}
+
+func getDBRestoreJob(envName, targetID string) (DBRestoreResult, bool) {
+	dbRestoreJobsMu.Lock()
+	defer dbRestoreJobsMu.Unlock()
+	result, ok := dbRestoreJobs[envName+"/"+targetID]
+	return result, ok
+}
+
+// POST /api/rds/snapshots/restore
+// Body: {"env": "dev", "snapshotId": "...", "targetId": "optional", "repoint": true}
+// Starts the restore and returns immediately. The restore and, with repoint, the
+// switch of the backend's database host continue in the background.
+//
+// GET /api/rds/snapshots/restore?env=<env>&targetId=<id>
+// Returns the progress of a restore started from this server: status becomes
+// "available" or "failed", with repointed and error set once it is done.
+func restoreRDSSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		result, ok := getDBRestoreJob(r.URL.Query().Get("env"), r.URL.Query().Get("targetId"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "restore not found"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Env string `json:"env"`
+		DBRestoreOptions
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Env == "" || req.SnapshotID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env and snapshotId are required"})
+		return
+	}
+
+	env, err := loadEnv(req.Env)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
+		return
+	}
+
+	client := rds.NewFromConfig(cfg)
+	result, err := startDBRestore(ctx, client, env, req.DBRestoreOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Repoint {
+		result.Note = "The backend is repointed once the database is available (10-30 minutes); poll GET /api/rds/snapshots/restore for the outcome."
+	}
+	setDBRestoreJob(env.Env, *result)
+
+	// The goroutine works on its own copy, the response is encoded from result
+	job := *result
+	go func() {
+		bg := context.Background()
+		if err := waitForDBRestore(bg, client, &job); err != nil {
+			log.Printf("[RDS] Restore of %s failed: %v", job.TargetID, err)
+			job.Status = "failed"
+			job.Error = err.Error()
+			setDBRestoreJob(env.Env, job)
+			return
+		}
+		if !req.Repoint {
+			setDBRestoreJob(env.Env, job)
+			return
+		}
+		if err := repointDBEndpoint(bg, cfg, env, job.Endpoint); err != nil {
+			log.Printf("[RDS] Failed to repoint %s to %s: %v", env.Env, job.Endpoint, err)
+			job.Error = err.Error()
+			setDBRestoreJob(env.Env, job)
+			return
+		}
+		job.Repointed = true
+		job.Note = dbRepointNote(env, job.Endpoint)
+		setDBRestoreJob(env.Env, job)
+		log.Printf("[RDS] %s backend now points to %s", env.Env, job.Endpoint)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
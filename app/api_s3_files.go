@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"gopkg.in/yaml.v2"
 )
@@ -57,9 +56,7 @@ func getS3File(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to load AWS config"})
@@ -127,9 +124,7 @@ func putS3File(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to load AWS config"})
@@ -180,9 +175,7 @@ func deleteS3File(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to load AWS config"})
@@ -227,9 +220,7 @@ func listS3Files(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to load AWS config"})
@@ -324,9 +315,7 @@ func listProjectS3Buckets(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to load AWS config"})
@@ -10,7 +10,6 @@ import (
 	"os/exec"
 	"sync"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/gorilla/websocket"
 	"gopkg.in/yaml.v2"
@@ -72,9 +71,7 @@ func startSSHSession(w http.ResponseWriter, r *http.Request) {
 
 	// Get AWS region from config
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		http.Error(w, "Failed to load AWS config: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -300,9 +297,7 @@ func getSSHCapability(w http.ResponseWriter, r *http.Request) {
 
 	// Load AWS config
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		http.Error(w, "Failed to load AWS config", http.StatusInternalServerError)
 		return
@@ -11,7 +11,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/creack/pty"
 	"github.com/gorilla/websocket"
 	"gopkg.in/yaml.v2"
@@ -69,9 +68,7 @@ func startSSHSessionPTY(w http.ResponseWriter, r *http.Request) {
 
 	// Get AWS region from config
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		http.Error(w, "Failed to load AWS config: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+// POST /api/ecs/run
+// Starts a one-off task with the request's command, follow it with /ws/ecs/run
+func runOneOffTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Env string `json:"env"`
+		OneOffTaskRequest
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Env == "" || req.Service == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env and service are required"})
+		return
+	}
+
+	env, err := loadEnv(req.Env)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to load AWS config: %v", err)})
+		return
+	}
+
+	task, err := startOneOffTask(ctx, ecs.NewFromConfig(cfg), ec2.NewFromConfig(cfg), env, req.OneOffTaskRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// GET /ws/ecs/run?env=<env>&service=<service>&task=<task id or arn>
+// Streams the logs and status of a one-off task and its exit code when it stops.
+// Closing the connection leaves the task running.
+func followOneOffTaskStream(w http.ResponseWriter, r *http.Request) {
+	envName := r.URL.Query().Get("env")
+	serviceName := r.URL.Query().Get("service")
+	taskID := r.URL.Query().Get("task")
+	if envName == "" || serviceName == "" || taskID == "" {
+		http.Error(w, "env, service and task parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, "Failed to upgrade to WebSocket", http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	env, err := loadEnv(envName)
+	if err != nil {
+		conn.WriteJSON(map[string]string{"error": "environment not found"})
+		return
+	}
+	task, err := findOneOffTask(env, serviceName, taskID)
+	if err != nil {
+		conn.WriteJSON(map[string]string{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		conn.WriteJSON(map[string]string{"error": "failed to load AWS config"})
+		return
+	}
+
+	// Read messages from client (for ping/pong and close detection)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	conn.WriteJSON(map[string]interface{}{"type": "connected", "data": task})
+
+	mapping := logFieldMapping(env, serviceName)
+	result, err := followOneOffTask(ctx, ecs.NewFromConfig(cfg), cloudwatchlogs.NewFromConfig(cfg), task, time.Unix(0, 0),
+		func(e serviceLogEvent) {
+			entry := parseLogEntry(mapping, e.Stream, e.Timestamp, e.Message)
+			conn.WriteJSON(map[string]interface{}{"type": "logs", "data": []LogEntry{entry}})
+		},
+		func(status string) {
+			conn.WriteJSON(map[string]interface{}{"type": "status", "status": status})
+		})
+	if err != nil {
+		if ctx.Err() == nil {
+			conn.WriteJSON(map[string]string{"error": err.Error()})
+		}
+		return
+	}
+	conn.WriteJSON(map[string]interface{}{"type": "exit", "data": result})
+}
@@ -8,7 +8,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"gopkg.in/yaml.v2"
@@ -50,9 +49,7 @@ func getServiceTasks(w http.ResponseWriter, r *http.Request) {
 
 	// Load AWS config
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(selectedAWSProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile)
 	if err != nil {
 		http.Error(w, "Failed to load AWS config: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// WAFBlockedStats represents blocked/allowed request counts for an environment's web ACL
+type WAFBlockedStats struct {
+	WebACL       string             `json:"webAcl"`
+	Region       string             `json:"region"`
+	Hours        int                `json:"hours"`
+	TotalBlocked float64            `json:"totalBlocked"`
+	TotalAllowed float64            `json:"totalAllowed"`
+	ByRule       map[string]float64 `json:"byRule"`   // rule name -> blocked requests
+	Timeline     []MetricPoint      `json:"timeline"` // blocked requests per period
+	Error        string             `json:"error,omitempty"`
+}
+
+// wafWebACLName matches the web ACL name created by modules/waf
+func wafWebACLName(project, env string) string {
+	return fmt.Sprintf("%s-waf-%s", project, env)
+}
+
+// wafMetricPrefix matches local.metric_prefix in modules/waf, CloudWatch metric names take no underscores
+func wafMetricPrefix(project, env string) string {
+	return strings.ReplaceAll(fmt.Sprintf("%s-%s", project, env), "_", "-")
+}
+
+// wafWebACLMetricName is the CloudWatch "WebACL" dimension, the web ACL's visibility_config
+// metric_name in modules/waf rather than its name
+func wafWebACLMetricName(project, env string) string {
+	return wafMetricPrefix(project, env) + "-waf"
+}
+
+// wafRuleMetricNames maps rule names shown to the user to the CloudWatch "Rule"
+// dimension values set in modules/waf visibility_config
+func wafRuleMetricNames(project, env string, waf WAF) map[string]string {
+	prefix := wafMetricPrefix(project, env)
+	rules := map[string]string{}
+	if len(waf.DenyIPs) > 0 {
+		rules["deny-list"] = prefix + "-deny-list"
+	}
+	if waf.RateLimit > 0 {
+		rules["rate-limit"] = prefix + "-rate-limit"
+	}
+	for _, rule := range waf.enabledManagedRules() {
+		if group, ok := wafManagedRuleGroups[rule]; ok {
+			rules[group] = prefix + "-" + strings.ReplaceAll(rule, "_", "-")
+		}
+	}
+	return rules
+}
+
+// GET /api/waf/blocked?env=<env>&hours=<hours>
+// Returns blocked request counts for the environment's WAF web ACL (default last 24 hours)
+func getWAFBlockedRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	envName := r.URL.Query().Get("env")
+	if envName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "env parameter is required"})
+		return
+	}
+
+	hours := 24
+	if h := r.URL.Query().Get("hours"); h != "" {
+		parsed, err := strconv.Atoi(h)
+		if err != nil || parsed < 1 || parsed > 24*14 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "hours must be between 1 and 336"})
+			return
+		}
+		hours = parsed
+	}
+
+	env, err := loadEnv(envName)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "environment not found"})
+		return
+	}
+
+	if !env.WAF.Enabled {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "WAF is not enabled for this environment"})
+		return
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForProfile(ctx, selectedAWSProfile, config.WithRegion(env.Region))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "failed to load AWS config"})
+		return
+	}
+
+	stats, err := fetchWAFBlockedStats(ctx, cloudwatch.NewFromConfig(cfg), env, hours)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("failed to get WAF metrics: %v", err)})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func fetchWAFBlockedStats(ctx context.Context, client *cloudwatch.Client, env Env, hours int) (*WAFBlockedStats, error) {
+	webACL := wafWebACLMetricName(env.Project, env.Env)
+	stats := &WAFBlockedStats{
+		WebACL:   wafWebACLName(env.Project, env.Env),
+		Region:   env.Region,
+		Hours:    hours,
+		ByRule:   map[string]float64{},
+		Timeline: []MetricPoint{},
+	}
+
+	// Keep the timeline to at most ~288 points
+	period := int32(300)
+	if hours > 24 {
+		period = 3600
+	}
+
+	metric := func(id, metricName, rule string) cloudwatchtypes.MetricDataQuery {
+		return cloudwatchtypes.MetricDataQuery{
+			Id: aws.String(id),
+			MetricStat: &cloudwatchtypes.MetricStat{
+				Metric: &cloudwatchtypes.Metric{
+					Namespace:  aws.String("AWS/WAFV2"),
+					MetricName: aws.String(metricName),
+					Dimensions: []cloudwatchtypes.Dimension{
+						{Name: aws.String("WebACL"), Value: aws.String(webACL)},
+						{Name: aws.String("Region"), Value: aws.String(env.Region)},
+						{Name: aws.String("Rule"), Value: aws.String(rule)},
+					},
+				},
+				Period: aws.Int32(period),
+				Stat:   aws.String("Sum"),
+			},
+		}
+	}
+
+	queries := []cloudwatchtypes.MetricDataQuery{
+		metric("blocked", "BlockedRequests", "ALL"),
+		metric("allowed", "AllowedRequests", "ALL"),
+	}
+	ruleIDs := map[string]string{}
+	i := 0
+	for name, ruleMetric := range wafRuleMetricNames(env.Project, env.Env, env.WAF) {
+		id := fmt.Sprintf("rule%d", i)
+		ruleIDs[id] = name
+		queries = append(queries, metric(id, "BlockedRequests", ruleMetric))
+		i++
+	}
+
+	now := time.Now()
+	start := now.Add(-time.Duration(hours) * time.Hour)
+	paginator := cloudwatch.NewGetMetricDataPaginator(client, &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         &start,
+		EndTime:           &now,
+		ScanBy:            cloudwatchtypes.ScanByTimestampAscending,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range page.MetricDataResults {
+			id := aws.ToString(result.Id)
+			for idx, value := range result.Values {
+				switch id {
+				case "blocked":
+					stats.TotalBlocked += value
+					stats.Timeline = append(stats.Timeline, MetricPoint{
+						Timestamp: result.Timestamps[idx].Format(time.RFC3339),
+						Value:     value,
+					})
+				case "allowed":
+					stats.TotalAllowed += value
+				default:
+					if name, ok := ruleIDs[id]; ok {
+						stats.ByRule[name] += value
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(stats.Timeline, func(a, b int) bool {
+		return stats.Timeline[a].Timestamp < stats.Timeline[b].Timestamp
+	})
+
+	return stats, nil
+}
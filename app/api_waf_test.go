@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestWAFMetricNamesMatchModule renders the metric names modules/waf sets and checks the
+// dimensions queried by /api/waf/blocked are among them
+func TestWAFMetricNamesMatchModule(t *testing.T) {
+	data, err := os.ReadFile("../modules/waf/main.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	module := string(data)
+	if !strings.Contains(module, `metric_prefix = replace("${var.project}-${var.env}", "_", "-")`) {
+		t.Fatal("local.metric_prefix in modules/waf changed, update wafMetricPrefix")
+	}
+
+	project, env := "my_shop", "dev_eu"
+	prefix := wafMetricPrefix(project, env)
+	var metricNames []string
+	for _, m := range regexp.MustCompile(`metric_name\s+= "(.*)"`).FindAllStringSubmatch(module, -1) {
+		name := strings.ReplaceAll(m[1], "${local.metric_prefix}", prefix)
+		if strings.Contains(name, "${replace(rule.value") {
+			for rule := range wafManagedRuleGroups {
+				metricNames = append(metricNames, strings.ReplaceAll(name, `${replace(rule.value, "_", "-")}`, strings.ReplaceAll(rule, "_", "-")))
+			}
+			continue
+		}
+		metricNames = append(metricNames, name)
+	}
+
+	if got := wafWebACLMetricName(project, env); got != "my-shop-dev-eu-waf" || !slices.Contains(metricNames, got) {
+		t.Errorf("wafWebACLMetricName() = %q, module metric names %v", got, metricNames)
+	}
+	rules := wafRuleMetricNames(project, env, WAF{Enabled: true, RateLimit: 100, DenyIPs: []string{"10.0.0.1/32"}})
+	if len(rules) != 5 {
+		t.Errorf("wafRuleMetricNames() = %v", rules)
+	}
+	for rule, metric := range rules {
+		if !slices.Contains(metricNames, metric) {
+			t.Errorf("rule %s metric %q is not set in modules/waf, module metric names %v", rule, metric, metricNames)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"gopkg.in/ini.v1"
+)
+
+// validCredentialSources are the credential_source values the SDKs accept
+var validCredentialSources = []string{"Environment", "Ec2InstanceMetadata", "EcsContainer"}
+
+// mfaSerialPattern matches virtual MFA device ARNs and hardware token serial numbers
+var mfaSerialPattern = regexp.MustCompile(`^(arn:aws[\w-]*:iam::\d{12}:mfa/[\w+=,.@/-]+|[A-Z0-9]{8,})$`)
+
+// validateAssumeRole validates a role profile and walks its source_profile chain
+func (pi *ProfileInspector) validateAssumeRole(section *ini.Section, info *ProfileInfo) {
+	info.RoleARN = section.Key("role_arn").String()
+	info.SourceProfile = section.Key("source_profile").String()
+	info.CredentialSource = section.Key("credential_source").String()
+	info.MFASerial = section.Key("mfa_serial").String()
+	info.Region = section.Key("region").String()
+	info.Output = section.Key("output").String()
+
+	if info.RoleARN == "" {
+		info.MissingFields = append(info.MissingFields, "role_arn")
+	}
+	if info.MFASerial != "" && !mfaSerialPattern.MatchString(info.MFASerial) {
+		info.MissingFields = append(info.MissingFields, fmt.Sprintf("mfa_serial (invalid: %s)", info.MFASerial))
+	}
+
+	switch {
+	case info.SourceProfile != "" && info.CredentialSource != "":
+		info.MissingFields = append(info.MissingFields, "source_profile or credential_source (not both)")
+	case info.SourceProfile != "":
+		pi.resolveRoleChain(info)
+	case info.CredentialSource != "":
+		if !slices.Contains(validCredentialSources, info.CredentialSource) {
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("credential_source (one of %s)", strings.Join(validCredentialSources, ", ")))
+		} else if info.CredentialSource == "Environment" {
+			info.SourceType = ProfileTypeEnvironment
+		}
+	default:
+		info.MissingFields = append(info.MissingFields, "source_profile or credential_source")
+	}
+
+	info.Complete = len(info.MissingFields) == 0
+}
+
+// resolveRoleChain follows source_profile references until it reaches the
+// profile that provides the base credentials. The chain may go through other
+// role profiles; a profile may name itself as source to use its own static keys.
+func (pi *ProfileInspector) resolveRoleChain(info *ProfileInfo) {
+	info.RoleChain = []string{info.Name}
+	current := pi.config.Section(getSectionName(info.Name))
+	next := info.SourceProfile
+
+	for {
+		last := info.RoleChain[len(info.RoleChain)-1]
+		if next == last {
+			if current.HasKey("aws_access_key_id") {
+				info.SourceType = ProfileTypeStaticKeys
+				return
+			}
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("source_profile ('%s' references itself without access keys)", next))
+			return
+		}
+		if slices.Contains(info.RoleChain, next) {
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("source_profile (cycle: %s -> %s)", strings.Join(info.RoleChain, " -> "), next))
+			return
+		}
+		if !pi.config.HasSection(getSectionName(next)) {
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("source_profile '%s' (not found)", next))
+			return
+		}
+
+		info.RoleChain = append(info.RoleChain, next)
+		current = pi.config.Section(getSectionName(next))
+		if !current.HasKey("role_arn") || !current.HasKey("source_profile") {
+			break
+		}
+		if info.MFASerial == "" {
+			info.MFASerial = current.Key("mfa_serial").String()
+		}
+		next = current.Key("source_profile").String()
+	}
+
+	root, err := pi.InspectProfile(info.RoleChain[len(info.RoleChain)-1])
+	if err != nil {
+		info.MissingFields = append(info.MissingFields, fmt.Sprintf("source_profile (%v)", err))
+		return
+	}
+	info.SourceType = root.Type
+	if root.Type == ProfileTypeAssumeRole && root.SourceType != "" {
+		// A role using credential_source
+		info.SourceType = root.SourceType
+	}
+	if root.MFASerial != "" && info.MFASerial == "" {
+		info.MFASerial = root.MFASerial
+	}
+	for _, field := range root.MissingFields {
+		info.MissingFields = append(info.MissingFields, fmt.Sprintf("%s (in source profile '%s')", field, root.Name))
+	}
+}
+
+// validateCredentialProcess checks that the credential_process executable can be found
+func (pi *ProfileInspector) validateCredentialProcess(section *ini.Section, info *ProfileInfo) {
+	info.CredentialProcess = section.Key("credential_process").String()
+	info.Region = section.Key("region").String()
+	info.Output = section.Key("output").String()
+
+	executable := credentialProcessExecutable(info.CredentialProcess)
+	switch {
+	case executable == "":
+		info.MissingFields = append(info.MissingFields, "credential_process")
+	case strings.ContainsRune(executable, filepath.Separator) || strings.Contains(executable, "/"):
+		if _, err := os.Stat(executable); err != nil {
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("credential_process executable '%s' (not found)", executable))
+		}
+	default:
+		if _, err := exec.LookPath(executable); err != nil {
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("credential_process executable '%s' (not in PATH)", executable))
+		}
+	}
+
+	info.Complete = len(info.MissingFields) == 0
+}
+
+// validateWebIdentity validates a role profile that exchanges an OIDC token file
+func (pi *ProfileInspector) validateWebIdentity(section *ini.Section, info *ProfileInfo) {
+	info.RoleARN = section.Key("role_arn").String()
+	info.WebIdentityTokenFile = section.Key("web_identity_token_file").String()
+	info.Region = section.Key("region").String()
+	info.Output = section.Key("output").String()
+
+	if info.RoleARN == "" {
+		info.MissingFields = append(info.MissingFields, "role_arn")
+	}
+	if _, err := os.Stat(info.WebIdentityTokenFile); err != nil {
+		info.MissingFields = append(info.MissingFields, fmt.Sprintf("web_identity_token_file '%s' (not readable)", info.WebIdentityTokenFile))
+	}
+
+	info.Complete = len(info.MissingFields) == 0
+}
+
+// credentialProcessExecutable returns the program a credential_process runs
+func credentialProcessExecutable(command string) string {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return ""
+	}
+	if quote := command[0]; quote == '"' || quote == '\'' {
+		if end := strings.IndexByte(command[1:], quote); end >= 0 {
+			return command[1 : end+1]
+		}
+	}
+	return strings.Fields(command)[0]
+}
+
+// inspectEnvironmentCredentials reports credentials supplied through environment
+// variables, as in CI. It returns nil when there are none.
+func inspectEnvironmentCredentials() *ProfileInfo {
+	info := &ProfileInfo{
+		Name:          "environment",
+		Exists:        true,
+		Type:          ProfileTypeEnvironment,
+		MissingFields: []string{},
+		Region:        os.Getenv("AWS_REGION"),
+	}
+	if info.Region == "" {
+		info.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	switch {
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "" || os.Getenv("AWS_SECRET_ACCESS_KEY") != "":
+		info.SourceType = ProfileTypeStaticKeys
+		for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+			if os.Getenv(name) == "" {
+				info.MissingFields = append(info.MissingFields, name)
+			}
+		}
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "":
+		info.SourceType = ProfileTypeWebIdentity
+		info.WebIdentityTokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+		info.RoleARN = os.Getenv("AWS_ROLE_ARN")
+		if info.RoleARN == "" {
+			info.MissingFields = append(info.MissingFields, "AWS_ROLE_ARN")
+		}
+		if _, err := os.Stat(info.WebIdentityTokenFile); err != nil {
+			info.MissingFields = append(info.MissingFields, fmt.Sprintf("AWS_WEB_IDENTITY_TOKEN_FILE '%s' (not readable)", info.WebIdentityTokenFile))
+		}
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		info.CredentialSource = "EcsContainer"
+	default:
+		return nil
+	}
+
+	info.Complete = len(info.MissingFields) == 0
+	return info
+}
+
+// lookupCredentialSource reports where credentials come from when the SDK,
+// the AWS CLI and terraform load the default chain: access keys and web
+// identity variables win over AWS_PROFILE, container credentials only apply
+// without a profile. Returns nil if the source can't be determined.
+func lookupCredentialSource(profile string) *ProfileInfo {
+	if envInfo := inspectEnvironmentCredentials(); envInfo != nil && (profile == "" || envInfo.CredentialSource == "") {
+		return envInfo
+	}
+	if profile == "" {
+		return nil
+	}
+	inspector, err := NewProfileInspector()
+	if err != nil {
+		return nil
+	}
+	info, err := inspector.InspectProfile(profile)
+	if err != nil || !info.Exists {
+		return nil
+	}
+	return info
+}
+
+// awsProfileExists reports whether the profile is configured in the AWS config file
+func awsProfileExists(profile string) bool {
+	inspector, err := NewProfileInspector()
+	if err != nil {
+		return false
+	}
+	info, err := inspector.InspectProfile(profile)
+	return err == nil && info.Exists
+}
+
+// UsesSSO reports whether the profile's credentials ultimately come from an SSO login
+func (info *ProfileInfo) UsesSSO() bool {
+	switch info.Type {
+	case ProfileTypeModernSSO, ProfileTypeLegacySSO:
+		return true
+	case ProfileTypeAssumeRole:
+		return info.SourceType == ProfileTypeModernSSO || info.SourceType == ProfileTypeLegacySSO
+	}
+	return false
+}
+
+// RootProfile returns the profile at the end of a role chain, or the profile itself
+func (info *ProfileInfo) RootProfile() string {
+	if len(info.RoleChain) > 0 {
+		return info.RoleChain[len(info.RoleChain)-1]
+	}
+	return info.Name
+}
+
+// Describe returns a short label for the credential source, e.g. "role deploy via dev -> base (SSO)"
+func (info *ProfileInfo) Describe() string {
+	switch info.Type {
+	case ProfileTypeEnvironment:
+		switch {
+		case info.SourceType == ProfileTypeStaticKeys:
+			return "environment access keys"
+		case info.SourceType == ProfileTypeWebIdentity:
+			return "environment web identity token for " + info.RoleARN
+		default:
+			return "container credentials"
+		}
+	case ProfileTypeAssumeRole:
+		label := "role " + info.RoleARN[strings.LastIndex(info.RoleARN, "/")+1:]
+		if len(info.RoleChain) > 1 {
+			label += " via " + strings.Join(info.RoleChain, " -> ")
+		} else if info.CredentialSource != "" {
+			label += " via " + info.CredentialSource
+		}
+		if info.UsesSSO() {
+			label += " (SSO)"
+		}
+		if info.MFASerial != "" {
+			label += " with MFA"
+		}
+		return label
+	case ProfileTypeModernSSO, ProfileTypeLegacySSO:
+		return "SSO profile " + info.Name
+	case ProfileTypeCredentialProcess:
+		return "credential_process profile " + info.Name
+	case ProfileTypeWebIdentity:
+		return "web identity profile " + info.Name
+	case ProfileTypeStaticKeys:
+		return "access keys profile " + info.Name
+	}
+	return "profile " + info.Name
+}
+
+// promptMFAToken asks for the current code when a role with mfa_serial is assumed
+func promptMFAToken() (string, error) {
+	fmt.Print("🔐 Enter MFA code: ")
+	var code string
+	if _, err := fmt.Scanln(&code); err != nil {
+		return "", fmt.Errorf("failed to read MFA code: %w", err)
+	}
+	return strings.TrimSpace(code), nil
+}
+
+// withMFAPrompt lets profiles with mfa_serial prompt for the code instead of failing
+func withMFAPrompt() config.LoadOptionsFunc {
+	return config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+		o.TokenProvider = promptMFAToken
+	})
+}
+
+var (
+	profileCredentialsMu sync.Mutex
+	profileCredentials   = map[string]aws.CredentialsProvider{} // Profile name -> cached credentials
+)
+
+// loadAWSConfigForProfile loads the SDK config of a named profile, or of the default chain when
+// profile is empty. Profiles with mfa_serial prompt for the code, and the credentials of a profile
+// are shared by all configs loaded for it, so the code is asked for once per run.
+func loadAWSConfigForProfile(ctx context.Context, profile string, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{withMFAPrompt()}
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, append(opts, optFns...)...)
+	if err != nil || profile == "" {
+		// The default chain follows the environment, which exportSessionCredentials changes
+		return cfg, err
+	}
+
+	profileCredentialsMu.Lock()
+	defer profileCredentialsMu.Unlock()
+	if cached, ok := profileCredentials[profile]; ok {
+		cfg.Credentials = cached
+	} else if cfg.Credentials != nil {
+		profileCredentials[profile] = cfg.Credentials
+	}
+	return cfg, nil
+}
+
+// rememberProfileCredentials shares credentials resolved for a profile with later
+// loadAWSConfigForProfile calls, e.g. the MFA session of the preflight check
+func rememberProfileCredentials(profile string, credentials aws.CredentialsProvider) {
+	if profile == "" || credentials == nil {
+		return
+	}
+	profileCredentialsMu.Lock()
+	defer profileCredentialsMu.Unlock()
+	profileCredentials[profile] = credentials
+}
+
+// exportSessionCredentials puts the temporary credentials of an MFA session into
+// the environment, so terraform and the AWS CLI don't ask for the code again.
+// Environment keys take precedence over AWS_PROFILE for both.
+func exportSessionCredentials(ctx context.Context, cfg aws.Config, profile string) error {
+	// Served from the credentials cache, no new MFA prompt
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	os.Setenv("AWS_ACCESS_KEY_ID", creds.AccessKeyID)
+	os.Setenv("AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey)
+	os.Setenv("AWS_SESSION_TOKEN", creds.SessionToken)
+	if creds.CanExpire {
+		fmt.Printf("🔑 Using the MFA session of profile %s until %s\n", profile, creds.Expires.Local().Format("15:04"))
+	}
+	return nil
+}
+
+// refreshAWSCredentials tries to recover from a credential error, depending on
+// where the credentials come from. It reports whether the call should be retried.
+func refreshAWSCredentials(info *ProfileInfo, cause error) (bool, error) {
+	errMsg := cause.Error()
+	expired := strings.Contains(errMsg, "SSO") || strings.Contains(errMsg, "expired") || strings.Contains(errMsg, "token")
+
+	if info == nil {
+		// Unknown source, assume an SSO profile as before
+		if !strings.Contains(errMsg, "SSO") && !strings.Contains(errMsg, "expired") {
+			return false, nil
+		}
+		awsProfile := os.Getenv("AWS_PROFILE")
+		fmt.Printf("⚠️  SSO token expired for profile: %s\n", awsProfile)
+		if err := refreshSSOToken(awsProfile); err != nil {
+			return false, fmt.Errorf("SSO token refresh failed: %v", err)
+		}
+		return true, nil
+	}
+
+	switch {
+	case info.MFASerial != "" && (strings.Contains(errMsg, "MultiFactorAuthentication") || strings.Contains(errMsg, "MFA")):
+		fmt.Printf("⚠️  MFA authentication failed for %s, try another code\n", info.MFASerial)
+		return true, nil
+
+	case info.UsesSSO():
+		if !expired {
+			return false, nil
+		}
+		root := info.RootProfile()
+		fmt.Printf("⚠️  SSO token expired for profile: %s\n", root)
+		if err := refreshSSOToken(root); err != nil {
+			return false, fmt.Errorf("SSO token refresh failed: %v", err)
+		}
+		return true, nil
+
+	case info.Type == ProfileTypeCredentialProcess:
+		// Run the process directly to surface its own error. Its output holds
+		// the credentials, so only stderr is shown.
+		fmt.Printf("🔄 Running credential_process for profile: %s\n", info.Name)
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+		if _, err := exec.Command(shell, flag, info.CredentialProcess).Output(); err != nil {
+			var stderr string
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				stderr = strings.TrimSpace(string(exitErr.Stderr))
+			}
+			return false, fmt.Errorf("credential_process failed: %v\nOutput: %s", err, stderr)
+		}
+		return true, nil
+	}
+
+	// Static keys, web identity and environment credentials can't be refreshed from here
+	return false, nil
+}
+
+// credentialRecoverySteps suggests how to fix credentials from the given source
+func credentialRecoverySteps(info *ProfileInfo, profile string) []string {
+	verify := "Verify credentials: aws sts get-caller-identity"
+	if profile != "" {
+		verify += " --profile " + profile
+	}
+
+	if info == nil {
+		return []string{
+			"Check if your AWS profile exists: aws configure list-profiles",
+			fmt.Sprintf("For SSO: Run 'aws sso login --profile %s'", profile),
+			fmt.Sprintf("For IAM keys: Run 'aws configure --profile %s'", profile),
+			verify,
+		}
+	}
+
+	var steps []string
+	switch {
+	case info.UsesSSO():
+		steps = append(steps, fmt.Sprintf("Run 'aws sso login --profile %s'", info.RootProfile()))
+		if info.Type == ProfileTypeAssumeRole {
+			steps = append(steps, fmt.Sprintf("Check that %s can be assumed from profile %s", info.RoleARN, info.RootProfile()))
+		}
+	case info.Type == ProfileTypeAssumeRole:
+		if info.MFASerial != "" {
+			steps = append(steps, fmt.Sprintf("Enter a current code from MFA device %s when prompted", info.MFASerial))
+		}
+		if len(info.RoleChain) > 1 {
+			steps = append(steps, fmt.Sprintf("Check the source credentials: aws sts get-caller-identity --profile %s", info.RootProfile()))
+		}
+		steps = append(steps, fmt.Sprintf("Check that the trust policy of %s allows the source identity", info.RoleARN))
+	case info.Type == ProfileTypeCredentialProcess:
+		steps = append(steps, fmt.Sprintf("Run the credential_process manually to see its error: %s", info.CredentialProcess))
+	case info.Type == ProfileTypeWebIdentity || info.SourceType == ProfileTypeWebIdentity:
+		steps = append(steps,
+			fmt.Sprintf("Check that the token file %s is current", info.WebIdentityTokenFile),
+			fmt.Sprintf("Check that the trust policy of %s allows the identity provider", info.RoleARN))
+	case info.Type == ProfileTypeStaticKeys:
+		steps = append(steps, fmt.Sprintf("Update the access keys: aws configure --profile %s", info.Name))
+	case info.Type == ProfileTypeEnvironment && info.SourceType == ProfileTypeStaticKeys:
+		steps = append(steps, "Check AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (plus AWS_SESSION_TOKEN for temporary keys)")
+	case info.Type == ProfileTypeEnvironment:
+		steps = append(steps, "Check the IAM role attached to the container or instance")
+	}
+	return append(steps, verify)
+}
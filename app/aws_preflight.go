@@ -16,24 +16,36 @@ import (
 func AWSPreflightCheck(env Env) error {
 	fmt.Println("\n🔍 Running AWS pre-flight checks...")
 
-	// Step 1: Validate AWS_PROFILE is set
+	// Step 1: Validate AWS_PROFILE is set, unless credentials come from the environment (CI)
 	awsProfile := os.Getenv("AWS_PROFILE")
+	envCreds := inspectEnvironmentCredentials()
 	if awsProfile == "" && env.AWSProfile != "" {
-		fmt.Printf("⚠️  AWS_PROFILE not set, using profile from config: %s\n", env.AWSProfile)
-		os.Setenv("AWS_PROFILE", env.AWSProfile)
-		awsProfile = env.AWSProfile
+		if envCreds != nil && !awsProfileExists(env.AWSProfile) {
+			fmt.Printf("ℹ️  Profile %s is not configured here, using %s\n", env.AWSProfile, envCreds.Describe())
+		} else {
+			fmt.Printf("⚠️  AWS_PROFILE not set, using profile from config: %s\n", env.AWSProfile)
+			os.Setenv("AWS_PROFILE", env.AWSProfile)
+			awsProfile = env.AWSProfile
+		}
 	}
 
-	if awsProfile == "" {
+	if awsProfile == "" && envCreds == nil {
 		return fmt.Errorf(`❌ AWS_PROFILE not set
 
 Recovery steps:
 1. Set AWS profile in your YAML config (aws_profile field)
 2. Or run: export AWS_PROFILE=your-profile-name
-3. Or select a profile when prompted by meroku`)
+3. Or select a profile when prompted by meroku
+4. In CI: set AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or AWS_WEB_IDENTITY_TOKEN_FILE/AWS_ROLE_ARN`)
 	}
 
-	fmt.Printf("✅ AWS_PROFILE set to: %s\n", awsProfile)
+	source := lookupCredentialSource(awsProfile)
+	if awsProfile != "" {
+		fmt.Printf("✅ AWS_PROFILE set to: %s\n", awsProfile)
+	}
+	if source != nil && (source.Type != ProfileTypeModernSSO && source.Type != ProfileTypeLegacySSO) {
+		fmt.Printf("🔑 Credentials: %s\n", source.Describe())
+	}
 
 	// Step 2: Check AWS CLI version
 	fmt.Println("🔧 Checking AWS CLI version...")
@@ -63,13 +75,8 @@ Recovery steps:
 
 	// Step 4: Validate AWS credentials work
 	if err := validateAWSCredentials(env.Region); err != nil {
-		return fmt.Errorf(`❌ AWS credentials validation failed: %v
-
-Recovery steps:
-1. Check if your AWS profile exists: aws configure list-profiles
-2. For SSO: Run 'aws sso login --profile %s'
-3. For IAM keys: Run 'aws configure --profile %s'
-4. Verify credentials: aws sts get-caller-identity --profile %s`, err, awsProfile, awsProfile, awsProfile)
+		return fmt.Errorf("❌ AWS credentials validation failed: %v\n\nRecovery steps:\n%s",
+			err, numberedSteps(credentialRecoverySteps(source, awsProfile)))
 	}
 
 	// Step 5: Check git repository status vs remote
@@ -82,30 +89,17 @@ Recovery steps:
 	// Step 6: Ensure S3 state bucket exists
 	fmt.Printf("🪣  Checking S3 state bucket: %s\n", env.StateBucket)
 	if err := checkBucketStateForEnv(env); err != nil {
-		// If SSO token expired, try to refresh
-		if strings.Contains(err.Error(), "SSO") || strings.Contains(err.Error(), "expired") {
-			fmt.Println("⚠️  SSO token appears expired, attempting to refresh...")
-			if err := refreshSSOToken(awsProfile); err != nil {
-				return fmt.Errorf(`❌ Failed to refresh SSO token: %v
-
-Recovery steps:
-1. Run: aws sso login --profile %s
-2. Then try again`, err, awsProfile)
-			}
-
-			// Retry bucket check after SSO refresh
-			fmt.Println("🔄 Retrying S3 bucket check after SSO refresh...")
-			if err := checkBucketStateForEnv(env); err != nil {
-				return fmt.Errorf(`❌ S3 bucket check failed: %v
-
-Recovery steps:
-1. Verify bucket name is valid: %s
-2. Check region is correct: %s
-3. Ensure you have S3 permissions
-4. Try creating bucket manually: aws s3 mb s3://%s --region %s`,
-					err, env.StateBucket, env.Region, env.StateBucket, env.Region)
-			}
-		} else {
+		// If the credentials expired meanwhile, try to refresh them
+		retry, refreshErr := refreshAWSCredentials(source, err)
+		if refreshErr != nil {
+			return fmt.Errorf("❌ Failed to refresh AWS credentials: %v\n\nRecovery steps:\n%s\nThen try again",
+				refreshErr, numberedSteps(credentialRecoverySteps(source, awsProfile)))
+		}
+		if retry {
+			fmt.Println("🔄 Retrying S3 bucket check after refreshing credentials...")
+			err = checkBucketStateForEnv(env)
+		}
+		if err != nil {
 			return fmt.Errorf(`❌ S3 bucket check failed: %v
 
 Recovery steps:
@@ -128,7 +122,8 @@ func validateAWSCredentials(region string) error {
 
 func validateAWSCredentialsWithRetry(region string, isRetry bool) error {
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	source := lookupCredentialSource(os.Getenv("AWS_PROFILE"))
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region), withMFAPrompt())
 	if err != nil {
 		return fmt.Errorf("failed to load AWS configuration: %v", err)
 	}
@@ -137,15 +132,15 @@ func validateAWSCredentialsWithRetry(region string, isRetry bool) error {
 	stsClient := sts.NewFromConfig(cfg)
 	result, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
-		// Check if SSO token expired
-		if !isRetry && (strings.Contains(err.Error(), "SSO") || strings.Contains(err.Error(), "expired")) {
-			awsProfile := os.Getenv("AWS_PROFILE")
-			fmt.Printf("⚠️  SSO token expired for profile: %s\n", awsProfile)
-			if err := refreshSSOToken(awsProfile); err != nil {
-				return fmt.Errorf("SSO token refresh failed: %v", err)
+		// Refresh once, depending on where the credentials come from
+		if !isRetry {
+			retry, refreshErr := refreshAWSCredentials(source, err)
+			if refreshErr != nil {
+				return refreshErr
+			}
+			if retry {
+				return validateAWSCredentialsWithRetry(region, true)
 			}
-			// Retry once after SSO refresh
-			return validateAWSCredentialsWithRetry(region, true)
 		}
 		return fmt.Errorf("failed to validate credentials: %v", err)
 	}
@@ -153,9 +148,27 @@ func validateAWSCredentialsWithRetry(region string, isRetry bool) error {
 	fmt.Printf("✅ AWS credentials valid - Account: %s, User: %s\n",
 		*result.Account, *result.Arn)
 
+	// Terraform and the AWS CLI would ask for the MFA code again
+	if source != nil && source.Type != ProfileTypeEnvironment && source.MFASerial != "" {
+		if err := exportSessionCredentials(ctx, cfg, source.Name); err != nil {
+			fmt.Printf("⚠️  Could not export the MFA session credentials: %v\n", err)
+		}
+		// Commands that load the profile by name would ignore the exported keys
+		rememberProfileCredentials(source.Name, cfg.Credentials)
+	}
+
 	return nil
 }
 
+// numberedSteps formats recovery steps as a numbered list
+func numberedSteps(steps []string) string {
+	lines := make([]string, len(steps))
+	for i, step := range steps {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, step)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // refreshSSOToken attempts to refresh SSO token by running aws sso login
 func refreshSSOToken(profile string) error {
 	fmt.Printf("🔄 Refreshing SSO token for profile: %s\n", profile)
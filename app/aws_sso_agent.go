@@ -6,9 +6,6 @@ import (
 	"os"
 	"strings"
 	"time"
-
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
 )
 
 // SSOAgentContext contains all information for the SSO agent
@@ -35,6 +32,7 @@ type SSOAgentContext struct {
 
 	// History
 	ActionHistory []SSOAgentAction
+	Conversation  []LLMMessage // Tool calls and tool results exchanged with the LLM
 	Iteration     int
 
 	// State management
@@ -46,7 +44,8 @@ type SSOAgentContext struct {
 
 // SSOAgentAction represents an action taken by the agent
 type SSOAgentAction struct {
-	Type        string // "think", "ask", "exec", "write", "validate", and new tools
+	Type        string // Tool name, or "think" when the LLM replied without calling a tool
+	ToolCallID  string // Tool call this action executes
 	Description string
 	Command     string
 	Question    string
@@ -63,29 +62,26 @@ type SSOAgentAction struct {
 
 // SSOAgent handles AWS SSO setup using ReAct pattern
 type SSOAgent struct {
-	client        *anthropic.Client
+	provider      LLMProvider
 	inspector     *ProfileInspector
 	maxIterations int
+	skipped       []LLMToolCall // Extra tool calls from the last turn that were not executed
 }
 
 // NewSSOAgent creates a new SSO agent
 func NewSSOAgent() (*SSOAgent, error) {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("ANTHROPIC_API_KEY not set\n\nPlease set it with:\n  export ANTHROPIC_API_KEY=your_key_here\n\nGet your key from: https://console.anthropic.com/settings/keys")
+	provider, err := NewLLMProvider(LLMFeatureSSOAgent)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n\nConfigure the AI provider in %s or set ANTHROPIC_API_KEY.\nGet an Anthropic key from: https://console.anthropic.com/settings/keys", err, LLMConfigFile)
 	}
 
-	client := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
-	)
-
 	inspector, err := NewProfileInspector()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create inspector: %w", err)
 	}
 
 	return &SSOAgent{
-		client:        &client,
+		provider:      provider,
 		inspector:     inspector,
 		maxIterations: 15,
 	}, nil
@@ -162,7 +158,7 @@ func (a *SSOAgent) Run(ctx context.Context, profileName string, yamlEnv *Env) er
 			agentCtx.Iteration, runNumber, agentCtx.TotalIterations, maxTotalIterations)
 
 		// Get next action from LLM
-		action, err := a.getNextAction(ctx, agentCtx)
+		action, input, err := a.getNextAction(ctx, agentCtx)
 		if err != nil {
 			// Save state before failing
 			SaveState(&SSOAgentState{
@@ -186,13 +182,14 @@ func (a *SSOAgent) Run(ctx context.Context, profileName string, yamlEnv *Env) er
 		}
 
 		// Execute action
-		if err := a.executeAction(ctx, action, agentCtx); err != nil {
+		if err := a.executeAction(ctx, action, input, agentCtx); err != nil {
 			action.Error = err
 			action.Result = fmt.Sprintf("Failed: %v", err)
 		}
 
-		// Add to history
+		// Add to history and send the result back to the LLM
 		agentCtx.ActionHistory = append(agentCtx.ActionHistory, *action)
+		a.addToolResult(agentCtx, action)
 
 		// Save state after each action
 		SaveState(&SSOAgentState{
@@ -289,40 +286,105 @@ func (a *SSOAgent) buildContext(profileName string, yamlEnv *Env) (*SSOAgentCont
 	return ctx, nil
 }
 
-// getNextAction asks Claude to decide the next action
-func (a *SSOAgent) getNextAction(ctx context.Context, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
+// getNextAction asks the LLM to decide the next action. A reply without a
+// tool call becomes a "think" action.
+func (a *SSOAgent) getNextAction(ctx context.Context, agentCtx *SSOAgentContext) (*SSOAgentAction, *ssoToolInput, error) {
 	prompt := a.buildPrompt(agentCtx)
 
 	// Debug logging
 	debugLogPrompt(agentCtx.ProfileName, agentCtx.Iteration, prompt)
 
-	// Create timeout context
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	if len(agentCtx.Conversation) == 0 {
+		agentCtx.Conversation = append(agentCtx.Conversation, LLMMessage{
+			Role:    "user",
+			Content: fmt.Sprintf("Set up AWS SSO for profile '%s'. Start by reading the AWS config file.", agentCtx.ProfileName),
+		})
+	}
 
-	message, err := a.client.Messages.New(timeoutCtx, anthropic.MessageNewParams{
-		Model:     "claude-sonnet-4-5-20250929",
+	message, err := a.provider.Complete(context.Background(), LLMRequest{
+		System:    prompt,
+		Messages:  agentCtx.Conversation,
+		Tools:     ssoAgentTools,
 		MaxTokens: 2048,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
+		Timeout:   60 * time.Second,
 	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("LLM API call failed: %w", err)
+	}
+
+	// Debug logging
+	debugLogResponse(agentCtx.ProfileName, agentCtx.Iteration, formatLLMResponse(message))
 
+	agentCtx.Conversation = append(agentCtx.Conversation, LLMMessage{Role: "assistant", Content: message.Text, ToolCalls: message.ToolCalls})
+	thought := strings.TrimSpace(message.Text)
+
+	if len(message.ToolCalls) == 0 {
+		agentCtx.Conversation = append(agentCtx.Conversation, LLMMessage{Role: "user", Content: "Call one of the tools to take the next step."})
+		return &SSOAgentAction{
+			Type:        "think",
+			Description: thought,
+			Timestamp:   time.Now(),
+		}, nil, nil
+	}
+
+	call := message.ToolCalls[0]
+	action := &SSOAgentAction{
+		Type:        call.Name,
+		ToolCallID:  call.ID,
+		Description: thought,
+		Timestamp:   time.Now(),
+	}
+
+	input, err := parseSSOToolCall(call)
 	if err != nil {
-		return nil, fmt.Errorf("LLM API call failed: %w", err)
+		// Report the bad call back to the LLM and let it try again
+		agentCtx.Conversation = append(agentCtx.Conversation, toolResult(call, err.Error(), true))
+		agentCtx.Conversation = append(agentCtx.Conversation, skippedToolResults(message.ToolCalls[1:])...)
+		return &SSOAgentAction{
+			Type:        "think",
+			Description: thought,
+			Result:      err.Error(),
+			Timestamp:   time.Now(),
+		}, nil, nil
 	}
 
-	if len(message.Content) == 0 {
-		return nil, fmt.Errorf("empty response from LLM")
+	// Only the first call runs; the rest are answered together with its result
+	a.skipped = message.ToolCalls[1:]
+
+	if call.Name == "complete" {
+		action.Description = input.Summary
 	}
+	return action, input, nil
+}
 
-	responseText := message.Content[0].Text
+// addToolResult sends the outcome of an executed action back to the LLM
+func (a *SSOAgent) addToolResult(agentCtx *SSOAgentContext, action *SSOAgentAction) {
+	if action.ToolCallID == "" {
+		return
+	}
 
-	// Debug logging
-	debugLogResponse(agentCtx.ProfileName, agentCtx.Iteration, responseText)
+	content := action.Result
+	if action.Error != nil && !strings.Contains(content, action.Error.Error()) {
+		content = fmt.Sprintf("Error: %v\n%s", action.Error, content)
+	}
+	if content == "" {
+		content = "Done"
+	}
 
-	// Parse the response
-	return a.parseResponse(responseText)
+	call := LLMToolCall{ID: action.ToolCallID, Name: action.Type}
+	agentCtx.Conversation = append(agentCtx.Conversation, toolResult(call, truncateOutput(content, maxToolResultLen), action.Error != nil))
+	agentCtx.Conversation = append(agentCtx.Conversation, skippedToolResults(a.skipped)...)
+	a.skipped = nil
+}
+
+// formatLLMResponse renders the response text and tool calls for the debug log
+func formatLLMResponse(message *LLMResponse) string {
+	var b strings.Builder
+	b.WriteString(message.Text)
+	for _, call := range message.ToolCalls {
+		b.WriteString(fmt.Sprintf("\nTOOL CALL %s: %s", call.Name, string(call.Input)))
+	}
+	return b.String()
 }
 
 // debugLogPrompt writes prompt to debug file
@@ -360,471 +422,65 @@ func (a *SSOAgent) buildPrompt(agentCtx *SSOAgentContext) string {
 	return BuildEnhancedSystemPrompt(agentCtx)
 }
 
-// DEPRECATED: Old buildPrompt kept for reference
-func (a *SSOAgent) buildPromptOld(agentCtx *SSOAgentContext) string {
-	return fmt.Sprintf(`You are an AWS SSO configuration expert. Your goal is to set up AWS SSO profiles correctly and efficiently.
-
-═══════════════════════════════════════════════════════════════════
-CURRENT SITUATION
-═══════════════════════════════════════════════════════════════════
-
-Profile Name: %s
-Config File: %s
-Config Exists: %v
-
-Profile Status:
-%s
-
-Available Information:
-- From YAML: AccountID=%s, Region=%s
-- Collected: SSOStartURL=%s, SSORegion=%s, AccountID=%s, RoleName=%s, Region=%s
-
-═══════════════════════════════════════════════════════════════════
-YOUR CAPABILITIES
-═══════════════════════════════════════════════════════════════════
-
-You can take the following actions:
-
-1. THINK - Analyze the situation and reason about what to do next
-   Format: THINK: <your reasoning>
-
-2. ASK - Ask the user a specific question to gather missing information
-   Format: ASK: <question>
-   Use this when you need information that's not available
-
-3. EXEC - Execute an AWS CLI command
-   Format: EXEC: <command>
-   Examples:
-   - EXEC: aws sso login --profile dev
-   - EXEC: aws sts get-caller-identity --profile dev
-   - EXEC: aws --version
-
-4. WRITE - Write AWS configuration
-   Format: WRITE: <profile_name>|<sso_start_url>|<sso_region>|<account_id>|<role_name>|<region>|<output>
-   Example: WRITE: dev|https://mycompany.awsapps.com/start|us-east-1|123456789012|AdministratorAccess|us-east-1|json
-   This will create a modern SSO configuration with sso-session
-
-5. COMPLETE - Mark the setup as complete
-   Format: COMPLETE: <summary message>
-   Use this when AWS SSO is fully configured and validated
-
-═══════════════════════════════════════════════════════════════════
-VALIDATION RULES (from AWS documentation)
-═══════════════════════════════════════════════════════════════════
-
-Modern SSO Configuration (RECOMMENDED):
-- Profile section needs: sso_session (name), sso_account_id (12 digits), sso_role_name
-- SSO-session section needs: sso_start_url (https://...), sso_region (e.g., us-east-1)
-
-Required Field Formats:
-- sso_start_url: Must be HTTPS URL, typically https://*.awsapps.com/start
-- sso_region: Valid AWS region (e.g., us-east-1, eu-west-1)
-- sso_account_id: Exactly 12 digits
-- sso_role_name: Valid IAM role name (e.g., AdministratorAccess, PowerUserAccess)
-- region: Valid AWS region
-- output: One of: json, yaml, text, table
-
-Common Role Names:
-- AdministratorAccess (full access)
-- PowerUserAccess (most services, limited IAM)
-- ReadOnlyAccess (read-only)
-
-═══════════════════════════════════════════════════════════════════
-STRATEGY
-═══════════════════════════════════════════════════════════════════
-
-1. Analyze what information is missing
-2. Ask user for missing critical information (SSO start URL is most important)
-3. Use information from YAML when available (don't ask for what you have)
-4. Write configuration once you have all required fields
-5. Execute "aws sso login" to authenticate
-6. Validate with "aws sts get-caller-identity"
-7. Verify account ID matches expected value
-8. Mark as COMPLETE when everything works
-
-Ask questions in priority order:
-1. SSO Start URL (critical, can't proceed without it)
-2. Role Name (has good defaults like AdministratorAccess)
-3. SSO Region (defaults to us-east-1)
-4. Other optional fields
-
-Don't ask for information you already have from YAML or previous answers!
-
-═══════════════════════════════════════════════════════════════════
-ACTION HISTORY (Recent actions taken)
-═══════════════════════════════════════════════════════════════════
-
-%s
-
-═══════════════════════════════════════════════════════════════════
-WHAT SHOULD YOU DO NEXT?
-═══════════════════════════════════════════════════════════════════
-
-Respond with ONE action in the format specified above (THINK, ASK, EXEC, WRITE, or COMPLETE).
-
-IMPORTANT:
-- Start with THINK to show your reasoning
-- Ask ONE question at a time
-- Use YAML data to avoid redundant questions
-- Validate credentials after configuration
-- Be concise and helpful
-`,
-		agentCtx.ProfileName,
-		agentCtx.ConfigPath,
-		agentCtx.ConfigExists,
-		a.formatProfileStatus(agentCtx),
-		agentCtx.YAMLEnv.AccountID,
-		agentCtx.YAMLEnv.Region,
-		agentCtx.SSOStartURL,
-		agentCtx.SSORegion,
-		agentCtx.AccountID,
-		agentCtx.RoleName,
-		agentCtx.Region,
-		a.formatHistory(agentCtx))
-}
-
-// formatProfileStatus formats the profile status for the prompt
-func (a *SSOAgent) formatProfileStatus(agentCtx *SSOAgentContext) string {
-	if agentCtx.ProfileInfo == nil {
-		return "Profile not analyzed yet"
-	}
-
-	info := agentCtx.ProfileInfo
-	if !info.Exists {
-		return "Profile does not exist - needs to be created"
-	}
-
-	if info.Complete {
-		return fmt.Sprintf("Profile exists and is complete (Type: %s)", info.Type)
-	}
-
-	return fmt.Sprintf("Profile exists but incomplete (Type: %s, Missing: %s)",
-		info.Type, strings.Join(info.MissingFields, ", "))
-}
-
-// formatHistory formats recent actions for the prompt
-func (a *SSOAgent) formatHistory(agentCtx *SSOAgentContext) string {
-	if len(agentCtx.ActionHistory) == 0 {
-		return "No actions taken yet"
-	}
-
-	var b strings.Builder
-	// Show last 5 actions
-	start := len(agentCtx.ActionHistory) - 5
-	if start < 0 {
-		start = 0
-	}
-
-	for i := start; i < len(agentCtx.ActionHistory); i++ {
-		action := agentCtx.ActionHistory[i]
-		b.WriteString(fmt.Sprintf("\n%d. %s", i+1, action.Type))
-		if action.Description != "" {
-			b.WriteString(fmt.Sprintf(": %s", action.Description))
-		}
-		if action.Question != "" {
-			b.WriteString(fmt.Sprintf(" | Question: %s | Answer: %s", action.Question, action.Answer))
-		}
-		if action.Result != "" {
-			b.WriteString(fmt.Sprintf(" | Result: %s", action.Result))
-		}
-		if action.Error != nil {
-			b.WriteString(fmt.Sprintf(" | Error: %v", action.Error))
-		}
-	}
-
-	return b.String()
-}
-
-// parseResponse parses the LLM's response into an action
-func (a *SSOAgent) parseResponse(response string) (*SSOAgentAction, error) {
-	lines := strings.Split(strings.TrimSpace(response), "\n")
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty response")
-	}
-
-	firstLine := strings.TrimSpace(lines[0])
-
-	// Parse THINK - but if there's a second line, prefer the action on line 2
-	if strings.HasPrefix(firstLine, "THINK:") {
-		// If there's a second line, it should be the action
-		if len(lines) > 1 {
-			secondLine := strings.TrimSpace(lines[1])
-			// Try to parse the second line as an action
-			if secondLine != "" && !strings.HasPrefix(secondLine, "THINK:") {
-				// Recursively parse the second line as the action
-				action, err := a.parseActionLine(secondLine)
-				if err == nil {
-					// Successfully parsed an action on line 2
-					thought := strings.TrimPrefix(firstLine, "THINK:")
-					action.Description = strings.TrimSpace(thought) + " | " + action.Description
-					return action, nil
-				}
-			}
-		}
-
-		// No valid action on line 2, just return the think
-		thought := strings.TrimPrefix(firstLine, "THINK:")
-		thought = strings.TrimSpace(thought)
-		return &SSOAgentAction{
-			Type:        "think",
-			Description: thought,
-			Timestamp:   time.Now(),
-		}, nil
-	}
-
-	// Not a THINK line, parse as action directly
-	return a.parseActionLine(firstLine)
-}
-
-// parseActionLine parses a single action line
-func (a *SSOAgent) parseActionLine(line string) (*SSOAgentAction, error) {
-	line = strings.TrimSpace(line)
-
-	// Parse new enhanced tools
-	if strings.HasPrefix(line, "read_aws_config:") || strings.HasPrefix(line, "ACTION: read_aws_config") {
-		command := extractCommand(line, "read_aws_config")
-		return &SSOAgentAction{
-			Type:      "read_aws_config",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "write_aws_config:") || strings.HasPrefix(line, "ACTION: write_aws_config") {
-		command := extractCommand(line, "write_aws_config")
-		return &SSOAgentAction{
-			Type:      "write_aws_config",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "read_yaml:") || strings.HasPrefix(line, "ACTION: read_yaml") {
-		command := extractCommand(line, "read_yaml")
-		return &SSOAgentAction{
-			Type:      "read_yaml",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "write_yaml:") || strings.HasPrefix(line, "ACTION: write_yaml") {
-		command := extractCommand(line, "write_yaml")
-		return &SSOAgentAction{
-			Type:      "write_yaml",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "ask_choice:") || strings.HasPrefix(line, "ACTION: ask_choice") {
-		command := extractCommand(line, "ask_choice")
-		return &SSOAgentAction{
-			Type:      "ask_choice",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "ask_confirm:") || strings.HasPrefix(line, "ACTION: ask_confirm") {
-		command := extractCommand(line, "ask_confirm")
-		return &SSOAgentAction{
-			Type:      "ask_confirm",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "ask_input:") || strings.HasPrefix(line, "ACTION: ask_input") {
-		command := extractCommand(line, "ask_input")
-		return &SSOAgentAction{
-			Type:      "ask_input",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "web_search:") || strings.HasPrefix(line, "ACTION: web_search") {
-		command := extractCommand(line, "web_search")
-		return &SSOAgentAction{
-			Type:      "web_search",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "aws_validate:") || strings.HasPrefix(line, "ACTION: aws_validate") {
-		command := extractCommand(line, "aws_validate")
-		return &SSOAgentAction{
-			Type:      "aws_validate",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	// Existing tool parsing
-	if strings.HasPrefix(line, "ASK:") {
-		question := strings.TrimPrefix(line, "ASK:")
-		question = strings.TrimSpace(question)
-		return &SSOAgentAction{
-			Type:      "ask",
-			Question:  question,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "EXEC:") {
-		command := strings.TrimPrefix(line, "EXEC:")
-		command = strings.TrimSpace(command)
-		return &SSOAgentAction{
-			Type:      "exec",
-			Command:   command,
-			Timestamp: time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "WRITE:") {
-		configLine := strings.TrimPrefix(line, "WRITE:")
-		configLine = strings.TrimSpace(configLine)
-		return &SSOAgentAction{
-			Type:        "write",
-			Description: configLine,
-			Timestamp:   time.Now(),
-		}, nil
-	}
-
-	if strings.HasPrefix(line, "COMPLETE:") {
-		summary := strings.TrimPrefix(line, "COMPLETE:")
-		summary = strings.TrimSpace(summary)
-		return &SSOAgentAction{
-			Type:        "complete",
-			Description: summary,
-			Timestamp:   time.Now(),
-		}, nil
-	}
-
-	return nil, fmt.Errorf("unrecognized action format: %s", line)
-}
-
-// extractCommand extracts command from action line
-func extractCommand(line, actionType string) string {
-	// Handle "ACTION: type" format
-	if strings.HasPrefix(line, "ACTION: "+actionType) {
-		line = strings.TrimPrefix(line, "ACTION: "+actionType)
-	} else {
-		line = strings.TrimPrefix(line, actionType+":")
-	}
-	return strings.TrimSpace(line)
-}
-
 // executeAction performs the action with enhanced tool support
-func (a *SSOAgent) executeAction(ctx context.Context, action *SSOAgentAction, agentCtx *SSOAgentContext) error {
+func (a *SSOAgent) executeAction(ctx context.Context, action *SSOAgentAction, input *ssoToolInput, agentCtx *SSOAgentContext) error {
 	startTime := time.Now()
+	callID, thought := action.ToolCallID, action.Description
 	defer func() {
+		// Tools return a fresh action; keep the call ID and reasoning
+		action.ToolCallID = callID
+		if action.Description == "" {
+			action.Description = thought
+		}
 		action.Duration = time.Since(startTime)
 	}()
 
-	switch action.Type {
-	case "think":
+	if action.Type == "think" {
 		fmt.Println("🤔 THINKING:")
 		fmt.Println("   " + action.Description)
 		return nil
+	}
 
-	// New enhanced tools
-	case "read_aws_config":
-		result, err := a.toolReadAWSConfig(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
+	if thought != "" {
+		fmt.Println("🤔 " + thought)
+	}
 
-	case "write_aws_config":
-		result, err := a.toolWriteAWSConfig(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
+	var result *SSOAgentAction
+	var err error
 
+	switch action.Type {
+	case "read_aws_config":
+		result, err = a.toolReadAWSConfig(ctx, input.Path, agentCtx)
+	case "write_aws_config":
+		result, err = a.toolWriteAWSConfig(ctx, input.Content, agentCtx)
 	case "read_yaml":
-		result, err := a.toolReadYAML(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
+		result, err = a.toolReadYAML(ctx, input.Path, agentCtx)
 	case "write_yaml":
-		result, err := a.toolWriteYAML(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
+		result, err = a.toolWriteYAML(ctx, input.Path, input.OldText, input.NewText, agentCtx)
 	case "ask_choice":
-		result, err := a.toolAskChoice(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
+		result, err = a.toolAskChoice(ctx, input.Question, input.Options, agentCtx)
 	case "ask_confirm":
-		result, err := a.toolAskConfirm(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
+		result, err = a.toolAskConfirm(ctx, input.Question, agentCtx)
 	case "ask_input":
-		result, err := a.toolAskInput(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
+		result, err = a.toolAskInput(ctx, input.Question, input.Validator, input.Placeholder, agentCtx)
 	case "web_search":
-		result, err := a.toolWebSearch(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
+		result, err = a.toolWebSearch(ctx, input.Query, agentCtx)
 	case "aws_validate":
-		result, err := a.toolAWSValidate(ctx, action.Command, agentCtx)
-		*action = *result
-		return err
-
-	// Existing tools
-	case "ask":
-		return a.askUser(action, agentCtx)
-
+		result, err = a.toolAWSValidate(ctx, input.Check, input.Profile, input.AccountID, agentCtx)
 	case "exec":
+		action.Command = input.Command
 		return a.execCommand(action)
-
-	case "write":
-		return a.writeConfig(action, agentCtx)
-
+	case "write_profile":
+		return a.writeProfile(action, input, agentCtx)
 	case "complete":
 		// Nothing to execute for complete
 		return nil
-
 	default:
 		return fmt.Errorf("unknown action type: %s", action.Type)
 	}
-}
 
-// askUser prompts the user for input
-func (a *SSOAgent) askUser(action *SSOAgentAction, agentCtx *SSOAgentContext) error {
-	fmt.Println()
-	fmt.Println("💬 " + action.Question)
-	fmt.Print("   Answer: ")
-
-	var answer string
-	fmt.Scanln(&answer)
-	answer = strings.TrimSpace(answer)
-
-	action.Answer = answer
-	action.Result = "User answered: " + answer
-
-	// Store the answer in context based on question context
-	questionLower := strings.ToLower(action.Question)
-	if strings.Contains(questionLower, "start url") || strings.Contains(questionLower, "sso url") {
-		agentCtx.SSOStartURL = answer
-	} else if strings.Contains(questionLower, "sso region") {
-		agentCtx.SSORegion = answer
-	} else if strings.Contains(questionLower, "account") {
-		agentCtx.AccountID = answer
-	} else if strings.Contains(questionLower, "role") {
-		agentCtx.RoleName = answer
-	} else if strings.Contains(questionLower, "region") && !strings.Contains(questionLower, "sso") {
-		agentCtx.Region = answer
-	} else if strings.Contains(questionLower, "output") {
-		agentCtx.Output = answer
-	}
-
-	return nil
+	*action = *result
+	return err
 }
 
 // execCommand executes an AWS CLI command
@@ -844,27 +500,26 @@ func (a *SSOAgent) execCommand(action *SSOAgentAction) error {
 	return nil
 }
 
-// writeConfig writes AWS SSO configuration
-func (a *SSOAgent) writeConfig(action *SSOAgentAction, agentCtx *SSOAgentContext) error {
+// writeProfile writes a modern AWS SSO profile
+func (a *SSOAgent) writeProfile(action *SSOAgentAction, input *ssoToolInput, agentCtx *SSOAgentContext) error {
 	fmt.Println()
 	fmt.Println("🔧 ACTION: Writing AWS SSO configuration")
 
-	// Parse the config line: profile|start_url|sso_region|account_id|role|region|output
-	parts := strings.Split(action.Description, "|")
-	if len(parts) < 7 {
-		return fmt.Errorf("invalid WRITE format, expected: profile|start_url|sso_region|account_id|role|region|output")
+	output := input.Output
+	if output == "" {
+		output = "json"
 	}
 
 	writer := NewConfigWriter()
 	opts := ModernSSOProfileOptions{
-		ProfileName:           strings.TrimSpace(parts[0]),
+		ProfileName:           strings.TrimSpace(input.Profile),
 		SSOSessionName:        "default-sso",
-		SSOStartURL:           strings.TrimSpace(parts[1]),
-		SSORegion:             strings.TrimSpace(parts[2]),
-		SSOAccountID:          strings.TrimSpace(parts[3]),
-		SSORoleName:           strings.TrimSpace(parts[4]),
-		Region:                strings.TrimSpace(parts[5]),
-		Output:                strings.TrimSpace(parts[6]),
+		SSOStartURL:           strings.TrimSpace(input.SSOStartURL),
+		SSORegion:             strings.TrimSpace(input.SSORegion),
+		SSOAccountID:          strings.TrimSpace(input.AccountID),
+		SSORoleName:           strings.TrimSpace(input.RoleName),
+		Region:                strings.TrimSpace(input.Region),
+		Output:                output,
 		SSORegistrationScopes: "sso:account:access",
 	}
 
@@ -873,6 +528,13 @@ func (a *SSOAgent) writeConfig(action *SSOAgentAction, agentCtx *SSOAgentContext
 		return err
 	}
 
+	agentCtx.SSOStartURL = opts.SSOStartURL
+	agentCtx.SSORegion = opts.SSORegion
+	agentCtx.AccountID = opts.SSOAccountID
+	agentCtx.RoleName = opts.SSORoleName
+	agentCtx.Region = opts.Region
+	agentCtx.Output = opts.Output
+
 	action.Result = "Configuration written successfully"
 	return nil
 }
@@ -163,93 +163,20 @@ const awsSSOTools = `═══════════════════
 YOUR AVAILABLE TOOLS
 ═══════════════════════════════════════════════════════════════════
 
-1. THINK - Analyze the situation and reason about next steps
-   Format: THINK: <your reasoning>
-   Use to: Show your logical process to the user
-
-2. read_aws_config - Read AWS configuration file
-   Format: read_aws_config: ~/.aws/config
-   Use to:
-   - See current configuration structure
-   - List existing profiles
-   - Find sso-session sections
-   - Understand what needs to be fixed
-
-3. write_aws_config - Write complete AWS configuration
-   Format: write_aws_config: <full config content>
-   Use to:
-   - Create new configuration
-   - Update existing configuration
-   - Fix configuration issues
-   Note: Always creates backup before writing
-
-4. read_yaml - Read project YAML configuration
-   Format: read_yaml: project/dev.yaml
-   Use to:
-   - Get account_id from YAML
-   - Get region from YAML
-   - Understand project configuration
-
-5. write_yaml - Update YAML configuration
-   Format: write_yaml: FILE:project/dev.yaml|OLD:old_text|NEW:new_text
-   Use to:
-   - Sync account_id between YAML and AWS
-   - Update profile name in YAML
-
-6. ask_choice - Ask user to select from options
-   Format: ask_choice: QUESTION:Which role?|OPTIONS:Admin,Power,ReadOnly
-   Use to:
-   - Get user preference from predefined choices
-   - Select IAM role
-   - Choose AWS region
-   - Pick configuration style
-
-7. ask_confirm - Ask user yes/no question
-   Format: ask_confirm: Replace existing SSO config?
-   Use to:
-   - Confirm before destructive actions
-   - Verify user intent
-   - Get permission to proceed
-
-8. ask_input - Ask user for text input with validation
-   Format: ask_input: QUESTION:SSO URL?|VALIDATOR:url|PLACEHOLDER:https://...
-   Use to:
-   - Get SSO start URL
-   - Get account ID
-   - Get any user-provided value
-   Validators: url, region, account_id, role_name, none
-
-9. web_search - Search for AWS documentation
-   Format: web_search: AWS SSO InvalidParameterException
-   Use to:
-   - Research unknown errors
-   - Find AWS documentation
-   - Learn about new issues
-   - Get best practices
-
-10. aws_validate - Validate AWS configuration
-    Format: aws_validate: sso_login|profile:dev
-    Format: aws_validate: credentials|profile:dev|account:123456789012
-    Format: aws_validate: cli_version
-    Use to:
-    - Test SSO login after config
-    - Verify credentials work
-    - Check AWS CLI version
-
-11. EXEC - Execute AWS CLI or shell commands
-    Format: EXEC: <command>
-    Examples:
-    - EXEC: aws sso login --profile dev
-    - EXEC: aws sts get-caller-identity --profile dev
-    - EXEC: aws --version
-    Use to:
-    - Run direct AWS CLI commands
-    - Execute validation commands
-    - Test configuration
-
-12. COMPLETE - Mark setup as complete
-    Format: COMPLETE: <summary message>
-    Use when: AWS SSO is fully configured and validated
+- read_aws_config: Read ~/.aws/config to see profiles and sso-session sections
+- write_aws_config: Replace the whole AWS config file (a backup is created first)
+- read_yaml: Read project YAML (account_id, region)
+- write_yaml: Replace text in project YAML, e.g. to sync account_id
+- ask_choice: Ask the user to pick an option (IAM role, region, config style)
+- ask_confirm: Ask a yes/no question before destructive actions
+- ask_input: Ask for a value (SSO start URL, account ID) with a validator:
+  url, region, account_id, role_name or none
+- web_search: Research unknown errors in AWS documentation
+- aws_validate: Check cli_version, run sso_login for a profile, or validate
+  credentials for a profile against an expected account
+- exec: Run AWS CLI or shell commands (aws sso login, aws sts get-caller-identity)
+- write_profile: Write a modern SSO profile with its sso-session section
+- complete: Finish once AWS SSO is fully configured and validated
 
 TOOL SELECTION STRATEGY:
 - Start with read_aws_config to understand current state
@@ -258,7 +185,7 @@ TOOL SELECTION STRATEGY:
 - Write configuration once you have all required fields
 - Validate with aws_validate after writing
 - If validation fails, use web_search to research errors
-- Mark COMPLETE only after successful validation
+- Call complete only after successful validation
 `
 
 // BuildEnhancedSystemPrompt constructs the comprehensive system prompt
@@ -326,7 +253,7 @@ func BuildEnhancedSystemPrompt(ctx *SSOAgentContext) string {
 	b.WriteString("5. Execute \"aws sso login\" to authenticate\n")
 	b.WriteString("6. Validate with \"aws sts get-caller-identity\"\n")
 	b.WriteString("7. Verify account ID matches expected value\n")
-	b.WriteString("8. Mark as COMPLETE when everything works\n\n")
+	b.WriteString("8. Call complete when everything works\n\n")
 
 	b.WriteString("Ask questions in priority order:\n")
 	b.WriteString("1. SSO Start URL (critical, can't proceed without it)\n")
@@ -336,101 +263,17 @@ func BuildEnhancedSystemPrompt(ctx *SSOAgentContext) string {
 
 	b.WriteString("Don't ask for information you already have from YAML or previous answers!\n\n")
 
-	// Action history
-	b.WriteString("═══════════════════════════════════════════════════════════════════\n")
-	b.WriteString("ACTION HISTORY (Recent actions taken)\n")
-	b.WriteString("═══════════════════════════════════════════════════════════════════\n\n")
-
-	if len(ctx.ActionHistory) == 0 {
-		b.WriteString("No actions taken yet\n")
-	} else {
-		// Show last 5 actions
-		start := len(ctx.ActionHistory) - 5
-		if start < 0 {
-			start = 0
-		}
-
-		for i := start; i < len(ctx.ActionHistory); i++ {
-			action := ctx.ActionHistory[i]
-			b.WriteString(fmt.Sprintf("%d. %s", i+1, action.Type))
-			if action.Description != "" {
-				b.WriteString(fmt.Sprintf(": %s", action.Description))
-			}
-			if action.Question != "" {
-				b.WriteString(fmt.Sprintf(" | Question: %s | Answer: %s", action.Question, action.Answer))
-			}
-			if action.Result != "" {
-				// Truncate long results
-				result := action.Result
-				if len(result) > 200 {
-					result = result[:200] + "..."
-				}
-				b.WriteString(fmt.Sprintf(" | Result: %s", result))
-			}
-			if action.Error != nil {
-				b.WriteString(fmt.Sprintf(" | Error: %v", action.Error))
-			}
-			b.WriteString("\n")
-		}
-	}
-
-	b.WriteString("\n")
-
 	// Final instructions
 	b.WriteString("═══════════════════════════════════════════════════════════════════\n")
-	b.WriteString("WHAT SHOULD YOU DO NEXT?\n")
+	b.WriteString("HOW TO RESPOND\n")
 	b.WriteString("═══════════════════════════════════════════════════════════════════\n\n")
 
-	b.WriteString("CRITICAL: You MUST respond with BOTH a THINK line AND an ACTION line.\n\n")
-
-	b.WriteString("RESPONSE FORMAT (REQUIRED):\n")
-	b.WriteString("Line 1: THINK: <brief reasoning about what to do next>\n")
-	b.WriteString("Line 2: <ACTION>: <command>\n\n")
-
-	b.WriteString("EXAMPLES OF CORRECT RESPONSES:\n\n")
-
-	b.WriteString("Example 1 - Reading config:\n")
-	b.WriteString("THINK: Need to see what's currently in the AWS config file\n")
-	b.WriteString("read_aws_config: ~/.aws/config\n\n")
-
-	b.WriteString("Example 2 - Asking for SSO URL:\n")
-	b.WriteString("THINK: Missing SSO start URL, must ask user\n")
-	b.WriteString("ask_input: QUESTION:What is your SSO start URL?|VALIDATOR:url|PLACEHOLDER:https://mycompany.awsapps.com/start\n\n")
-
-	b.WriteString("Example 3 - Writing config:\n")
-	b.WriteString("THINK: Have all required info, writing modern SSO config\n")
-	b.WriteString("write_aws_config: [sso-session my-sso]\nsso_start_url = https://example.awsapps.com/start\n...\n\n")
-
-	b.WriteString("Example 4 - Validating:\n")
-	b.WriteString("THINK: Config written, now test SSO login\n")
-	b.WriteString("aws_validate: sso_login|profile:dev\n\n")
-
-	b.WriteString("IMPORTANT RULES:\n")
-	b.WriteString("- NEVER output just THINK alone - you MUST follow with an ACTION\n")
-	b.WriteString("- THINK line explains WHY you're doing something\n")
-	b.WriteString("- ACTION line specifies WHAT tool to use and HOW\n")
-	b.WriteString("- If you don't know what action to take, use read_aws_config first\n")
+	b.WriteString("- Call exactly ONE tool per step; its result is returned to you before the next step\n")
+	b.WriteString("- Before the tool call, explain your reasoning in one short sentence\n")
+	b.WriteString("- If you don't know what to do, call read_aws_config first\n")
 	b.WriteString("- Ask ONE question at a time (use ask_input for missing info)\n")
 	b.WriteString("- Use YAML data to avoid redundant questions\n")
-	b.WriteString("- Be concise and helpful\n\n")
-
-	b.WriteString("NEXT STEP LOGIC:\n")
-	if len(ctx.ActionHistory) == 0 {
-		b.WriteString("This is your FIRST action - start by reading the AWS config file:\n")
-		b.WriteString("THINK: Need to see current configuration\n")
-		b.WriteString("read_aws_config: ~/.aws/config\n")
-	} else {
-		lastAction := ctx.ActionHistory[len(ctx.ActionHistory)-1]
-		b.WriteString(fmt.Sprintf("Your LAST action was: %s\n", lastAction.Type))
-		if lastAction.Type == "think" {
-			b.WriteString("⚠️  WARNING: You just did THINK without an action! You MUST take an action now.\n")
-			if ctx.AWSConfigContent == "" {
-				b.WriteString("→ Read the AWS config file first: read_aws_config: ~/.aws/config\n")
-			} else if ctx.SSOStartURL == "" {
-				b.WriteString("→ Ask for SSO start URL: ask_input: QUESTION:What is your SSO start URL?|VALIDATOR:url|PLACEHOLDER:https://mycompany.awsapps.com/start\n")
-			}
-		}
-	}
+	b.WriteString("- Be concise and helpful\n")
 
 	return b.String()
 }
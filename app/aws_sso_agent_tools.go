@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -91,6 +92,156 @@ func validateYAMLPath(filePath string) error {
 	return nil
 }
 
+// ssoAgentTools are the tools the SSO agent can call
+var ssoAgentTools = []LLMTool{
+	{
+		Name:        "read_aws_config",
+		Description: "Read the AWS config file to see existing profiles and sso-session sections.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"path": stringProperty("Config file path; defaults to ~/.aws/config"),
+		}),
+	},
+	{
+		Name:        "write_aws_config",
+		Description: "Replace the whole AWS config file. A backup is created first.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"content": stringProperty("Complete config file content"),
+		}, "content"),
+	},
+	{
+		Name:        "read_yaml",
+		Description: "Read a project YAML file, e.g. to get account_id and region.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"path": stringProperty("YAML file path, e.g. project/dev.yaml"),
+		}, "path"),
+	},
+	{
+		Name:        "write_yaml",
+		Description: "Replace text in a project YAML file under project/. A backup is created first.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"path":     stringProperty("YAML file path, e.g. project/dev.yaml"),
+			"old_text": stringProperty("Exact text to replace"),
+			"new_text": stringProperty("Replacement text"),
+		}, "path", "old_text", "new_text"),
+	},
+	{
+		Name:        "ask_choice",
+		Description: "Ask the user to pick one of several options, e.g. an IAM role or region.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"question": stringProperty("Question to show"),
+			"options": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Options to choose from",
+			},
+		}, "question", "options"),
+	},
+	{
+		Name:        "ask_confirm",
+		Description: "Ask the user a yes/no question, e.g. before replacing existing configuration.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"question": stringProperty("Question to show"),
+		}, "question"),
+	},
+	{
+		Name:        "ask_input",
+		Description: "Ask the user for a value such as the SSO start URL or account ID. Ask one question at a time.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"question":    stringProperty("Question to show"),
+			"validator":   stringProperty("How to validate the answer", "url", "region", "account_id", "role_name", "none"),
+			"placeholder": stringProperty("Example value shown in the input field"),
+		}, "question"),
+	},
+	{
+		Name:        "web_search",
+		Description: "Search AWS documentation for an unknown error.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"query": stringProperty("Search query"),
+		}, "query"),
+	},
+	{
+		Name:        "aws_validate",
+		Description: "Check the AWS CLI version, run SSO login for a profile, or validate a profile's credentials against an expected account.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"check":      stringProperty("What to validate", "cli_version", "sso_login", "credentials"),
+			"profile":    stringProperty("AWS profile name (sso_login and credentials)"),
+			"account_id": stringProperty("Expected 12-digit account ID (credentials)"),
+		}, "check"),
+	},
+	{
+		Name:        "exec",
+		Description: "Run an AWS CLI or shell command, e.g. aws sts get-caller-identity --profile dev.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProperty("Command to run"),
+		}, "command"),
+	},
+	{
+		Name:        "write_profile",
+		Description: "Write a modern SSO profile with its sso-session section.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"profile":       stringProperty("Profile name"),
+			"sso_start_url": stringProperty("SSO start URL, e.g. https://mycompany.awsapps.com/start"),
+			"sso_region":    stringProperty("Region where IAM Identity Center is hosted"),
+			"account_id":    stringProperty("12-digit AWS account ID"),
+			"role_name":     stringProperty("Permission set / role name, e.g. AdministratorAccess"),
+			"region":        stringProperty("Default region for the profile"),
+			"output":        stringProperty("CLI output format", "json", "yaml", "text", "table"),
+		}, "profile", "sso_start_url", "sso_region", "account_id", "role_name", "region"),
+	},
+	{
+		Name:        "complete",
+		Description: "Finish once the profile is configured and validated.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"summary": stringProperty("What was configured"),
+		}, "summary"),
+	},
+}
+
+// ssoToolInput holds the arguments of any SSO agent tool
+type ssoToolInput struct {
+	Path        string   `json:"path,omitempty"`
+	Content     string   `json:"content,omitempty"`
+	OldText     string   `json:"old_text,omitempty"`
+	NewText     string   `json:"new_text,omitempty"`
+	Question    string   `json:"question,omitempty"`
+	Options     []string `json:"options,omitempty"`
+	Validator   string   `json:"validator,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Query       string   `json:"query,omitempty"`
+	Check       string   `json:"check,omitempty"`
+	Profile     string   `json:"profile,omitempty"`
+	AccountID   string   `json:"account_id,omitempty"`
+	Command     string   `json:"command,omitempty"`
+	SSOStartURL string   `json:"sso_start_url,omitempty"`
+	SSORegion   string   `json:"sso_region,omitempty"`
+	RoleName    string   `json:"role_name,omitempty"`
+	Region      string   `json:"region,omitempty"`
+	Output      string   `json:"output,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+}
+
+// parseSSOToolCall validates a tool call and decodes its arguments
+func parseSSOToolCall(call LLMToolCall) (*ssoToolInput, error) {
+	known := false
+	for _, tool := range ssoAgentTools {
+		if tool.Name == call.Name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown tool: %s", call.Name)
+	}
+
+	input := &ssoToolInput{}
+	if len(call.Input) > 0 {
+		if err := json.Unmarshal(call.Input, input); err != nil {
+			return nil, fmt.Errorf("invalid %s arguments: %w", call.Name, err)
+		}
+	}
+	return input, nil
+}
+
 // Tool: read_aws_config
 func (a *SSOAgent) toolReadAWSConfig(ctx context.Context, command string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
 	configPath := strings.TrimSpace(command)
@@ -207,29 +358,7 @@ func (a *SSOAgent) toolReadYAML(ctx context.Context, command string, agentCtx *S
 }
 
 // Tool: write_yaml (uses file_edit pattern)
-func (a *SSOAgent) toolWriteYAML(ctx context.Context, command string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
-	// Parse: FILE:path|OLD:old_text|NEW:new_text
-	parts := strings.Split(command, "|")
-	if len(parts) != 3 {
-		return &SSOAgentAction{
-			Type:   "write_yaml",
-			Result: "Error: invalid format, expected FILE:path|OLD:text|NEW:text",
-			Error:  fmt.Errorf("invalid format"),
-		}, fmt.Errorf("invalid format")
-	}
-
-	var filePath, oldText, newText string
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if strings.HasPrefix(part, "FILE:") {
-			filePath = strings.TrimPrefix(part, "FILE:")
-		} else if strings.HasPrefix(part, "OLD:") {
-			oldText = strings.TrimPrefix(part, "OLD:")
-		} else if strings.HasPrefix(part, "NEW:") {
-			newText = strings.TrimPrefix(part, "NEW:")
-		}
-	}
-
+func (a *SSOAgent) toolWriteYAML(ctx context.Context, filePath, oldText, newText string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
 	// CRITICAL: Validate path before any file operations
 	if err := validateYAMLPath(filePath); err != nil {
 		return &SSOAgentAction{
@@ -289,31 +418,13 @@ func (a *SSOAgent) toolWriteYAML(ctx context.Context, command string, agentCtx *
 }
 
 // Tool: ask_choice
-func (a *SSOAgent) toolAskChoice(ctx context.Context, command string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
-	// Parse: QUESTION:text|OPTIONS:opt1,opt2,opt3
-	parts := strings.Split(command, "|")
-	if len(parts) != 2 {
+func (a *SSOAgent) toolAskChoice(ctx context.Context, question string, options []string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
+	if len(options) == 0 {
 		return &SSOAgentAction{
 			Type:   "ask_choice",
-			Result: "Error: invalid format, expected QUESTION:text|OPTIONS:opt1,opt2,opt3",
-			Error:  fmt.Errorf("invalid format"),
-		}, fmt.Errorf("invalid format")
-	}
-
-	var question string
-	var options []string
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if strings.HasPrefix(part, "QUESTION:") {
-			question = strings.TrimPrefix(part, "QUESTION:")
-		} else if strings.HasPrefix(part, "OPTIONS:") {
-			optStr := strings.TrimPrefix(part, "OPTIONS:")
-			options = strings.Split(optStr, ",")
-			for i := range options {
-				options[i] = strings.TrimSpace(options[i])
-			}
-		}
+			Result: "Error: no options specified",
+			Error:  fmt.Errorf("no options specified"),
+		}, fmt.Errorf("no options specified")
 	}
 
 	selected, err := AskChoice(question, options)
@@ -361,20 +472,9 @@ func (a *SSOAgent) toolAskConfirm(ctx context.Context, command string, agentCtx
 }
 
 // Tool: ask_input
-func (a *SSOAgent) toolAskInput(ctx context.Context, command string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
-	// Parse: QUESTION:text|VALIDATOR:type|PLACEHOLDER:text
-	parts := strings.Split(command, "|")
-
-	var question, validator, placeholder string
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if strings.HasPrefix(part, "QUESTION:") {
-			question = strings.TrimPrefix(part, "QUESTION:")
-		} else if strings.HasPrefix(part, "VALIDATOR:") {
-			validator = strings.TrimPrefix(part, "VALIDATOR:")
-		} else if strings.HasPrefix(part, "PLACEHOLDER:") {
-			placeholder = strings.TrimPrefix(part, "PLACEHOLDER:")
-		}
+func (a *SSOAgent) toolAskInput(ctx context.Context, question, validator, placeholder string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
+	if validator == "" {
+		validator = "none"
 	}
 
 	if question == "" {
@@ -396,6 +496,22 @@ func (a *SSOAgent) toolAskInput(ctx context.Context, command string, agentCtx *S
 		}, err
 	}
 
+	// Remember collected values so the prompt can show them
+	switch validator {
+	case "url":
+		agentCtx.SSOStartURL = input
+	case "account_id":
+		agentCtx.AccountID = input
+	case "role_name":
+		agentCtx.RoleName = input
+	case "region":
+		if strings.Contains(strings.ToLower(question), "sso") {
+			agentCtx.SSORegion = input
+		} else {
+			agentCtx.Region = input
+		}
+	}
+
 	return &SSOAgentAction{
 		Type:      "ask_input",
 		Question:  question,
@@ -426,12 +542,8 @@ func (a *SSOAgent) toolWebSearch(ctx context.Context, query string, agentCtx *SS
 }
 
 // Tool: aws_validate
-func (a *SSOAgent) toolAWSValidate(ctx context.Context, command string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
-	// Parse command: sso_login|profile:dev
-	//            or: credentials|profile:dev|account:123456789012
-	//            or: cli_version
-
-	if command == "cli_version" {
+func (a *SSOAgent) toolAWSValidate(ctx context.Context, validationType, profile, expectedAccount string, agentCtx *SSOAgentContext) (*SSOAgentAction, error) {
+	if validationType == "cli_version" {
 		// Check AWS CLI version
 		err := a.inspector.CheckAWSCLI()
 		if err != nil {
@@ -449,18 +561,6 @@ func (a *SSOAgent) toolAWSValidate(ctx context.Context, command string, agentCtx
 		}, nil
 	}
 
-	parts := strings.Split(command, "|")
-	validationType := parts[0]
-
-	var profile, expectedAccount string
-	for _, part := range parts[1:] {
-		if strings.HasPrefix(part, "profile:") {
-			profile = strings.TrimPrefix(part, "profile:")
-		} else if strings.HasPrefix(part, "account:") {
-			expectedAccount = strings.TrimPrefix(part, "account:")
-		}
-	}
-
 	if validationType == "sso_login" {
 		// Test SSO login
 		autoLogin := NewAutoLogin(profile)
@@ -44,11 +44,8 @@ func (al *AutoLogin) ValidateCredentials(expectedAccountID, expectedRegion strin
 
 	ctx := context.Background()
 
-	// Load AWS config with the profile
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(al.profile),
-		config.WithRegion(expectedRegion),
-	)
+	// Load AWS config with the profile (environment credentials when empty)
+	cfg, err := loadAWSConfigForProfile(ctx, al.profile, config.WithRegion(expectedRegion))
 	if err != nil {
 		// Check if SSO token expired
 		if strings.Contains(err.Error(), "token") || strings.Contains(err.Error(), "sso") {
@@ -153,9 +150,7 @@ func (vr *ValidationResult) String() string {
 func CheckSSOTokenStatus(profile string) (bool, error) {
 	// Try a quick STS call without interactive login
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// SSOAccountRole is a permission set the user can assume in an account
+type SSOAccountRole struct {
+	AccountID   string
+	AccountName string
+	RoleName    string
+}
+
+// SSOBootstrapProfile is a profile bootstrap proposes for an account role
+type SSOBootstrapProfile struct {
+	SSOAccountRole
+	ProfileName string
+	Existing    bool // A profile for this account and role is already configured
+}
+
+// SSOEnvMapping points an environment's aws_profile at a bootstrapped profile
+type SSOEnvMapping struct {
+	Path       string
+	EnvName    string
+	AccountID  string
+	OldProfile string
+	NewProfile string // Empty when no profile matches the account
+}
+
+// Changed reports whether the environment's aws_profile needs updating
+func (m SSOEnvMapping) Changed() bool {
+	return m.NewProfile != "" && m.NewProfile != m.OldProfile
+}
+
+// ssoPortalAPI is the part of the SSO portal client bootstrap uses
+type ssoPortalAPI interface {
+	sso.ListAccountsAPIClient
+	sso.ListAccountRolesAPIClient
+}
+
+// ssoCachedToken is the token file written by aws sso login
+type ssoCachedToken struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+var profileNameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// loadSSOSessionToken reads the cached access token for an sso-session
+func loadSSOSessionToken(sessionName string) (string, error) {
+	path, err := ssocreds.StandardCachedTokenFilepath(sessionName)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("no cached SSO token for session %s", sessionName)
+	}
+	var token ssoCachedToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", fmt.Errorf("invalid SSO token cache %s: %w", path, err)
+	}
+	if token.AccessToken == "" || time.Now().After(token.ExpiresAt) {
+		return "", fmt.Errorf("SSO token for session %s expired", sessionName)
+	}
+	return token.AccessToken, nil
+}
+
+// listSSOAccountRoles lists every account and permission set reachable with the token
+func listSSOAccountRoles(ctx context.Context, client ssoPortalAPI, accessToken string) ([]SSOAccountRole, error) {
+	var roles []SSOAccountRole
+
+	accounts := sso.NewListAccountsPaginator(client, &sso.ListAccountsInput{AccessToken: aws.String(accessToken)})
+	for accounts.HasMorePages() {
+		page, err := accounts.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SSO accounts: %w", err)
+		}
+		for _, account := range page.AccountList {
+			accountRoles := sso.NewListAccountRolesPaginator(client, &sso.ListAccountRolesInput{
+				AccessToken: aws.String(accessToken),
+				AccountId:   account.AccountId,
+			})
+			for accountRoles.HasMorePages() {
+				rolePage, err := accountRoles.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("failed to list roles for account %s: %w", aws.ToString(account.AccountId), err)
+				}
+				for _, role := range rolePage.RoleList {
+					roles = append(roles, SSOAccountRole{
+						AccountID:   aws.ToString(account.AccountId),
+						AccountName: aws.ToString(account.AccountName),
+						RoleName:    aws.ToString(role.RoleName),
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].AccountName != roles[j].AccountName {
+			return roles[i].AccountName < roles[j].AccountName
+		}
+		return roles[i].RoleName < roles[j].RoleName
+	})
+	return roles, nil
+}
+
+// existingSSOProfiles maps "<account>/<role>" to the name of a configured profile,
+// and every profile name in the config to its SSO account ID (empty for other profiles)
+func existingSSOProfiles(content string) (map[string]string, map[string]string, error) {
+	cfg, err := ini.Load([]byte(content))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	targets := map[string]string{}
+	accounts := map[string]string{}
+	for _, section := range cfg.Sections() {
+		name := section.Name()
+		switch {
+		case name == "default":
+		case strings.HasPrefix(name, "profile "):
+			name = strings.TrimPrefix(name, "profile ")
+		default:
+			continue
+		}
+		account := section.Key("sso_account_id").String()
+		role := section.Key("sso_role_name").String()
+		accounts[name] = account
+		if key := account + "/" + role; account != "" && role != "" && targets[key] == "" {
+			targets[key] = name
+		}
+	}
+	return targets, accounts, nil
+}
+
+// proposeSSOProfiles names a profile for each account role: the slugged account
+// name with the prefix, plus the role when an account has several. Account roles
+// that already have a profile keep it; when role is set only that permission set is used.
+func proposeSSOProfiles(roles []SSOAccountRole, existing, taken map[string]string, prefix, role string) []SSOBootstrapProfile {
+	if role != "" {
+		var filtered []SSOAccountRole
+		for _, r := range roles {
+			if strings.EqualFold(r.RoleName, role) {
+				filtered = append(filtered, r)
+			}
+		}
+		roles = filtered
+	}
+
+	rolesPerAccount := map[string]int{}
+	for _, r := range roles {
+		rolesPerAccount[r.AccountID]++
+	}
+
+	used := map[string]bool{}
+	for name := range taken {
+		used[name] = true
+	}
+
+	profiles := make([]SSOBootstrapProfile, 0, len(roles))
+	for _, r := range roles {
+		if name := existing[r.AccountID+"/"+r.RoleName]; name != "" {
+			profiles = append(profiles, SSOBootstrapProfile{SSOAccountRole: r, ProfileName: name, Existing: true})
+			continue
+		}
+
+		parts := []string{prefix, r.AccountName}
+		if r.AccountName == "" {
+			parts[1] = r.AccountID
+		}
+		if rolesPerAccount[r.AccountID] > 1 {
+			parts = append(parts, r.RoleName)
+		}
+		base := strings.Trim(profileNameUnsafe.ReplaceAllString(strings.ToLower(strings.Join(parts, "-")), "-"), "-")
+
+		name := base
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[name] = true
+		profiles = append(profiles, SSOBootstrapProfile{SSOAccountRole: r, ProfileName: name})
+	}
+	return profiles
+}
+
+// mapEnvsToSSOProfiles picks a profile for each environment's account_id. An
+// aws_profile that already targets the account is kept; otherwise the preferred
+// role wins, then AdministratorAccess, then the first profile by name.
+// profileAccounts maps configured profile names to their SSO account IDs.
+func mapEnvsToSSOProfiles(envs map[string]Env, profiles []SSOBootstrapProfile, profileAccounts map[string]string, role string) []SSOEnvMapping {
+	byAccount := map[string][]SSOBootstrapProfile{}
+	for _, p := range profiles {
+		byAccount[p.AccountID] = append(byAccount[p.AccountID], p)
+	}
+
+	paths := make([]string, 0, len(envs))
+	for path := range envs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var mappings []SSOEnvMapping
+	for _, path := range paths {
+		env := envs[path]
+		if env.AccountID == "" {
+			continue
+		}
+		mapping := SSOEnvMapping{Path: path, EnvName: env.Env, AccountID: env.AccountID, OldProfile: env.AWSProfile}
+		if env.AWSProfile != "" && profileAccounts[env.AWSProfile] == env.AccountID {
+			mapping.NewProfile = env.AWSProfile
+			mappings = append(mappings, mapping)
+			continue
+		}
+
+		candidates := byAccount[env.AccountID]
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].ProfileName < candidates[j].ProfileName })
+		for _, preferred := range []func(SSOBootstrapProfile) bool{
+			func(p SSOBootstrapProfile) bool { return role != "" && strings.EqualFold(p.RoleName, role) },
+			func(p SSOBootstrapProfile) bool { return p.RoleName == "AdministratorAccess" },
+			func(p SSOBootstrapProfile) bool { return true },
+		} {
+			for _, p := range candidates {
+				if mapping.NewProfile == "" && preferred(p) {
+					mapping.NewProfile = p.ProfileName
+				}
+			}
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings
+}
+
+// loadProjectEnvs reads the environment files in dir, skipping other YAML
+// files such as dns.yaml and llm.yaml
+func loadProjectEnvs(dir string) (map[string]Env, error) {
+	files, err := findYAMLFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := map[string]Env{}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var env Env
+		if err := yaml.Unmarshal(data, &env); err != nil || env.Project == "" || env.Env == "" {
+			continue
+		}
+		envs[path] = env
+	}
+	return envs, nil
+}
+
+// SSOBootstrapOptions configures meroku sso bootstrap
+type SSOBootstrapOptions struct {
+	Session string // sso-session name in ~/.aws/config
+	Role    string // Only create profiles for this permission set
+	Prefix  string // Prepended to every new profile name
+	Region  string // Default region for new profiles, default the SSO region
+	DryRun  bool
+	Yes     bool // Don't ask for confirmation
+}
+
+// runSSOBootstrap creates a profile for every account role reachable from an
+// sso-session and points each environment's aws_profile at its account
+func runSSOBootstrap(opts SSOBootstrapOptions) error {
+	ctx := context.Background()
+
+	content, err := ReadAWSConfig(getAWSConfigPath())
+	if err != nil {
+		return err
+	}
+	if opts.Session == "" {
+		sessions, err := ParseSSOSessions(content)
+		if err != nil {
+			return err
+		}
+		if len(sessions) != 1 {
+			return fmt.Errorf("--session is required (sessions in config: %s)", strings.Join(sessions, ", "))
+		}
+		opts.Session = sessions[0]
+	}
+	session, err := GetSSOSessionSection(content, opts.Session)
+	if err != nil {
+		return err
+	}
+	if session["sso_start_url"] == "" || session["sso_region"] == "" {
+		return fmt.Errorf("sso-session %s needs sso_start_url and sso_region", opts.Session)
+	}
+
+	token, err := loadSSOSessionToken(opts.Session)
+	if err != nil {
+		fmt.Printf("🔐 %v, logging in...\n", err)
+		if output, err := runCommandWithOutput("aws", "sso", "login", "--sso-session", opts.Session); err != nil {
+			return fmt.Errorf("aws sso login failed: %w\n%s", err, output)
+		}
+		if token, err = loadSSOSessionToken(opts.Session); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("🔍 Listing accounts for %s...\n", session["sso_start_url"])
+	client := sso.New(sso.Options{Region: session["sso_region"]})
+	roles, err := listSSOAccountRoles(ctx, client, token)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return fmt.Errorf("no accounts are assigned to you in %s", session["sso_start_url"])
+	}
+
+	existing, taken, err := existingSSOProfiles(content)
+	if err != nil {
+		return err
+	}
+	profiles := proposeSSOProfiles(roles, existing, taken, opts.Prefix, opts.Role)
+	if len(profiles) == 0 {
+		return fmt.Errorf("permission set %s is not assigned in any account", opts.Role)
+	}
+
+	envs, err := loadProjectEnvs(".")
+	if err != nil {
+		return err
+	}
+	mappings := mapEnvsToSSOProfiles(envs, profiles, taken, opts.Role)
+
+	printSSOBootstrapPlan(profiles, mappings)
+
+	var newProfiles []ModernSSOProfileOptions
+	region := opts.Region
+	if region == "" {
+		region = session["sso_region"]
+	}
+	for _, p := range profiles {
+		if !p.Existing {
+			newProfiles = append(newProfiles, ModernSSOProfileOptions{
+				ProfileName:           p.ProfileName,
+				SSOSessionName:        opts.Session,
+				SSOStartURL:           session["sso_start_url"],
+				SSORegion:             session["sso_region"],
+				SSOAccountID:          p.AccountID,
+				SSORoleName:           p.RoleName,
+				SSORegistrationScopes: session["sso_registration_scopes"],
+				Region:                region,
+				Output:                "json",
+			})
+		}
+	}
+	var changed []SSOEnvMapping
+	for _, m := range mappings {
+		if m.Changed() {
+			changed = append(changed, m)
+		}
+	}
+
+	if len(newProfiles) == 0 && len(changed) == 0 {
+		fmt.Println("\n✅ Everything is already configured")
+		return nil
+	}
+	if opts.DryRun {
+		fmt.Println("\nDry run: nothing was written")
+		return nil
+	}
+	if !opts.Yes {
+		fmt.Printf("\nWrite %d profile(s) and update %d environment(s)? (y/n): ", len(newProfiles), len(changed))
+		var response string
+		fmt.Scanln(&response)
+		if response = strings.ToLower(strings.TrimSpace(response)); response != "y" && response != "yes" {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	if len(newProfiles) > 0 {
+		if err := NewConfigWriter().WriteModernSSOProfiles(newProfiles); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range changed {
+		env, err := loadEnv(strings.TrimSuffix(filepath.Base(m.Path), ".yaml"))
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", m.Path, err)
+		}
+		env.AWSProfile = m.NewProfile
+		if err := saveEnvToFile(env, m.Path); err != nil {
+			return fmt.Errorf("failed to save %s: %w", m.Path, err)
+		}
+		fmt.Printf("✅ %s: aws_profile = %s\n", m.Path, m.NewProfile)
+	}
+	return nil
+}
+
+// printSSOBootstrapPlan shows the profiles and environment mappings bootstrap would write
+func printSSOBootstrapPlan(profiles []SSOBootstrapProfile, mappings []SSOEnvMapping) {
+	fmt.Println("\nProfiles:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PROFILE\tACCOUNT\tNAME\tPERMISSION SET\tSTATUS")
+	for _, p := range profiles {
+		status := "new"
+		if p.Existing {
+			status = "exists"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", p.ProfileName, p.AccountID, p.AccountName, p.RoleName, status)
+	}
+	w.Flush()
+
+	if len(mappings) == 0 {
+		return
+	}
+	fmt.Println("\nEnvironments:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range mappings {
+		switch {
+		case m.NewProfile == "":
+			fmt.Fprintf(w, "  %s\t%s\t⚠️  no profile for this account\n", m.Path, m.AccountID)
+		case m.Changed():
+			old := m.OldProfile
+			if old == "" {
+				old = "(none)"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s → %s\n", m.Path, m.AccountID, old, m.NewProfile)
+		default:
+			fmt.Fprintf(w, "  %s\t%s\t%s (unchanged)\n", m.Path, m.AccountID, m.NewProfile)
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"gopkg.in/ini.v1"
+)
+
+// fakeSSOPortal serves accounts and roles, two per page
+type fakeSSOPortal struct {
+	accounts []ssotypes.AccountInfo
+	roles    map[string][]string
+}
+
+func (f *fakeSSOPortal) ListAccounts(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+	start := 0
+	if params.NextToken != nil {
+		start = 2
+	}
+	out := &sso.ListAccountsOutput{AccountList: f.accounts[start:min(start+2, len(f.accounts))]}
+	if start == 0 && len(f.accounts) > 2 {
+		out.NextToken = aws.String("page-2")
+	}
+	return out, nil
+}
+
+func (f *fakeSSOPortal) ListAccountRoles(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+	out := &sso.ListAccountRolesOutput{}
+	for _, role := range f.roles[aws.ToString(params.AccountId)] {
+		out.RoleList = append(out.RoleList, ssotypes.RoleInfo{AccountId: params.AccountId, RoleName: aws.String(role)})
+	}
+	return out, nil
+}
+
+func TestListSSOAccountRoles(t *testing.T) {
+	portal := &fakeSSOPortal{
+		accounts: []ssotypes.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("Prod")},
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("Dev")},
+			{AccountId: aws.String("333333333333"), AccountName: aws.String("Audit")},
+		},
+		roles: map[string][]string{
+			"111111111111": {"ReadOnly", "AdministratorAccess"},
+			"222222222222": {"AdministratorAccess"},
+			"333333333333": {"ReadOnly"},
+		},
+	}
+
+	roles, err := listSSOAccountRoles(context.Background(), portal, "token")
+	if err != nil {
+		t.Fatalf("listSSOAccountRoles() error = %v", err)
+	}
+	var got []string
+	for _, r := range roles {
+		got = append(got, r.AccountName+"/"+r.RoleName)
+	}
+	if want := "Audit/ReadOnly,Dev/AdministratorAccess,Prod/AdministratorAccess,Prod/ReadOnly"; strings.Join(got, ",") != want {
+		t.Errorf("roles = %v, want %s", got, want)
+	}
+}
+
+const bootstrapTestConfig = `[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = us-east-1
+
+[profile acme-prod]
+sso_session = acme
+sso_account_id = 111111111111
+sso_role_name = AdministratorAccess
+
+[profile dev]
+region = eu-west-1
+`
+
+func TestProposeSSOProfiles(t *testing.T) {
+	existing, taken, err := existingSSOProfiles(bootstrapTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := []SSOAccountRole{
+		{AccountID: "111111111111", AccountName: "Prod", RoleName: "AdministratorAccess"},
+		{AccountID: "111111111111", AccountName: "Prod", RoleName: "ReadOnly"},
+		{AccountID: "222222222222", AccountName: "Dev", RoleName: "AdministratorAccess"},
+		{AccountID: "333333333333", AccountName: "Data & ML (EU)", RoleName: "AdministratorAccess"},
+	}
+
+	names := func(profiles []SSOBootstrapProfile) string {
+		var parts []string
+		for _, p := range profiles {
+			name := p.ProfileName
+			if p.Existing {
+				name += "*"
+			}
+			parts = append(parts, name)
+		}
+		return strings.Join(parts, ",")
+	}
+
+	// The existing profile is reused and "dev" is taken by a non-SSO profile
+	if got, want := names(proposeSSOProfiles(roles, existing, taken, "", "")), "acme-prod*,prod-readonly,dev-2,data-ml-eu"; got != want {
+		t.Errorf("profiles = %s, want %s", got, want)
+	}
+	// With a single permission set the role is left out, so the name collides with the admin profile
+	if got, want := names(proposeSSOProfiles(roles, existing, taken, "Acme", "readonly")), "acme-prod-2"; got != want {
+		t.Errorf("profiles with role = %s, want %s", got, want)
+	}
+}
+
+func TestMapEnvsToSSOProfiles(t *testing.T) {
+	profiles := []SSOBootstrapProfile{
+		{SSOAccountRole: SSOAccountRole{AccountID: "111111111111", RoleName: "ReadOnly"}, ProfileName: "prod-readonly"},
+		{SSOAccountRole: SSOAccountRole{AccountID: "111111111111", RoleName: "AdministratorAccess"}, ProfileName: "prod-admin"},
+		{SSOAccountRole: SSOAccountRole{AccountID: "222222222222", RoleName: "Developer"}, ProfileName: "dev"},
+	}
+	envs := map[string]Env{
+		"prod.yaml":    {Env: "prod", AccountID: "111111111111", AWSProfile: "old-prod"},
+		"dev.yaml":     {Env: "dev", AccountID: "222222222222", AWSProfile: "my-dev"},
+		"staging.yaml": {Env: "staging", AccountID: "444444444444"},
+		"local.yaml":   {Env: "local"},
+	}
+	profileAccounts := map[string]string{"old-prod": "999999999999", "my-dev": "222222222222"}
+
+	mappings := mapEnvsToSSOProfiles(envs, profiles, profileAccounts, "")
+	got := map[string]SSOEnvMapping{}
+	for _, m := range mappings {
+		got[m.EnvName] = m
+	}
+	if len(mappings) != 3 {
+		t.Fatalf("got %d mappings, want 3: %+v", len(mappings), mappings)
+	}
+	if m := got["prod"]; m.NewProfile != "prod-admin" || !m.Changed() {
+		t.Errorf("prod mapping = %+v, want prod-admin", m)
+	}
+	if m := got["dev"]; m.NewProfile != "my-dev" || m.Changed() {
+		t.Errorf("dev mapping = %+v, want the existing profile kept", m)
+	}
+	if m := got["staging"]; m.NewProfile != "" || m.Changed() {
+		t.Errorf("staging mapping = %+v, want no profile", m)
+	}
+
+	if m := mapEnvsToSSOProfiles(envs, profiles, profileAccounts, "ReadOnly"); m[1].EnvName != "prod" || m[1].NewProfile != "prod-readonly" {
+		t.Errorf("preferred role mapping = %+v", m[1])
+	}
+}
+
+func TestWriteModernSSOProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(bootstrapTestConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writer := &ConfigWriter{configPath: path}
+
+	var profiles []ModernSSOProfileOptions
+	for _, name := range []string{"prod-readonly", "dev-2"} {
+		profiles = append(profiles, ModernSSOProfileOptions{
+			ProfileName:    name,
+			SSOSessionName: "acme",
+			SSOStartURL:    "https://acme.awsapps.com/start",
+			SSORegion:      "us-east-1",
+			SSOAccountID:   "111111111111",
+			SSORoleName:    "ReadOnly",
+			Region:         "eu-west-1",
+		})
+	}
+	if err := writer.WriteModernSSOProfiles(profiles); err != nil {
+		t.Fatalf("WriteModernSSOProfiles() error = %v", err)
+	}
+
+	cfg, err := ini.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"profile acme-prod", "profile dev", "profile prod-readonly", "profile dev-2"} {
+		if !cfg.HasSection(name) {
+			t.Errorf("config is missing [%s]", name)
+		}
+	}
+	if got := cfg.Section("profile dev-2").Key("sso_session").String(); got != "acme" {
+		t.Errorf("sso_session = %q", got)
+	}
+}
+
+func TestLoadProjectEnvsSkipsOtherYAML(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"dev.yaml": "project: demo\nenv: dev\naccount_id: \"222222222222\"\n",
+		"dns.yaml": "root_domain: example.com\n",
+		"llm.yaml": "provider: anthropic\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	envs, err := loadProjectEnvs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 1 || envs[filepath.Join(dir, "dev.yaml")].AccountID != "222222222222" {
+		t.Errorf("loadProjectEnvs() = %+v", envs)
+	}
+}
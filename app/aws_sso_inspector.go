@@ -35,6 +35,16 @@ type ProfileInfo struct {
 	Region           string
 	Output           string
 	SSOSessionInfo   *SSOSessionInfo // For modern SSO
+
+	// Role assumption and other credential sources
+	RoleARN              string
+	SourceProfile        string
+	CredentialSource     string // Environment, Ec2InstanceMetadata or EcsContainer
+	MFASerial            string // Set if any role in the chain requires MFA
+	WebIdentityTokenFile string
+	CredentialProcess    string
+	RoleChain            []string    // This profile followed by its source_profile chain
+	SourceType           ProfileType // Type of the profile providing the base credentials
 }
 
 // SSOSessionInfo contains sso-session section details
@@ -57,6 +67,9 @@ const (
 	ProfileTypeLegacySSO   ProfileType = "legacy_sso"   // Direct SSO fields
 	ProfileTypeStaticKeys  ProfileType = "static_keys"  // Access key/secret
 	ProfileTypeAssumeRole  ProfileType = "assume_role"  // Role assumption
+	ProfileTypeCredentialProcess ProfileType = "credential_process" // External credential helper
+	ProfileTypeWebIdentity       ProfileType = "web_identity"       // Role assumed with an OIDC token file
+	ProfileTypeEnvironment       ProfileType = "environment"        // Credentials from environment variables
 )
 
 // NewProfileInspector creates a new profile inspector
@@ -124,11 +137,11 @@ func (pi *ProfileInspector) InspectProfile(profileName string) (*ProfileInfo, er
 		// Static keys are handled by AWS SDK, we don't validate them
 		info.Complete = true
 	case ProfileTypeAssumeRole:
-		// Assume role profiles need source_profile
-		info.Complete = section.HasKey("source_profile")
-		if !info.Complete {
-			info.MissingFields = append(info.MissingFields, "source_profile")
-		}
+		pi.validateAssumeRole(section, info)
+	case ProfileTypeWebIdentity:
+		pi.validateWebIdentity(section, info)
+	case ProfileTypeCredentialProcess:
+		pi.validateCredentialProcess(section, info)
 	default:
 		info.Complete = false
 		info.MissingFields = append(info.MissingFields, "unknown_profile_type")
@@ -139,6 +152,14 @@ func (pi *ProfileInspector) InspectProfile(profileName string) (*ProfileInfo, er
 
 // detectProfileType determines what kind of profile this is
 func (pi *ProfileInspector) detectProfileType(section *ini.Section) ProfileType {
+	// A role_arn wins over everything else, as in the AWS SDKs
+	if section.HasKey("role_arn") {
+		if section.HasKey("web_identity_token_file") {
+			return ProfileTypeWebIdentity
+		}
+		return ProfileTypeAssumeRole
+	}
+
 	// Check for modern SSO (uses sso_session reference)
 	if section.HasKey("sso_session") {
 		return ProfileTypeModernSSO
@@ -161,8 +182,13 @@ func (pi *ProfileInspector) detectProfileType(section *ini.Section) ProfileType
 		return ProfileTypeStaticKeys
 	}
 
-	// Check for assume role
-	if section.HasKey("source_profile") || section.HasKey("role_arn") {
+	// Check for an external credential helper
+	if section.HasKey("credential_process") {
+		return ProfileTypeCredentialProcess
+	}
+
+	// Check for assume role missing its role_arn
+	if section.HasKey("source_profile") {
 		return ProfileTypeAssumeRole
 	}
 
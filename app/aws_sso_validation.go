@@ -66,8 +66,14 @@ func (v *AWSProfileValidator) ValidateProfile(env *Env) ProfileValidationResult
 		Errors:     []string{},
 	}
 
+	// Credentials from the environment (CI) stand in for a profile that isn't configured here
+	envInfo := inspectEnvironmentCredentials()
+
 	// Check if profile is specified
 	if env.AWSProfile == "" {
+		if envInfo != nil {
+			return v.validateEnvironmentCredentials(env, envInfo, result)
+		}
 		result.Success = false
 		result.Errors = append(result.Errors, "No AWS profile specified in YAML (aws_profile field missing)")
 		return result
@@ -85,6 +91,10 @@ func (v *AWSProfileValidator) ValidateProfile(env *Env) ProfileValidationResult
 
 	// Check if profile exists
 	if !info.Exists {
+		if envInfo != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Profile '%s' not found, using %s", env.AWSProfile, envInfo.Describe()))
+			return v.validateEnvironmentCredentials(env, envInfo, result)
+		}
 		result.Success = false
 		result.Errors = append(result.Errors, fmt.Sprintf("Profile '%s' not found in AWS config", env.AWSProfile))
 		result.Fixable = true
@@ -97,47 +107,75 @@ func (v *AWSProfileValidator) ValidateProfile(env *Env) ProfileValidationResult
 		result.Success = false
 		result.Errors = append(result.Errors, fmt.Sprintf("Profile '%s' is incomplete. Missing: %s",
 			env.AWSProfile, strings.Join(info.MissingFields, ", ")))
-		result.Fixable = true
-		result.FixSuggestion = "Run the AWS SSO Setup Wizard to complete the profile configuration"
+		switch info.Type {
+		case ProfileTypeModernSSO, ProfileTypeLegacySSO, ProfileTypeUnknown:
+			result.Fixable = true
+			result.FixSuggestion = "Run the AWS SSO Setup Wizard to complete the profile configuration"
+		default:
+			// The SSO wizard would replace the profile with an SSO one
+			result.FixSuggestion = fmt.Sprintf("Edit [%s] in %s", getSectionName(env.AWSProfile), getAWSConfigPath())
+		}
 		return result
 	}
 
-	// Check SSO token status (if SSO profile)
-	if info.Type == ProfileTypeModernSSO || info.Type == ProfileTypeLegacySSO {
-		tokenValid, err := CheckSSOTokenStatus(env.AWSProfile)
+	// Check SSO token status (if SSO profile or a role chain starting at one)
+	if info.UsesSSO() {
+		root := info.RootProfile()
+		tokenValid, err := CheckSSOTokenStatus(root)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("Could not check SSO token status: %v", err))
 		} else if !tokenValid {
 			result.Success = false
 			result.Errors = append(result.Errors, "SSO token expired or missing")
 			result.Fixable = true
-			result.FixSuggestion = fmt.Sprintf("Run: aws sso login --profile %s", env.AWSProfile)
+			result.FixSuggestion = fmt.Sprintf("Run: aws sso login --profile %s", root)
 		}
 	}
 
 	// Validate credentials work
 	if result.Success {
-		autoLogin := NewAutoLogin(env.AWSProfile)
-		validationResult, err := autoLogin.ValidateCredentials(env.AccountID, env.Region)
-		if err != nil {
-			result.Success = false
-			result.Errors = append(result.Errors, fmt.Sprintf("Credential validation failed: %v", err))
-			result.Fixable = true
-			result.FixSuggestion = fmt.Sprintf("Run: aws sso login --profile %s", env.AWSProfile)
-		} else if validationResult.AccountIDMismatch {
-			result.Success = false
-			result.Errors = append(result.Errors, fmt.Sprintf(
-				"Account ID mismatch: YAML says %s, AWS returns %s",
-				validationResult.ExpectedAccountID,
-				validationResult.AccountID))
-			result.Fixable = true
-			result.FixSuggestion = "Update the account_id in your YAML file or reconfigure the AWS profile"
-		}
+		v.validateCredentials(env, env.AWSProfile, info, &result)
 	}
 
 	return result
 }
 
+// validateEnvironmentCredentials validates credentials set through environment variables
+func (v *AWSProfileValidator) validateEnvironmentCredentials(env *Env, info *ProfileInfo, result ProfileValidationResult) ProfileValidationResult {
+	result.ProfileInfo = info
+	if result.Profile == "" {
+		result.Profile = info.Describe()
+	}
+	if !info.Complete {
+		result.Success = false
+		result.Errors = append(result.Errors, fmt.Sprintf("Environment credentials are incomplete. Missing: %s",
+			strings.Join(info.MissingFields, ", ")))
+		return result
+	}
+	v.validateCredentials(env, "", info, &result)
+	return result
+}
+
+// validateCredentials calls STS with the profile and records how to fix a failure
+func (v *AWSProfileValidator) validateCredentials(env *Env, profile string, info *ProfileInfo, result *ProfileValidationResult) {
+	autoLogin := NewAutoLogin(profile)
+	validationResult, err := autoLogin.ValidateCredentials(env.AccountID, env.Region)
+	if err != nil && (validationResult == nil || !validationResult.AccountIDMismatch) {
+		result.Success = false
+		result.Errors = append(result.Errors, fmt.Sprintf("Credential validation failed: %v", err))
+		result.Fixable = info.UsesSSO()
+		result.FixSuggestion = credentialRecoverySteps(info, profile)[0]
+	} else if validationResult.AccountIDMismatch {
+		result.Success = false
+		result.Errors = append(result.Errors, fmt.Sprintf(
+			"Account ID mismatch: YAML says %s, AWS returns %s",
+			validationResult.ExpectedAccountID,
+			validationResult.AccountID))
+		result.Fixable = true
+		result.FixSuggestion = "Update the account_id in your YAML file or reconfigure the AWS profile"
+	}
+}
+
 // ProfileValidationResult contains the validation result for a profile
 type ProfileValidationResult struct {
 	YAMLPath      string
@@ -173,7 +211,7 @@ func (r ProfileValidationResult) String() string {
 		for _, err := range r.Errors {
 			b.WriteString(fmt.Sprintf("   • %s\n", err))
 		}
-		if r.Fixable && r.FixSuggestion != "" {
+		if r.FixSuggestion != "" {
 			b.WriteString(fmt.Sprintf("   💡 Fix: %s\n", r.FixSuggestion))
 		}
 	}
@@ -22,6 +22,11 @@ func NewConfigWriter() *ConfigWriter {
 
 // WriteModernSSOProfile writes a modern SSO profile configuration
 func (cw *ConfigWriter) WriteModernSSOProfile(opts ModernSSOProfileOptions) error {
+	return cw.WriteModernSSOProfiles([]ModernSSOProfileOptions{opts})
+}
+
+// WriteModernSSOProfiles writes several modern SSO profiles with a single backup and save
+func (cw *ConfigWriter) WriteModernSSOProfiles(profiles []ModernSSOProfileOptions) error {
 	// Create backup first
 	if err := cw.createBackup(); err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
@@ -33,14 +38,16 @@ func (cw *ConfigWriter) WriteModernSSOProfile(opts ModernSSOProfileOptions) erro
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Write sso-session section
-	if err := cw.writeSSOSessionSection(cfg, opts.SSOSessionName, opts); err != nil {
-		return fmt.Errorf("failed to write sso-session: %w", err)
-	}
+	for _, opts := range profiles {
+		// Write sso-session section
+		if err := cw.writeSSOSessionSection(cfg, opts.SSOSessionName, opts); err != nil {
+			return fmt.Errorf("failed to write sso-session: %w", err)
+		}
 
-	// Write profile section
-	if err := cw.writeProfileSection(cfg, opts.ProfileName, opts); err != nil {
-		return fmt.Errorf("failed to write profile: %w", err)
+		// Write profile section
+		if err := cw.writeProfileSection(cfg, opts.ProfileName, opts); err != nil {
+			return fmt.Errorf("failed to write profile %s: %w", opts.ProfileName, err)
+		}
 	}
 
 	// Save configuration
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/miekg/dns"
+)
+
+// certificateExpiryWarningDays flags certificates that expire within this many days
+const certificateExpiryWarningDays = 30
+
+// certificateStuckAfter is how long a certificate may wait for DNS validation before
+// it's reported as stuck, ACM usually issues within minutes once the CNAME resolves
+const certificateStuckAfter = time.Hour
+
+// acmCertificatesAPI is the part of the ACM client used to inspect certificates
+type acmCertificatesAPI interface {
+	acm.ListCertificatesAPIClient
+	DescribeCertificate(ctx context.Context, params *acm.DescribeCertificateInput, optFns ...func(*acm.Options)) (*acm.DescribeCertificateOutput, error)
+}
+
+// CertificateInfo is an ACM certificate with its validation and renewal state
+type CertificateInfo struct {
+	ARN                string                        `json:"arn"`
+	DomainName         string                        `json:"domainName"`
+	AlternativeNames   []string                      `json:"alternativeNames,omitempty"`
+	Region             string                        `json:"region"`
+	Referenced         bool                          `json:"referenced"` // Covers a domain the environment config uses
+	Status             string                        `json:"status"`
+	Type               string                        `json:"type"`
+	InUseBy            []string                      `json:"inUseBy,omitempty"`
+	CreatedAt          *time.Time                    `json:"createdAt,omitempty"`
+	NotAfter           *time.Time                    `json:"notAfter,omitempty"`
+	DaysUntilExpiry    *int                          `json:"daysUntilExpiry,omitempty"`
+	RenewalEligibility string                        `json:"renewalEligibility,omitempty"`
+	RenewalStatus      string                        `json:"renewalStatus,omitempty"`
+	ValidationRecords  []CertificateValidationRecord `json:"validationRecords,omitempty"`
+	Warnings           []string                      `json:"warnings,omitempty"`
+}
+
+// CertificateValidationRecord is a CNAME that ACM waits for before it issues or renews a certificate
+type CertificateValidationRecord struct {
+	Domains []string `json:"domains"` // Names it validates, the apex and its wildcard share one record
+	Name    string   `json:"name"`
+	Value   string   `json:"value"`
+	Zone    string   `json:"zone,omitempty"` // Hosted zone from dns.yaml or the environment that can hold it
+	Present bool     `json:"present"`        // Whether the zone's nameservers already serve it
+}
+
+// CertificateReport lists the certificates of an environment
+type CertificateReport struct {
+	Env          string            `json:"env"`
+	Domains      []string          `json:"domains,omitempty"` // Domains the environment config references
+	Regions      []string          `json:"regions"`
+	Certificates []CertificateInfo `json:"certificates"`
+	Errors       []string          `json:"errors,omitempty"` // Regions that could not be listed
+}
+
+// MissingValidationRecords returns validation records that are not served yet
+func (r *CertificateReport) MissingValidationRecords() []CertificateValidationRecord {
+	var missing []CertificateValidationRecord
+	seen := map[string]bool{}
+	for _, cert := range r.Certificates {
+		for _, record := range cert.ValidationRecords {
+			if !record.Present && !seen[record.Name] {
+				seen[record.Name] = true
+				missing = append(missing, record)
+			}
+		}
+	}
+	return missing
+}
+
+// certificateRegions returns the environment's region and us-east-1,
+// where CloudFront and Amplify custom domains need their certificates
+func certificateRegions(env Env) []string {
+	regions := []string{"us-east-1"}
+	if env.Region != "" && env.Region != "us-east-1" {
+		regions = append([]string{env.Region}, regions...)
+	}
+	return regions
+}
+
+// envCertificateDomains returns the domains the environment config uses
+// certificates for: its DNS zone and Amplify custom domains outside of it
+func envCertificateDomains(env Env) []string {
+	var domains []string
+	add := func(domain string) {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if domain != "" && !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	if env.Domain.Enabled {
+		add(envDNSZoneDomain(env))
+	}
+	for _, app := range env.AmplifyApps {
+		add(app.CustomDomain)
+	}
+	return domains
+}
+
+// certificateMatchesDomain reports whether any of the names is one of the domains or their subdomains
+func certificateMatchesDomain(names []string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		for _, name := range names {
+			name = strings.TrimPrefix(strings.ToLower(name), "*.")
+			if name == domain || strings.HasSuffix(name, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listCertificates returns the certificates in a region whose names match the domain
+func listCertificates(ctx context.Context, client acmCertificatesAPI, region string, domains []string, now time.Time) ([]CertificateInfo, error) {
+	// Without a key type filter ACM only lists RSA 1024 and 2048 certificates
+	paginator := acm.NewListCertificatesPaginator(client, &acm.ListCertificatesInput{
+		Includes: &acmtypes.Filters{KeyTypes: acmtypes.KeyAlgorithm("").Values()},
+	})
+
+	var certs []CertificateInfo
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list certificates: %w", err)
+		}
+		for _, summary := range page.CertificateSummaryList {
+			resp, err := client.DescribeCertificate(ctx, &acm.DescribeCertificateInput{CertificateArn: summary.CertificateArn})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe %s: %w", aws.ToString(summary.DomainName), err)
+			}
+			cert := certificateInfo(resp.Certificate, region, now)
+			cert.Referenced = certificateMatchesDomain(append([]string{cert.DomainName}, cert.AlternativeNames...), domains)
+			certs = append(certs, cert)
+		}
+	}
+
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].DomainName < certs[j].DomainName })
+	return certs, nil
+}
+
+// certificateInfo summarizes a certificate and explains what needs attention
+func certificateInfo(detail *acmtypes.CertificateDetail, region string, now time.Time) CertificateInfo {
+	cert := CertificateInfo{
+		ARN:                aws.ToString(detail.CertificateArn),
+		DomainName:         aws.ToString(detail.DomainName),
+		Region:             region,
+		Status:             string(detail.Status),
+		Type:               string(detail.Type),
+		InUseBy:            detail.InUseBy,
+		CreatedAt:          detail.CreatedAt,
+		NotAfter:           detail.NotAfter,
+		RenewalEligibility: string(detail.RenewalEligibility),
+	}
+	for _, name := range detail.SubjectAlternativeNames {
+		if name != cert.DomainName {
+			cert.AlternativeNames = append(cert.AlternativeNames, name)
+		}
+	}
+	if detail.NotAfter != nil {
+		days := int(detail.NotAfter.Sub(now).Hours() / 24)
+		cert.DaysUntilExpiry = &days
+	}
+
+	validations := detail.DomainValidationOptions
+	if detail.RenewalSummary != nil {
+		cert.RenewalStatus = string(detail.RenewalSummary.RenewalStatus)
+		if detail.RenewalSummary.RenewalStatus == acmtypes.RenewalStatusPendingValidation {
+			validations = detail.RenewalSummary.DomainValidationOptions
+		}
+	}
+	if detail.Status == acmtypes.CertificateStatusPendingValidation || cert.RenewalStatus == string(acmtypes.RenewalStatusPendingValidation) {
+		cert.ValidationRecords = pendingValidationRecords(validations)
+	}
+
+	switch detail.Status {
+	case acmtypes.CertificateStatusPendingValidation:
+		if detail.CreatedAt != nil && now.Sub(*detail.CreatedAt) > certificateStuckAfter {
+			cert.Warnings = append(cert.Warnings, fmt.Sprintf("waiting for DNS validation since %s, check that the validation CNAMEs exist",
+				detail.CreatedAt.Format("2006-01-02 15:04")))
+		}
+	case acmtypes.CertificateStatusFailed:
+		cert.Warnings = append(cert.Warnings, fmt.Sprintf("issuance failed: %s", detail.FailureReason))
+	case acmtypes.CertificateStatusValidationTimedOut:
+		cert.Warnings = append(cert.Warnings, "DNS validation timed out after 72 hours, request a new certificate")
+	case acmtypes.CertificateStatusExpired:
+		cert.Warnings = append(cert.Warnings, "expired")
+	case acmtypes.CertificateStatusRevoked:
+		cert.Warnings = append(cert.Warnings, "revoked")
+	case acmtypes.CertificateStatusIssued:
+		switch {
+		case cert.RenewalStatus == string(acmtypes.RenewalStatusFailed):
+			cert.Warnings = append(cert.Warnings, fmt.Sprintf("renewal failed: %s", detail.RenewalSummary.RenewalStatusReason))
+		case cert.RenewalStatus == string(acmtypes.RenewalStatusPendingValidation):
+			cert.Warnings = append(cert.Warnings, "renewal is waiting for DNS validation")
+		case cert.DaysUntilExpiry == nil || *cert.DaysUntilExpiry > certificateExpiryWarningDays:
+		case detail.Type == acmtypes.CertificateTypeImported:
+			cert.Warnings = append(cert.Warnings, fmt.Sprintf("expires in %d days, ACM does not renew imported certificates", *cert.DaysUntilExpiry))
+		case detail.RenewalEligibility == acmtypes.RenewalEligibilityIneligible:
+			cert.Warnings = append(cert.Warnings, fmt.Sprintf("expires in %d days and is not eligible for renewal, it is not in use", *cert.DaysUntilExpiry))
+		}
+	}
+	return cert
+}
+
+// pendingValidationRecords returns the DNS validation CNAMEs that have not succeeded yet
+func pendingValidationRecords(validations []acmtypes.DomainValidation) []CertificateValidationRecord {
+	var records []CertificateValidationRecord
+	index := map[string]int{}
+	for _, v := range validations {
+		if v.ValidationMethod != acmtypes.ValidationMethodDns || v.ResourceRecord == nil || v.ValidationStatus == acmtypes.DomainStatusSuccess {
+			continue
+		}
+		name := strings.ToLower(aws.ToString(v.ResourceRecord.Name))
+		if i, ok := index[name]; ok {
+			records[i].Domains = append(records[i].Domains, aws.ToString(v.DomainName))
+			continue
+		}
+		index[name] = len(records)
+		records = append(records, CertificateValidationRecord{
+			Domains: []string{aws.ToString(v.DomainName)},
+			Name:    name,
+			Value:   aws.ToString(v.ResourceRecord.Value),
+		})
+	}
+	return records
+}
+
+// certificateValidationZones returns the hosted zones validation records can go to:
+// the environment's zone and the zones in dns.yaml
+func certificateValidationZones(config *DNSConfig, env Env) []dnsRecordZone {
+	var zones []dnsRecordZone
+	if env.Domain.Enabled && env.Domain.DomainName != "" {
+		zones = append(zones, dnsRecordZone{
+			Source:  env.Env + ".yaml",
+			Domain:  envDNSZoneDomain(env),
+			ZoneID:  env.Domain.ZoneID,
+			Profile: awsProfileForEnv(env),
+		})
+	}
+	if config != nil {
+		for _, zone := range config.DelegatedZones {
+			profile, _ := findAWSProfileByAccountID(zone.AccountID)
+			zones = append(zones, dnsRecordZone{Source: DNSConfigFile, Domain: zone.Subdomain, ZoneID: zone.ZoneID, Profile: profile})
+		}
+		if config.RootDomain != "" {
+			// Fall back to the default credentials if no profile targets the root account
+			profile, _ := findAWSProfileByAccountID(config.RootAccount.AccountID)
+			zones = append(zones, dnsRecordZone{Source: DNSConfigFile, Domain: config.RootDomain, ZoneID: config.RootAccount.ZoneID, Profile: profile})
+		}
+	}
+	return zones
+}
+
+// findValidationZone returns the most specific zone that contains the name
+func findValidationZone(zones []dnsRecordZone, name string) *dnsRecordZone {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	var best *dnsRecordZone
+	for i := range zones {
+		domain := strings.ToLower(strings.TrimSuffix(zones[i].Domain, "."))
+		if name != domain && !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+		if best == nil || len(domain) > len(best.Domain) {
+			best = &zones[i]
+		}
+	}
+	return best
+}
+
+// validationRecordPresent reports whether the servers answer the validation CNAME with the expected value
+func validationRecordPresent(servers []string, record CertificateValidationRecord) bool {
+	answers, err := queryDNSRecord(servers, record.Name, dns.TypeCNAME)
+	if err != nil {
+		return false
+	}
+	want := normalizeDNSValue("CNAME", record.Value)
+	for _, answer := range answers {
+		if answer == want {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValidationRecords assigns a hosted zone to every validation record and checks
+// whether the zone's nameservers already serve it
+func checkValidationRecords(report *CertificateReport, zones []dnsRecordZone) {
+	servers := map[string][]string{}
+	for i := range report.Certificates {
+		for j := range report.Certificates[i].ValidationRecords {
+			record := &report.Certificates[i].ValidationRecords[j]
+			zone := findValidationZone(zones, record.Name)
+			if zone == nil {
+				continue
+			}
+			record.Zone = zone.Domain
+			if _, ok := servers[zone.Domain]; !ok {
+				ns, _ := queryNameservers(zone.Domain)
+				servers[zone.Domain] = nameserverAddresses(ns)
+			}
+			record.Present = len(servers[zone.Domain]) > 0 && validationRecordPresent(servers[zone.Domain], *record)
+		}
+	}
+}
+
+// buildCertificateReport lists all certificates in the environment's region and
+// us-east-1, marking the ones that cover domains the environment config uses
+func buildCertificateReport(ctx context.Context, env Env) (*CertificateReport, error) {
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	report := &CertificateReport{Env: env.Env, Domains: envCertificateDomains(env), Regions: certificateRegions(env), Certificates: []CertificateInfo{}}
+	now := time.Now()
+	for _, region := range report.Regions {
+		client := acm.NewFromConfig(cfg, func(o *acm.Options) { o.Region = region })
+		certs, err := listCertificates(ctx, client, region, report.Domains, now)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", region, err))
+			continue
+		}
+		report.Certificates = append(report.Certificates, certs...)
+	}
+	if len(report.Errors) == len(report.Regions) {
+		return nil, fmt.Errorf("failed to list certificates:\n  - %s", strings.Join(report.Errors, "\n  - "))
+	}
+
+	config, err := loadDNSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DNS config: %w", err)
+	}
+	checkValidationRecords(report, certificateValidationZones(config, env))
+	return report, nil
+}
+
+// createValidationRecords upserts the missing validation CNAMEs in the zones they belong to
+// and returns the records it created
+func createValidationRecords(ctx context.Context, env Env, records []CertificateValidationRecord) ([]CertificateValidationRecord, error) {
+	config, err := loadDNSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DNS config: %w", err)
+	}
+	zones := certificateValidationZones(config, env)
+
+	byZone := map[*dnsRecordZone][]dnsRecordChange{}
+	var order []*dnsRecordZone
+	var created []CertificateValidationRecord
+	for _, record := range records {
+		zone := findValidationZone(zones, record.Name)
+		if zone == nil {
+			continue
+		}
+		if _, ok := byZone[zone]; !ok {
+			order = append(order, zone)
+		}
+		byZone[zone] = append(byZone[zone], validationRecordChange(record))
+		record.Zone = zone.Domain
+		created = append(created, record)
+	}
+
+	for _, zone := range order {
+		client, err := newRoute53Client(ctx, zone.Profile)
+		if err != nil {
+			return nil, err
+		}
+		if zone.ZoneID == "" {
+			if zone.ZoneID, err = lookupZoneID(ctx, client, zone.Domain); err != nil {
+				return nil, err
+			}
+		}
+		if err := applyDNSRecordChanges(ctx, client, zone.ZoneID, byZone[zone]); err != nil {
+			return nil, fmt.Errorf("%s: %w", zone.Domain, err)
+		}
+	}
+	return created, nil
+}
+
+// validationRecordChange upserts a validation CNAME, ACM keeps using it for renewals
+func validationRecordChange(record CertificateValidationRecord) dnsRecordChange {
+	return dnsRecordChange{
+		Action: types.ChangeActionUpsert,
+		Name:   record.Name,
+		Type:   "CNAME",
+		New:    record.Value,
+		Set: types.ResourceRecordSet{
+			Name:            aws.String(record.Name),
+			Type:            types.RRTypeCname,
+			TTL:             aws.Int64(defaultDNSRecordTTL),
+			ResourceRecords: []types.ResourceRecord{{Value: aws.String(record.Value)}},
+		},
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+)
+
+// fakeACM serves certificates one per page to exercise pagination
+type fakeACM struct {
+	certs []acmtypes.CertificateDetail
+}
+
+func (f *fakeACM) ListCertificates(ctx context.Context, params *acm.ListCertificatesInput, optFns ...func(*acm.Options)) (*acm.ListCertificatesOutput, error) {
+	i := 0
+	if params.NextToken != nil {
+		i = len(aws.ToString(params.NextToken))
+	}
+	out := &acm.ListCertificatesOutput{}
+	if i < len(f.certs) {
+		c := f.certs[i]
+		out.CertificateSummaryList = []acmtypes.CertificateSummary{{
+			CertificateArn:                  c.CertificateArn,
+			DomainName:                      c.DomainName,
+			SubjectAlternativeNameSummaries: c.SubjectAlternativeNames,
+		}}
+	}
+	if i+1 < len(f.certs) {
+		out.NextToken = aws.String(strings.Repeat("x", i+1))
+	}
+	return out, nil
+}
+
+func (f *fakeACM) DescribeCertificate(ctx context.Context, params *acm.DescribeCertificateInput, optFns ...func(*acm.Options)) (*acm.DescribeCertificateOutput, error) {
+	for _, c := range f.certs {
+		if aws.ToString(c.CertificateArn) == aws.ToString(params.CertificateArn) {
+			return &acm.DescribeCertificateOutput{Certificate: &c}, nil
+		}
+	}
+	return nil, nil
+}
+
+func dnsValidation(domain, name, value string, status acmtypes.DomainStatus) acmtypes.DomainValidation {
+	return acmtypes.DomainValidation{
+		DomainName:       aws.String(domain),
+		ValidationMethod: acmtypes.ValidationMethodDns,
+		ValidationStatus: status,
+		ResourceRecord:   &acmtypes.ResourceRecord{Name: aws.String(name), Type: acmtypes.RecordTypeCname, Value: aws.String(value)},
+	}
+}
+
+func TestListCertificates(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-3 * time.Hour)
+	client := &fakeACM{certs: []acmtypes.CertificateDetail{
+		{
+			CertificateArn:          aws.String("arn:pending"),
+			DomainName:              aws.String("dev.example.com"),
+			SubjectAlternativeNames: []string{"dev.example.com", "*.dev.example.com"},
+			Status:                  acmtypes.CertificateStatusPendingValidation,
+			Type:                    acmtypes.CertificateTypeAmazonIssued,
+			CreatedAt:               &created,
+			DomainValidationOptions: []acmtypes.DomainValidation{
+				dnsValidation("dev.example.com", "_abc.dev.example.com.", "_xyz.acm-validations.aws.", acmtypes.DomainStatusPendingValidation),
+				dnsValidation("*.dev.example.com", "_ABC.dev.example.com.", "_xyz.acm-validations.aws.", acmtypes.DomainStatusPendingValidation),
+			},
+		},
+		{
+			CertificateArn: aws.String("arn:other"),
+			DomainName:     aws.String("other.org"),
+			Status:         acmtypes.CertificateStatusIssued,
+		},
+		{
+			CertificateArn:     aws.String("arn:imported"),
+			DomainName:         aws.String("api.dev.example.com"),
+			Status:             acmtypes.CertificateStatusIssued,
+			Type:               acmtypes.CertificateTypeImported,
+			NotAfter:           aws.Time(now.Add(10 * 24 * time.Hour)),
+			RenewalEligibility: acmtypes.RenewalEligibilityIneligible,
+		},
+	}}
+
+	certs, err := listCertificates(context.Background(), client, "us-east-1", []string{"dev.example.com"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 3 {
+		t.Fatalf("got %d certificates, want 3: %+v", len(certs), certs)
+	}
+	if other := certs[2]; other.DomainName != "other.org" || other.Referenced {
+		t.Errorf("other.org certificate = %+v, want unreferenced", other)
+	}
+
+	imported := certs[0]
+	if !imported.Referenced || !certs[1].Referenced {
+		t.Errorf("certificates under dev.example.com should be referenced: %+v", certs[:2])
+	}
+	if imported.DomainName != "api.dev.example.com" || *imported.DaysUntilExpiry != 10 || len(imported.Warnings) != 1 ||
+		!strings.Contains(imported.Warnings[0], "does not renew imported certificates") {
+		t.Errorf("imported certificate = %+v", imported)
+	}
+
+	pending := certs[1]
+	if len(pending.AlternativeNames) != 1 || pending.AlternativeNames[0] != "*.dev.example.com" {
+		t.Errorf("AlternativeNames = %v", pending.AlternativeNames)
+	}
+	if len(pending.ValidationRecords) != 1 || len(pending.ValidationRecords[0].Domains) != 2 ||
+		pending.ValidationRecords[0].Name != "_abc.dev.example.com." {
+		t.Errorf("ValidationRecords = %+v", pending.ValidationRecords)
+	}
+	if len(pending.Warnings) != 1 || !strings.Contains(pending.Warnings[0], "waiting for DNS validation") {
+		t.Errorf("Warnings = %v", pending.Warnings)
+	}
+}
+
+func TestCertificateInfoRenewal(t *testing.T) {
+	now := time.Now()
+	detail := &acmtypes.CertificateDetail{
+		DomainName: aws.String("example.com"),
+		Status:     acmtypes.CertificateStatusIssued,
+		NotAfter:   aws.Time(now.Add(20 * 24 * time.Hour)),
+		DomainValidationOptions: []acmtypes.DomainValidation{
+			dnsValidation("example.com", "_old.example.com.", "_old.acm-validations.aws.", acmtypes.DomainStatusSuccess),
+		},
+		RenewalSummary: &acmtypes.RenewalSummary{
+			RenewalStatus: acmtypes.RenewalStatusPendingValidation,
+			DomainValidationOptions: []acmtypes.DomainValidation{
+				dnsValidation("example.com", "_new.example.com.", "_new.acm-validations.aws.", acmtypes.DomainStatusPendingValidation),
+			},
+		},
+	}
+	cert := certificateInfo(detail, "eu-west-1", now)
+	if len(cert.ValidationRecords) != 1 || cert.ValidationRecords[0].Name != "_new.example.com." {
+		t.Errorf("ValidationRecords = %+v", cert.ValidationRecords)
+	}
+	if len(cert.Warnings) != 1 || cert.Warnings[0] != "renewal is waiting for DNS validation" {
+		t.Errorf("Warnings = %v", cert.Warnings)
+	}
+}
+
+func TestEnvCertificateDomains(t *testing.T) {
+	env := Env{
+		Env:         "dev",
+		Domain:      Domain{Enabled: true, DomainName: "example.com", RootZoneID: "Z1"},
+		AmplifyApps: []AmplifyApp{{Name: "web", CustomDomain: "app.example.org"}, {Name: "admin", SubdomainPrefix: "admin"}},
+	}
+	if got := envCertificateDomains(env); strings.Join(got, ",") != "dev.example.com,app.example.org" {
+		t.Errorf("envCertificateDomains() = %v", got)
+	}
+}
+
+func TestCertificateRegions(t *testing.T) {
+	if got := certificateRegions(Env{Region: "eu-west-1"}); strings.Join(got, ",") != "eu-west-1,us-east-1" {
+		t.Errorf("certificateRegions(eu-west-1) = %v", got)
+	}
+	if got := certificateRegions(Env{Region: "us-east-1"}); strings.Join(got, ",") != "us-east-1" {
+		t.Errorf("certificateRegions(us-east-1) = %v", got)
+	}
+}
+
+func TestFindValidationZone(t *testing.T) {
+	zones := []dnsRecordZone{{Domain: "dev.example.com"}, {Domain: "example.com"}}
+	for name, want := range map[string]string{
+		"_abc.dev.example.com.":    "dev.example.com",
+		"_abc.EXAMPLE.com.":        "example.com",
+		"_abc.staging.example.com": "example.com",
+		"_abc.example.org.":        "",
+		"_abc.notexample.com.":     "",
+	} {
+		got := ""
+		if zone := findValidationZone(zones, name); zone != nil {
+			got = zone.Domain
+		}
+		if got != want {
+			t.Errorf("findValidationZone(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidationRecordPresent(t *testing.T) {
+	server := startStubDNSServer(t, `_abc.dev.example.com. 300 IN CNAME _XYZ.acm-validations.aws.`)
+	record := CertificateValidationRecord{Name: "_abc.dev.example.com.", Value: "_xyz.acm-validations.aws."}
+	if !validationRecordPresent([]string{server}, record) {
+		t.Error("expected the record to be present")
+	}
+	record.Value = "_other.acm-validations.aws."
+	if validationRecordPresent([]string{server}, record) {
+		t.Error("expected a different value to be reported missing")
+	}
+}
+
+func TestMissingValidationRecords(t *testing.T) {
+	record := CertificateValidationRecord{Name: "_abc.example.com.", Value: "_xyz.acm-validations.aws."}
+	report := &CertificateReport{Certificates: []CertificateInfo{
+		{ValidationRecords: []CertificateValidationRecord{record, {Name: "_ok.example.com.", Present: true}}},
+		{ValidationRecords: []CertificateValidationRecord{record}},
+	}}
+	if missing := report.MissingValidationRecords(); len(missing) != 1 || missing[0].Name != record.Name {
+		t.Errorf("MissingValidationRecords() = %+v", missing)
+	}
+}
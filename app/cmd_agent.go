@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// handleAgentCommand handles AI agent session subcommands
+func handleAgentCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("AI agent commands:")
+		fmt.Println("  agent list                                          - List saved agent sessions")
+		fmt.Println("  agent resume <id>                                   - Continue a saved session")
+		fmt.Println("  agent export <id> [--format md|json] [--output f]   - Export a session transcript")
+		fmt.Println("  agent eval <scenario.json|dir>                      - Replay recorded scenarios offline")
+		return
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] != "list" && len(args) < 2 {
+		if args[0] == "eval" {
+			fmt.Println("Usage: agent eval <scenario.json|dir>")
+		} else {
+			fmt.Printf("Usage: agent %s <id>\n", args[0])
+		}
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		err = runAgentList(wd)
+	case "resume":
+		err = runAgentResume(wd, args[1])
+	case "export":
+		fs := flag.NewFlagSet("agent export", flag.ExitOnError)
+		format := fs.String("format", "md", "Export format: md or json")
+		output := fs.String("output", "", "Write to a file instead of stdout")
+		fs.Parse(args[2:])
+		err = runAgentExport(wd, args[1], *format, *output)
+	case "eval":
+		err = runAgentEval(args[1])
+	default:
+		fmt.Printf("Unknown agent command: %s\n", args[0])
+		fmt.Println("Available commands: list, resume, export, eval")
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runAgentList(projectDir string) error {
+	sessions, err := ListAgentSessions(projectDir)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No saved agent sessions in this project")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tENV\tITERATIONS\tOUTCOME\tUPDATED\tPROBLEM")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			s.ID,
+			s.State.Context.Environment,
+			len(s.State.Iterations),
+			s.Outcome(),
+			s.UpdatedAt.Local().Format(time.DateTime),
+			truncateString(strings.SplitN(s.State.Context.InitialError, "\n", 2)[0], 60),
+		)
+	}
+	return w.Flush()
+}
+
+func runAgentResume(projectDir, id string) error {
+	session, err := LoadAgentSession(projectDir, id)
+	if err != nil {
+		return err
+	}
+	if !isAIHelperAvailable() {
+		return fmt.Errorf("the AI agent requires an LLM provider to be configured")
+	}
+
+	fmt.Printf("🚀 Resuming AI agent session %s (%d iterations, %s)\n", session.ID, len(session.State.Iterations), session.Outcome())
+	return RunResumedAIAgentTUI(session)
+}
+
+func runAgentExport(projectDir, id, format, output string) error {
+	session, err := LoadAgentSession(projectDir, id)
+	if err != nil {
+		return err
+	}
+
+	content, err := ExportAgentSession(session, format)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Printf("✓ Exported session %s to %s\n", id, output)
+	return nil
+}
+
+func runAgentEval(path string) error {
+	scenarios, err := LoadAgentScenarios(path)
+	if err != nil {
+		return err
+	}
+	if len(scenarios) == 0 {
+		return fmt.Errorf("no scenarios found in %s", path)
+	}
+
+	failed := 0
+	for _, scenario := range scenarios {
+		result, err := RunAgentScenario(scenario)
+		if err != nil {
+			return err
+		}
+		if result.Passed() {
+			fmt.Printf("✓ %s (%d iterations, %s)\n", result.Name, len(result.Iterations), result.Outcome)
+			continue
+		}
+		failed++
+		fmt.Printf("✗ %s\n", result.Name)
+		for _, failure := range result.Failures {
+			fmt.Printf("    %s\n", failure)
+		}
+	}
+
+	fmt.Printf("\n%d/%d scenarios passed\n", len(scenarios)-failed, len(scenarios))
+	if failed > 0 {
+		return fmt.Errorf("%d scenario(s) failed", failed)
+	}
+	return nil
+}
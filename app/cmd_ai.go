@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// handleAICommand handles AI feature subcommands
+func handleAICommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("AI commands:")
+		fmt.Println("  ai usage [--days 30] [--by feature|model|day|session]   - Summarize LLM tokens, latency and cost")
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "usage":
+		fs := flag.NewFlagSet("ai usage", flag.ExitOnError)
+		days := fs.Int("days", 30, "Number of days to include")
+		by := fs.String("by", "feature", "Group by feature, model, day or session")
+		fs.Parse(args[1:])
+		err = runAIUsage(*days, *by)
+	default:
+		fmt.Printf("Unknown ai command: %s\n", args[0])
+		fmt.Println("Available commands: usage")
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// llmUsageGroupKey returns the value a record is grouped by
+func llmUsageGroupKey(record LLMUsageRecord, by string) (string, error) {
+	switch by {
+	case "feature":
+		return record.Feature, nil
+	case "model":
+		return record.Model, nil
+	case "day":
+		return record.Time.Local().Format(time.DateOnly), nil
+	case "session":
+		if record.Session == "" {
+			return "(no session)", nil
+		}
+		return record.Session, nil
+	default:
+		return "", fmt.Errorf("unknown grouping %q (expected feature, model, day or session)", by)
+	}
+}
+
+func runAIUsage(days int, by string) error {
+	if _, err := llmUsageGroupKey(LLMUsageRecord{}, by); err != nil {
+		return err
+	}
+	config, err := loadLLMConfig()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	since := now.AddDate(0, 0, -days)
+	if monthStart.Before(since) {
+		since = monthStart
+	}
+
+	records, err := readLLMUsage(config.usageLogPath(), since)
+	if err != nil {
+		return err
+	}
+
+	periodStart := now.AddDate(0, 0, -days)
+	groups := map[string]*LLMUsage{}
+	var total, month LLMUsage
+	unpriced := map[string]bool{}
+	for _, record := range records {
+		if !record.Time.Before(monthStart) {
+			month.Add(record)
+		}
+		if record.Time.Before(periodStart) {
+			continue
+		}
+		key, _ := llmUsageGroupKey(record, by)
+		if groups[key] == nil {
+			groups[key] = &LLMUsage{}
+		}
+		groups[key].Add(record)
+		total.Add(record)
+		if record.Unpriced {
+			unpriced[record.Model] = true
+		}
+	}
+
+	if total.Calls == 0 {
+		fmt.Printf("No LLM calls recorded in the last %d days (%s)\n", days, config.usageLogPath())
+		return nil
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if by == "day" {
+			return keys[i] < keys[j]
+		}
+		return groups[keys[i]].CostUSD > groups[keys[j]].CostUSD
+	})
+
+	fmt.Printf("LLM usage for the last %d days, by %s\n\n", days, by)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tCALLS\tINPUT TOKENS\tOUTPUT TOKENS\tAVG LATENCY\tCOST\n", strings.ToUpper(by))
+	for _, key := range keys {
+		writeLLMUsageRow(w, key, *groups[key])
+	}
+	writeLLMUsageRow(w, "TOTAL", total)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nThis month (%s): $%.2f over %d calls\n", monthStart.Format("January 2006"), month.CostUSD, month.Calls)
+	if config.SessionBudgetUSD > 0 || config.DailyBudgetUSD > 0 {
+		fmt.Printf("Caps: $%.2f per agent session, $%.2f per day (0 = no cap)\n", config.SessionBudgetUSD, config.DailyBudgetUSD)
+	}
+	for model := range unpriced {
+		fmt.Printf("⚠️  No price known for %s; add it under prices: in %s\n", model, LLMConfigFile)
+	}
+	return nil
+}
+
+func writeLLMUsageRow(w *tabwriter.Writer, key string, usage LLMUsage) {
+	avg := time.Duration(0)
+	if usage.Calls > 0 {
+		avg = usage.Latency / time.Duration(usage.Calls)
+	}
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%v\t$%.4f\n",
+		key, usage.Calls, usage.InputTokens, usage.OutputTokens, avg.Round(10*time.Millisecond), usage.CostUSD)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// handleCertsCommand handles the certs command
+func handleCertsCommand(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Println("Usage: certs <env> [--yes]")
+		fmt.Println("  Lists ACM certificates in the environment's region and us-east-1 (CloudFront/Amplify),")
+		fmt.Println("  their validation status and expiry, and offers to create missing DNS validation records.")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("certs", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "Create missing validation records without asking for confirmation")
+	fs.Parse(args[1:])
+
+	if err := runCerts(args[0], *yes); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCerts(envName string, yes bool) error {
+	ctx := context.Background()
+	env, err := loadEnv(envName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+
+	report, err := buildCertificateReport(ctx, env)
+	if err != nil {
+		return err
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("⚠️  %s\n", e)
+	}
+
+	if len(report.Domains) > 0 {
+		fmt.Printf("Domains in %s.yaml: %s (other certificates are marked unreferenced)\n", envName, strings.Join(report.Domains, ", "))
+	}
+	for _, region := range report.Regions {
+		printRegionCertificates(report, region)
+	}
+
+	missing := report.MissingValidationRecords()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var creatable []CertificateValidationRecord
+	for _, record := range missing {
+		if record.Zone != "" {
+			creatable = append(creatable, record)
+		}
+	}
+	if len(creatable) < len(missing) {
+		fmt.Println("\nSome validation records are outside the zones meroku manages, add them at your DNS provider:")
+		for _, record := range missing {
+			if record.Zone == "" {
+				fmt.Printf("  %s CNAME %s\n", record.Name, record.Value)
+			}
+		}
+	}
+	if len(creatable) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n%d validation record(s) can be created in Route53.\n", len(creatable))
+	if !yes {
+		fmt.Print("Create them now? (y/N): ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	created, err := createValidationRecords(ctx, env, creatable)
+	if err != nil {
+		return err
+	}
+	for _, record := range created {
+		fmt.Printf("✓ %s CNAME %s (%s)\n", record.Name, record.Value, record.Zone)
+	}
+	fmt.Println("  ACM usually issues the certificates within a few minutes once the records resolve.")
+	fmt.Printf("  Check again with: meroku certs %s\n", envName)
+	return nil
+}
+
+// printRegionCertificates prints the certificates of a region with their warnings and pending validation records
+func printRegionCertificates(report *CertificateReport, region string) {
+	var certs []CertificateInfo
+	for _, cert := range report.Certificates {
+		if cert.Region == region {
+			certs = append(certs, cert)
+		}
+	}
+
+	fmt.Printf("\n🔐 %s\n", region)
+	if len(certs) == 0 {
+		fmt.Println("  No certificates")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Domain\tStatus\tExpires\tIn use\tConfig")
+	fmt.Fprintln(w, strings.Repeat("─", 90))
+	for _, cert := range certs {
+		domain := cert.DomainName
+		if len(cert.AlternativeNames) > 0 {
+			domain += fmt.Sprintf(" (+%d)", len(cert.AlternativeNames))
+		}
+		expires := "-"
+		if cert.NotAfter != nil && cert.DaysUntilExpiry != nil {
+			expires = fmt.Sprintf("%s (%d days)", cert.NotAfter.Local().Format("2006-01-02"), *cert.DaysUntilExpiry)
+		}
+		inUse := "no"
+		if len(cert.InUseBy) > 0 {
+			inUse = fmt.Sprintf("%d resource(s)", len(cert.InUseBy))
+		}
+		config := "referenced"
+		if !cert.Referenced {
+			config = "unreferenced"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", domain, cert.Status, expires, inUse, config)
+	}
+	w.Flush()
+
+	for _, cert := range certs {
+		for _, warning := range cert.Warnings {
+			fmt.Printf("  ⚠️  %s: %s\n", cert.DomainName, warning)
+		}
+		if len(cert.ValidationRecords) == 0 {
+			continue
+		}
+		fmt.Printf("  Validation records for %s:\n", cert.DomainName)
+		for _, record := range cert.ValidationRecords {
+			status := "❌ missing"
+			if record.Present {
+				status = "✓ present"
+			}
+			zone := "no managed zone"
+			if record.Zone != "" {
+				zone = "zone " + record.Zone
+			}
+			fmt.Printf("    %s %s CNAME %s (%s, %s)\n", status, record.Name, record.Value, strings.Join(record.Domains, ", "), zone)
+		}
+	}
+}
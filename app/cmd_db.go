@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// handleDBCommand handles database subcommands
+func handleDBCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Database commands:")
+		fmt.Println("  db snapshot <env> [--id <snapshot-id>]                             - Create a manual snapshot")
+		fmt.Println("  db list-snapshots <env>                                            - List automated and manual snapshots")
+		fmt.Println("  db restore <env> --snapshot <id> [--target <id>] [--repoint]       - Restore a snapshot into a new database")
+		fmt.Println("  db tunnel <env> [--local-port 5432]                                - Forward a local port to the database via a backend task")
+		return
+	}
+
+	if len(args) < 2 {
+		fmt.Printf("Usage: db %s <env> [options]\n", args[0])
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "snapshot":
+		fs := flag.NewFlagSet("db snapshot", flag.ExitOnError)
+		id := fs.String("id", "", "Snapshot identifier (default: <db>-manual-<timestamp>)")
+		fs.Parse(args[2:])
+		err = runDBSnapshot(args[1], *id)
+	case "list-snapshots":
+		err = runDBListSnapshots(args[1])
+	case "restore":
+		fs := flag.NewFlagSet("db restore", flag.ExitOnError)
+		opts := DBRestoreOptions{}
+		fs.StringVar(&opts.SnapshotID, "snapshot", "", "Snapshot identifier to restore (required)")
+		fs.StringVar(&opts.TargetID, "target", "", "Identifier of the new instance/cluster (default: <db>-restored-<timestamp>)")
+		fs.BoolVar(&opts.Repoint, "repoint", false, "Point the backend at the restored database and redeploy it")
+		fs.Parse(args[2:])
+		if opts.SnapshotID == "" {
+			fmt.Println("Usage: db restore <env> --snapshot <id> [--target <id>] [--repoint]")
+			os.Exit(1)
+		}
+		err = runDBRestore(args[1], opts)
+	case "tunnel":
+		fs := flag.NewFlagSet("db tunnel", flag.ExitOnError)
+		localPort := fs.Int("local-port", 5432, "Local port to listen on")
+		fs.Parse(args[2:])
+		err = runDBTunnel(args[1], *localPort)
+	default:
+		fmt.Printf("Unknown db command: %s\n", args[0])
+		fmt.Println("Available commands: snapshot, list-snapshots, restore, tunnel")
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadDBEnv loads an environment and an RDS client for it
+func loadDBEnv(ctx context.Context, envName string) (Env, *rds.Client, error) {
+	env, err := loadEnv(envName)
+	if err != nil {
+		return env, nil, fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+	if !env.Postgres.Enabled {
+		return env, nil, fmt.Errorf("postgres is not enabled in %s.yaml", envName)
+	}
+
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return env, nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return env, rds.NewFromConfig(cfg), nil
+}
+
+func runDBSnapshot(envName, snapshotID string) error {
+	ctx := context.Background()
+	env, client, err := loadDBEnv(ctx, envName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📸 Creating snapshot of %s\n", dbSourceIdentifier(env))
+	snapshot, err := createDBSnapshot(ctx, client, env, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Snapshot %s started (status: %s)\n", snapshot.ID, snapshot.Status)
+	fmt.Printf("  Track it with: meroku db list-snapshots %s\n", envName)
+	return nil
+}
+
+func runDBListSnapshots(envName string) error {
+	ctx := context.Background()
+	env, client, err := loadDBEnv(ctx, envName)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := listDBSnapshots(ctx, client, env)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots found for %s\n", dbSourceIdentifier(env))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Snapshot\tType\tStatus\tCreated\tAge\tSize")
+	fmt.Fprintln(w, strings.Repeat("─", 90))
+	for _, s := range snapshots {
+		created := "-"
+		if !s.CreatedAt.IsZero() {
+			created = s.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d GB\n", s.ID, s.Type, s.Status, created, s.Age, s.SizeGB)
+	}
+	return w.Flush()
+}
+
+func runDBRestore(envName string, opts DBRestoreOptions) error {
+	ctx := context.Background()
+	env, client, err := loadDBEnv(ctx, envName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("⟳ Restoring %s into a new %s\n", opts.SnapshotID, map[bool]string{true: "Aurora cluster", false: "RDS instance"}[env.Postgres.Aurora])
+	result, err := startDBRestore(ctx, client, env, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Waiting for %s to become available (this usually takes 10-30 minutes)...\n", result.TargetID)
+	if err := waitForDBRestore(ctx, client, result); err != nil {
+		return err
+	}
+	fmt.Printf("✓ %s is available at %s\n", result.TargetID, result.Endpoint)
+
+	if !opts.Repoint {
+		fmt.Println("  The backend still uses the original database (use --repoint to switch it on restore).")
+		return nil
+	}
+
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if err := repointDBEndpoint(ctx, cfg, env, result.Endpoint); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Updated %s and redeployed the backend\n", dbEndpointSSMName(env))
+	fmt.Printf("  Note: %s\n", dbRepointNote(env, result.Endpoint))
+	return nil
+}
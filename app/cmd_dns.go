@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -106,7 +107,74 @@ func runDNSStatus(cmd interface{}, args []string) error {
 	return nil
 }
 
+// runDNSSetup runs the setup wizard, or with --non-interactive the same steps driven by a DNS config file
+func runDNSSetup(args []string) error {
+	fs := flag.NewFlagSet("dns setup", flag.ExitOnError)
+	dnssec := fs.Bool("dnssec", false, "Sign the root zone")
+	from := fs.String("from", DNSConfigFile, "DNS config with the root domain, root account and delegated zones")
+	nonInteractive := fs.Bool("non-interactive", false, "Run without the wizard, e.g. in CI")
+	asJSON := fs.Bool("json", false, "Print progress as JSON lines (with --non-interactive)")
+	fs.Parse(args)
+
+	if !*nonInteractive {
+		runDNSSetupWizard(*dnssec)
+		return nil
+	}
+
+	spec, err := loadDNSConfigFile(*from)
+	if err != nil {
+		return err
+	}
+	if spec == nil {
+		return fmt.Errorf("%s not found", *from)
+	}
+	spec.DNSSEC = spec.DNSSEC || *dnssec
+
+	state, err := loadDNSConfig()
+	if err != nil {
+		return err
+	}
+
+	_, err = runHeadlessDNSSetup(spec, state, defaultDNSSetupOps(), func(event DNSSetupEvent) {
+		printDNSSetupEvent(event, *asJSON)
+	})
+	if err != nil && !*asJSON {
+		fmt.Printf("\nProgress is saved in %s, fix the problem and run the same command again to resume.\n", DNSConfigFile)
+	}
+	return err
+}
+
+// parseDNSValidateArgs parses `dns validate [--dnssec] [--resolvers ip,ip]`
+func parseDNSValidateArgs(args []string) (bool, []DNSResolver, error) {
+	var dnssec bool
+	var resolvers []DNSResolver
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--dnssec":
+			dnssec = true
+		case "--resolvers":
+			if i+1 >= len(args) {
+				return false, nil, fmt.Errorf("--resolvers requires a comma-separated list of addresses")
+			}
+			i++
+			for _, address := range strings.Split(args[i], ",") {
+				if address = strings.TrimSpace(address); address != "" {
+					resolvers = append(resolvers, DNSResolver{Name: address, Address: address})
+				}
+			}
+		default:
+			return false, nil, fmt.Errorf("unknown argument %q", args[i])
+		}
+	}
+	return dnssec, resolvers, nil
+}
+
 func runDNSValidate(cmd interface{}, args []string) error {
+	checkDNSSECChain, resolvers, err := parseDNSValidateArgs(args)
+	if err != nil {
+		return err
+	}
+
 	config, err := loadDNSConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load DNS config: %w", err)
@@ -138,7 +206,10 @@ func runDNSValidate(cmd interface{}, args []string) error {
 	
 	// Check DNS propagation
 	fmt.Print("Checking DNS propagation... ")
-	propagation, err := checkDNSPropagation(config.RootDomain, ns)
+	if len(resolvers) == 0 {
+		resolvers = propagationResolvers()
+	}
+	propagation, err := checkDNSPropagationWith(resolvers, config.RootDomain, ns)
 	if err != nil {
 		warnings = append(warnings, fmt.Sprintf("Failed to check propagation: %v", err))
 		fmt.Println("⚠️")
@@ -195,6 +266,49 @@ func runDNSValidate(cmd interface{}, args []string) error {
 		}
 	}
 	
+	// Check the DNSSEC chain of trust from the parent zones
+	if checkDNSSECChain || config.DNSSEC {
+		zones := []string{config.RootDomain}
+		for _, zone := range config.DelegatedZones {
+			zones = append(zones, zone.Subdomain)
+		}
+		for _, zone := range zones {
+			fmt.Printf("Checking DNSSEC for %s... ", zone)
+			status := checkZoneDNSSEC(zone)
+			switch {
+			case len(status.Problems) > 0:
+				issues = append(issues, fmt.Sprintf("DNSSEC for %s: %s", zone, status.Summary()))
+				fmt.Println("❌")
+			case status.Valid:
+				fmt.Printf("✅ (%s)\n", status.Summary())
+			case config.DNSSEC && zone == config.RootDomain:
+				issues = append(issues, fmt.Sprintf("DNSSEC is enabled in dns.yaml but %s is not signed", zone))
+				fmt.Println("❌")
+			default:
+				fmt.Println("➖ (unsigned)")
+			}
+		}
+	}
+	
+	// Verify managed records resolve with the configured values
+	envs, err := loadProjectEnvs(".")
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("Failed to load environments: %v", err))
+	}
+	recordZones, err := collectDNSRecordZones(config, envs, "")
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	for _, zone := range recordZones {
+		fmt.Printf("Checking %d record(s) in %s... ", len(zone.Records), zone.Domain)
+		if problems := verifyDNSRecords(zone, nil); len(problems) > 0 {
+			issues = append(issues, problems...)
+			fmt.Println("❌")
+		} else {
+			fmt.Println("✅")
+		}
+	}
+	
 	fmt.Println(strings.Repeat("─", 50))
 	
 	// Summary
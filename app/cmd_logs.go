@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// logPrefixColors are the colors of the service prefixes when several services are tailed
+var logPrefixColors = []lipgloss.Color{"6", "5", "3", "2", "4", "13", "14", "11"}
+
+// LogsOptions are the options of `meroku logs`
+type LogsOptions struct {
+	Services []string
+	Since    string
+	Filter   string
+	Task     string
+	Follow   bool
+}
+
+// handleLogsCommand handles the logs command
+func handleLogsCommand(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "query":
+			handleLogsQueryCommand(args[1:])
+			return
+		case "queries":
+			handleSavedLogQueriesCommand(args[1:])
+			return
+		}
+	}
+
+	opts, envName, err := parseLogsArgs(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Usage: logs <env> <service> [service...] [--follow] [--since 1h] [--filter pattern] [--task id]")
+		fmt.Println("       logs query <env> \"<insights query>\" [--services a,b] [--since 1h] [--limit n] [--save name] [--json]")
+		fmt.Println("       logs query <env> --saved <name>")
+		fmt.Println("       logs queries [delete <name>]")
+		os.Exit(1)
+	}
+
+	if err := runLogs(envName, opts); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseLogsArgs parses `logs <env> <service> [service...] [flags]`, services may also be comma-separated
+func parseLogsArgs(args []string) (LogsOptions, string, error) {
+	opts := LogsOptions{}
+	var positional []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	fs.BoolVar(&opts.Follow, "follow", false, "Keep printing new events")
+	fs.BoolVar(&opts.Follow, "f", false, "Shorthand for --follow")
+	fs.StringVar(&opts.Since, "since", "1h", "Start with events from this long ago (15m, 1h, 2d) or from a time (2024-01-02T15:04)")
+	fs.StringVar(&opts.Filter, "filter", "", "CloudWatch filter pattern, e.g. ERROR or '{ $.level = \"error\" }'")
+	fs.StringVar(&opts.Task, "task", "", "Only show the logs of this ECS task (ID or ARN)")
+	if err := fs.Parse(args); err != nil {
+		return opts, "", err
+	}
+	positional = append(positional, fs.Args()...)
+
+	if len(positional) < 2 {
+		return opts, "", fmt.Errorf("environment and service are required")
+	}
+	for _, arg := range positional[1:] {
+		for _, service := range strings.Split(arg, ",") {
+			if service = strings.TrimSpace(service); service != "" {
+				opts.Services = append(opts.Services, service)
+			}
+		}
+	}
+	return opts, positional[0], nil
+}
+
+func runLogs(envName string, opts LogsOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	now := time.Now()
+	since, err := parseLogsSince(opts.Since, now)
+	if err != nil {
+		return err
+	}
+
+	env, err := loadEnv(envName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := cloudwatchlogs.NewFromConfig(cfg)
+
+	sources, err := resolveLogSources(ctx, client, env, opts.Services, opts.Task)
+	if err != nil {
+		return err
+	}
+	printEvent := newLogPrinter(opts.Services)
+
+	events, truncated, err := fetchLogEvents(ctx, client, sources, since, now, opts.Filter)
+	if err != nil {
+		return err
+	}
+	cursor := newLogCursor(since)
+	cursor.Print(events, printEvent)
+	if truncated {
+		fmt.Fprintf(os.Stderr, "Stopped after %d events per service, narrow it down with --since or --filter\n", logsMaxEvents)
+	}
+
+	if !opts.Follow {
+		return nil
+	}
+	return followLogs(ctx, client, sources, cursor, opts.Filter, printEvent, func(notice string) {
+		fmt.Fprintln(os.Stderr, notice)
+	})
+}
+
+// newLogPrinter prints events with a timestamp, and with a colored service prefix
+// when several services are interleaved
+func newLogPrinter(services []string) func(serviceLogEvent) {
+	width := 0
+	prefixes := map[string]string{}
+	for _, service := range services {
+		width = max(width, len(service))
+	}
+	for i, service := range services {
+		style := lipgloss.NewStyle().Foreground(logPrefixColors[i%len(logPrefixColors)])
+		prefixes[service] = style.Render(fmt.Sprintf("%-*s |", width, service)) + " "
+	}
+
+	return func(event serviceLogEvent) {
+		timestamp := time.UnixMilli(event.Timestamp).Local().Format("2006-01-02 15:04:05")
+		prefix := ""
+		if len(services) > 1 {
+			prefix = prefixes[event.Service]
+		}
+		fmt.Printf("%s %s%s\n", timestamp, prefix, event.Message)
+	}
+}
+
+// handleLogsQueryCommand runs a Logs Insights query, `logs query <env> "<query>" [flags]`
+func handleLogsQueryCommand(args []string) {
+	var positional []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("logs query", flag.ExitOnError)
+	services := fs.String("services", "", "Comma-separated services (default: all services and tasks of the environment)")
+	since := fs.String("since", "1h", "Query events from this long ago (15m, 1h, 2d) or from a time (2024-01-02T15:04)")
+	limit := fs.Int("limit", 0, "Maximum number of rows (default: the query's limit)")
+	saved := fs.String("saved", "", "Run a saved query")
+	save := fs.String("save", "", "Save the query under this name after it ran")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+	positional = append(positional, fs.Args()...)
+
+	if len(positional) < 1 || (len(positional) < 2 && *saved == "") {
+		fmt.Println("Usage: logs query <env> \"<insights query>\" [--services a,b] [--since 1h] [--limit n] [--save name] [--json]")
+		fmt.Println("       logs query <env> --saved <name>")
+		os.Exit(1)
+	}
+
+	query := SavedLogQuery{Name: *save}
+	if *saved != "" {
+		found, err := findSavedLogQuery(*saved)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		query = *found
+	}
+	if len(positional) > 1 {
+		query.Query = strings.Join(positional[1:], " ")
+	}
+	if *services != "" {
+		query.Services = strings.Split(*services, ",")
+	}
+
+	if err := runLogsQuery(positional[0], query, *since, int32(*limit), *asJSON); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *save != "" {
+		query.Name = *save
+		if err := saveLogQuery(query); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !*asJSON {
+			fmt.Printf("✓ Saved as %q in %s\n", *save, SavedLogQueriesFile)
+		}
+	}
+}
+
+func runLogsQuery(envName string, query SavedLogQuery, since string, limit int32, asJSON bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	now := time.Now()
+	start, err := parseLogsSince(since, now)
+	if err != nil {
+		return err
+	}
+	env, err := loadEnv(envName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	if !asJSON {
+		fmt.Fprintln(os.Stderr, "⟳ Running query...")
+	}
+	result, err := runInsightsQuery(ctx, cloudwatchlogs.NewFromConfig(cfg), env, InsightsQuery{
+		Query:    query.Query,
+		Services: query.Services,
+		Start:    start,
+		End:      now,
+		Limit:    limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	printInsightsResult(result)
+	return nil
+}
+
+// printInsightsResult prints the rows as a table, long values are shortened to keep rows on one line
+func printInsightsResult(result *InsightsResult) {
+	if len(result.Rows) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(result.Columns, "\t"))
+		for _, row := range result.Rows {
+			cells := make([]string, len(row))
+			for i, value := range row {
+				value = strings.Join(strings.Fields(value), " ")
+				if len(value) > 160 {
+					value = value[:157] + "..."
+				}
+				cells[i] = value
+			}
+			fmt.Fprintln(w, strings.Join(cells, "\t"))
+		}
+		w.Flush()
+	}
+	fmt.Printf("\n%d row(s), %.0f record(s) matched, %.0f scanned (%.1f MB) in %d log group(s)\n",
+		len(result.Rows), result.RecordsMatched, result.RecordsScanned, result.BytesScanned/1024/1024, len(result.LogGroups))
+}
+
+// handleSavedLogQueriesCommand lists or deletes saved queries
+func handleSavedLogQueriesCommand(args []string) {
+	if len(args) >= 2 && args[0] == "delete" {
+		if err := deleteLogQuery(args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Deleted %q\n", args[1])
+		return
+	}
+
+	queries, err := loadSavedLogQueries()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(queries) == 0 {
+		fmt.Println("No saved queries. Save one with: meroku logs query <env> \"<query>\" --save <name>")
+		return
+	}
+	for _, q := range queries {
+		services := "all services"
+		if len(q.Services) > 0 {
+			services = strings.Join(q.Services, ", ")
+		}
+		fmt.Printf("%s (%s)\n", q.Name, services)
+		if q.Description != "" {
+			fmt.Printf("  %s\n", q.Description)
+		}
+		fmt.Printf("  %s\n", q.Query)
+	}
+}
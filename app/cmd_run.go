@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+// envVarsFlag collects repeated --env KEY=VALUE flags
+type envVarsFlag map[string]string
+
+func (f envVarsFlag) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f envVarsFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("use KEY=VALUE")
+	}
+	f[name] = v
+	return nil
+}
+
+// handleRunCommand handles `run <env> <service> [--env KEY=VALUE]... -- <command>`
+func handleRunCommand(args []string) {
+	req, envName, err := parseRunArgs(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Usage: run <env> <service> [--env KEY=VALUE]... -- <command> [args...]")
+		fmt.Println("  Runs the command in a one-off Fargate task from the latest task definition of a service,")
+		fmt.Println("  scheduled or event task, prints its logs and exits with the command's exit code.")
+		fmt.Println("  Example: run dev backend -- rails db:migrate")
+		os.Exit(1)
+	}
+
+	exitCode, err := runOneOffCommand(envName, req)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// parseRunArgs splits `<env> <service> [flags] -- <command>`, the command keeps its own flags
+func parseRunArgs(args []string) (OneOffTaskRequest, string, error) {
+	req := OneOffTaskRequest{Environment: map[string]string{}}
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, req.Command = args[:i], args[i+1:]
+	}
+	if len(args) < 2 || strings.HasPrefix(args[0], "-") || strings.HasPrefix(args[1], "-") {
+		return req, "", fmt.Errorf("environment and service are required")
+	}
+	envName := args[0]
+	req.Service = args[1]
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Var(envVarsFlag(req.Environment), "env", "Environment variable for the command, KEY=VALUE (repeatable)")
+	if err := fs.Parse(args[2:]); err != nil {
+		return req, "", err
+	}
+	if fs.NArg() > 0 {
+		return req, "", fmt.Errorf("unexpected %q, put the command after --", fs.Arg(0))
+	}
+	return req, envName, nil
+}
+
+// runOneOffCommand starts the task, follows it and returns the command's exit code.
+// Ctrl+C stops the task.
+func runOneOffCommand(envName string, req OneOffTaskRequest) (int, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env, err := loadEnv(envName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	ecsClient := ecs.NewFromConfig(cfg)
+
+	started := time.Now()
+	task, err := startOneOffTask(ctx, ecsClient, ec2.NewFromConfig(cfg), env, req)
+	if err != nil {
+		return 0, err
+	}
+	command := "the default command"
+	if len(req.Command) > 0 {
+		command = strings.Join(req.Command, " ")
+	}
+	fmt.Fprintf(os.Stderr, "🚀 Started task %s (%s) running %s\n", task.TaskID, ecsTaskID(task.TaskDefinitionArn), command)
+
+	result, err := followOneOffTask(ctx, ecsClient, cloudwatchlogs.NewFromConfig(cfg), task, started,
+		newLogPrinter([]string{req.Service}),
+		func(status string) { fmt.Fprintf(os.Stderr, "⟳ Task %s\n", strings.ToLower(status)) })
+	if err != nil {
+		if ctx.Err() != nil {
+			if err := stopOneOffTask(context.Background(), ecsClient, task); err != nil {
+				return 0, fmt.Errorf("interrupted, failed to stop task %s: %w", task.TaskID, err)
+			}
+			fmt.Fprintf(os.Stderr, "✗ Interrupted, stopped task %s\n", task.TaskID)
+			return 130, nil
+		}
+		return 0, err
+	}
+
+	if result.ExitCode == 0 {
+		fmt.Fprintf(os.Stderr, "✓ Task %s exited with 0 after %s\n", task.TaskID, time.Since(started).Round(time.Second))
+	} else {
+		fmt.Fprintf(os.Stderr, "✗ Task %s exited with %d after %s\n", task.TaskID, result.ExitCode, time.Since(started).Round(time.Second))
+	}
+	return result.ExitCode, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// handleSiteCommand handles static site subcommands
+func handleSiteCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Static site commands:")
+		fmt.Println("  site publish <env> <site> <dir> - Upload a built site to S3 and invalidate CloudFront")
+		return
+	}
+
+	switch args[0] {
+	case "publish":
+		if len(args) < 4 {
+			fmt.Println("Usage: site publish <env> <site> <dir>")
+			os.Exit(1)
+		}
+		if err := runSitePublish(args[1], args[2], args[3]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown site command: %s\n", args[0])
+		fmt.Println("Available commands: publish")
+		os.Exit(1)
+	}
+}
+
+// runSitePublish syncs dir to the site bucket (uploading all files, removing stale
+// objects) and invalidates the CloudFront distribution
+func runSitePublish(envName, siteName, dir string) error {
+	env, err := loadEnv(envName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+
+	var site *StaticSite
+	for i := range env.StaticSites {
+		if env.StaticSites[i].Name == siteName {
+			site = &env.StaticSites[i]
+			break
+		}
+	}
+	if site == nil {
+		return fmt.Errorf("static site '%s' not found in %s.yaml", siteName, envName)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	siteID := staticSiteID(env.Project, site.Name, env.Env)
+	s3Client := s3.NewFromConfig(cfg)
+
+	fmt.Printf("📦 Publishing %s to s3://%s\n", dir, siteID)
+
+	uploaded := map[string]bool{}
+	err = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		contentType, cacheControl := siteObjectHeaders(key, site.indexDocument())
+		_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:       aws.String(siteID),
+			Key:          aws.String(key),
+			Body:         file,
+			ContentType:  aws.String(contentType),
+			CacheControl: aws.String(cacheControl),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		uploaded[key] = true
+		fmt.Printf("  ↑ %s\n", key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !uploaded[site.indexDocument()] {
+		fmt.Printf("  ⚠️  %s not found in %s, the site root will return an error\n", site.indexDocument(), dir)
+	}
+
+	removed, err := deleteStaleSiteObjects(ctx, s3Client, siteID, uploaded)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Uploaded %d file(s), removed %d stale object(s)\n", len(uploaded), removed)
+
+	cfClient := cloudfront.NewFromConfig(cfg)
+	distributionID, err := findSiteDistribution(ctx, cfClient, siteID)
+	if err != nil {
+		return err
+	}
+
+	_, err = cfClient.CreateInvalidation(ctx, &cloudfront.CreateInvalidationInput{
+		DistributionId: aws.String(distributionID),
+		InvalidationBatch: &cftypes.InvalidationBatch{
+			CallerReference: aws.String(fmt.Sprintf("meroku-%d", time.Now().UnixNano())),
+			Paths: &cftypes.Paths{
+				Quantity: aws.Int32(1),
+				Items:    []string{"/*"},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to invalidate distribution %s: %w", distributionID, err)
+	}
+
+	fmt.Printf("✓ Invalidated CloudFront distribution %s\n", distributionID)
+	return nil
+}
+
+func (s StaticSite) indexDocument() string {
+	if s.IndexDocument != "" {
+		return s.IndexDocument
+	}
+	return "index.html"
+}
+
+// siteObjectHeaders returns Content-Type and Cache-Control for an uploaded file.
+// HTML must always be revalidated so new deploys are picked up; other assets
+// are usually content-hashed by the frontend build and can be cached longer.
+func siteObjectHeaders(key, indexDocument string) (string, string) {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	cacheControl := "public, max-age=86400"
+	if strings.HasSuffix(key, ".html") || key == indexDocument {
+		cacheControl = "no-cache"
+	}
+
+	return contentType, cacheControl
+}
+
+// deleteStaleSiteObjects removes objects that are not part of the current upload
+func deleteStaleSiteObjects(ctx context.Context, client *s3.Client, bucket string, keep map[string]bool) (int, error) {
+	var stale []s3types.ObjectIdentifier
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list bucket %s: %w", bucket, err)
+		}
+		for _, obj := range page.Contents {
+			if !keep[aws.ToString(obj.Key)] {
+				stale = append(stale, s3types.ObjectIdentifier{Key: obj.Key})
+			}
+		}
+	}
+
+	// DeleteObjects accepts at most 1000 keys per request
+	for start := 0; start < len(stale); start += 1000 {
+		end := min(start+1000, len(stale))
+		_, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3types.Delete{Objects: stale[start:end], Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete stale objects: %w", err)
+		}
+	}
+
+	return len(stale), nil
+}
+
+// findSiteDistribution finds the distribution created by modules/static_site by its comment
+func findSiteDistribution(ctx context.Context, client *cloudfront.Client, siteID string) (string, error) {
+	paginator := cloudfront.NewListDistributionsPaginator(client, &cloudfront.ListDistributionsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list CloudFront distributions: %w", err)
+		}
+		if page.DistributionList == nil {
+			continue
+		}
+		for _, dist := range page.DistributionList.Items {
+			if aws.ToString(dist.Comment) == siteID {
+				return aws.ToString(dist.Id), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("CloudFront distribution for %s not found. Run terraform apply first", siteID)
+}
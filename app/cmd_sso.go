@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// handleSSOCommand handles AWS SSO subcommands
+func handleSSOCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("SSO commands:")
+		fmt.Println("  sso bootstrap [--session <name>] [--role <permission-set>] [--prefix <p>] [--region <r>] [--dry-run] [--yes]")
+		fmt.Println("      Create a profile for every account reachable from an sso-session and map each env's account_id to it")
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "bootstrap":
+		fs := flag.NewFlagSet("sso bootstrap", flag.ExitOnError)
+		opts := SSOBootstrapOptions{}
+		fs.StringVar(&opts.Session, "session", "", "sso-session name in ~/.aws/config (default: the only session)")
+		fs.StringVar(&opts.Role, "role", "", "Only create profiles for this permission set, e.g. AdministratorAccess")
+		fs.StringVar(&opts.Prefix, "prefix", "", "Prefix for new profile names, e.g. the project name")
+		fs.StringVar(&opts.Region, "region", "", "Default region for new profiles (default: the SSO region)")
+		fs.BoolVar(&opts.DryRun, "dry-run", false, "Show the profiles and environment changes without writing them")
+		fs.BoolVar(&opts.Yes, "yes", false, "Write without asking for confirmation")
+		fs.Parse(args[1:])
+		err = runSSOBootstrap(opts)
+	default:
+		fmt.Printf("Unknown sso command: %s\n", args[0])
+		fmt.Println("Available commands: bootstrap")
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+// handleTaskCommand handles the scheduled task commands
+func handleTaskCommand(args []string) {
+	if len(args) < 3 || strings.HasPrefix(args[1], "-") || strings.HasPrefix(args[2], "-") {
+		printTaskUsage()
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "run":
+		fs := flag.NewFlagSet("task run", flag.ExitOnError)
+		detach := fs.Bool("detach", false, "Start the task and return without following its logs")
+		fs.Parse(args[3:])
+		err = runScheduledTaskCommand(args[1], args[2], *detach)
+	case "history":
+		fs := flag.NewFlagSet("task history", flag.ExitOnError)
+		limit := fs.Int("limit", scheduledTaskHistoryLimit, "Number of runs to show")
+		asJSON := fs.Bool("json", false, "Print the history as JSON")
+		fs.Parse(args[3:])
+		err = runScheduledTaskHistory(args[1], args[2], *limit, *asJSON)
+	default:
+		printTaskUsage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printTaskUsage() {
+	fmt.Println("Usage: task run <env> <name> [--detach]")
+	fmt.Println("       task history <env> <name> [--limit 20] [--json]")
+	fmt.Println("  Runs a scheduled task now with its configured command, or lists its recent runs.")
+}
+
+// runScheduledTaskCommand starts a scheduled task and, unless detached, follows it like `meroku run`
+func runScheduledTaskCommand(envName, name string, detach bool) error {
+	env, err := loadEnv(envName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+	if err := checkScheduledTask(env, name); err != nil {
+		return err
+	}
+
+	if !detach {
+		exitCode, err := runOneOffCommand(envName, OneOffTaskRequest{Service: name})
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	}
+
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	task, err := startScheduledTask(ctx, ecs.NewFromConfig(cfg), ec2.NewFromConfig(cfg), env, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🚀 Started task %s (%s)\n", task.TaskID, ecsTaskID(task.TaskDefinitionArn))
+	fmt.Printf("   Logs: meroku logs %s %s --task %s --follow\n", envName, name, task.TaskID)
+	return nil
+}
+
+func runScheduledTaskHistory(envName, name string, limit int, asJSON bool) error {
+	ctx := context.Background()
+	env, err := loadEnv(envName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment %s: %w", envName, err)
+	}
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	history, err := scheduledTaskHistory(ctx, ecs.NewFromConfig(cfg), cloudwatchlogs.NewFromConfig(cfg), env, name, cfg.Region, limit)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(history)
+	}
+	if len(history) == 0 {
+		fmt.Printf("No runs of %s found\n", name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tDURATION\tSTATUS\tEXIT\tTRIGGER\tTASK")
+	for _, run := range history {
+		started, duration, exitCode := "-", "-", "-"
+		if run.StartedAt != nil {
+			started = run.StartedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if run.DurationSeconds > 0 {
+			duration = (time.Duration(run.DurationSeconds) * time.Second).String()
+			if run.Approximate {
+				duration = "~" + duration
+			}
+		}
+		if run.ExitCode != nil {
+			exitCode = fmt.Sprint(*run.ExitCode)
+		}
+		status := strings.ToLower(run.Status)
+		if status == "" {
+			status = "-"
+		}
+		trigger := run.Trigger
+		if trigger == "" {
+			trigger = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", started, duration, status, exitCode, trigger, run.TaskID)
+	}
+	w.Flush()
+	fmt.Println("\nExit codes are only known for runs of the last hour, older runs are found by their logs.")
+	fmt.Println("Durations marked ~ come from the log stream's last event time, which CloudWatch updates with a delay.")
+	return nil
+}
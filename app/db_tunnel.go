@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// dbTunnelTarget is a running backend container that can forward traffic to the database
+type dbTunnelTarget struct {
+	ClusterName string
+	TaskID      string
+	RuntimeID   string
+}
+
+// ssmTarget returns the Session Manager target for an ECS container
+func (t dbTunnelTarget) ssmTarget() string {
+	return fmt.Sprintf("ecs:%s_%s_%s", t.ClusterName, t.TaskID, t.RuntimeID)
+}
+
+// runDBTunnel forwards localPort to the environment's database through a running
+// backend task (ECS Exec / SSM port forwarding) until interrupted
+func runDBTunnel(envName string, localPort int) error {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return fmt.Errorf("AWS CLI not found in PATH")
+	}
+	if _, err := exec.LookPath("session-manager-plugin"); err != nil {
+		return fmt.Errorf("session-manager-plugin not found in PATH. Install it from https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html")
+	}
+
+	ctx := context.Background()
+	env, rdsClient, err := loadDBEnv(ctx, envName)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadAWSConfigForEnv(ctx, env)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dbInfo, err := lookupDatabaseEndpoint(ctx, rdsClient, env.Project, env.Env, env.Postgres.Aurora)
+	if err != nil {
+		return err
+	}
+	if dbInfo.Endpoint == "" {
+		return fmt.Errorf("database has no endpoint yet (status: %s)", dbInfo.Status)
+	}
+	remotePort := dbInfo.Port
+	if remotePort == 0 {
+		remotePort = 5432
+	}
+
+	target, err := findDBTunnelTarget(ctx, ecs.NewFromConfig(cfg), env)
+	if err != nil {
+		return err
+	}
+
+	password, err := fetchDBPassword(ctx, ssm.NewFromConfig(cfg), env)
+	if err != nil {
+		fmt.Printf("⚠️  Could not read the database password from SSM: %v\n", err)
+	}
+
+	parameters, _ := json.Marshal(map[string][]string{
+		"host":            {dbInfo.Endpoint},
+		"portNumber":      {fmt.Sprintf("%d", remotePort)},
+		"localPortNumber": {fmt.Sprintf("%d", localPort)},
+	})
+
+	fmt.Printf("🔌 Tunneling localhost:%d → %s:%d via task %s\n", localPort, dbInfo.Endpoint, remotePort, target.TaskID)
+	fmt.Println()
+	fmt.Printf("  Connection string:\n  %s\n", dbConnectionString(env, password, localPort))
+	fmt.Println()
+	fmt.Println("  Press Ctrl+C to close the tunnel.")
+	fmt.Println()
+
+	cmd := exec.Command("aws", "ssm", "start-session",
+		"--target", target.ssmTarget(),
+		"--document-name", "AWS-StartPortForwardingSessionToRemoteHost",
+		"--parameters", string(parameters),
+		"--region", cfg.Region,
+	)
+	cmd.Env = append(os.Environ(), "AWS_PAGER=")
+	if profile := awsProfileForEnv(env); profile != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("AWS_PROFILE=%s", profile))
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Ctrl+C is delivered to the whole process group, let the session plugin
+	// handle it and exit on its own instead of killing meroku first
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && !exitErr.Exited() {
+			// Terminated by the interrupt
+			return nil
+		}
+		return fmt.Errorf("port forwarding session failed: %w", err)
+	}
+	return nil
+}
+
+// findDBTunnelTarget picks a running backend task with ECS Exec enabled
+func findDBTunnelTarget(ctx context.Context, client *ecs.Client, env Env) (*dbTunnelTarget, error) {
+	clusterName := fmt.Sprintf("%s_cluster_%s", env.Project, env.Env)
+	serviceName := fmt.Sprintf("%s_service_%s", env.Project, env.Env)
+
+	tasks, err := client.ListTasks(ctx, &ecs.ListTasksInput{
+		Cluster:     aws.String(clusterName),
+		ServiceName: aws.String(serviceName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backend tasks: %w", err)
+	}
+	if len(tasks.TaskArns) == 0 {
+		return nil, fmt.Errorf("no running backend tasks in %s, the tunnel needs one to forward through", clusterName)
+	}
+
+	described, err := client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+		Cluster: aws.String(clusterName),
+		Tasks:   tasks.TaskArns,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe backend tasks: %w", err)
+	}
+
+	execDisabled := false
+	for _, task := range described.Tasks {
+		if aws.ToString(task.LastStatus) != "RUNNING" {
+			continue
+		}
+		if !task.EnableExecuteCommand {
+			execDisabled = true
+			continue
+		}
+		for _, container := range task.Containers {
+			if aws.ToString(container.Name) != serviceName || container.RuntimeId == nil {
+				continue
+			}
+			arn := aws.ToString(task.TaskArn)
+			return &dbTunnelTarget{
+				ClusterName: clusterName,
+				TaskID:      arn[strings.LastIndex(arn, "/")+1:],
+				RuntimeID:   aws.ToString(container.RuntimeId),
+			}, nil
+		}
+	}
+
+	if execDisabled {
+		return nil, fmt.Errorf("execute command is not enabled for the backend tasks. Set workload.backend_remote_access: true and redeploy")
+	}
+	return nil, fmt.Errorf("no running backend container found in %s", clusterName)
+}
+
+// fetchDBPassword reads the master password created by modules/postgres
+func fetchDBPassword(ctx context.Context, client *ssm.Client, env Env) (string, error) {
+	out, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(fmt.Sprintf("/%s/%s/postgres_password", env.Env, env.Project)),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+// dbConnectionString builds a postgres URL for the tunneled database, using the
+// same username and database name defaults as modules/postgres
+func dbConnectionString(env Env, password string, localPort int) string {
+	username := env.Postgres.Username
+	if username == "" {
+		username = "postgres"
+	}
+	dbName := env.Postgres.Dbname
+	if dbName == "" {
+		dbName = env.Project
+	}
+
+	user := url.User(username)
+	if password != "" {
+		user = url.UserPassword(username, password)
+	}
+
+	u := url.URL{
+		Scheme: "postgresql",
+		User:   user,
+		Host:   fmt.Sprintf("localhost:%d", localPort),
+		Path:   "/" + dbName,
+	}
+	return u.String()
+}
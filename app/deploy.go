@@ -145,12 +145,19 @@ func applyTemplate(env string) {
 		fmt.Printf("error loading environment: %v", err)
 		os.Exit(1)
 	}
-	envMap["modules"] = "../../infrastructure/modules"
-	envMap["custom_modules"] = "../../custom"
+	e, err := loadEnv(env)
 	if err != nil {
 		fmt.Printf("error loading environment: %v", err)
 		os.Exit(1)
 	}
+	for _, validate := range []func(*Env) error{ValidateNetworkConfig, ValidateWAFConfig, ValidateStaticSites, ValidateDNSRecords, ValidatePostgresBackup, ValidateLogFields} {
+		if err := validate(&e); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	}
+	envMap["modules"] = "../../infrastructure/modules"
+	envMap["custom_modules"] = "../../custom"
 	// Create a new template and parse the content
 	tmpl, err := raymond.Parse(string(templateContent))
 	if err != nil {
@@ -22,13 +22,12 @@ import (
 )
 
 
-// createHostedZone creates a Route53 hosted zone and returns zone ID and nameservers
-func createHostedZone(profile, domain string) (string, []string, error) {
+// createHostedZone creates a Route53 hosted zone and returns zone ID and nameservers,
+// with dnssec the zone is also signed
+func createHostedZone(profile, domain string, dnssec bool) (string, []string, error) {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -66,6 +65,11 @@ func createHostedZone(profile, domain string) (string, []string, error) {
 					if nsErr != nil {
 						return "", nil, fmt.Errorf("failed to get nameservers: %w", nsErr)
 					}
+					if dnssec {
+						if err := enableHostedZoneDNSSEC(ctx, cfg, zoneID); err != nil {
+							return "", nil, err
+						}
+					}
 					return zoneID, nameservers, nil
 				}
 			}
@@ -84,6 +88,12 @@ func createHostedZone(profile, domain string) (string, []string, error) {
 		return "", nil, fmt.Errorf("failed to get nameservers: %w", err)
 	}
 
+	if dnssec {
+		if err := enableHostedZoneDNSSEC(ctx, cfg, zoneID); err != nil {
+			return "", nil, err
+		}
+	}
+
 	return zoneID, nameservers, nil
 }
 
@@ -116,52 +126,69 @@ func getZoneNameservers(ctx context.Context, client *route53.Client, zoneID stri
 	return nameservers, nil
 }
 
-// checkDNSPropagation checks if DNS has propagated to various servers
+// defaultDNSResolvers are used for propagation checks when dns.yaml doesn't list any
+var defaultDNSResolvers = []DNSResolver{
+	{Name: "Google", Address: "8.8.8.8"},
+	{Name: "Cloudflare", Address: "1.1.1.1"},
+	{Name: "Quad9", Address: "9.9.9.9"},
+	{Name: "OpenDNS", Address: "208.67.222.222"},
+}
+
+// propagationResolvers returns the resolvers configured in dns.yaml or the public defaults
+func propagationResolvers() []DNSResolver {
+	if config, err := loadDNSConfig(); err == nil && config != nil && len(config.Resolvers) > 0 {
+		return config.Resolvers
+	}
+	return defaultDNSResolvers
+}
+
+// resolverAddress adds the default DNS port to a host
+func resolverAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), "53")
+}
+
+// checkDNSPropagation checks if DNS has propagated to the configured resolvers
 func checkDNSPropagation(domain string, expectedNS []string) (map[string]bool, error) {
-	servers := map[string]string{
-		"8.8.8.8":        "Google",
-		"1.1.1.1":        "Cloudflare",
-		"9.9.9.9":        "Quad9",
-		"208.67.222.222": "OpenDNS",
+	return checkDNSPropagationWith(propagationResolvers(), domain, expectedNS)
+}
+
+// checkDNSPropagationWith asks each resolver for the NS records of the domain and reports,
+// by resolver address, whether they include any of the expected nameservers
+func checkDNSPropagationWith(resolvers []DNSResolver, domain string, expectedNS []string) (map[string]bool, error) {
+	if len(resolvers) == 0 {
+		return nil, fmt.Errorf("no DNS resolvers configured")
 	}
 
+	c := &dns.Client{Timeout: 5 * time.Second}
 	results := make(map[string]bool)
-	
-	for server := range servers {
-		r := &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{
-					Timeout: time.Second * 5,
-				}
-				return d.DialContext(ctx, network, server+":53")
-			},
-		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	for _, resolver := range resolvers {
+		m := new(dns.Msg)
+		m.SetQuestion(dns.Fqdn(domain), dns.TypeNS)
 
-		ns, err := r.LookupNS(ctx, domain)
-		if err != nil {
-			results[server] = false
+		r, _, err := c.Exchange(m, resolverAddress(resolver.Address))
+		if err != nil || r.Rcode != dns.RcodeSuccess {
+			results[resolver.Address] = false
 			continue
 		}
 
 		// Check if returned nameservers match expected
 		matched := false
-		for _, n := range ns {
-			nsHost := strings.TrimSuffix(n.Host, ".")
+		for _, rr := range r.Answer {
+			ns, ok := rr.(*dns.NS)
+			if !ok {
+				continue
+			}
 			for _, expected := range expectedNS {
-				if strings.EqualFold(nsHost, expected) {
+				if strings.EqualFold(strings.TrimSuffix(ns.Ns, "."), strings.TrimSuffix(expected, ".")) {
 					matched = true
-					break
 				}
 			}
-			if matched {
-				break
-			}
 		}
-		results[server] = matched
+		results[resolver.Address] = matched
 	}
 
 	return results, nil
@@ -171,9 +198,7 @@ func checkDNSPropagation(domain string, expectedNS []string) (map[string]bool, e
 func createDNSDelegationRole(profile string, trustedAccounts []string) (string, error) {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
 	if err != nil {
 		return "", fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -220,30 +245,33 @@ func createDNSDelegationRole(profile string, trustedAccounts []string) (string,
 		Description:              aws.String("Role for cross-account DNS delegation"),
 	}
 
+	var roleArn string
 	roleResp, err := iamClient.CreateRole(ctx, createRoleInput)
 	if err != nil {
 		// Check if role already exists
-		if strings.Contains(err.Error(), "EntityAlreadyExists") {
-			getRoleResp, getRoleErr := iamClient.GetRole(ctx, &iam.GetRoleInput{
-				RoleName: aws.String(roleName),
-			})
-			if getRoleErr != nil {
-				return "", fmt.Errorf("role exists but failed to retrieve: %w", getRoleErr)
-			}
-			// Update trust policy for existing role
-			_, updateErr := iamClient.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
-				RoleName:       aws.String(roleName),
-				PolicyDocument: aws.String(string(trustPolicyJSON)),
-			})
-			if updateErr != nil {
-				return "", fmt.Errorf("failed to update role trust policy: %w", updateErr)
-			}
-			return *getRoleResp.Role.Arn, nil
+		if !strings.Contains(err.Error(), "EntityAlreadyExists") {
+			return "", fmt.Errorf("failed to create role: %w", err)
 		}
-		return "", fmt.Errorf("failed to create role: %w", err)
+		getRoleResp, getRoleErr := iamClient.GetRole(ctx, &iam.GetRoleInput{
+			RoleName: aws.String(roleName),
+		})
+		if getRoleErr != nil {
+			return "", fmt.Errorf("role exists but failed to retrieve: %w", getRoleErr)
+		}
+		// Update trust policy for existing role
+		_, updateErr := iamClient.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+			RoleName:       aws.String(roleName),
+			PolicyDocument: aws.String(string(trustPolicyJSON)),
+		})
+		if updateErr != nil {
+			return "", fmt.Errorf("failed to update role trust policy: %w", updateErr)
+		}
+		roleArn = *getRoleResp.Role.Arn
+	} else {
+		roleArn = *roleResp.Role.Arn
 	}
 
-	// Attach Route53 policy
+	// Attach Route53 policy, also to an existing role in case an earlier run stopped before this
 	policyArn := "arn:aws:iam::aws:policy/AmazonRoute53FullAccess"
 	_, err = iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
 		RoleName:  aws.String(roleName),
@@ -253,16 +281,14 @@ func createDNSDelegationRole(profile string, trustedAccounts []string) (string,
 		return "", fmt.Errorf("failed to attach policy: %w", err)
 	}
 
-	return *roleResp.Role.Arn, nil
+	return roleArn, nil
 }
 
 // createNSRecordDelegation creates NS records in root zone for subdomain delegation
 func createNSRecordDelegation(rootProfile, childProfile, rootZoneID, subdomain string, nsRecords []string) error {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(rootProfile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, rootProfile)
 	if err != nil {
 		return fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -303,11 +329,12 @@ func createNSRecordDelegation(rootProfile, childProfile, rootZoneID, subdomain s
 	return nil
 }
 
-// assumeRoleAndCreateNSRecords assumes role and creates NS records in root account
-func assumeRoleAndCreateNSRecords(roleArn, rootZoneID, subdomain string, nsRecords []string) error {
+// assumeRoleAndCreateNSRecords assumes role and creates NS records in root account,
+// sourceProfile holds the credentials that assume the role (default credentials if empty)
+func assumeRoleAndCreateNSRecords(sourceProfile, roleArn, rootZoneID, subdomain string, nsRecords []string) error {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := loadAWSConfigForProfile(ctx, sourceProfile)
 	if err != nil {
 		return fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -1056,9 +1083,7 @@ func getAllAWSAccounts() ([]AccountInfo, error) {
 	profiles := []string{"default", "prod", "dev", "staging"}
 	
 	for _, profile := range profiles {
-		profileCfg, err := config.LoadDefaultConfig(ctx,
-			config.WithSharedConfigProfile(profile),
-		)
+		profileCfg, err := loadAWSConfigForProfile(ctx, profile)
 		if err != nil {
 			continue // Skip profiles that can't be loaded
 		}
@@ -1083,9 +1108,7 @@ func getAllAWSAccounts() ([]AccountInfo, error) {
 func deleteDNSZone(profile, zoneID string) error {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
 	if err != nil {
 		return fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -1146,9 +1169,7 @@ func deleteDNSZone(profile, zoneID string) error {
 func validateHostedZone(profile, zoneID string) (string, []string, error) {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -10,9 +10,14 @@ import (
 const DNSConfigFile = "dns.yaml"
 
 func loadDNSConfig() (*DNSConfig, error) {
+	return loadDNSConfigFile(DNSConfigFile)
+}
+
+// loadDNSConfigFile reads a DNS config, it returns nil if the file doesn't exist
+func loadDNSConfigFile(path string) (*DNSConfig, error) {
 	var config DNSConfig
 
-	data, err := os.ReadFile(DNSConfigFile)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/miekg/dns"
+)
+
+// dnssecKSKName is the name of the key signing key created in Route53
+const dnssecKSKName = "meroku_ksk"
+
+// DNSSECStatus describes the chain of trust between a zone and its parent
+type DNSSECStatus struct {
+	Zone      string
+	Signed    bool     // The zone serves DNSKEY records
+	Delegated bool     // The parent serves DS records
+	Valid     bool     // A DS record matches a key that signs the DNSKEY set
+	KeyTag    uint16   // Key tag of the matching key signing key
+	Problems  []string // Why the chain of trust is broken
+}
+
+// Summary returns a one-line description of the status
+func (s DNSSECStatus) Summary() string {
+	switch {
+	case s.Valid:
+		return fmt.Sprintf("signed, DS matches key %d", s.KeyTag)
+	case len(s.Problems) > 0:
+		return strings.Join(s.Problems, "; ")
+	case !s.Signed && !s.Delegated:
+		return "unsigned"
+	}
+	return "unknown"
+}
+
+// checkZoneDNSSEC finds the authoritative servers of the zone and its parent and checks the chain of trust
+func checkZoneDNSSEC(zone string) DNSSECStatus {
+	zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+	labels := strings.SplitN(zone, ".", 2)
+	if len(labels) < 2 {
+		return DNSSECStatus{Zone: zone, Problems: []string{fmt.Sprintf("%s has no parent zone", zone)}}
+	}
+
+	childNS, err := queryNameservers(zone)
+	if err != nil {
+		return DNSSECStatus{Zone: zone, Problems: []string{fmt.Sprintf("failed to find nameservers of %s: %v", zone, err)}}
+	}
+	parentNS, err := queryNameservers(labels[1])
+	if err != nil {
+		return DNSSECStatus{Zone: zone, Problems: []string{fmt.Sprintf("failed to find nameservers of %s: %v", labels[1], err)}}
+	}
+
+	return checkDNSSEC(zone, nameserverAddresses(parentNS), nameserverAddresses(childNS))
+}
+
+// nameserverAddresses turns nameserver host names into addresses to query
+func nameserverAddresses(nameservers []string) []string {
+	addresses := make([]string, 0, len(nameservers))
+	for _, ns := range nameservers {
+		addresses = append(addresses, resolverAddress(strings.TrimSuffix(ns, ".")))
+	}
+	return addresses
+}
+
+// checkDNSSEC checks the DS records served by the parent servers against the
+// DNSKEY records served by the zone's own servers
+func checkDNSSEC(zone string, parentServers, childServers []string) DNSSECStatus {
+	fqdn := dns.Fqdn(strings.ToLower(zone))
+	status := DNSSECStatus{Zone: strings.TrimSuffix(fqdn, ".")}
+
+	keysResp, err := queryDNSSECRecords(childServers, fqdn, dns.TypeDNSKEY)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("DNSKEY query failed: %v", err))
+		return status
+	}
+	dsResp, err := queryDNSSECRecords(parentServers, fqdn, dns.TypeDS)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("DS query failed: %v", err))
+		return status
+	}
+
+	var keys []*dns.DNSKEY
+	var keySet []dns.RR
+	var sigs []*dns.RRSIG
+	for _, rr := range keysResp.Answer {
+		switch r := rr.(type) {
+		case *dns.DNSKEY:
+			keys = append(keys, r)
+			keySet = append(keySet, r)
+		case *dns.RRSIG:
+			if r.TypeCovered == dns.TypeDNSKEY {
+				sigs = append(sigs, r)
+			}
+		}
+	}
+	var dsRecords []*dns.DS
+	for _, rr := range dsResp.Answer {
+		if ds, ok := rr.(*dns.DS); ok {
+			dsRecords = append(dsRecords, ds)
+		}
+	}
+	status.Signed = len(keys) > 0
+	status.Delegated = len(dsRecords) > 0
+
+	switch {
+	case !status.Signed && !status.Delegated:
+		return status
+	case !status.Delegated:
+		status.Problems = append(status.Problems, fmt.Sprintf("%s is signed but the parent has no DS record, add it at the registrar", status.Zone))
+		return status
+	case !status.Signed:
+		status.Problems = append(status.Problems, fmt.Sprintf("the parent has a DS record but %s serves no DNSKEY, validating resolvers will fail to resolve it", status.Zone))
+		return status
+	}
+
+	var matched []*dns.DNSKEY
+	for _, ds := range dsRecords {
+		for _, key := range keys {
+			if key.KeyTag() != ds.KeyTag || key.Algorithm != ds.Algorithm {
+				continue
+			}
+			if digest := key.ToDS(ds.DigestType); digest != nil && strings.EqualFold(digest.Digest, ds.Digest) {
+				matched = append(matched, key)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		status.Problems = append(status.Problems, fmt.Sprintf("no DS record at the parent matches a DNSKEY of %s (DS key tags %s, DNSKEY key tags %s)",
+			status.Zone, dsKeyTags(dsRecords), dnskeyKeyTags(keys)))
+		return status
+	}
+
+	now := time.Now()
+	for _, key := range matched {
+		for _, sig := range sigs {
+			if sig.KeyTag == key.KeyTag() && sig.ValidityPeriod(now) && sig.Verify(key, keySet) == nil {
+				status.Valid = true
+				status.KeyTag = key.KeyTag()
+				return status
+			}
+		}
+	}
+	status.Problems = append(status.Problems, fmt.Sprintf("the DNSKEY set of %s has no valid signature by key %d", status.Zone, matched[0].KeyTag()))
+	return status
+}
+
+// queryDNSSECRecords asks the servers in turn for records with the DNSSEC OK bit set,
+// an empty answer or NXDOMAIN means the records don't exist
+func queryDNSSECRecords(servers []string, name string, qtype uint16) (*dns.Msg, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no nameservers to query")
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.RecursionDesired = false
+	m.SetEdns0(4096, true)
+
+	var lastErr error
+	for _, server := range servers {
+		c := &dns.Client{Timeout: 5 * time.Second}
+		r, _, err := c.Exchange(m, server)
+		if err == nil && r.Truncated {
+			c.Net = "tcp"
+			r, _, err = c.Exchange(m, server)
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", server, err)
+			continue
+		}
+		if r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s: %s", server, dns.RcodeToString[r.Rcode])
+			continue
+		}
+		return r, nil
+	}
+	return nil, lastErr
+}
+
+func dsKeyTags(records []*dns.DS) string {
+	tags := make([]string, len(records))
+	for i, ds := range records {
+		tags[i] = fmt.Sprint(ds.KeyTag)
+	}
+	return strings.Join(tags, ", ")
+}
+
+func dnskeyKeyTags(keys []*dns.DNSKEY) string {
+	tags := make([]string, len(keys))
+	for i, key := range keys {
+		tags[i] = fmt.Sprint(key.KeyTag())
+	}
+	return strings.Join(tags, ", ")
+}
+
+// enableHostedZoneDNSSEC signs a hosted zone with a key signing key backed by a new KMS key,
+// it's safe to call again for a zone that is already signed
+func enableHostedZoneDNSSEC(ctx context.Context, cfg aws.Config, zoneID string) error {
+	client := route53.NewFromConfig(cfg)
+	zoneID = strings.TrimPrefix(zoneID, "/hostedzone/")
+
+	current, err := client.GetDNSSEC(ctx, &route53.GetDNSSECInput{HostedZoneId: aws.String(zoneID)})
+	if err != nil {
+		return fmt.Errorf("failed to get DNSSEC status: %w", err)
+	}
+	if current.Status != nil && aws.ToString(current.Status.ServeSignature) == "SIGNING" {
+		return nil
+	}
+
+	ksk := namedKeySigningKey(current.KeySigningKeys, dnssecKSKName)
+	switch {
+	case activeKeySigningKey(current.KeySigningKeys) != nil:
+		// Signing was disabled but a key is still active
+	case ksk != nil:
+		// A previous run left the key inactive, Route53 rejects a second key with the same name
+		_, err := client.ActivateKeySigningKey(ctx, &route53.ActivateKeySigningKeyInput{
+			HostedZoneId: aws.String(zoneID),
+			Name:         ksk.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to activate key signing key %s: %w", dnssecKSKName, err)
+		}
+	default:
+		keyArn, err := createDNSSECSigningKey(ctx, cfg, zoneID)
+		if err != nil {
+			return err
+		}
+		_, err = client.CreateKeySigningKey(ctx, &route53.CreateKeySigningKeyInput{
+			CallerReference:         aws.String(fmt.Sprintf("%s-%d", zoneID, time.Now().Unix())),
+			HostedZoneId:            aws.String(zoneID),
+			KeyManagementServiceArn: aws.String(keyArn),
+			Name:                    aws.String(dnssecKSKName),
+			Status:                  aws.String("ACTIVE"),
+		})
+		if err != nil {
+			// Don't leave an unused KMS key behind
+			kmsClient := kms.NewFromConfig(cfg, func(o *kms.Options) { o.Region = "us-east-1" })
+			kmsClient.ScheduleKeyDeletion(ctx, &kms.ScheduleKeyDeletionInput{KeyId: aws.String(keyArn), PendingWindowInDays: aws.Int32(7)})
+			return fmt.Errorf("failed to create key signing key: %w", err)
+		}
+	}
+
+	if _, err := client.EnableHostedZoneDNSSEC(ctx, &route53.EnableHostedZoneDNSSECInput{HostedZoneId: aws.String(zoneID)}); err != nil {
+		return fmt.Errorf("failed to enable DNSSEC signing: %w", err)
+	}
+	return nil
+}
+
+// createDNSSECSigningKey creates the KMS key for a key signing key,
+// Route53 requires an ECC_NIST_P256 key in us-east-1 that its DNSSEC service may use
+func createDNSSECSigningKey(ctx context.Context, cfg aws.Config, zoneID string) (string, error) {
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	accountID := aws.ToString(identity.Account)
+
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":       "EnableIAMUserPermissions",
+				"Effect":    "Allow",
+				"Principal": map[string]string{"AWS": fmt.Sprintf("arn:aws:iam::%s:root", accountID)},
+				"Action":    "kms:*",
+				"Resource":  "*",
+			},
+			{
+				"Sid":       "AllowRoute53DNSSECService",
+				"Effect":    "Allow",
+				"Principal": map[string]string{"Service": "dnssec-route53.amazonaws.com"},
+				"Action":    []string{"kms:DescribeKey", "kms:GetPublicKey", "kms:Sign"},
+				"Resource":  "*",
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{"aws:SourceAccount": accountID},
+				},
+			},
+			{
+				"Sid":       "AllowRoute53DNSSECToCreateGrant",
+				"Effect":    "Allow",
+				"Principal": map[string]string{"Service": "dnssec-route53.amazonaws.com"},
+				"Action":    "kms:CreateGrant",
+				"Resource":  "*",
+				"Condition": map[string]interface{}{
+					"Bool": map[string]bool{"kms:GrantIsForAWSResource": true},
+				},
+			},
+		},
+	}
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal key policy: %w", err)
+	}
+
+	kmsClient := kms.NewFromConfig(cfg, func(o *kms.Options) { o.Region = "us-east-1" })
+	resp, err := kmsClient.CreateKey(ctx, &kms.CreateKeyInput{
+		KeySpec:     kmstypes.KeySpecEccNistP256,
+		KeyUsage:    kmstypes.KeyUsageTypeSignVerify,
+		Policy:      aws.String(string(policyJSON)),
+		Description: aws.String(fmt.Sprintf("DNSSEC key signing key for hosted zone %s", zoneID)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create KMS key: %w", err)
+	}
+	return aws.ToString(resp.KeyMetadata.Arn), nil
+}
+
+// activeKeySigningKey returns the first active key signing key, if any
+func activeKeySigningKey(keys []types.KeySigningKey) *types.KeySigningKey {
+	for i := range keys {
+		if aws.ToString(keys[i].Status) == "ACTIVE" {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// namedKeySigningKey returns the zone's key signing key with the given name, in any status
+func namedKeySigningKey(keys []types.KeySigningKey, name string) *types.KeySigningKey {
+	for i := range keys {
+		if aws.ToString(keys[i].Name) == name {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// getZoneDSRecord returns the DS record to add at the registrar for a signed hosted zone
+func getZoneDSRecord(profile, zoneID string) (string, error) {
+	ctx := context.Background()
+
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	resp, err := route53.NewFromConfig(cfg).GetDNSSEC(ctx, &route53.GetDNSSECInput{
+		HostedZoneId: aws.String(strings.TrimPrefix(zoneID, "/hostedzone/")),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get DNSSEC status: %w", err)
+	}
+	key := activeKeySigningKey(resp.KeySigningKeys)
+	if key == nil {
+		return "", fmt.Errorf("hosted zone %s has no active key signing key", zoneID)
+	}
+	return aws.ToString(key.DSRecord), nil
+}
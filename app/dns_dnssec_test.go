@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/miekg/dns"
+)
+
+func TestResolverAddress(t *testing.T) {
+	for input, want := range map[string]string{
+		"8.8.8.8":          "8.8.8.8:53",
+		"10.0.0.2:5353":    "10.0.0.2:5353",
+		"2001:4860::8888":  "[2001:4860::8888]:53",
+		"[2001:db8::1]:53": "[2001:db8::1]:53",
+		"dns.corp.local":   "dns.corp.local:53",
+	} {
+		if got := resolverAddress(input); got != want {
+			t.Errorf("resolverAddress(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCheckDNSPropagationWith(t *testing.T) {
+	propagated := startStubDNSServer(t, `
+example.com. 300 IN NS ns-1.awsdns-01.org.
+example.com. 300 IN NS ns-2.awsdns-02.com.
+`)
+	stale := startStubDNSServer(t, `example.com. 300 IN NS ns1.old-registrar.net.`)
+
+	results, err := checkDNSPropagationWith([]DNSResolver{
+		{Name: "internal", Address: propagated},
+		{Name: "office", Address: stale},
+	}, "example.com", []string{"NS-2.awsdns-02.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[propagated] || results[stale] || len(results) != 2 {
+		t.Errorf("results = %v", results)
+	}
+
+	if _, err := checkDNSPropagationWith(nil, "example.com", nil); err == nil {
+		t.Error("expected an error without resolvers")
+	}
+}
+
+// signedZone returns a key signing key for example.com with its DS record and a DNSKEY signature
+func signedZone(t *testing.T) (*dns.DNSKEY, *dns.DS, *dns.RRSIG, crypto.Signer) {
+	t.Helper()
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := key.Generate(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &dns.RRSIG{
+		Hdr:         dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: 3600},
+		TypeCovered: dns.TypeDNSKEY,
+		Algorithm:   key.Algorithm,
+		Labels:      2,
+		OrigTtl:     3600,
+		Inception:   uint32(time.Now().Add(-time.Hour).Unix()),
+		Expiration:  uint32(time.Now().Add(24 * time.Hour).Unix()),
+		KeyTag:      key.KeyTag(),
+		SignerName:  "example.com.",
+	}
+	if err := sig.Sign(priv.(crypto.Signer), []dns.RR{key}); err != nil {
+		t.Fatal(err)
+	}
+	return key, key.ToDS(dns.SHA256), sig, priv.(crypto.Signer)
+}
+
+func TestCheckDNSSEC(t *testing.T) {
+	key, ds, sig, signer := signedZone(t)
+	_, otherDS, _, _ := signedZone(t)
+	expiredSig := *sig
+	expiredSig.Inception = uint32(time.Now().Add(-48 * time.Hour).Unix())
+	expiredSig.Expiration = uint32(time.Now().Add(-24 * time.Hour).Unix())
+	if err := expiredSig.Sign(signer, []dns.RR{key}); err != nil {
+		t.Fatal(err)
+	}
+
+	signed := startStubDNSServer(t, key.String()+"\n"+sig.String())
+	expired := startStubDNSServer(t, key.String()+"\n"+expiredSig.String())
+	parent := startStubDNSServer(t, ds.String())
+	wrongParent := startStubDNSServer(t, otherDS.String())
+	empty := startStubDNSServer(t, "")
+
+	tests := []struct {
+		name          string
+		parent, child string
+		valid         bool
+		problem       string
+	}{
+		{name: "valid", parent: parent, child: signed, valid: true},
+		{name: "unsigned", parent: empty, child: empty},
+		{name: "missing DS", parent: empty, child: signed, problem: "parent has no DS record"},
+		{name: "missing DNSKEY", parent: parent, child: empty, problem: "serves no DNSKEY"},
+		{name: "DS mismatch", parent: wrongParent, child: signed, problem: "no DS record at the parent matches"},
+		{name: "expired signature", parent: parent, child: expired, problem: "no valid signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := checkDNSSEC("Example.com", []string{tt.parent}, []string{tt.child})
+			if status.Valid != tt.valid {
+				t.Errorf("Valid = %v, want %v (%s)", status.Valid, tt.valid, status.Summary())
+			}
+			if got := strings.Join(status.Problems, "; "); tt.problem == "" && got != "" || !strings.Contains(got, tt.problem) {
+				t.Errorf("problems = %q, want %q", got, tt.problem)
+			}
+		})
+	}
+
+	if got, want := checkDNSSEC("example.com", []string{parent}, []string{signed}).Summary(), fmt.Sprintf("signed, DS matches key %d", ds.KeyTag); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestNamedKeySigningKey(t *testing.T) {
+	keys := []types.KeySigningKey{
+		{Name: aws.String("other"), Status: aws.String("ACTIVE")},
+		{Name: aws.String(dnssecKSKName), Status: aws.String("INACTIVE")},
+	}
+	if ksk := namedKeySigningKey(keys, dnssecKSKName); ksk == nil || aws.ToString(ksk.Status) != "INACTIVE" {
+		t.Errorf("namedKeySigningKey() = %+v, want the inactive %s", ksk, dnssecKSKName)
+	}
+	if ksk := namedKeySigningKey(keys[:1], dnssecKSKName); ksk != nil {
+		t.Errorf("namedKeySigningKey() = %+v, want nil", ksk)
+	}
+}
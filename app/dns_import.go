@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/miekg/dns"
+)
+
+// route53RecordTypes are the record types a Route53 zone can hold
+var route53RecordTypes = []string{"A", "AAAA", "CAA", "CNAME", "DS", "HTTPS", "MX", "NAPTR", "NS", "PTR", "SPF", "SRV", "SSHFP", "SVCB", "TLSA", "TXT"}
+
+// dnsImportBatchSize keeps change batches well below Route53's limits
+const dnsImportBatchSize = 100
+
+// readZoneFile parses a BIND zone file exported from another DNS provider
+func readZoneFile(path, domain string) ([]types.ResourceRecordSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zone file: %w", err)
+	}
+	defer f.Close()
+	return parseZoneFile(f, domain, path)
+}
+
+// parseZoneFile groups the records of a zone file into record sets in Route53's value format
+func parseZoneFile(r io.Reader, domain, filename string) ([]types.ResourceRecordSet, error) {
+	zp := dns.NewZoneParser(r, dns.Fqdn(domain), filename)
+	zp.SetIncludeAllowed(false)
+
+	var sets []types.ResourceRecordSet
+	index := map[string]int{}
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		hdr := rr.Header()
+		name := strings.ToLower(hdr.Name)
+		typeName := dns.TypeToString[hdr.Rrtype]
+		key := name + " " + typeName
+		i, found := index[key]
+		if !found {
+			i = len(sets)
+			index[key] = i
+			sets = append(sets, types.ResourceRecordSet{
+				Name: aws.String(name),
+				Type: types.RRType(typeName),
+				TTL:  aws.Int64(int64(hdr.Ttl)),
+			})
+		}
+		// The presentation format after the header matches Route53's value format
+		value := strings.TrimPrefix(rr.String(), hdr.String())
+		sets[i].ResourceRecords = append(sets[i].ResourceRecords, types.ResourceRecord{Value: aws.String(value)})
+	}
+	if err := zp.Err(); err != nil {
+		return nil, fmt.Errorf("invalid zone file: %w", err)
+	}
+	return sets, nil
+}
+
+// importableRecordSets prepares source record sets for the target zone: the apex
+// NS and SOA belong to the new zone, aliases to records in the source zone are
+// pointed at the target zone and health checks, which are account specific, are
+// dropped. It returns what was skipped or changed for the user to review.
+func importableRecordSets(domain string, sets []types.ResourceRecordSet, sourceZoneID, targetZoneID string) ([]types.ResourceRecordSet, []string) {
+	apex := dns.Fqdn(strings.ToLower(domain))
+	var keep []types.ResourceRecordSet
+	var notes []string
+	for _, set := range sets {
+		name := normalizeRoute53Name(aws.ToString(set.Name))
+		display := strings.TrimSuffix(name, ".") + " " + string(set.Type)
+		switch {
+		case name == apex && (set.Type == types.RRTypeNs || set.Type == types.RRTypeSoa):
+			continue
+		case name != apex && !strings.HasSuffix(name, "."+apex):
+			notes = append(notes, fmt.Sprintf("skipped %s: outside %s", display, strings.TrimSuffix(apex, ".")))
+			continue
+		case !slices.Contains(route53RecordTypes, string(set.Type)):
+			notes = append(notes, fmt.Sprintf("skipped %s: not supported by Route53", display))
+			continue
+		}
+
+		if set.AliasTarget != nil && sourceZoneID != "" && strings.TrimPrefix(aws.ToString(set.AliasTarget.HostedZoneId), "/hostedzone/") == sourceZoneID {
+			target := *set.AliasTarget
+			target.HostedZoneId = aws.String(targetZoneID)
+			set.AliasTarget = &target
+		}
+		if set.HealthCheckId != nil {
+			notes = append(notes, fmt.Sprintf("%s: health check %s dropped, recreate it in the target account", display, aws.ToString(set.HealthCheckId)))
+			set.HealthCheckId = nil
+		}
+		keep = append(keep, set)
+	}
+	return keep, notes
+}
+
+// recordSetKey identifies a record set, including routing policy variants
+func recordSetKey(set types.ResourceRecordSet) string {
+	return normalizeRoute53Name(aws.ToString(set.Name)) + " " + string(set.Type) + " " + aws.ToString(set.SetIdentifier)
+}
+
+// planZoneImport returns the changes that make the target zone hold the source record sets,
+// records that only exist in the target zone are left alone
+func planZoneImport(sets, existing []types.ResourceRecordSet) []dnsRecordChange {
+	current := map[string]types.ResourceRecordSet{}
+	for _, set := range existing {
+		current[recordSetKey(set)] = set
+	}
+
+	var changes []dnsRecordChange
+	for _, set := range sets {
+		change := dnsRecordChange{
+			Action: types.ChangeActionCreate,
+			Name:   normalizeRoute53Name(aws.ToString(set.Name)),
+			Type:   string(set.Type),
+			New:    recordSetSummary(set),
+			Set:    set,
+		}
+		if old, ok := current[recordSetKey(set)]; ok {
+			if recordSetSummary(old) == change.New {
+				continue
+			}
+			change.Action = types.ChangeActionUpsert
+			change.Old = recordSetSummary(old)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// compareZoneServers queries both sets of nameservers for every record set and
+// reports differences. Aliases and routing policies can legitimately answer
+// differently per query, so they are only counted as skipped.
+func compareZoneServers(sets []types.ResourceRecordSet, sourceServers, targetServers []string) (int, int, []string) {
+	compared, skipped := 0, 0
+	var problems []string
+	for _, set := range sets {
+		if set.AliasTarget != nil || set.SetIdentifier != nil {
+			skipped++
+			continue
+		}
+		name := normalizeRoute53Name(aws.ToString(set.Name))
+		qtype := dns.StringToType[string(set.Type)]
+		display := strings.TrimSuffix(name, ".") + " " + string(set.Type)
+
+		before, err := queryDNSRecord(sourceServers, name, qtype)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: old nameservers: %v", display, err))
+			continue
+		}
+		after, err := queryDNSRecord(targetServers, name, qtype)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: new nameservers: %v", display, err))
+			continue
+		}
+		sort.Strings(before)
+		sort.Strings(after)
+		if !equalStringSlices(before, after) {
+			problems = append(problems, fmt.Sprintf("%s: old nameservers answer %s, new nameservers answer %s",
+				display, answerSummary(before), answerSummary(after)))
+		}
+		compared++
+	}
+	return compared, skipped, problems
+}
+
+func answerSummary(values []string) string {
+	if len(values) == 0 {
+		return "nothing"
+	}
+	return strings.Join(values, ", ")
+}
+
+// apexNameservers returns the NS values of the zone apex from a list of record sets
+func apexNameservers(domain string, sets []types.ResourceRecordSet) []string {
+	apex := dns.Fqdn(strings.ToLower(domain))
+	for _, set := range sets {
+		if set.Type == types.RRTypeNs && normalizeRoute53Name(aws.ToString(set.Name)) == apex {
+			var servers []string
+			for _, rr := range set.ResourceRecords {
+				servers = append(servers, strings.TrimSuffix(aws.ToString(rr.Value), "."))
+			}
+			return servers
+		}
+	}
+	return nil
+}
+
+// runDNSImport copies an existing zone from another Route53 account or a zone file
+// into a Route53 zone managed by meroku and checks both answer the same before
+// the nameservers are switched
+func runDNSImport(args []string) error {
+	const usage = "usage: dns import <domain> (--from-profile profile | --zone-file path) [--profile target] [--yes]"
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf(usage)
+	}
+	domain := strings.TrimSuffix(strings.ToLower(args[0]), ".")
+
+	fs := flag.NewFlagSet("dns import", flag.ExitOnError)
+	fromProfile := fs.String("from-profile", "", "AWS profile of the account that hosts the existing Route53 zone")
+	zoneFile := fs.String("zone-file", "", "BIND zone file exported from the current DNS provider")
+	profile := fs.String("profile", "", "AWS profile of the target account (default: the root account in dns.yaml)")
+	yes := fs.Bool("yes", false, "Import without asking for confirmation")
+	fs.Parse(args[1:])
+
+	if (*fromProfile == "") == (*zoneFile == "") {
+		return fmt.Errorf(usage)
+	}
+
+	config, err := loadDNSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load DNS config: %w", err)
+	}
+	targetProfile := *profile
+	if targetProfile == "" && config != nil && config.RootAccount.AccountID != "" {
+		if targetProfile, err = findAWSProfileByAccountID(config.RootAccount.AccountID); err != nil {
+			return fmt.Errorf("cannot find an AWS profile for the root account %s, pass --profile: %w", config.RootAccount.AccountID, err)
+		}
+	}
+	if targetProfile == "" {
+		return fmt.Errorf("no DNS configuration found, pass --profile for the target account")
+	}
+
+	ctx := context.Background()
+
+	// Read the existing records and the nameservers that serve them today
+	var sourceSets []types.ResourceRecordSet
+	var sourceZoneID string
+	var sourceNS []string
+	if *fromProfile != "" {
+		fmt.Printf("Reading %s from Route53 (profile %s)... ", domain, *fromProfile)
+		client, err := newRoute53Client(ctx, *fromProfile)
+		if err != nil {
+			return err
+		}
+		if sourceZoneID, err = lookupZoneID(ctx, client, domain); err != nil {
+			fmt.Println("❌")
+			return err
+		}
+		if sourceSets, err = listRecordSets(ctx, client, sourceZoneID); err != nil {
+			fmt.Println("❌")
+			return err
+		}
+	} else {
+		fmt.Printf("Reading %s from %s... ", domain, *zoneFile)
+		if sourceSets, err = readZoneFile(*zoneFile, domain); err != nil {
+			fmt.Println("❌")
+			return err
+		}
+	}
+	fmt.Printf("✅ %d record sets\n", len(sourceSets))
+	if sourceNS = apexNameservers(domain, sourceSets); len(sourceNS) == 0 {
+		if sourceNS, err = queryNameservers(domain); err != nil {
+			return fmt.Errorf("failed to find the current nameservers of %s: %w", domain, err)
+		}
+	}
+
+	// A rerun after a failed parity check continues with the zone the first run created. In the
+	// source account the lookup finds the source zone itself, so nothing is created there either.
+	fmt.Printf("Preparing the hosted zone in the target account (profile %s)... ", targetProfile)
+	targetZoneID, targetNS, created, err := ensureHostedZone(targetProfile, domain, false)
+	if err != nil {
+		fmt.Println("❌")
+		return err
+	}
+	if targetZoneID == sourceZoneID {
+		fmt.Println("❌")
+		return fmt.Errorf("%s is already hosted in the target account (zone %s)", domain, targetZoneID)
+	}
+	if created {
+		fmt.Printf("✅ created %s\n", targetZoneID)
+	} else {
+		fmt.Printf("✅ using existing %s\n", targetZoneID)
+	}
+
+	sets, notes := importableRecordSets(domain, sourceSets, sourceZoneID, targetZoneID)
+	client, err := newRoute53Client(ctx, targetProfile)
+	if err != nil {
+		return err
+	}
+	existing, err := listRecordSets(ctx, client, targetZoneID)
+	if err != nil {
+		return err
+	}
+	changes := planZoneImport(sets, existing)
+	printDNSRecordPlan(dnsRecordZone{Source: "import", Domain: domain, ZoneID: targetZoneID}, changes)
+	for _, note := range notes {
+		fmt.Printf("  ⚠️  %s\n", note)
+	}
+
+	if len(changes) > 0 {
+		fmt.Printf("\nPlan: %d change(s) in the target zone. The current nameservers are not touched.\n", len(changes))
+		if !*yes {
+			fmt.Print("Import these records? (y/N): ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				fmt.Println("Cancelled.")
+				return nil
+			}
+		}
+		for start := 0; start < len(changes); start += dnsImportBatchSize {
+			batch := changes[start:min(start+dnsImportBatchSize, len(changes))]
+			fmt.Printf("Importing records %d-%d of %d... ", start+1, start+len(batch), len(changes))
+			if err := applyDNSRecordChanges(ctx, client, targetZoneID, batch); err != nil {
+				fmt.Println("❌")
+				return err
+			}
+			fmt.Println("✅")
+		}
+	}
+
+	// Only hand over the new nameservers once they answer like the old ones
+	fmt.Println("\nComparing answers from the old and the new nameservers...")
+	compared, skipped, problems := compareZoneServers(sets, nameserverAddresses(sourceNS), nameserverAddresses(targetNS))
+	if len(problems) > 0 {
+		fmt.Println("\n❌ The zones differ:")
+		for _, problem := range problems {
+			fmt.Printf("  • %s\n", problem)
+		}
+		return fmt.Errorf("parity check failed for %d of %d record sets, keep the current nameservers", len(problems), compared)
+	}
+	fmt.Printf("✅ %d record sets answer the same", compared)
+	if skipped > 0 {
+		fmt.Printf(" (%d aliases or routing policy records not compared)", skipped)
+	}
+	fmt.Println()
+
+	if config == nil || config.RootDomain == domain {
+		if err := saveImportedRootZone(config, domain, targetProfile, targetZoneID); err != nil {
+			fmt.Printf("⚠️  Could not update dns.yaml: %v\n", err)
+		}
+	}
+
+	fmt.Println("\nNext, switch the nameservers:")
+	fmt.Printf("  1. At the registrar of %s (or in the parent zone for a subdomain), replace the nameservers with:\n", domain)
+	for _, ns := range targetNS {
+		fmt.Printf("       %s\n", ns)
+	}
+	fmt.Println("  2. Keep the old zone unchanged until the old NS records expire from caches (up to 48 hours)")
+	fmt.Println("  3. Run './meroku dns validate' once resolvers return the new nameservers")
+	if status := checkZoneDNSSEC(domain); status.Delegated {
+		fmt.Printf("\n⚠️  %s has a DS record: remove it at the registrar and wait for its TTL before switching,\n", domain)
+		fmt.Println("   then sign the new zone. The new zone can't answer for the old signing keys.")
+	}
+	return nil
+}
+
+// saveImportedRootZone records the imported zone as the root zone in dns.yaml
+func saveImportedRootZone(config *DNSConfig, domain, profile, zoneID string) error {
+	accountID, err := getAWSAccountID(profile)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		config = &DNSConfig{RootDomain: domain, DelegatedZones: []DelegatedZone{}}
+	}
+	if config.RootAccount.AccountID != "" && config.RootAccount.AccountID != accountID {
+		fmt.Println("⚠️  The root zone moved to another account, run './meroku dns setup' to recreate the delegation role")
+		config.RootAccount.DelegationRoleArn = ""
+	}
+	config.RootAccount.AccountID = accountID
+	config.RootAccount.ZoneID = zoneID
+	if err := saveDNSConfig(config); err != nil {
+		return err
+	}
+	return propagateRootZoneInfo(config)
+}
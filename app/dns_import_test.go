@@ -0,0 +1,142 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+const importTestZoneFile = `$TTL 3600
+@       IN SOA  ns1.old-dns.net. hostmaster.example.com. 2024010101 7200 3600 1209600 3600
+@       IN NS   ns1.old-dns.net.
+@       IN NS   ns2.old-dns.net.
+@       IN MX   10 mx1.mail.example.net.
+@       IN MX   20 mx2.mail.example.net.
+@       IN TXT  "v=spf1 include:_spf.example.net ~all"
+www 300 IN CNAME example.com.
+api     IN A    192.0.2.10
+dev     IN NS   ns-1.awsdns-01.org.
+*.apps  IN A    192.0.2.20
+@       IN HINFO "PC" "Linux"
+`
+
+func TestParseZoneFile(t *testing.T) {
+	sets, err := parseZoneFile(strings.NewReader(importTestZoneFile), "example.com", "example.com.zone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 9 {
+		t.Fatalf("got %d record sets, want 9", len(sets))
+	}
+	mx := sets[2]
+	if aws.ToString(mx.Name) != "example.com." || mx.Type != types.RRTypeMx || len(mx.ResourceRecords) != 2 ||
+		aws.ToString(mx.ResourceRecords[1].Value) != "20 mx2.mail.example.net." || aws.ToInt64(mx.TTL) != 3600 {
+		t.Errorf("MX set = %+v", mx)
+	}
+	if www := sets[4]; aws.ToInt64(www.TTL) != 300 || aws.ToString(www.ResourceRecords[0].Value) != "example.com." {
+		t.Errorf("CNAME set = %+v", www)
+	}
+	if ns := apexNameservers("example.com", sets); strings.Join(ns, ",") != "ns1.old-dns.net,ns2.old-dns.net" {
+		t.Errorf("apexNameservers() = %v", ns)
+	}
+
+	if _, err := parseZoneFile(strings.NewReader("www IN A not-an-address\n"), "example.com", "bad.zone"); err == nil {
+		t.Error("expected an error for an invalid zone file")
+	}
+}
+
+func TestImportableRecordSets(t *testing.T) {
+	sets, err := parseZoneFile(strings.NewReader(importTestZoneFile), "example.com", "example.com.zone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets = append(sets,
+		types.ResourceRecordSet{Name: aws.String("app.example.com."), Type: types.RRTypeA,
+			AliasTarget: &types.AliasTarget{DNSName: aws.String("api.example.com."), HostedZoneId: aws.String("/hostedzone/ZOLD")}},
+		types.ResourceRecordSet{Name: aws.String("cdn.example.com."), Type: types.RRTypeA,
+			AliasTarget: &types.AliasTarget{DNSName: aws.String("d111.cloudfront.net."), HostedZoneId: aws.String("Z2FDTNDATAQYW2")}},
+		types.ResourceRecordSet{Name: aws.String("eu.example.com."), Type: types.RRTypeA, SetIdentifier: aws.String("eu"),
+			HealthCheckId: aws.String("hc-1"), ResourceRecords: []types.ResourceRecord{{Value: aws.String("192.0.2.30")}}},
+		types.ResourceRecordSet{Name: aws.String("example.org."), Type: types.RRTypeA},
+	)
+
+	keep, notes := importableRecordSets("example.com", sets, "ZOLD", "ZNEW")
+	if len(keep) != 9 {
+		t.Fatalf("kept %d record sets, want 9", len(keep))
+	}
+	for _, set := range keep {
+		if set.Type == types.RRTypeSoa || (set.Type == types.RRTypeNs && aws.ToString(set.Name) == "example.com.") {
+			t.Errorf("apex %s was not skipped", set.Type)
+		}
+	}
+	if got := aws.ToString(keep[6].AliasTarget.HostedZoneId); got != "ZNEW" {
+		t.Errorf("alias in the source zone points at %s, want ZNEW", got)
+	}
+	if got := aws.ToString(keep[7].AliasTarget.HostedZoneId); got != "Z2FDTNDATAQYW2" {
+		t.Errorf("CloudFront alias points at %s", got)
+	}
+	if keep[8].HealthCheckId != nil {
+		t.Error("health check was kept")
+	}
+	if got := strings.Join(notes, "\n"); !strings.Contains(got, "example.com HINFO: not supported by Route53") ||
+		!strings.Contains(got, "health check hc-1 dropped") || !strings.Contains(got, "example.org A: outside example.com") {
+		t.Errorf("notes = %v", notes)
+	}
+	// The source set is not modified
+	if aws.ToString(sets[9].AliasTarget.HostedZoneId) != "/hostedzone/ZOLD" || sets[11].HealthCheckId == nil {
+		t.Error("source record sets were modified")
+	}
+}
+
+func TestPlanZoneImport(t *testing.T) {
+	sets := []types.ResourceRecordSet{
+		{Name: aws.String("api.example.com."), Type: types.RRTypeA, TTL: aws.Int64(3600), ResourceRecords: []types.ResourceRecord{{Value: aws.String("192.0.2.10")}}},
+		{Name: aws.String("*.apps.example.com."), Type: types.RRTypeA, TTL: aws.Int64(3600), ResourceRecords: []types.ResourceRecord{{Value: aws.String("192.0.2.20")}}},
+		{Name: aws.String("www.example.com."), Type: types.RRTypeCname, TTL: aws.Int64(300), ResourceRecords: []types.ResourceRecord{{Value: aws.String("example.com.")}}},
+	}
+	existing := []types.ResourceRecordSet{
+		{Name: aws.String("example.com."), Type: types.RRTypeNs, TTL: aws.Int64(172800), ResourceRecords: []types.ResourceRecord{{Value: aws.String("ns-1.awsdns-01.org.")}}},
+		{Name: aws.String(`\052.apps.example.com.`), Type: types.RRTypeA, TTL: aws.Int64(3600), ResourceRecords: []types.ResourceRecord{{Value: aws.String("192.0.2.20")}}},
+		{Name: aws.String("www.example.com."), Type: types.RRTypeCname, TTL: aws.Int64(300), ResourceRecords: []types.ResourceRecord{{Value: aws.String("old.example.net.")}}},
+	}
+
+	changes := planZoneImport(sets, existing)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if changes[0].Action != types.ChangeActionCreate || changes[0].Name != "api.example.com." {
+		t.Errorf("first change = %+v", changes[0])
+	}
+	if changes[1].Action != types.ChangeActionUpsert || changes[1].Old != "old.example.net (ttl 300)" {
+		t.Errorf("second change = %+v", changes[1])
+	}
+}
+
+func TestCompareZoneServers(t *testing.T) {
+	oldServer := startStubDNSServer(t, `
+example.com. 3600 IN MX 10 mx1.mail.example.net.
+example.com. 3600 IN TXT "v=spf1 " "~all"
+api.example.com. 3600 IN A 192.0.2.10
+`)
+	newServer := startStubDNSServer(t, `
+example.com. 300 IN MX 10 MX1.mail.example.net.
+example.com. 300 IN TXT "v=spf1 ~all"
+api.example.com. 300 IN A 192.0.2.99
+`)
+	sets := []types.ResourceRecordSet{
+		{Name: aws.String("example.com."), Type: types.RRTypeMx},
+		{Name: aws.String("example.com."), Type: types.RRTypeTxt},
+		{Name: aws.String("api.example.com."), Type: types.RRTypeA},
+		{Name: aws.String("app.example.com."), Type: types.RRTypeA, AliasTarget: &types.AliasTarget{DNSName: aws.String("api.example.com.")}},
+	}
+
+	compared, skipped, problems := compareZoneServers(sets, []string{oldServer}, []string{newServer})
+	if compared != 3 || skipped != 1 || len(problems) != 1 {
+		t.Fatalf("compared %d, skipped %d, problems %v", compared, skipped, problems)
+	}
+	if want := "api.example.com A: old nameservers answer 192.0.2.10, new nameservers answer 192.0.2.99"; problems[0] != want {
+		t.Errorf("problem = %q, want %q", problems[0], want)
+	}
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v2"
+)
+
+// dnsRecordTypes are the record types `meroku dns records` manages
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SRV", "CAA", "NS"}
+
+const defaultDNSRecordTTL = 300
+
+// route53RecordsAPI is the part of the Route53 client used to sync records
+type route53RecordsAPI interface {
+	route53.ListResourceRecordSetsAPIClient
+	route53.GetChangeAPIClient
+	ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error)
+	ListHostedZonesByName(ctx context.Context, params *route53.ListHostedZonesByNameInput, optFns ...func(*route53.Options)) (*route53.ListHostedZonesByNameOutput, error)
+}
+
+// ManagedDNSRecordsFile remembers which record sets `meroku dns records apply`
+// created in each zone, so records removed from the configuration can be reported
+const ManagedDNSRecordsFile = ".meroku/dns-records.yaml"
+
+// dnsRecordZone is a hosted zone together with the records configured for it
+type dnsRecordZone struct {
+	Source   string // dns.yaml or the environment file
+	Domain   string
+	ZoneID   string // Looked up by domain when empty
+	Profile  string
+	Records  []DNSRecord
+	Orphaned []string // "<fqdn> <type>" applied earlier, removed from the configuration but still in the zone
+}
+
+type managedDNSRecords struct {
+	Zones map[string][]string `yaml:"zones"` // Zone ID to "<fqdn> <type>" keys
+}
+
+// dnsRecordChange is a planned change to one record set
+type dnsRecordChange struct {
+	Action types.ChangeAction
+	Name   string
+	Type   string
+	Old    string // Current values for display
+	New    string
+	Set    types.ResourceRecordSet
+}
+
+// envDNSZoneDomain returns the zone an environment's records go to, mirroring
+// the domain module in env/main.hbs: delegated subdomain zones for non-prod
+// environments, the root zone otherwise
+func envDNSZoneDomain(env Env) string {
+	if env.Env != "prod" && env.Domain.RootZoneID != "" {
+		return env.Env + "." + env.Domain.DomainName
+	}
+	return env.Domain.DomainName
+}
+
+// collectDNSRecordZones gathers the configured records per zone: records from
+// dns.yaml go to the root zone, dns_records from each environment to its zone.
+// If only is set, only that environment's zone is returned ("root" for dns.yaml).
+func collectDNSRecordZones(config *DNSConfig, envs map[string]Env, only string) ([]dnsRecordZone, error) {
+	var zones []dnsRecordZone
+
+	if config != nil && len(config.Records) > 0 && (only == "" || only == "root") {
+		// Fall back to the default credentials if no profile targets the root account
+		profile, _ := findAWSProfileByAccountID(config.RootAccount.AccountID)
+		zones = append(zones, dnsRecordZone{
+			Source:  DNSConfigFile,
+			Domain:  config.RootDomain,
+			ZoneID:  config.RootAccount.ZoneID,
+			Profile: profile,
+			Records: config.Records,
+		})
+	}
+
+	var paths []string
+	for path := range envs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		env := envs[path]
+		if len(env.DNSRecords) == 0 || (only != "" && only != env.Env) {
+			continue
+		}
+		if !env.Domain.Enabled || env.Domain.DomainName == "" {
+			return nil, fmt.Errorf("%s: dns_records requires domain.enabled and domain.domain_name", path)
+		}
+		zones = append(zones, dnsRecordZone{
+			Source:  path,
+			Domain:  envDNSZoneDomain(env),
+			ZoneID:  env.Domain.ZoneID,
+			Profile: env.AWSProfile,
+			Records: env.DNSRecords,
+		})
+	}
+
+	if only != "" && len(zones) == 0 {
+		return nil, fmt.Errorf("no DNS records configured for %s", only)
+	}
+	return zones, nil
+}
+
+// validateDNSRecordList checks records before anything is sent to Route53
+func validateDNSRecordList(records []DNSRecord) []string {
+	var errors []string
+	seen := map[string]bool{}
+
+	for _, r := range records {
+		label := fmt.Sprintf("record %s %s", displayRecordName(r.Name), r.Type)
+		if !slices.Contains(dnsRecordTypes, r.Type) {
+			errors = append(errors, fmt.Sprintf("%s: type must be one of %s", label, strings.Join(dnsRecordTypes, ", ")))
+			continue
+		}
+		key := strings.ToLower(displayRecordName(strings.TrimSuffix(r.Name, "."))) + " " + r.Type
+		if seen[key] {
+			errors = append(errors, fmt.Sprintf("%s: duplicate record, put all values in one entry", label))
+		}
+		seen[key] = true
+
+		switch {
+		case r.Alias != nil && len(r.Values) > 0:
+			errors = append(errors, fmt.Sprintf("%s: set either values or alias, not both", label))
+		case r.Alias != nil:
+			if r.Type != "A" && r.Type != "AAAA" && r.Type != "CNAME" {
+				errors = append(errors, fmt.Sprintf("%s: alias is only supported for A, AAAA and CNAME records", label))
+			}
+			if r.Alias.DNSName == "" || r.Alias.HostedZoneID == "" {
+				errors = append(errors, fmt.Sprintf("%s: alias needs dns_name and hosted_zone_id", label))
+			}
+		case len(r.Values) == 0:
+			errors = append(errors, fmt.Sprintf("%s: values or alias required", label))
+		case r.Type == "CNAME" && len(r.Values) > 1:
+			errors = append(errors, fmt.Sprintf("%s: a CNAME can only have one value", label))
+		}
+		if r.Type == "CNAME" && (r.Name == "" || r.Name == "@") {
+			errors = append(errors, fmt.Sprintf("%s: a CNAME can't be at the zone apex, use an alias A record", label))
+		}
+		if r.TTL < 0 {
+			errors = append(errors, fmt.Sprintf("%s: ttl must be positive", label))
+		}
+	}
+
+	return errors
+}
+
+func displayRecordName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return name
+}
+
+// dnsRecordFQDN returns the record name with a trailing dot. Names are
+// relative to the zone unless they already end with the zone name.
+func dnsRecordFQDN(name, zone string) string {
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	switch {
+	case name == "" || name == "@" || name == zone:
+		return zone + "."
+	case strings.HasSuffix(name, "."+zone):
+		return name + "."
+	default:
+		return name + "." + zone + "."
+	}
+}
+
+// route53RecordValues renders configured values the way Route53 expects them:
+// TXT values are quoted and split into 255 character strings
+func route53RecordValues(r DNSRecord) []string {
+	values := make([]string, 0, len(r.Values))
+	for _, v := range r.Values {
+		if r.Type == "TXT" && !strings.HasPrefix(v, `"`) {
+			var chunks []string
+			for len(v) > 255 {
+				chunks = append(chunks, v[:255])
+				v = v[255:]
+			}
+			chunks = append(chunks, v)
+			for i, chunk := range chunks {
+				chunks[i] = `"` + strings.ReplaceAll(chunk, `"`, `\"`) + `"`
+			}
+			v = strings.Join(chunks, " ")
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// desiredRecordSet converts a configured record into a Route53 record set
+func desiredRecordSet(zone string, r DNSRecord) types.ResourceRecordSet {
+	set := types.ResourceRecordSet{
+		Name: aws.String(dnsRecordFQDN(r.Name, zone)),
+		Type: types.RRType(r.Type),
+	}
+	if r.Alias != nil {
+		set.AliasTarget = &types.AliasTarget{
+			DNSName:              aws.String(r.Alias.DNSName),
+			HostedZoneId:         aws.String(r.Alias.HostedZoneID),
+			EvaluateTargetHealth: r.Alias.EvaluateTargetHealth,
+		}
+		return set
+	}
+	ttl := r.TTL
+	if ttl == 0 {
+		ttl = defaultDNSRecordTTL
+	}
+	set.TTL = aws.Int64(ttl)
+	for _, v := range route53RecordValues(r) {
+		set.ResourceRecords = append(set.ResourceRecords, types.ResourceRecord{Value: aws.String(v)})
+	}
+	return set
+}
+
+// normalizeRoute53Name lowercases a record name and decodes Route53's octal escapes (\052 for *)
+func normalizeRoute53Name(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), `\052`, "*")
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// normalizeDNSValue makes a value comparable regardless of case, trailing dots,
+// TXT quoting and whitespace
+func normalizeDNSValue(recordType, value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	switch recordType {
+	case "TXT":
+		if strings.HasPrefix(value, `"`) {
+			var b strings.Builder
+			inQuote, escaped := false, false
+			for _, c := range value {
+				switch {
+				case escaped:
+					b.WriteRune(c)
+					escaped = false
+				case c == '\\' && inQuote:
+					escaped = true
+				case c == '"':
+					inQuote = !inQuote
+				case inQuote:
+					b.WriteRune(c)
+				}
+			}
+			return b.String()
+		}
+		return value
+	case "CAA":
+		return value
+	default:
+		return strings.TrimSuffix(strings.ToLower(value), ".")
+	}
+}
+
+// recordSetSummary renders a record set's values for plans and comparisons
+func recordSetSummary(set types.ResourceRecordSet) string {
+	if alias := set.AliasTarget; alias != nil {
+		summary := fmt.Sprintf("alias %s (zone %s", strings.TrimSuffix(strings.ToLower(aws.ToString(alias.DNSName)), "."),
+			strings.ToUpper(strings.TrimPrefix(aws.ToString(alias.HostedZoneId), "/hostedzone/")))
+		if alias.EvaluateTargetHealth {
+			summary += ", evaluate target health"
+		}
+		return summary + ")"
+	}
+	values := make([]string, 0, len(set.ResourceRecords))
+	for _, rr := range set.ResourceRecords {
+		values = append(values, normalizeDNSValue(string(set.Type), aws.ToString(rr.Value)))
+	}
+	sort.Strings(values)
+	summary := strings.Join(values, ", ")
+	if set.TTL != nil {
+		summary += fmt.Sprintf(" (ttl %d)", aws.ToInt64(set.TTL))
+	}
+	return summary
+}
+
+func dnsRecordKey(name, recordType string) string {
+	return normalizeRoute53Name(name) + " " + recordType
+}
+
+// planDNSRecordChanges compares the configured records with the zone's record
+// sets. Only records listed in the configuration are managed; other records in
+// the zone are left alone.
+func planDNSRecordChanges(zone string, records []DNSRecord, existing []types.ResourceRecordSet) []dnsRecordChange {
+	current := map[string]types.ResourceRecordSet{}
+	for _, set := range existing {
+		if set.SetIdentifier != nil {
+			// Weighted, latency or failover records are managed elsewhere
+			continue
+		}
+		current[dnsRecordKey(aws.ToString(set.Name), string(set.Type))] = set
+	}
+
+	var changes []dnsRecordChange
+	for _, r := range records {
+		desired := desiredRecordSet(zone, r)
+		change := dnsRecordChange{
+			Name: aws.ToString(desired.Name),
+			Type: r.Type,
+			New:  recordSetSummary(desired),
+			Set:  desired,
+		}
+		old, ok := current[dnsRecordKey(change.Name, r.Type)]
+		switch {
+		case !ok:
+			change.Action = types.ChangeActionCreate
+		case recordSetSummary(old) != change.New:
+			change.Action = types.ChangeActionUpsert
+			change.Old = recordSetSummary(old)
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// orphanedDNSRecords returns the previously applied records that are no longer
+// configured but still exist in the zone. They are reported, never deleted.
+func orphanedDNSRecords(zone string, records []DNSRecord, managed []string, existing []types.ResourceRecordSet) []string {
+	configured := map[string]bool{}
+	for _, r := range records {
+		configured[dnsRecordKey(dnsRecordFQDN(r.Name, zone), r.Type)] = true
+	}
+	present := map[string]bool{}
+	for _, set := range existing {
+		present[dnsRecordKey(aws.ToString(set.Name), string(set.Type))] = true
+	}
+	var orphaned []string
+	for _, key := range managed {
+		if !configured[key] && present[key] {
+			orphaned = append(orphaned, key)
+		}
+	}
+	sort.Strings(orphaned)
+	return orphaned
+}
+
+// loadManagedDNSRecords reads the records applied per zone, none if the file doesn't exist
+func loadManagedDNSRecords() (map[string][]string, error) {
+	data, err := os.ReadFile(ManagedDNSRecordsFile)
+	if os.IsNotExist(err) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ManagedDNSRecordsFile, err)
+	}
+	var managed managedDNSRecords
+	if err := yaml.Unmarshal(data, &managed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManagedDNSRecordsFile, err)
+	}
+	if managed.Zones == nil {
+		managed.Zones = map[string][]string{}
+	}
+	return managed.Zones, nil
+}
+
+// saveManagedDNSRecords records the configured and still orphaned records of a zone after an apply
+func saveManagedDNSRecords(zone dnsRecordZone) error {
+	zones, err := loadManagedDNSRecords()
+	if err != nil {
+		return err
+	}
+	keys := append([]string{}, zone.Orphaned...)
+	for _, r := range zone.Records {
+		keys = append(keys, dnsRecordKey(dnsRecordFQDN(r.Name, zone.Domain), r.Type))
+	}
+	sort.Strings(keys)
+	zones[zone.ZoneID] = slices.Compact(keys)
+
+	data, err := yaml.Marshal(managedDNSRecords{Zones: zones})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ManagedDNSRecordsFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(ManagedDNSRecordsFile), err)
+	}
+	return os.WriteFile(ManagedDNSRecordsFile, data, 0644)
+}
+
+// newRoute53Client creates a Route53 client for the profile (default credentials when empty)
+func newRoute53Client(ctx context.Context, profile string) (*route53.Client, error) {
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return route53.NewFromConfig(cfg), nil
+}
+
+// errHostedZoneNotFound is returned by lookupZoneID when the domain has no public hosted zone
+var errHostedZoneNotFound = errors.New("no public hosted zone found")
+
+// lookupZoneID finds the public hosted zone for a domain
+func lookupZoneID(ctx context.Context, client route53RecordsAPI, domain string) (string, error) {
+	resp, err := client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{DNSName: aws.String(domain)})
+	if err != nil {
+		return "", fmt.Errorf("failed to list hosted zones: %w", err)
+	}
+	for _, zone := range resp.HostedZones {
+		if zone.Config != nil && zone.Config.PrivateZone {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSuffix(aws.ToString(zone.Name), "."), strings.TrimSuffix(domain, ".")) {
+			return strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/"), nil
+		}
+	}
+	return "", fmt.Errorf("%w for %s", errHostedZoneNotFound, domain)
+}
+
+// listRecordSets returns all record sets in a zone
+func listRecordSets(ctx context.Context, client route53RecordsAPI, zoneID string) ([]types.ResourceRecordSet, error) {
+	var sets []types.ResourceRecordSet
+	paginator := route53.NewListResourceRecordSetsPaginator(client, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list records: %w", err)
+		}
+		sets = append(sets, page.ResourceRecordSets...)
+	}
+	return sets, nil
+}
+
+// planDNSRecordZone resolves the zone ID if needed, plans its changes and finds
+// orphaned records
+func planDNSRecordZone(ctx context.Context, client route53RecordsAPI, zone *dnsRecordZone) ([]dnsRecordChange, error) {
+	if zone.ZoneID == "" {
+		id, err := lookupZoneID(ctx, client, zone.Domain)
+		if err != nil {
+			return nil, err
+		}
+		zone.ZoneID = id
+	}
+	existing, err := listRecordSets(ctx, client, zone.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	managed, err := loadManagedDNSRecords()
+	if err != nil {
+		return nil, err
+	}
+	zone.Orphaned = orphanedDNSRecords(zone.Domain, zone.Records, managed[zone.ZoneID], existing)
+	return planDNSRecordChanges(zone.Domain, zone.Records, existing), nil
+}
+
+// applyDNSRecordChanges submits the changes as one batch and waits until Route53 has them in sync
+func applyDNSRecordChanges(ctx context.Context, client route53RecordsAPI, zoneID string, changes []dnsRecordChange) error {
+	batch := &types.ChangeBatch{Comment: aws.String("meroku dns records apply")}
+	for _, c := range changes {
+		set := c.Set
+		batch.Changes = append(batch.Changes, types.Change{Action: c.Action, ResourceRecordSet: &set})
+	}
+
+	resp, err := client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch:  batch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change records: %w", err)
+	}
+
+	waiter := route53.NewResourceRecordSetsChangedWaiter(client)
+	if err := waiter.Wait(ctx, &route53.GetChangeInput{Id: resp.ChangeInfo.Id}, 3*time.Minute); err != nil {
+		fmt.Printf("⚠️  Changes submitted but not in sync yet: %v\n", err)
+	}
+	return nil
+}
+
+func printDNSRecordPlan(zone dnsRecordZone, changes []dnsRecordChange) {
+	fmt.Printf("\n%s (%s, zone %s)\n", zone.Domain, zone.Source, zone.ZoneID)
+	if len(changes) == 0 && len(zone.Orphaned) == 0 {
+		fmt.Println("  ✅ Up to date")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range changes {
+		symbol := "+"
+		if c.Action == types.ChangeActionUpsert {
+			symbol = "~"
+		}
+		fmt.Fprintf(w, "  %s %s\t%s\t%s\n", symbol, strings.TrimSuffix(c.Name, "."), c.Type, c.New)
+		if c.Old != "" {
+			fmt.Fprintf(w, "    \t\twas: %s\n", c.Old)
+		}
+	}
+	for _, key := range zone.Orphaned {
+		name, recordType, _ := strings.Cut(key, " ")
+		fmt.Fprintf(w, "  ! %s\t%s\torphaned: removed from %s but still in Route53, delete it there or add it back\n",
+			strings.TrimSuffix(name, "."), recordType, zone.Source)
+	}
+	w.Flush()
+}
+
+// runDNSRecords implements `meroku dns records plan|apply [--env name] [--yes]`
+func runDNSRecords(args []string) error {
+	if len(args) == 0 || (args[0] != "plan" && args[0] != "apply") {
+		return fmt.Errorf("usage: dns records plan|apply [--env name|root] [--yes]")
+	}
+	apply := args[0] == "apply"
+
+	fs := flag.NewFlagSet("dns records", flag.ExitOnError)
+	only := fs.String("env", "", "Only this environment's zone (root for the records in dns.yaml)")
+	yes := fs.Bool("yes", false, "Apply without asking for confirmation")
+	fs.Parse(args[1:])
+
+	config, err := loadDNSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load DNS config: %w", err)
+	}
+	envs, err := loadProjectEnvs(".")
+	if err != nil {
+		return fmt.Errorf("failed to load environments: %w", err)
+	}
+	zones, err := collectDNSRecordZones(config, envs, *only)
+	if err != nil {
+		return err
+	}
+	if len(zones) == 0 {
+		fmt.Println("No DNS records configured.")
+		fmt.Println("Add a records list to dns.yaml or dns_records to an environment file.")
+		return nil
+	}
+	for _, zone := range zones {
+		if problems := validateDNSRecordList(zone.Records); len(problems) > 0 {
+			return fmt.Errorf("%s: invalid DNS records:\n  - %s", zone.Source, strings.Join(problems, "\n  - "))
+		}
+	}
+
+	ctx := context.Background()
+	type zonePlan struct {
+		zone    dnsRecordZone
+		client  *route53.Client
+		changes []dnsRecordChange
+	}
+	var plans []zonePlan
+	total, orphaned := 0, 0
+	for _, zone := range zones {
+		client, err := newRoute53Client(ctx, zone.Profile)
+		if err != nil {
+			return err
+		}
+		changes, err := planDNSRecordZone(ctx, client, &zone)
+		if err != nil {
+			return fmt.Errorf("%s: %w", zone.Domain, err)
+		}
+		printDNSRecordPlan(zone, changes)
+		plans = append(plans, zonePlan{zone, client, changes})
+		total += len(changes)
+		orphaned += len(zone.Orphaned)
+	}
+
+	fmt.Printf("\nPlan: %d change(s). Records not listed in the configuration are left alone.\n", total)
+	if orphaned > 0 {
+		fmt.Printf("%d orphaned record(s) were applied earlier and removed from the configuration; they are not deleted.\n", orphaned)
+	}
+	if !apply {
+		return nil
+	}
+	if total > 0 && !*yes {
+		fmt.Print("Apply these changes? (y/N): ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	for _, plan := range plans {
+		if len(plan.changes) > 0 {
+			fmt.Printf("Applying %d change(s) to %s... ", len(plan.changes), plan.zone.Domain)
+			if err := applyDNSRecordChanges(ctx, plan.client, plan.zone.ZoneID, plan.changes); err != nil {
+				fmt.Println("❌")
+				return err
+			}
+			fmt.Println("✅")
+		}
+		// Remember what meroku manages so later removals show up as orphaned
+		if err := saveManagedDNSRecords(plan.zone); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	fmt.Println("\nRun './meroku dns validate' to check that the records resolve.")
+	return nil
+}
+
+// verifyDNSRecords queries the zone's nameservers for every configured record
+// and reports records that are missing or have different values. Without
+// servers the zone's public nameservers are used.
+func verifyDNSRecords(zone dnsRecordZone, servers []string) []string {
+	if len(servers) == 0 {
+		ns, err := queryNameservers(zone.Domain)
+		if err != nil || len(ns) == 0 {
+			return []string{fmt.Sprintf("%s: could not find the zone's nameservers", zone.Domain)}
+		}
+		for _, n := range ns {
+			servers = append(servers, strings.TrimSuffix(n, ".")+":53")
+		}
+	}
+
+	var problems []string
+	for _, r := range zone.Records {
+		name := dnsRecordFQDN(r.Name, zone.Domain)
+		qtype := dns.StringToType[r.Type]
+		if r.Alias != nil && r.Type == "CNAME" {
+			qtype = dns.TypeA
+		}
+
+		answers, err := queryDNSRecord(servers, name, qtype)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s: %v", strings.TrimSuffix(name, "."), r.Type, err))
+			continue
+		}
+		if r.Alias != nil {
+			if len(answers) == 0 {
+				problems = append(problems, fmt.Sprintf("%s %s: alias does not resolve", strings.TrimSuffix(name, "."), r.Type))
+			}
+			continue
+		}
+
+		var want []string
+		for _, v := range route53RecordValues(r) {
+			want = append(want, normalizeDNSValue(r.Type, v))
+		}
+		sort.Strings(want)
+		sort.Strings(answers)
+		if !equalStringSlices(want, answers) {
+			got := "nothing"
+			if len(answers) > 0 {
+				got = strings.Join(answers, ", ")
+			}
+			problems = append(problems, fmt.Sprintf("%s %s: expected %s, got %s",
+				strings.TrimSuffix(name, "."), r.Type, strings.Join(want, ", "), got))
+		}
+	}
+	return problems
+}
+
+// queryDNSRecord asks the first responding server for a record and returns the
+// answers of that type as normalized values
+func queryDNSRecord(servers []string, name string, qtype uint16) ([]string, error) {
+	client := &dns.Client{Timeout: 3 * time.Second}
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	var lastErr error
+	for _, server := range servers {
+		resp, _, err := client.Exchange(msg, server)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s answered %s", server, dns.RcodeToString[resp.Rcode])
+			continue
+		}
+
+		typeName := dns.TypeToString[qtype]
+		var values []string
+		for _, rr := range resp.Answer {
+			if rr.Header().Rrtype != qtype {
+				continue
+			}
+			var value string
+			switch v := rr.(type) {
+			case *dns.TXT:
+				value = strings.Join(v.Txt, "")
+			default:
+				// The presentation format after the header matches Route53's value format
+				value = strings.TrimPrefix(rr.String(), rr.Header().String())
+			}
+			values = append(values, normalizeDNSValue(typeName, value))
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("no nameserver answered: %v", lastErr)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/miekg/dns"
+)
+
+// startStubDNSServer serves the given records, one per line, over UDP on localhost and returns its address
+func startStubDNSServer(t *testing.T, zone string) string {
+	t.Helper()
+	records := map[string][]dns.RR{}
+	for _, line := range strings.Split(strings.TrimSpace(zone), "\n") {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("invalid stub record %q: %v", line, err)
+		}
+		if rr == nil {
+			continue
+		}
+		key := strings.ToLower(rr.Header().Name) + " " + dns.TypeToString[rr.Header().Rrtype]
+		records[key] = append(records[key], rr)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		resp.Authoritative = true
+		q := req.Question[0]
+		resp.Answer = append([]dns.RR(nil), records[strings.ToLower(q.Name)+" "+dns.TypeToString[q.Qtype]]...)
+		// Signatures are served with the records they cover
+		for _, rr := range records[strings.ToLower(q.Name)+" RRSIG"] {
+			if rr.(*dns.RRSIG).TypeCovered == q.Qtype && len(resp.Answer) > 0 {
+				resp.Answer = append(resp.Answer, rr)
+			}
+		}
+		if len(resp.Answer) == 0 {
+			resp.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(resp)
+	})}
+	started := make(chan struct{})
+	server.NotifyStartedFunc = func() { close(started) }
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestDNSRecordFQDN(t *testing.T) {
+	for _, tt := range []struct{ name, want string }{
+		{"", "example.com."},
+		{"@", "example.com."},
+		{"www", "www.example.com."},
+		{"WWW.Example.com", "www.example.com."},
+		{"mail.example.com.", "mail.example.com."},
+		{"*.apps", "*.apps.example.com."},
+	} {
+		if got := dnsRecordFQDN(tt.name, "example.com."); got != tt.want {
+			t.Errorf("dnsRecordFQDN(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRoute53RecordValuesSplitsLongTXT(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	values := route53RecordValues(DNSRecord{Type: "TXT", Values: []string{long, `say "hi"`, `"already quoted"`}})
+	if want := `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("a", 45) + `"`; values[0] != want {
+		t.Errorf("long TXT = %s", values[0])
+	}
+	if values[1] != `"say \"hi\""` || values[2] != `"already quoted"` {
+		t.Errorf("values = %v", values)
+	}
+	if got := normalizeDNSValue("TXT", values[0]); got != long {
+		t.Errorf("normalizeDNSValue() = %q, want the original value", got)
+	}
+}
+
+func TestPlanDNSRecordChanges(t *testing.T) {
+	records := []DNSRecord{
+		{Name: "_verify", Type: "TXT", Values: []string{"token=abc"}},
+		{Name: "@", Type: "MX", Values: []string{"10 mx1.mail.example.net", "20 mx2.mail.example.net"}, TTL: 3600},
+		{Name: "docs", Type: "CNAME", Values: []string{"acme.gitbook.io"}},
+		{Name: "*.apps", Type: "A", Values: []string{"192.0.2.10"}},
+		{Name: "www", Type: "A", Alias: &DNSAlias{DNSName: "d111.cloudfront.net", HostedZoneID: "Z2FDTNDATAQYW2"}},
+	}
+	existing := []types.ResourceRecordSet{
+		{Name: aws.String("_verify.example.com."), Type: types.RRTypeTxt, TTL: aws.Int64(300), ResourceRecords: []types.ResourceRecord{{Value: aws.String(`"token=abc"`)}}},
+		{Name: aws.String("example.com."), Type: types.RRTypeMx, TTL: aws.Int64(3600), ResourceRecords: []types.ResourceRecord{{Value: aws.String("10 old-mx.example.net.")}}},
+		{Name: aws.String(`\052.apps.example.com.`), Type: types.RRTypeA, TTL: aws.Int64(300), ResourceRecords: []types.ResourceRecord{{Value: aws.String("192.0.2.10")}}},
+		{Name: aws.String("www.example.com."), Type: types.RRTypeA, AliasTarget: &types.AliasTarget{DNSName: aws.String("D111.cloudfront.net."), HostedZoneId: aws.String("Z2FDTNDATAQYW2")}},
+		// Weighted records and records not in the configuration are left alone
+		{Name: aws.String("docs.example.com."), Type: types.RRTypeCname, SetIdentifier: aws.String("blue"), ResourceRecords: []types.ResourceRecord{{Value: aws.String("blue.example.net")}}},
+		{Name: aws.String("legacy.example.com."), Type: types.RRTypeA, TTL: aws.Int64(300), ResourceRecords: []types.ResourceRecord{{Value: aws.String("192.0.2.99")}}},
+	}
+
+	changes := planDNSRecordChanges("example.com", records, existing)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if c := changes[0]; c.Action != types.ChangeActionUpsert || c.Name != "example.com." || c.Old != "10 old-mx.example.net (ttl 3600)" ||
+		c.New != "10 mx1.mail.example.net, 20 mx2.mail.example.net (ttl 3600)" {
+		t.Errorf("MX change = %+v", c)
+	}
+	if c := changes[1]; c.Action != types.ChangeActionCreate || c.Name != "docs.example.com." || aws.ToInt64(c.Set.TTL) != defaultDNSRecordTTL {
+		t.Errorf("CNAME change = %+v", c)
+	}
+}
+
+func TestPlanDNSRecordChangesAlias(t *testing.T) {
+	existing := []types.ResourceRecordSet{
+		{Name: aws.String("www.example.com."), Type: types.RRTypeA, AliasTarget: &types.AliasTarget{DNSName: aws.String("d111.cloudfront.net."), HostedZoneId: aws.String("Z2FDTNDATAQYW2")}},
+		{Name: aws.String("api.example.com."), Type: types.RRTypeA, AliasTarget: &types.AliasTarget{DNSName: aws.String("alb.amazonaws.com."), HostedZoneId: aws.String("Z35SXDOTRQ7X7K")}},
+	}
+	records := []DNSRecord{
+		{Name: "www", Type: "A", Alias: &DNSAlias{DNSName: "d111.cloudfront.net", HostedZoneID: "Z1OTHERZONE"}},
+		{Name: "api", Type: "A", Alias: &DNSAlias{DNSName: "alb.amazonaws.com", HostedZoneID: "Z35SXDOTRQ7X7K", EvaluateTargetHealth: true}},
+	}
+
+	changes := planDNSRecordChanges("example.com", records, existing)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if c := changes[0]; c.Action != types.ChangeActionUpsert || c.New != "alias d111.cloudfront.net (zone Z1OTHERZONE)" {
+		t.Errorf("hosted zone change = %+v", c)
+	}
+	if c := changes[1]; c.Action != types.ChangeActionUpsert || c.New != "alias alb.amazonaws.com (zone Z35SXDOTRQ7X7K, evaluate target health)" {
+		t.Errorf("evaluate target health change = %+v", c)
+	}
+}
+
+func TestOrphanedDNSRecords(t *testing.T) {
+	existing := []types.ResourceRecordSet{
+		{Name: aws.String("_verify.example.com."), Type: types.RRTypeTxt},
+		{Name: aws.String("old.example.com."), Type: types.RRTypeCname},
+		{Name: aws.String("manual.example.com."), Type: types.RRTypeA},
+	}
+	records := []DNSRecord{{Name: "_verify", Type: "TXT", Values: []string{"token"}}}
+	managed := []string{"_verify.example.com. TXT", "old.example.com. CNAME", "deleted.example.com. A"}
+
+	got := orphanedDNSRecords("example.com", records, managed, existing)
+	if strings.Join(got, ",") != "old.example.com. CNAME" {
+		t.Errorf("orphanedDNSRecords() = %v", got)
+	}
+}
+
+// fakeRoute53 serves record sets in pages of two and records changes
+type fakeRoute53 struct {
+	sets     []types.ResourceRecordSet
+	changed  []*route53.ChangeResourceRecordSetsInput
+	zonesErr error
+}
+
+func (f *fakeRoute53) ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	start := 0
+	if params.StartRecordName != nil {
+		start = 2
+	}
+	out := &route53.ListResourceRecordSetsOutput{ResourceRecordSets: f.sets[start:min(start+2, len(f.sets))]}
+	if start == 0 && len(f.sets) > 2 {
+		out.IsTruncated = true
+		out.NextRecordName = f.sets[2].Name
+		out.NextRecordType = f.sets[2].Type
+	}
+	return out, nil
+}
+
+func (f *fakeRoute53) ListHostedZonesByName(ctx context.Context, params *route53.ListHostedZonesByNameInput, optFns ...func(*route53.Options)) (*route53.ListHostedZonesByNameOutput, error) {
+	if f.zonesErr != nil {
+		return nil, f.zonesErr
+	}
+	return &route53.ListHostedZonesByNameOutput{HostedZones: []types.HostedZone{
+		{Id: aws.String("/hostedzone/ZPRIVATE"), Name: aws.String("dev.example.com."), Config: &types.HostedZoneConfig{PrivateZone: true}},
+		{Id: aws.String("/hostedzone/ZDEV"), Name: aws.String("dev.example.com."), Config: &types.HostedZoneConfig{}},
+	}}, nil
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.changed = append(f.changed, params)
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &types.ChangeInfo{Id: aws.String("/change/C1")}}, nil
+}
+
+func (f *fakeRoute53) GetChange(ctx context.Context, params *route53.GetChangeInput, optFns ...func(*route53.Options)) (*route53.GetChangeOutput, error) {
+	return &route53.GetChangeOutput{ChangeInfo: &types.ChangeInfo{Id: params.Id, Status: types.ChangeStatusInsync}}, nil
+}
+
+func TestLookupZoneID(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeRoute53{}
+	if id, err := lookupZoneID(ctx, client, "dev.example.com"); err != nil || id != "ZDEV" {
+		t.Errorf("lookupZoneID() = %q, %v", id, err)
+	}
+	if _, err := lookupZoneID(ctx, client, "stage.example.com"); !errors.Is(err, errHostedZoneNotFound) {
+		t.Errorf("lookupZoneID() missing zone error = %v", err)
+	}
+
+	// Only a missing zone may be created, other failures are not "not found"
+	client.zonesErr = errors.New("AccessDenied: not authorized to perform route53:ListHostedZonesByName")
+	if _, err := lookupZoneID(ctx, client, "dev.example.com"); err == nil || errors.Is(err, errHostedZoneNotFound) {
+		t.Errorf("lookupZoneID() list error = %v", err)
+	}
+}
+
+func TestPlanAndApplyDNSRecordZone(t *testing.T) {
+	client := &fakeRoute53{sets: []types.ResourceRecordSet{
+		{Name: aws.String("dev.example.com."), Type: types.RRTypeNs},
+		{Name: aws.String("dev.example.com."), Type: types.RRTypeSoa},
+		{Name: aws.String("_verify.dev.example.com."), Type: types.RRTypeTxt, TTL: aws.Int64(300), ResourceRecords: []types.ResourceRecord{{Value: aws.String(`"old"`)}}},
+	}}
+	envs := map[string]Env{"dev.yaml": {
+		Project: "demo", Env: "dev", AWSProfile: "demo-dev",
+		Domain:     Domain{Enabled: true, DomainName: "example.com", RootZoneID: "ZROOT"},
+		DNSRecords: []DNSRecord{{Name: "_verify", Type: "TXT", Values: []string{"new"}}},
+	}}
+	zones, err := collectDNSRecordZones(nil, envs, "dev")
+	if err != nil || len(zones) != 1 || zones[0].Domain != "dev.example.com" || zones[0].Profile != "demo-dev" {
+		t.Fatalf("collectDNSRecordZones() = %+v, %v", zones, err)
+	}
+
+	ctx := context.Background()
+	changes, err := planDNSRecordZone(ctx, client, &zones[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zones[0].ZoneID != "ZDEV" || len(changes) != 1 || changes[0].Old != "old (ttl 300)" {
+		t.Fatalf("zone = %s, changes = %+v", zones[0].ZoneID, changes)
+	}
+
+	if err := applyDNSRecordChanges(ctx, client, zones[0].ZoneID, changes); err != nil {
+		t.Fatal(err)
+	}
+	batch := client.changed[0].ChangeBatch.Changes
+	if aws.ToString(client.changed[0].HostedZoneId) != "ZDEV" || len(batch) != 1 || aws.ToString(batch[0].ResourceRecordSet.ResourceRecords[0].Value) != `"new"` {
+		t.Errorf("unexpected change batch: %+v", client.changed[0])
+	}
+
+	if _, err := collectDNSRecordZones(nil, envs, "prod"); err == nil {
+		t.Error("expected an error for an environment without records")
+	}
+}
+
+func TestVerifyDNSRecords(t *testing.T) {
+	server := startStubDNSServer(t, `
+example.com. 300 IN MX 10 mx1.mail.example.net.
+example.com. 300 IN MX 20 mx2.mail.example.net.
+_verify.example.com. 300 IN TXT "token=abc"
+docs.example.com. 300 IN CNAME wrong.example.net.
+www.example.com. 60 IN A 192.0.2.1
+`)
+	zone := dnsRecordZone{Domain: "example.com", Records: []DNSRecord{
+		{Name: "@", Type: "MX", Values: []string{"20 mx2.mail.example.net", "10 MX1.mail.example.net."}},
+		{Name: "_verify", Type: "TXT", Values: []string{"token=abc"}},
+		{Name: "docs", Type: "CNAME", Values: []string{"acme.gitbook.io"}},
+		{Name: "www", Type: "A", Alias: &DNSAlias{DNSName: "d111.cloudfront.net", HostedZoneID: "Z2FDTNDATAQYW2"}},
+		{Name: "missing", Type: "TXT", Values: []string{"x"}},
+	}}
+
+	problems := verifyDNSRecords(zone, []string{server})
+	if len(problems) != 2 {
+		t.Fatalf("problems = %v, want docs and missing", problems)
+	}
+	if !strings.Contains(problems[0], "docs.example.com CNAME: expected acme.gitbook.io, got wrong.example.net") ||
+		!strings.Contains(problems[1], "missing.example.com TXT: expected x, got nothing") {
+		t.Errorf("problems = %v", problems)
+	}
+}
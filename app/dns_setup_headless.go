@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"gopkg.in/yaml.v2"
+)
+
+// Status of a DNS setup step
+const (
+	dnsSetupStarted = "started"
+	dnsSetupDone    = "done"
+	dnsSetupSkipped = "skipped" // Nothing to change, e.g. on a rerun
+	dnsSetupFailed  = "failed"
+)
+
+// DNSSetupEvent reports the progress of `dns setup --non-interactive`
+type DNSSetupEvent struct {
+	Time    time.Time      `json:"time"`
+	Step    string         `json:"step"`             // root_zone, delegation_role, child_zone, ns_delegation, environments or complete
+	Target  string         `json:"target,omitempty"` // Domain the step works on
+	Status  string         `json:"status"`
+	Message string         `json:"message,omitempty"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// dnsSetupOps are the AWS and file operations of the headless setup, all of them
+// are idempotent so a failed setup can be resumed by running it again
+type dnsSetupOps struct {
+	profileForAccount  func(accountID string) (string, error)
+	ensureZone         func(profile, domain string, dnssec bool) (zoneID string, nameservers []string, created bool, err error)
+	dsRecord           func(profile, zoneID string) (string, error)
+	ensureRole         func(profile string, trustedAccounts []string) (string, error)
+	delegateWithRole   func(sourceProfile, roleArn, rootZoneID, subdomain string, nameservers []string) error
+	delegateDirect     func(rootProfile, rootZoneID, subdomain string, nameservers []string) error
+	updateEnvironments func(config *DNSConfig) error
+	save               func(config *DNSConfig) error
+}
+
+func defaultDNSSetupOps() dnsSetupOps {
+	return dnsSetupOps{
+		profileForAccount: dnsSetupProfile,
+		ensureZone:        ensureHostedZone,
+		dsRecord:          getZoneDSRecord,
+		ensureRole:        createDNSDelegationRole,
+		delegateWithRole:  assumeRoleAndCreateNSRecords,
+		delegateDirect: func(rootProfile, rootZoneID, subdomain string, nameservers []string) error {
+			return createNSRecordDelegation(rootProfile, "", rootZoneID, subdomain, nameservers)
+		},
+		updateEnvironments: updateEnvironmentsForDNSSetup,
+		save:               saveDNSConfig,
+	}
+}
+
+var awsAccountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// validateDNSSetupSpec checks a declarative DNS config before anything is created
+func validateDNSSetupSpec(spec *DNSConfig) error {
+	if spec == nil {
+		return fmt.Errorf("DNS config is empty")
+	}
+	if !isValidDomain(spec.RootDomain) {
+		return fmt.Errorf("root_domain %q is not a valid domain", spec.RootDomain)
+	}
+	if !awsAccountIDPattern.MatchString(spec.RootAccount.AccountID) {
+		return fmt.Errorf("root_account.account_id %q is not an AWS account ID", spec.RootAccount.AccountID)
+	}
+	seen := map[string]bool{}
+	for _, zone := range spec.DelegatedZones {
+		subdomain := strings.ToLower(zone.Subdomain)
+		if !strings.HasSuffix(subdomain, "."+strings.ToLower(spec.RootDomain)) {
+			return fmt.Errorf("delegated zone %q is not a subdomain of %s", zone.Subdomain, spec.RootDomain)
+		}
+		if seen[subdomain] {
+			return fmt.Errorf("delegated zone %s is listed twice", zone.Subdomain)
+		}
+		seen[subdomain] = true
+		if zone.AccountID != "" && !awsAccountIDPattern.MatchString(zone.AccountID) {
+			return fmt.Errorf("delegated zone %s: account_id %q is not an AWS account ID", zone.Subdomain, zone.AccountID)
+		}
+	}
+	return nil
+}
+
+// mergeDNSSetupState combines the desired config with the progress recorded in dns.yaml
+// by an earlier run, so finished steps are recognized when the setup is resumed
+func mergeDNSSetupState(spec, state *DNSConfig) *DNSConfig {
+	merged := *spec
+	merged.DelegatedZones = slices.Clone(spec.DelegatedZones)
+	for i := range merged.DelegatedZones {
+		if merged.DelegatedZones[i].AccountID == "" {
+			merged.DelegatedZones[i].AccountID = spec.RootAccount.AccountID
+		}
+	}
+
+	if state == nil || !strings.EqualFold(state.RootDomain, spec.RootDomain) {
+		return &merged
+	}
+	if state.RootAccount.AccountID == spec.RootAccount.AccountID {
+		if merged.RootAccount.ZoneID == "" {
+			merged.RootAccount.ZoneID = state.RootAccount.ZoneID
+		}
+		if merged.RootAccount.DelegationRoleArn == "" {
+			merged.RootAccount.DelegationRoleArn = state.RootAccount.DelegationRoleArn
+		}
+	}
+	for i := range merged.DelegatedZones {
+		zone := &merged.DelegatedZones[i]
+		recorded := findDelegatedZone(state, zone.Subdomain)
+		if zone.ZoneID != "" || recorded == nil || recorded.AccountID != zone.AccountID {
+			continue
+		}
+		zone.ZoneID, zone.NSRecords, zone.Status = recorded.ZoneID, recorded.NSRecords, recorded.Status
+	}
+	if len(merged.Records) == 0 {
+		merged.Records = state.Records
+	}
+	if len(merged.Resolvers) == 0 {
+		merged.Resolvers = state.Resolvers
+	}
+	return &merged
+}
+
+// headlessDNSSetup runs the steps of the DNS setup wizard without a terminal
+type headlessDNSSetup struct {
+	ops      dnsSetupOps
+	emit     func(DNSSetupEvent)
+	config   *DNSConfig
+	profiles map[string]string // AWS profile per account ID
+}
+
+// runHeadlessDNSSetup creates the root zone, the delegation role, the delegated zones and their
+// NS records. Progress is saved after every step, a rerun skips what already exists.
+func runHeadlessDNSSetup(spec, state *DNSConfig, ops dnsSetupOps, emit func(DNSSetupEvent)) (*DNSConfig, error) {
+	if err := validateDNSSetupSpec(spec); err != nil {
+		return nil, err
+	}
+	s := &headlessDNSSetup{ops: ops, emit: emit, config: mergeDNSSetupState(spec, state), profiles: map[string]string{}}
+
+	rootNameservers, dsRecord, err := s.rootZone()
+	if err != nil {
+		return s.config, err
+	}
+	if err := s.delegationRole(); err != nil {
+		return s.config, err
+	}
+	for i := range s.config.DelegatedZones {
+		if err := s.delegatedZone(&s.config.DelegatedZones[i]); err != nil {
+			return s.config, err
+		}
+	}
+	if err := s.step("environments", "", func() (string, string, map[string]any, error) {
+		if err := s.ops.updateEnvironments(s.config); err != nil {
+			return "", "", nil, err
+		}
+		return dnsSetupDone, "updated environment files with the zone IDs", nil, nil
+	}); err != nil {
+		return s.config, err
+	}
+
+	data := map[string]any{"nameservers": rootNameservers}
+	if dsRecord != "" {
+		data["ds_record"] = dsRecord
+	}
+	s.event("complete", s.config.RootDomain, dnsSetupDone, "point the domain at these nameservers at your registrar", data)
+	return s.config, nil
+}
+
+// step emits the events of a step and saves the progress if it changed anything
+func (s *headlessDNSSetup) step(name, target string, run func() (status, message string, data map[string]any, err error)) error {
+	s.event(name, target, dnsSetupStarted, "", nil)
+	status, message, data, err := run()
+	if err == nil && status == dnsSetupDone {
+		err = s.ops.save(s.config)
+	}
+	if err != nil {
+		s.event(name, target, dnsSetupFailed, err.Error(), data)
+		return fmt.Errorf("%s: %w", strings.TrimSpace(name+" "+target), err)
+	}
+	s.event(name, target, status, message, data)
+	return nil
+}
+
+func (s *headlessDNSSetup) event(step, target, status, message string, data map[string]any) {
+	s.emit(DNSSetupEvent{Time: time.Now(), Step: step, Target: target, Status: status, Message: message, Data: data})
+}
+
+// profile returns the AWS profile for an account, looked up once per run
+func (s *headlessDNSSetup) profile(accountID string) (string, error) {
+	if profile, ok := s.profiles[accountID]; ok {
+		return profile, nil
+	}
+	profile, err := s.ops.profileForAccount(accountID)
+	if err != nil {
+		return "", err
+	}
+	s.profiles[accountID] = profile
+	return profile, nil
+}
+
+func (s *headlessDNSSetup) rootZone() ([]string, string, error) {
+	var nameservers []string
+	var dsRecord string
+	root := &s.config.RootAccount
+	err := s.step("root_zone", s.config.RootDomain, func() (string, string, map[string]any, error) {
+		profile, err := s.profile(root.AccountID)
+		if err != nil {
+			return "", "", nil, err
+		}
+		zoneID, ns, created, err := s.ops.ensureZone(profile, s.config.RootDomain, s.config.DNSSEC)
+		if err != nil {
+			return "", "", nil, err
+		}
+		nameservers = ns
+		data := map[string]any{"zone_id": zoneID, "nameservers": ns}
+		if s.config.DNSSEC {
+			if dsRecord, err = s.ops.dsRecord(profile, zoneID); err != nil {
+				return "", "", data, err
+			}
+			data["ds_record"] = dsRecord
+		}
+
+		status, message := dnsSetupSkipped, fmt.Sprintf("zone %s already exists", zoneID)
+		if created {
+			status, message = dnsSetupDone, fmt.Sprintf("created zone %s", zoneID)
+		} else if root.ZoneID != zoneID {
+			status = dnsSetupDone // Record the zone found in Route53
+		}
+		root.ZoneID = zoneID
+		return status, message, data, nil
+	})
+	return nameservers, dsRecord, err
+}
+
+func (s *headlessDNSSetup) delegationRole() error {
+	root := &s.config.RootAccount
+	return s.step("delegation_role", root.AccountID, func() (string, string, map[string]any, error) {
+		var trusted []string
+		for _, zone := range s.config.DelegatedZones {
+			if zone.AccountID != root.AccountID && !slices.Contains(trusted, zone.AccountID) {
+				trusted = append(trusted, zone.AccountID)
+			}
+		}
+		if len(trusted) == 0 {
+			return dnsSetupSkipped, "all delegated zones are in the root account", nil, nil
+		}
+
+		profile, err := s.profile(root.AccountID)
+		if err != nil {
+			return "", "", nil, err
+		}
+		// Always called so the trust policy follows the accounts in the config
+		roleArn, err := s.ops.ensureRole(profile, trusted)
+		if err != nil {
+			return "", "", nil, err
+		}
+		root.DelegationRoleArn = roleArn
+		return dnsSetupDone, fmt.Sprintf("%s trusts %s", roleArn, strings.Join(trusted, ", ")), map[string]any{"role_arn": roleArn}, nil
+	})
+}
+
+func (s *headlessDNSSetup) delegatedZone(zone *DelegatedZone) error {
+	root := s.config.RootAccount
+	delegatedNS, delegated := slices.Clone(zone.NSRecords), zone.Status == "active"
+	var profile string
+
+	err := s.step("child_zone", zone.Subdomain, func() (string, string, map[string]any, error) {
+		var err error
+		if profile, err = s.profile(zone.AccountID); err != nil {
+			return "", "", nil, err
+		}
+		zoneID, ns, created, err := s.ops.ensureZone(profile, zone.Subdomain, false)
+		if err != nil {
+			return "", "", nil, err
+		}
+		data := map[string]any{"zone_id": zoneID, "nameservers": ns}
+
+		status, message := dnsSetupSkipped, fmt.Sprintf("zone %s already exists", zoneID)
+		if created {
+			status, message = dnsSetupDone, fmt.Sprintf("created zone %s in account %s", zoneID, zone.AccountID)
+		} else if zone.ZoneID != zoneID || !equalStringSlices(zone.NSRecords, ns) {
+			status = dnsSetupDone
+		}
+		zone.ZoneID, zone.NSRecords = zoneID, ns
+		return status, message, data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.step("ns_delegation", zone.Subdomain, func() (string, string, map[string]any, error) {
+		data := map[string]any{"nameservers": zone.NSRecords}
+		if delegated && equalStringSlices(delegatedNS, zone.NSRecords) {
+			return dnsSetupSkipped, "NS records already point at the zone", data, nil
+		}
+
+		var err error
+		if zone.AccountID == root.AccountID {
+			err = s.ops.delegateDirect(profile, root.ZoneID, zone.Subdomain, zone.NSRecords)
+		} else {
+			err = s.ops.delegateWithRole(profile, root.DelegationRoleArn, root.ZoneID, zone.Subdomain, zone.NSRecords)
+		}
+		if err != nil {
+			zone.Status = "pending"
+			return "", "", data, err
+		}
+		zone.Status = "active"
+		return dnsSetupDone, fmt.Sprintf("created NS records in %s", s.config.RootDomain), data, nil
+	})
+}
+
+// dnsSetupProfile finds the AWS profile for an account, falling back to the default
+// credentials (e.g. environment variables in CI) if they belong to the account
+func dnsSetupProfile(accountID string) (string, error) {
+	if profile, err := findAWSProfileByAccountID(accountID); err == nil {
+		return profile, nil
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err == nil {
+		identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err == nil && aws.ToString(identity.Account) == accountID {
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("no AWS profile for account %s, create one with 'meroku sso bootstrap' or export credentials for it", accountID)
+}
+
+// ensureHostedZone returns the public hosted zone of a domain and creates it if there is none.
+// Route53 allows several zones with the same name, so a rerun must look before it creates.
+func ensureHostedZone(profile, domain string, dnssec bool) (string, []string, bool, error) {
+	ctx := context.Background()
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := route53.NewFromConfig(cfg)
+
+	zoneID, err := lookupZoneID(ctx, client, domain)
+	if errors.Is(err, errHostedZoneNotFound) {
+		zoneID, nameservers, err := createHostedZone(profile, domain, dnssec)
+		return zoneID, nameservers, err == nil, err
+	}
+	if err != nil {
+		// Denied or throttled lookups must not create a second zone
+		return "", nil, false, err
+	}
+
+	nameservers, err := getZoneNameservers(ctx, client, zoneID)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("failed to get nameservers: %w", err)
+	}
+	if dnssec {
+		if err := enableHostedZoneDNSSEC(ctx, cfg, zoneID); err != nil {
+			return "", nil, false, err
+		}
+	}
+	return zoneID, nameservers, false, nil
+}
+
+// updateEnvironmentsForDNSSetup writes the zones to the environment files like the wizard does.
+// Environments whose zone was created by the setup use it instead of creating one in Terraform.
+func updateEnvironmentsForDNSSetup(config *DNSConfig) error {
+	if err := ensureProductionEnvironment(config.RootDomain, config.RootAccount.ZoneID, config.RootAccount.AccountID); err != nil {
+		return fmt.Errorf("failed to update prod.yaml: %w", err)
+	}
+	if err := propagateRootZoneInfo(config); err != nil {
+		return err
+	}
+
+	for _, zone := range config.DelegatedZones {
+		path := strings.TrimSuffix(strings.ToLower(zone.Subdomain), "."+strings.ToLower(config.RootDomain)) + ".yaml"
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// No environment file for this zone
+			continue
+		}
+		var env Env
+		if err := yaml.Unmarshal(data, &env); err != nil {
+			return fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		env.Domain.Enabled = true
+		env.Domain.DomainName = config.RootDomain
+		env.Domain.AddEnvDomainPrefix = true
+		env.Domain.CreateDomainZone = false
+		env.Domain.ZoneID = zone.ZoneID
+		env.Domain.RootZoneID = config.RootAccount.ZoneID
+		env.Domain.RootAccountID = config.RootAccount.AccountID
+		if err := saveEnvToFile(env, path); err != nil {
+			return fmt.Errorf("failed to update %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// printDNSSetupEvent prints a progress event as a JSON line or as text
+func printDNSSetupEvent(event DNSSetupEvent, asJSON bool) {
+	if asJSON {
+		line, _ := json.Marshal(event)
+		fmt.Println(string(line))
+		return
+	}
+
+	if event.Status == dnsSetupStarted {
+		return
+	}
+	icon := map[string]string{dnsSetupDone: "✓", dnsSetupSkipped: "•", dnsSetupFailed: "❌"}[event.Status]
+	line := fmt.Sprintf("%s %s", icon, strings.ReplaceAll(event.Step, "_", " "))
+	if event.Target != "" {
+		line += " " + event.Target
+	}
+	if event.Message != "" {
+		line += ": " + event.Message
+	}
+	fmt.Println(line)
+
+	if event.Step == "complete" {
+		if ns, ok := event.Data["nameservers"].([]string); ok {
+			for _, server := range ns {
+				fmt.Printf("    %s\n", server)
+			}
+		}
+		if ds, ok := event.Data["ds_record"].(string); ok {
+			fmt.Printf("  Add this DS record at your registrar to complete DNSSEC:\n    %s\n", ds)
+		}
+	}
+}
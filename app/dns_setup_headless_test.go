@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeDNSSetupAWS records the operations of a headless setup against in-memory zones
+type fakeDNSSetupAWS struct {
+	zones       map[string]string // domain -> zone ID
+	calls       []string
+	failNS      error
+	saved       *DNSConfig
+	delegations map[string]string // subdomain -> how the NS records were created
+}
+
+func newFakeDNSSetupAWS() *fakeDNSSetupAWS {
+	return &fakeDNSSetupAWS{zones: map[string]string{}, delegations: map[string]string{}}
+}
+
+func (f *fakeDNSSetupAWS) ops() dnsSetupOps {
+	return dnsSetupOps{
+		profileForAccount: func(accountID string) (string, error) { return "profile-" + accountID, nil },
+		ensureZone: func(profile, domain string, dnssec bool) (string, []string, bool, error) {
+			ns := []string{"ns-1." + domain + ".awsdns.org", "ns-2." + domain + ".awsdns.com"}
+			if id, ok := f.zones[domain]; ok {
+				return id, ns, false, nil
+			}
+			f.calls = append(f.calls, "create "+domain+" as "+profile)
+			f.zones[domain] = fmt.Sprintf("Z%d", len(f.zones)+1)
+			return f.zones[domain], ns, true, nil
+		},
+		dsRecord: func(profile, zoneID string) (string, error) { return "example.com. 3600 IN DS 1 13 2 ABCD", nil },
+		ensureRole: func(profile string, trusted []string) (string, error) {
+			f.calls = append(f.calls, "role trusts "+strings.Join(trusted, ","))
+			return "arn:aws:iam::111111111111:role/dns-delegation-role", nil
+		},
+		delegateWithRole: func(sourceProfile, roleArn, rootZoneID, subdomain string, ns []string) error {
+			if f.failNS != nil {
+				return f.failNS
+			}
+			f.delegations[subdomain] = "role from " + sourceProfile + " in " + rootZoneID
+			return nil
+		},
+		delegateDirect: func(rootProfile, rootZoneID, subdomain string, ns []string) error {
+			f.delegations[subdomain] = "direct from " + rootProfile + " in " + rootZoneID
+			return nil
+		},
+		updateEnvironments: func(config *DNSConfig) error { return nil },
+		save: func(config *DNSConfig) error {
+			copied := *config
+			copied.DelegatedZones = slices.Clone(config.DelegatedZones)
+			f.saved = &copied
+			return nil
+		},
+	}
+}
+
+func headlessSetupSpec() *DNSConfig {
+	return &DNSConfig{
+		RootDomain:  "example.com",
+		RootAccount: DNSRootAccount{AccountID: "111111111111"},
+		DelegatedZones: []DelegatedZone{
+			{Subdomain: "dev.example.com", AccountID: "222222222222"},
+			{Subdomain: "staging.example.com"},
+		},
+		DNSSEC: true,
+	}
+}
+
+func eventLog(events []DNSSetupEvent) []string {
+	var log []string
+	for _, e := range events {
+		if e.Status != dnsSetupStarted {
+			log = append(log, strings.TrimSpace(e.Step+" "+e.Target)+" "+e.Status)
+		}
+	}
+	return log
+}
+
+func TestRunHeadlessDNSSetup(t *testing.T) {
+	fake := newFakeDNSSetupAWS()
+	var events []DNSSetupEvent
+	config, err := runHeadlessDNSSetup(headlessSetupSpec(), nil, fake.ops(), func(e DNSSetupEvent) { events = append(events, e) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"root_zone example.com done",
+		"delegation_role 111111111111 done",
+		"child_zone dev.example.com done",
+		"ns_delegation dev.example.com done",
+		"child_zone staging.example.com done",
+		"ns_delegation staging.example.com done",
+		"environments done",
+		"complete example.com done",
+	}
+	if got := eventLog(events); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := strings.Join(fake.calls, "; "); got != "create example.com as profile-111111111111; role trusts 222222222222; "+
+		"create dev.example.com as profile-222222222222; create staging.example.com as profile-111111111111" {
+		t.Errorf("calls = %s", got)
+	}
+	if got := fake.delegations["dev.example.com"]; got != "role from profile-222222222222 in Z1" {
+		t.Errorf("dev delegation = %s", got)
+	}
+	if got := fake.delegations["staging.example.com"]; got != "direct from profile-111111111111 in Z1" {
+		t.Errorf("staging delegation = %s", got)
+	}
+	if config.RootAccount.ZoneID != "Z1" || config.RootAccount.DelegationRoleArn == "" ||
+		config.DelegatedZones[0].ZoneID != "Z2" || config.DelegatedZones[0].Status != "active" || len(config.DelegatedZones[1].NSRecords) != 2 {
+		t.Errorf("config = %+v", config)
+	}
+	if complete := events[len(events)-1]; complete.Data["ds_record"] == nil {
+		t.Errorf("complete event has no DS record: %+v", complete)
+	}
+
+	// A second run finds everything in place
+	fake.calls, fake.delegations, events = nil, map[string]string{}, nil
+	if _, err := runHeadlessDNSSetup(headlessSetupSpec(), fake.saved, fake.ops(), func(e DNSSetupEvent) { events = append(events, e) }); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.calls, "; "); got != "role trusts 222222222222" {
+		t.Errorf("rerun calls = %s", got)
+	}
+	if len(fake.delegations) != 0 {
+		t.Errorf("rerun delegated again: %v", fake.delegations)
+	}
+	for _, line := range eventLog(events) {
+		if strings.HasPrefix(line, "root_zone") || strings.HasPrefix(line, "child_zone") || strings.HasPrefix(line, "ns_delegation") {
+			if !strings.HasSuffix(line, dnsSetupSkipped) {
+				t.Errorf("rerun event %q, want skipped", line)
+			}
+		}
+	}
+}
+
+func TestRunHeadlessDNSSetupResume(t *testing.T) {
+	fake := newFakeDNSSetupAWS()
+	fake.failNS = errors.New("AccessDenied")
+	var events []DNSSetupEvent
+	_, err := runHeadlessDNSSetup(headlessSetupSpec(), nil, fake.ops(), func(e DNSSetupEvent) { events = append(events, e) })
+	if err == nil || !strings.Contains(err.Error(), "ns_delegation dev.example.com: AccessDenied") {
+		t.Fatalf("err = %v", err)
+	}
+	if last := events[len(events)-1]; last.Status != dnsSetupFailed || last.Message != "AccessDenied" {
+		t.Errorf("last event = %+v", last)
+	}
+	// The zone created before the failure is recorded
+	if zone := findDelegatedZone(fake.saved, "dev.example.com"); zone == nil || zone.ZoneID != "Z2" || zone.Status == "active" {
+		t.Fatalf("saved dev zone = %+v", zone)
+	}
+
+	fake.failNS, fake.calls = nil, nil
+	config, err := runHeadlessDNSSetup(headlessSetupSpec(), fake.saved, fake.ops(), func(DNSSetupEvent) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fake.calls, "; "); got != "role trusts 222222222222; create staging.example.com as profile-111111111111" {
+		t.Errorf("resume calls = %s", got)
+	}
+	if fake.delegations["dev.example.com"] == "" || config.DelegatedZones[0].Status != "active" {
+		t.Errorf("dev.example.com was not delegated on resume: %+v", config.DelegatedZones[0])
+	}
+}
+
+func TestValidateDNSSetupSpec(t *testing.T) {
+	tests := map[string]func(*DNSConfig){
+		"root_domain":           func(c *DNSConfig) { c.RootDomain = "not a domain" },
+		"not an AWS account ID": func(c *DNSConfig) { c.RootAccount.AccountID = "prod" },
+		"not a subdomain":       func(c *DNSConfig) { c.DelegatedZones[0].Subdomain = "dev.example.org" },
+		"listed twice":          func(c *DNSConfig) { c.DelegatedZones[1].Subdomain = "DEV.example.com" },
+	}
+	for want, modify := range tests {
+		spec := headlessSetupSpec()
+		modify(spec)
+		if err := validateDNSSetupSpec(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validateDNSSetupSpec() = %v, want error containing %q", err, want)
+		}
+	}
+	if err := validateDNSSetupSpec(headlessSetupSpec()); err != nil {
+		t.Errorf("valid spec: %v", err)
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -86,6 +85,8 @@ type DNSSetupModel struct {
 	isViewingExisting     bool     // Whether we're viewing existing config (not creating new)
 	dnsDebugScrollOffset  int      // Scroll offset for DNS debug window
 	showDNSDebugLog       bool     // Toggle to show/hide DNS debug log
+	enableDNSSEC          bool     // Whether to sign the root zone when creating it
+	dsRecord              string   // DS record to add at the registrar for a signed zone
 }
 
 type DNSEnvironment struct {
@@ -959,6 +960,11 @@ func (m DNSSetupModel) viewDisplayNameservers() string {
 	}
 	b.WriteString(nsBoxStyle.Render(nsList.String()) + "\n\n")
 
+	if m.dsRecord != "" {
+		b.WriteString(headerStyle.Render("DNSSEC is enabled, also add this DS record at your registrar:") + "\n")
+		b.WriteString(nsStyle.Render(m.dsRecord) + "\n\n")
+	}
+
 	// Show copy status
 	if m.copiedNSIndex > 0 && m.copiedNSTimer > 0 {
 		b.WriteString(successStyle.Render(fmt.Sprintf("✅ Copied nameserver %d to clipboard!", m.copiedNSIndex)) + "\n\n")
@@ -1066,16 +1072,12 @@ func (m DNSSetupModel) viewWaitPropagation() string {
 	b.WriteString(fmt.Sprintf("Checking DNS propagation for %s\n\n", m.rootDomain))
 	b.WriteString("DNS Servers Status:\n")
 
-	servers := map[string]string{
-		"Google DNS (8.8.8.8)":     "8.8.8.8",
-		"Cloudflare (1.1.1.1)":     "1.1.1.1",
-		"Quad9 (9.9.9.9)":          "9.9.9.9",
-		"OpenDNS (208.67.222.222)": "208.67.222.222",
-	}
+	servers := propagationResolvers()
 
 	successCount := 0
-	for name, ip := range servers {
-		if status, ok := m.propagationStatus[ip]; ok {
+	for _, server := range servers {
+		name := fmt.Sprintf("%s (%s)", server.Name, server.Address)
+		if status, ok := m.propagationStatus[server.Address]; ok {
 			if status {
 				b.WriteString(fmt.Sprintf("✓ %s\n", name))
 				successCount++
@@ -2063,6 +2065,9 @@ func (m DNSSetupModel) handleDNSOperation(msg dnsOperationMsg) (DNSSetupModel, t
 		// Sort nameservers to ensure consistent ordering
 		sort.Strings(nameservers)
 		m.nameservers = nameservers
+		if dsRecord, ok := data["dsRecord"].(string); ok {
+			m.dsRecord = dsRecord
+		}
 
 		// Update progress - DNS zone created (only if array is initialized)
 		if len(m.setupStepStatus) > 2 {
@@ -2652,7 +2657,7 @@ func (m DNSSetupModel) createRootZone() tea.Cmd {
 		}
 
 		// Actually create the hosted zone in AWS Route53
-		zoneID, nameservers, err := createHostedZone(profile, m.rootDomain)
+		zoneID, nameservers, err := createHostedZone(profile, m.rootDomain, m.enableDNSSEC)
 		if err != nil {
 			return dnsOperationMsg{Type: "create_zone", Success: false, Error: err}
 		}
@@ -2662,6 +2667,15 @@ func (m DNSSetupModel) createRootZone() tea.Cmd {
 			"nameservers": nameservers,
 		}
 
+		// The registrar needs the DS record to complete the chain of trust
+		if m.enableDNSSEC {
+			dsRecord, err := getZoneDSRecord(profile, zoneID)
+			if err != nil {
+				return dnsOperationMsg{Type: "create_zone", Success: false, Error: err}
+			}
+			data["dsRecord"] = dsRecord
+		}
+
 		return dnsOperationMsg{Type: "create_zone", Success: true, Data: data}
 	}
 }
@@ -2853,9 +2867,7 @@ func (m DNSSetupModel) checkIAMPermissions() tea.Cmd {
 		delegationRoleArn := m.delegationRoleArn
 		if delegationRoleArn == "" && m.selectedProfile != "" {
 			// Try to get the existing delegation role
-			cfg, _ := config.LoadDefaultConfig(context.Background(),
-				config.WithSharedConfigProfile(m.selectedProfile),
-			)
+			cfg, _ := loadAWSConfigForProfile(context.Background(), m.selectedProfile)
 			iamClient := iam.NewFromConfig(cfg)
 			getRoleResp, err := iamClient.GetRole(context.Background(), &iam.GetRoleInput{
 				RoleName: aws.String("dns-delegation-role"),
@@ -2992,9 +3004,7 @@ func (m DNSSetupModel) fixIAMPermissions() tea.Cmd {
 func getTrustedAccountsFromRole(profile, roleArn string) []string {
 	ctx := context.Background()
 	
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-	)
+	cfg, err := loadAWSConfigForProfile(ctx, profile)
 	if err != nil {
 		return []string{}
 	}
@@ -3139,6 +3149,14 @@ func (m DNSSetupModel) saveConfiguration() tea.Cmd {
 				DelegationRoleArn: m.delegationRoleArn,
 			},
 			DelegatedZones: []DelegatedZone{},
+			DNSSEC:         m.enableDNSSEC,
+		}
+
+		// Keep the settings that are not part of the wizard
+		if existing, err := loadDNSConfig(); err == nil && existing != nil {
+			config.Records = existing.Records
+			config.Resolvers = existing.Resolvers
+			config.DNSSEC = config.DNSSEC || existing.DNSSEC
 		}
 
 		// Delegated zones are now created by Terraform, not by the DNS setup tool
@@ -3480,9 +3498,14 @@ func propagateRootZoneInfo(dnsConfig *DNSConfig) error {
 	return nil
 }
 
-func runDNSSetupWizard() {
+func runDNSSetupWizard(enableDNSSEC bool) {
+	if existing, err := loadDNSConfig(); err == nil && existing != nil && existing.DNSSEC {
+		enableDNSSEC = true
+	}
 	for {
-		p := tea.NewProgram(NewDNSSetupModel(), tea.WithAltScreen())
+		model := NewDNSSetupModel()
+		model.enableDNSSEC = enableDNSSEC
+		p := tea.NewProgram(model, tea.WithAltScreen())
 		finalModel, err := p.Run()
 		if err != nil {
 			fmt.Printf("Error running DNS setup wizard: %v\n", err)
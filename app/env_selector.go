@@ -66,7 +66,7 @@ func selectEnvironment() error {
 
 	if selected == "dns-setup" {
 		// Run DNS setup wizard
-		runDNSSetupWizard()
+		runDNSSetupWizard(false)
 		// After DNS setup, return to environment selection
 		return selectEnvironment()
 	}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// anthropicProvider talks to the Anthropic Messages API
+type anthropicProvider struct {
+	client *anthropic.Client
+	model  string
+}
+
+func newAnthropicProvider(apiKey, baseURL, model string) *anthropicProvider {
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	client := anthropic.NewClient(opts...)
+	return &anthropicProvider{client: &client, model: model}
+}
+
+func (p *anthropicProvider) Name() string { return LLMProviderAnthropic }
+
+func (p *anthropicProvider) params(req LLMRequest) anthropic.MessageNewParams {
+	model := p.model
+	if req.Model != "" {
+		model = req.Model
+	}
+
+	messages := make([]anthropic.MessageParam, 0, len(req.Messages))
+	for i, m := range req.Messages {
+		switch m.Role {
+		case "assistant":
+			var blocks []anthropic.ContentBlockParamUnion
+			if m.Content != "" {
+				blocks = append(blocks, anthropic.NewTextBlock(m.Content))
+			}
+			for _, call := range m.ToolCalls {
+				blocks = append(blocks, anthropic.NewToolUseBlock(call.ID, toolInput(call.Input), call.Name))
+			}
+			messages = append(messages, anthropic.NewAssistantMessage(blocks...))
+		case "tool":
+			// Results for parallel tool calls must share a single user turn
+			block := anthropic.NewToolResultBlock(m.ToolCallID, m.Content, m.IsError)
+			if i > 0 && req.Messages[i-1].Role == "tool" {
+				last := &messages[len(messages)-1]
+				last.Content = append(last.Content, block)
+				continue
+			}
+			messages = append(messages, anthropic.NewUserMessage(block))
+		default:
+			messages = append(messages, anthropic.NewUserMessage(anthropic.NewTextBlock(m.Content)))
+		}
+	}
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: int64(req.MaxTokens),
+		Messages:  messages,
+	}
+	if req.System != "" {
+		params.System = []anthropic.TextBlockParam{{Text: req.System}}
+	}
+	for _, tool := range req.Tools {
+		schema := anthropic.ToolInputSchemaParam{Properties: tool.InputSchema["properties"]}
+		if required, ok := tool.InputSchema["required"].([]string); ok {
+			schema.Required = required
+		}
+		params.Tools = append(params.Tools, anthropic.ToolUnionParam{OfTool: &anthropic.ToolParam{
+			Name:        tool.Name,
+			Description: anthropic.String(tool.Description),
+			InputSchema: schema,
+		}})
+	}
+	return params
+}
+
+// toolInput returns a tool call's arguments, defaulting to an empty object
+func toolInput(input json.RawMessage) json.RawMessage {
+	if len(input) == 0 {
+		return json.RawMessage("{}")
+	}
+	return input
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	message, err := p.client.Messages.New(ctx, p.params(req))
+	if err != nil {
+		return nil, fmt.Errorf("anthropic API call failed: %w", err)
+	}
+	return anthropicResponse(message)
+}
+
+func (p *anthropicProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	stream := p.client.Messages.NewStreaming(ctx, p.params(req))
+	defer stream.Close()
+
+	message := anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("anthropic stream failed: %w", err)
+		}
+		if delta, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent); ok && delta.Delta.Text != "" {
+			onDelta(delta.Delta.Text)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("anthropic API call failed: %w", err)
+	}
+	return anthropicResponse(&message)
+}
+
+func anthropicResponse(message *anthropic.Message) (*LLMResponse, error) {
+	var text string
+	var toolCalls []LLMToolCall
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			text += block.Text
+		case "tool_use":
+			toolCalls = append(toolCalls, LLMToolCall{ID: block.ID, Name: block.Name, Input: block.Input})
+		}
+	}
+	if text == "" && len(toolCalls) == 0 {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+	return &LLMResponse{
+		Text:         text,
+		ToolCalls:    toolCalls,
+		Model:        string(message.Model),
+		StopReason:   string(message.StopReason),
+		InputTokens:  int(message.Usage.InputTokens),
+		OutputTokens: int(message.Usage.OutputTokens),
+	}, nil
+}
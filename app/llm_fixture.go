@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LLMFixture is a recorded conversation with an LLM, replayed by the fixture provider
+type LLMFixture struct {
+	Exchanges []LLMExchange `json:"exchanges"`
+}
+
+// LLMExchange is one request/response pair. When Match is set, replay fails
+// unless the request's last message contains it.
+type LLMExchange struct {
+	Match    string      `json:"match,omitempty"`
+	Request  *LLMRequest `json:"request,omitempty"`
+	Response LLMResponse `json:"response"`
+}
+
+// fixtureProvider replays recorded responses in order
+type fixtureProvider struct {
+	mu        sync.Mutex
+	exchanges []LLMExchange
+	next      int
+}
+
+// newFixtureProvider creates a provider that returns the given responses in order
+func newFixtureProvider(exchanges ...LLMExchange) *fixtureProvider {
+	return &fixtureProvider{exchanges: exchanges}
+}
+
+func loadLLMFixture(path string) (*fixtureProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading LLM fixture %s: %w", path, err)
+	}
+	var fixture LLMFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("error parsing LLM fixture %s: %w", path, err)
+	}
+	return newFixtureProvider(fixture.Exchanges...), nil
+}
+
+func (p *fixtureProvider) Name() string { return LLMProviderFixture }
+
+func (p *fixtureProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.next >= len(p.exchanges) {
+		return nil, fmt.Errorf("LLM fixture exhausted after %d responses", len(p.exchanges))
+	}
+	exchange := p.exchanges[p.next]
+	p.next++
+
+	if exchange.Match != "" {
+		last := ""
+		if len(req.Messages) > 0 {
+			last = req.Messages[len(req.Messages)-1].Content
+		}
+		if !strings.Contains(last, exchange.Match) {
+			return nil, fmt.Errorf("LLM fixture response %d expects a prompt containing %q", p.next, exchange.Match)
+		}
+	}
+
+	response := exchange.Response
+	return &response, nil
+}
+
+func (p *fixtureProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	response, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	onDelta(response.Text)
+	return response, nil
+}
+
+// remaining returns how many recorded responses have not been used
+func (p *fixtureProvider) remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.exchanges) - p.next
+}
+
+// recordingProvider wraps a real provider and appends every exchange to a fixture
+// file, so a live session can later be replayed with the fixture provider
+type recordingProvider struct {
+	LLMProvider
+	mu   sync.Mutex
+	path string
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	response, err := p.LLMProvider.Complete(ctx, req)
+	if err == nil {
+		p.record(req, response)
+	}
+	return response, err
+}
+
+func (p *recordingProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	response, err := p.LLMProvider.Stream(ctx, req, onDelta)
+	if err == nil {
+		p.record(req, response)
+	}
+	return response, err
+}
+
+func (p *recordingProvider) record(req LLMRequest, response *LLMResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var fixture LLMFixture
+	if data, err := os.ReadFile(p.path); err == nil {
+		json.Unmarshal(data, &fixture)
+	}
+	fixture.Exchanges = append(fixture.Exchanges, LLMExchange{Request: &req, Response: *response})
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(p.path, data, 0o600)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ollamaProvider talks to a local (or self-hosted) Ollama server, so nothing leaves the machine
+type ollamaProvider struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+func newOllamaProvider(baseURL, model string) *ollamaProvider {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	return &ollamaProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		httpClient: &http.Client{},
+	}
+}
+
+func (p *ollamaProvider) Name() string { return LLMProviderOllama }
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"` // Same function schema as OpenAI
+	Stream   bool            `json:"stream"`
+	Options  map[string]int  `json:"options,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// ollamaToolCall carries arguments as a JSON object and has no call ID
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChatResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content   string           `json:"content"`
+		ToolCalls []ollamaToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+func (p *ollamaProvider) do(ctx context.Context, req LLMRequest, stream bool) (*http.Response, error) {
+	model := p.model
+	if req.Model != "" {
+		model = req.Model
+	}
+
+	messages := make([]ollamaMessage, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, ollamaMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		message := ollamaMessage{Role: m.Role, Content: m.Content, ToolName: m.ToolName}
+		for _, call := range m.ToolCalls {
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = toolInput(call.Input)
+			message.ToolCalls = append(message.ToolCalls, toolCall)
+		}
+		messages = append(messages, message)
+	}
+
+	body := ollamaChatRequest{Model: model, Messages: messages, Tools: openAITools(req.Tools), Stream: stream}
+	if req.MaxTokens > 0 {
+		body.Options = map[string]int{"num_predict": req.MaxTokens}
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama API call failed (is ollama running at %s?): %w", p.baseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return resp, nil
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	resp, err := p.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chat ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	if chat.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", chat.Error)
+	}
+	toolCalls := ollamaToolCalls(chat.Message.ToolCalls, 0)
+	if chat.Message.Content == "" && len(toolCalls) == 0 {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+
+	return &LLMResponse{
+		Text:         chat.Message.Content,
+		ToolCalls:    toolCalls,
+		Model:        chat.Model,
+		StopReason:   chat.DoneReason,
+		InputTokens:  chat.PromptEvalCount,
+		OutputTokens: chat.EvalCount,
+	}, nil
+}
+
+// Stream reads Ollama's newline-delimited JSON stream
+func (p *ollamaProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	resp, err := p.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &LLMResponse{}
+	var text strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		result.ToolCalls = append(result.ToolCalls, ollamaToolCalls(chunk.Message.ToolCalls, len(result.ToolCalls))...)
+		if chunk.Done {
+			result.Model = chunk.Model
+			result.StopReason = chunk.DoneReason
+			result.InputTokens = chunk.PromptEvalCount
+			result.OutputTokens = chunk.EvalCount
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stream: %w", err)
+	}
+
+	result.Text = text.String()
+	if result.Text == "" && len(result.ToolCalls) == 0 {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+	return result, nil
+}
+
+// ollamaToolCalls converts Ollama tool calls, numbering IDs from offset since Ollama doesn't assign any
+func ollamaToolCalls(calls []ollamaToolCall, offset int) []LLMToolCall {
+	var result []LLMToolCall
+	for i, call := range calls {
+		result = append(result, LLMToolCall{
+			ID:    fmt.Sprintf("call_%d", offset+i),
+			Name:  call.Function.Name,
+			Input: call.Function.Arguments,
+		})
+	}
+	return result
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openAIProvider talks to OpenAI-compatible chat completion APIs: OpenAI itself,
+// Azure OpenAI (when apiVersion is set) and self-hosted gateways such as vLLM or LiteLLM
+type openAIProvider struct {
+	apiKey     string
+	baseURL    string
+	apiVersion string
+	model      string
+	httpClient *http.Client
+}
+
+func newOpenAIProvider(apiKey, baseURL, apiVersion, model string) *openAIProvider {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &openAIProvider{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiVersion: apiVersion,
+		model:      model,
+		httpClient: &http.Client{},
+	}
+}
+
+func (p *openAIProvider) Name() string { return LLMProviderOpenAI }
+
+type openAIChatRequest struct {
+	Model         string              `json:"model,omitempty"`
+	Messages      []openAIMessage     `json:"messages"`
+	Tools         []openAITool        `json:"tools,omitempty"`
+	MaxTokens     int                 `json:"max_tokens,omitempty"`
+	Stream        bool                `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOption `json:"stream_options,omitempty"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    int    `json:"index"` // Identifies the call across streamed chunks
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIStreamOption struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"message"`
+		Delta struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// endpoint returns the chat completions URL. Azure routes by deployment name
+// (the model) and requires an api-version query parameter.
+func (p *openAIProvider) endpoint(model string) string {
+	if p.apiVersion != "" {
+		return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			p.baseURL, url.PathEscape(model), url.QueryEscape(p.apiVersion))
+	}
+	return p.baseURL + "/chat/completions"
+}
+
+func (p *openAIProvider) do(ctx context.Context, req LLMRequest, stream bool) (*http.Response, string, error) {
+	model := p.model
+	if req.Model != "" {
+		model = req.Model
+	}
+
+	body := openAIChatRequest{
+		Messages:  openAIMessages(req),
+		Tools:     openAITools(req.Tools),
+		MaxTokens: req.MaxTokens,
+		Stream:    stream,
+	}
+	if p.apiVersion == "" {
+		body.Model = model
+	}
+	if stream {
+		body.StreamOptions = &openAIStreamOption{IncludeUsage: true}
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint(model), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		if p.apiVersion != "" {
+			httpReq.Header.Set("api-key", p.apiKey)
+		} else {
+			httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+		}
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("openai-compatible API call failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, "", fmt.Errorf("openai-compatible API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return resp, model, nil
+}
+
+// openAIMessages converts the conversation to chat completion messages,
+// with the system prompt as the first message
+func openAIMessages(req LLMRequest) []openAIMessage {
+	messages := make([]openAIMessage, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		message := openAIMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		for i, call := range m.ToolCalls {
+			toolCall := openAIToolCall{Index: i, ID: call.ID, Type: "function"}
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = string(toolInput(call.Input))
+			message.ToolCalls = append(message.ToolCalls, toolCall)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func openAITools(tools []LLMTool) []openAITool {
+	var result []openAITool
+	for _, tool := range tools {
+		t := openAITool{Type: "function"}
+		t.Function.Name = tool.Name
+		t.Function.Description = tool.Description
+		t.Function.Parameters = tool.InputSchema
+		result = append(result, t)
+	}
+	return result
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	resp, model, err := p.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chat openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+	choice := chat.Choices[0]
+	if choice.Message.Content == "" && len(choice.Message.ToolCalls) == 0 {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+
+	result := &LLMResponse{
+		Text:       choice.Message.Content,
+		Model:      model,
+		StopReason: choice.FinishReason,
+	}
+	for _, call := range choice.Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, LLMToolCall{
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(call.Function.Arguments),
+		})
+	}
+	if chat.Model != "" {
+		result.Model = chat.Model
+	}
+	if chat.Usage != nil {
+		result.InputTokens = chat.Usage.PromptTokens
+		result.OutputTokens = chat.Usage.CompletionTokens
+	}
+	return result, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	resp, model, err := p.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &LLMResponse{Model: model}
+	var text strings.Builder
+	// Tool call arguments arrive in fragments, keyed by the call's index
+	var calls []*openAIToolCall
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.InputTokens = chunk.Usage.PromptTokens
+			result.OutputTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+			for _, delta := range choice.Delta.ToolCalls {
+				for len(calls) <= delta.Index {
+					calls = append(calls, &openAIToolCall{})
+				}
+				call := calls[delta.Index]
+				if delta.ID != "" {
+					call.ID = delta.ID
+				}
+				if delta.Function.Name != "" {
+					call.Function.Name = delta.Function.Name
+				}
+				call.Function.Arguments += delta.Function.Arguments
+			}
+			if choice.FinishReason != "" {
+				result.StopReason = choice.FinishReason
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stream: %w", err)
+	}
+
+	result.Text = text.String()
+	for _, call := range calls {
+		result.ToolCalls = append(result.ToolCalls, LLMToolCall{
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(call.Function.Arguments),
+		})
+	}
+	if result.Text == "" && len(result.ToolCalls) == 0 {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+	return result, nil
+}
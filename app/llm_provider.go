@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LLMConfigFile is the optional project-level AI configuration (next to dns.yaml and the env yamls)
+const LLMConfigFile = "llm.yaml"
+
+// LLM providers
+const (
+	LLMProviderAnthropic = "anthropic"
+	LLMProviderOpenAI    = "openai" // OpenAI-compatible: OpenAI, Azure OpenAI, vLLM, LiteLLM and other gateways
+	LLMProviderOllama    = "ollama"
+	LLMProviderFixture   = "fixture" // Replays recorded responses, used by tests
+)
+
+// AI features that can use a different model each
+const (
+	LLMFeatureAgent         = "agent"
+	LLMFeatureErrorHelper   = "error_helper"
+	LLMFeatureSSOAgent      = "sso_agent"
+	LLMFeatureVisualization = "visualization"
+)
+
+// defaultLLMModels are used when neither llm.yaml nor MEROKU_LLM_MODEL set a model
+var defaultLLMModels = map[string]string{
+	LLMProviderAnthropic: "claude-sonnet-4-5-20250929",
+	LLMProviderOpenAI:    "gpt-4o",
+	LLMProviderOllama:    "llama3.1",
+}
+
+// LLMConfig selects and configures the LLM provider used by all AI features.
+// Values come from llm.yaml and can be overridden with MEROKU_LLM_* environment variables.
+type LLMConfig struct {
+	Provider       string            `yaml:"provider"`                  // anthropic (default), openai, ollama or fixture
+	Model          string            `yaml:"model,omitempty"`           // Default model for all features
+	Models         map[string]string `yaml:"models,omitempty"`          // Per-feature overrides: agent, error_helper, sso_agent, visualization
+	BaseURL        string            `yaml:"base_url,omitempty"`        // API endpoint, e.g. https://my-gateway/v1 or an Azure resource URL
+	APIKeyEnv      string            `yaml:"api_key_env,omitempty"`     // Environment variable holding the API key
+	APIVersion     string            `yaml:"api_version,omitempty"`     // Azure OpenAI api-version; enables Azure-style requests
+	TimeoutSeconds int               `yaml:"timeout_seconds,omitempty"` // Overrides the per-feature default timeouts
+	FixtureFile    string            `yaml:"fixture_file,omitempty"`    // Recorded responses for the fixture provider
+	RecordFile     string            `yaml:"record_file,omitempty"`     // Append every exchange to this fixture file
+
+	Prices           map[string]LLMPrice `yaml:"prices,omitempty"`             // USD per million tokens by model prefix, extends the built-in table
+	SessionBudgetUSD float64             `yaml:"session_budget_usd,omitempty"` // Stop an agent session once it has spent this much
+	DailyBudgetUSD   float64             `yaml:"daily_budget_usd,omitempty"`   // Stop all AI features once today's spend reaches this
+	UsageLog         string              `yaml:"usage_log,omitempty"`          // Defaults to .meroku/llm-usage.jsonl
+
+	Redaction LLMRedactionConfig `yaml:"redaction,omitempty"` // Secrets are removed from every prompt unless disabled
+}
+
+// LLMMessage is a single chat turn
+type LLMMessage struct {
+	Role       string        `json:"role"` // "user", "assistant" or "tool"
+	Content    string        `json:"content"`
+	ToolCalls  []LLMToolCall `json:"toolCalls,omitempty"`  // Assistant turns: tools the model asked to run
+	ToolCallID string        `json:"toolCallId,omitempty"` // Tool turns: the call this result answers
+	ToolName   string        `json:"toolName,omitempty"`   // Tool turns: name of the tool that ran
+	IsError    bool          `json:"isError,omitempty"`    // Tool turns: the tool failed
+}
+
+// LLMTool declares a tool the model may call. InputSchema is a JSON schema object.
+type LLMTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// LLMToolCall is a tool invocation requested by the model
+type LLMToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// LLMRequest is a provider-independent completion request
+type LLMRequest struct {
+	System    string        `json:"system,omitempty"`
+	Messages  []LLMMessage  `json:"messages"`
+	Tools     []LLMTool     `json:"tools,omitempty"`
+	MaxTokens int           `json:"maxTokens"`
+	Model     string        `json:"model,omitempty"` // Overrides the provider's model for this call
+	Timeout   time.Duration `json:"-"`               // Default timeout, replaced by timeout_seconds when configured
+}
+
+// LLMResponse is a provider-independent completion result
+type LLMResponse struct {
+	Text         string        `json:"text"`
+	ToolCalls    []LLMToolCall `json:"toolCalls,omitempty"`
+	Model        string        `json:"model,omitempty"`
+	StopReason   string        `json:"stopReason,omitempty"`
+	InputTokens  int           `json:"inputTokens,omitempty"`
+	OutputTokens int           `json:"outputTokens,omitempty"`
+}
+
+// toolResult builds the tool turn answering call
+func toolResult(call LLMToolCall, content string, isError bool) LLMMessage {
+	return LLMMessage{Role: "tool", Content: content, ToolCallID: call.ID, ToolName: call.Name, IsError: isError}
+}
+
+// skippedToolResults answers tool calls that were not executed. Agents run one
+// tool per step, but every call needs a result before the conversation can continue.
+func skippedToolResults(calls []LLMToolCall) []LLMMessage {
+	var messages []LLMMessage
+	for _, call := range calls {
+		messages = append(messages, toolResult(call, "Not executed: only one tool runs per step. Call it again if it is still needed.", true))
+	}
+	return messages
+}
+
+// objectSchema builds a JSON schema for a tool input object
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// stringProperty builds a JSON schema string property, optionally restricted to values
+func stringProperty(description string, values ...string) map[string]interface{} {
+	property := map[string]interface{}{"type": "string", "description": description}
+	if len(values) > 0 {
+		property["enum"] = values
+	}
+	return property
+}
+
+// LLMProvider is implemented by every LLM backend
+type LLMProvider interface {
+	// Name returns the provider name used in logs and error messages
+	Name() string
+	// Complete sends the request and returns the full response
+	Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error)
+	// Stream sends the request and calls onDelta for each chunk of text as it arrives
+	Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error)
+}
+
+// userPrompt builds a single-turn request
+func userPrompt(prompt string, maxTokens int, timeout time.Duration) LLMRequest {
+	return LLMRequest{
+		Messages:  []LLMMessage{{Role: "user", Content: prompt}},
+		MaxTokens: maxTokens,
+		Timeout:   timeout,
+	}
+}
+
+// loadLLMConfig reads llm.yaml (if present) and applies MEROKU_LLM_* overrides
+func loadLLMConfig() (*LLMConfig, error) {
+	config := &LLMConfig{}
+
+	data, err := os.ReadFile(LLMConfigFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading %s: %v", LLMConfigFile, err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", LLMConfigFile, err)
+		}
+	}
+
+	if v := os.Getenv("MEROKU_LLM_PROVIDER"); v != "" {
+		config.Provider = v
+	}
+	if v := os.Getenv("MEROKU_LLM_MODEL"); v != "" {
+		config.Model = v
+	}
+	if v := os.Getenv("MEROKU_LLM_BASE_URL"); v != "" {
+		config.BaseURL = v
+	}
+	if v := os.Getenv("MEROKU_LLM_FIXTURE"); v != "" {
+		config.FixtureFile = v
+	}
+	if v := os.Getenv("MEROKU_LLM_RECORD"); v != "" {
+		config.RecordFile = v
+	}
+	if v := os.Getenv("MEROKU_LLM_TIMEOUT"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("MEROKU_LLM_TIMEOUT must be a positive number of seconds, got %q", v)
+		}
+		config.TimeoutSeconds = seconds
+	}
+
+	for name, target := range map[string]*float64{
+		"MEROKU_LLM_SESSION_BUDGET": &config.SessionBudgetUSD,
+		"MEROKU_LLM_DAILY_BUDGET":   &config.DailyBudgetUSD,
+	} {
+		if v := os.Getenv(name); v != "" {
+			budget, err := strconv.ParseFloat(v, 64)
+			if err != nil || budget < 0 {
+				return nil, fmt.Errorf("%s must be an amount in USD, got %q", name, v)
+			}
+			*target = budget
+		}
+	}
+
+	config.Provider = strings.ToLower(strings.TrimSpace(config.Provider))
+	if config.Provider == "" {
+		config.Provider = LLMProviderAnthropic
+	}
+
+	switch config.Provider {
+	case LLMProviderAnthropic, LLMProviderOpenAI, LLMProviderOllama, LLMProviderFixture:
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (expected anthropic, openai, ollama or fixture)", config.Provider)
+	}
+
+	return config, nil
+}
+
+// modelFor returns the model to use for a feature
+func (c *LLMConfig) modelFor(feature string) string {
+	if model := c.Models[feature]; model != "" {
+		return model
+	}
+	if c.Model != "" {
+		return c.Model
+	}
+	return defaultLLMModels[c.Provider]
+}
+
+// apiKey returns the API key for the configured provider
+func (c *LLMConfig) apiKey() string {
+	if c.APIKeyEnv != "" {
+		return os.Getenv(c.APIKeyEnv)
+	}
+	if key := os.Getenv("MEROKU_LLM_API_KEY"); key != "" {
+		return key
+	}
+	switch c.Provider {
+	case LLMProviderAnthropic:
+		return os.Getenv("ANTHROPIC_API_KEY")
+	case LLMProviderOpenAI:
+		if c.APIVersion != "" {
+			return os.Getenv("AZURE_OPENAI_API_KEY")
+		}
+		return os.Getenv("OPENAI_API_KEY")
+	}
+	return ""
+}
+
+// keyEnvName returns the environment variable users should set for the API key
+func (c *LLMConfig) keyEnvName() string {
+	if c.APIKeyEnv != "" {
+		return c.APIKeyEnv
+	}
+	switch c.Provider {
+	case LLMProviderAnthropic:
+		return "ANTHROPIC_API_KEY"
+	case LLMProviderOpenAI:
+		if c.APIVersion != "" {
+			return "AZURE_OPENAI_API_KEY"
+		}
+		return "OPENAI_API_KEY"
+	}
+	return "MEROKU_LLM_API_KEY"
+}
+
+// timeout returns the configured timeout, or fallback when none is set
+func (c *LLMConfig) timeout(fallback time.Duration) time.Duration {
+	if c.TimeoutSeconds > 0 {
+		return time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return fallback
+}
+
+// validate reports what is missing for the configured provider to work
+func (c *LLMConfig) validate() error {
+	switch c.Provider {
+	case LLMProviderAnthropic:
+		if c.apiKey() == "" {
+			return fmt.Errorf("%s not set", c.keyEnvName())
+		}
+	case LLMProviderOpenAI:
+		// Self-hosted gateways often run without authentication, but api.openai.com and Azure need a key
+		if c.apiKey() == "" && (c.BaseURL == "" || c.APIVersion != "") {
+			return fmt.Errorf("%s not set", c.keyEnvName())
+		}
+		if c.APIVersion != "" && c.BaseURL == "" {
+			return fmt.Errorf("base_url is required for Azure OpenAI")
+		}
+	case LLMProviderFixture:
+		if c.FixtureFile == "" {
+			return fmt.Errorf("fixture_file (or MEROKU_LLM_FIXTURE) is required for the fixture provider")
+		}
+	}
+	return nil
+}
+
+// NewLLMProvider creates the configured provider for an AI feature
+func NewLLMProvider(feature string) (LLMProvider, error) {
+	config, err := loadLLMConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newLLMProviderFromConfig(config, feature)
+}
+
+func newLLMProviderFromConfig(config *LLMConfig, feature string) (LLMProvider, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	var provider LLMProvider
+	switch config.Provider {
+	case LLMProviderAnthropic:
+		provider = newAnthropicProvider(config.apiKey(), config.BaseURL, config.modelFor(feature))
+	case LLMProviderOpenAI:
+		provider = newOpenAIProvider(config.apiKey(), config.BaseURL, config.APIVersion, config.modelFor(feature))
+	case LLMProviderOllama:
+		provider = newOllamaProvider(config.BaseURL, config.modelFor(feature))
+	case LLMProviderFixture:
+		fixture, err := loadLLMFixture(config.FixtureFile)
+		if err != nil {
+			return nil, err
+		}
+		provider = fixture
+	}
+
+	if config.RecordFile != "" && config.Provider != LLMProviderFixture {
+		provider = &recordingProvider{LLMProvider: provider, path: config.RecordFile}
+	}
+
+	provider = &timeoutProvider{LLMProvider: provider, config: config}
+	if !config.Redaction.Disabled {
+		redactor, err := newRedactor(config.Redaction, feature)
+		if err != nil {
+			return nil, err
+		}
+		provider = &redactingProvider{LLMProvider: provider, redactor: redactor}
+	}
+	return &usageProvider{LLMProvider: provider, config: config, feature: feature, model: config.modelFor(feature)}, nil
+}
+
+// timeoutProvider applies the configured (or per-request default) timeout to every call
+type timeoutProvider struct {
+	LLMProvider
+	config *LLMConfig
+}
+
+func (p *timeoutProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	ctx, cancel := p.withTimeout(ctx, req)
+	defer cancel()
+	return p.LLMProvider.Complete(ctx, req)
+}
+
+func (p *timeoutProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	ctx, cancel := p.withTimeout(ctx, req)
+	defer cancel()
+	return p.LLMProvider.Stream(ctx, req, onDelta)
+}
+
+func (p *timeoutProvider) withTimeout(ctx context.Context, req LLMRequest) (context.Context, context.CancelFunc) {
+	timeout := p.config.timeout(req.Timeout)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// isLLMConfigured reports whether the configured provider has everything it needs
+func isLLMConfigured() bool {
+	return llmConfigError() == nil
+}
+
+// llmSetupSteps explains why AI features are unavailable with the configured
+// provider and lists what the user can do about it. It returns an empty problem
+// when the provider is ready.
+func llmSetupSteps() (problem string, steps []string) {
+	config, err := loadLLMConfig()
+	if err != nil {
+		return err.Error(), []string{
+			fmt.Sprintf("Fix %s or MEROKU_LLM_PROVIDER (providers: anthropic, openai, ollama)", LLMConfigFile),
+		}
+	}
+	err = config.validate()
+	if err == nil {
+		return "", nil
+	}
+
+	problem = fmt.Sprintf("%s provider: %v", config.Provider, err)
+	switch {
+	case config.Provider == LLMProviderFixture:
+		steps = append(steps, fmt.Sprintf("Set fixture_file in %s or export MEROKU_LLM_FIXTURE", LLMConfigFile))
+	case config.Provider == LLMProviderOpenAI && config.APIVersion != "" && config.BaseURL == "":
+		steps = append(steps, fmt.Sprintf("Set base_url in %s to your Azure OpenAI resource URL", LLMConfigFile))
+	default:
+		steps = append(steps, fmt.Sprintf("export %s=your_key_here", config.keyEnvName()))
+		if config.Provider == LLMProviderAnthropic && config.BaseURL == "" {
+			steps = append(steps, "Get a key at https://console.anthropic.com/settings/keys")
+		}
+	}
+	steps = append(steps, fmt.Sprintf("Or choose another provider (anthropic, openai, ollama) in %s or with MEROKU_LLM_PROVIDER", LLMConfigFile))
+	return problem, steps
+}
+
+// printLLMSetupHelp prints why AI features are unavailable and how to enable them
+func printLLMSetupHelp(feature string) {
+	problem, steps := llmSetupSteps()
+	fmt.Printf("\n⚠️  %s requires a configured LLM provider (%s)\n", feature, problem)
+	for _, step := range steps {
+		fmt.Printf("   %s\n", step)
+	}
+}
+
+// llmConfigError explains why AI features are unavailable, or returns nil
+func llmConfigError() error {
+	config, err := loadLLMConfig()
+	if err != nil {
+		return err
+	}
+	return config.validate()
+}
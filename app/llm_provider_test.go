@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chdirTemp runs the test in an empty directory so no llm.yaml is picked up
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func clearLLMEnv(t *testing.T) {
+	for _, name := range []string{
+		"MEROKU_LLM_PROVIDER", "MEROKU_LLM_MODEL", "MEROKU_LLM_BASE_URL", "MEROKU_LLM_API_KEY",
+		"MEROKU_LLM_FIXTURE", "MEROKU_LLM_RECORD", "MEROKU_LLM_TIMEOUT", "MEROKU_LLM_SESSION_BUDGET", "MEROKU_LLM_DAILY_BUDGET",
+		"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "AZURE_OPENAI_API_KEY",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadLLMConfig(t *testing.T) {
+	chdirTemp(t)
+	clearLLMEnv(t)
+
+	config, err := loadLLMConfig()
+	if err != nil {
+		t.Fatalf("loadLLMConfig() error = %v", err)
+	}
+	if config.Provider != LLMProviderAnthropic {
+		t.Errorf("default provider = %q, want anthropic", config.Provider)
+	}
+	if err := config.validate(); err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Errorf("validate() without key = %v, want ANTHROPIC_API_KEY error", err)
+	}
+
+	yamlConfig := `provider: openai
+base_url: https://gateway.internal/v1
+model: gpt-4o-mini
+models:
+  visualization: gpt-4o
+timeout_seconds: 90
+`
+	if err := os.WriteFile(LLMConfigFile, []byte(yamlConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = loadLLMConfig()
+	if err != nil {
+		t.Fatalf("loadLLMConfig() error = %v", err)
+	}
+	if config.Provider != LLMProviderOpenAI || config.BaseURL != "https://gateway.internal/v1" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if got := config.modelFor(LLMFeatureAgent); got != "gpt-4o-mini" {
+		t.Errorf("modelFor(agent) = %q, want gpt-4o-mini", got)
+	}
+	if got := config.modelFor(LLMFeatureVisualization); got != "gpt-4o" {
+		t.Errorf("modelFor(visualization) = %q, want gpt-4o", got)
+	}
+	if got := config.timeout(30 * time.Second); got != 90*time.Second {
+		t.Errorf("timeout() = %v, want 90s", got)
+	}
+	// Self-hosted gateways may run without a key
+	if err := config.validate(); err != nil {
+		t.Errorf("validate() for keyless gateway = %v", err)
+	}
+
+	t.Setenv("MEROKU_LLM_PROVIDER", "ollama")
+	t.Setenv("MEROKU_LLM_MODEL", "qwen2.5")
+	config, err = loadLLMConfig()
+	if err != nil {
+		t.Fatalf("loadLLMConfig() error = %v", err)
+	}
+	if config.Provider != LLMProviderOllama || config.modelFor(LLMFeatureAgent) != "qwen2.5" {
+		t.Errorf("environment overrides not applied: %+v", config)
+	}
+
+	t.Setenv("MEROKU_LLM_PROVIDER", "bedrock")
+	if _, err := loadLLMConfig(); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	var gotPath, gotAuth, gotAPIKey string
+	var gotBody openAIChatRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.String()
+		gotAuth = r.Header.Get("Authorization")
+		gotAPIKey = r.Header.Get("api-key")
+		gotBody = openAIChatRequest{}
+		json.NewDecoder(r.Body).Decode(&gotBody)
+
+		if gotBody.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintln(w, `data: {"choices":[{"delta":{"content":"Hel"}}]}`)
+			fmt.Fprintln(w, `data: {"choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}`)
+			fmt.Fprintln(w, `data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":2}}`)
+			fmt.Fprintln(w, `data: [DONE]`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"Hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":7,"completion_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	provider := newOpenAIProvider("secret", server.URL+"/v1", "", "gpt-4o-mini")
+	req := userPrompt("hi", 100, 0)
+	req.System = "be brief"
+
+	resp, err := provider.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello" || resp.InputTokens != 7 || resp.OutputTokens != 2 {
+		t.Errorf("Complete() = %+v", resp)
+	}
+	if gotPath != "/v1/chat/completions" || gotAuth != "Bearer secret" {
+		t.Errorf("request path %q auth %q", gotPath, gotAuth)
+	}
+	if len(gotBody.Messages) != 2 || gotBody.Messages[0].Role != "system" || gotBody.Model != "gpt-4o-mini" {
+		t.Errorf("unexpected request body: %+v", gotBody)
+	}
+
+	var deltas []string
+	resp, err = provider.Stream(context.Background(), req, func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if resp.Text != "Hello" || len(deltas) != 2 || resp.OutputTokens != 2 || resp.StopReason != "stop" {
+		t.Errorf("Stream() = %+v, deltas %v", resp, deltas)
+	}
+
+	azure := newOpenAIProvider("azure-key", server.URL, "2024-06-01", "my-deployment")
+	if _, err := azure.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Azure Complete() error = %v", err)
+	}
+	if gotPath != "/openai/deployments/my-deployment/chat/completions?api-version=2024-06-01" {
+		t.Errorf("Azure path = %q", gotPath)
+	}
+	if gotAPIKey != "azure-key" || gotAuth != "" || gotBody.Model != "" {
+		t.Errorf("Azure request used api-key %q auth %q model %q", gotAPIKey, gotAuth, gotBody.Model)
+	}
+}
+
+func TestOllamaProvider(t *testing.T) {
+	var gotBody ollamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		gotBody = ollamaChatRequest{}
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody.Stream {
+			fmt.Fprintln(w, `{"model":"llama3.1","message":{"content":"Hel"},"done":false}`)
+			fmt.Fprintln(w, `{"model":"llama3.1","message":{"content":"lo"},"done":false}`)
+			fmt.Fprintln(w, `{"model":"llama3.1","message":{"content":""},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":2}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"llama3.1","message":{"content":"Hello"},"done":true,"prompt_eval_count":5,"eval_count":2}`)
+	}))
+	defer server.Close()
+
+	provider := newOllamaProvider(server.URL, "llama3.1")
+
+	resp, err := provider.Complete(context.Background(), userPrompt("hi", 64, 0))
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello" || resp.InputTokens != 5 || gotBody.Options["num_predict"] != 64 {
+		t.Errorf("Complete() = %+v, request %+v", resp, gotBody)
+	}
+
+	var streamed strings.Builder
+	resp, err = provider.Stream(context.Background(), userPrompt("hi", 64, 0), func(d string) { streamed.WriteString(d) })
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if resp.Text != "Hello" || streamed.String() != "Hello" || resp.OutputTokens != 2 {
+		t.Errorf("Stream() = %+v", resp)
+	}
+}
+
+func TestAnthropicProvider(t *testing.T) {
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-test","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","usage":{"input_tokens":9,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	provider := newAnthropicProvider("key", server.URL, "claude-test")
+	req := userPrompt("hi", 128, 0)
+	req.System = "be brief"
+
+	resp, err := provider.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if resp.Text != "Hello" || resp.InputTokens != 9 || resp.StopReason != "end_turn" {
+		t.Errorf("Complete() = %+v", resp)
+	}
+	if gotBody["model"] != "claude-test" || gotBody["system"] == nil {
+		t.Errorf("unexpected request body: %v", gotBody)
+	}
+}
+
+func TestFixtureProvider(t *testing.T) {
+	provider := newFixtureProvider(
+		LLMExchange{Match: "first", Response: LLMResponse{Text: "one"}},
+		LLMExchange{Response: LLMResponse{Text: "two"}},
+	)
+
+	resp, err := provider.Complete(context.Background(), userPrompt("the first prompt", 10, 0))
+	if err != nil || resp.Text != "one" {
+		t.Fatalf("first response = %v, %v", resp, err)
+	}
+
+	var streamed string
+	resp, err = provider.Stream(context.Background(), userPrompt("anything", 10, 0), func(d string) { streamed += d })
+	if err != nil || resp.Text != "two" || streamed != "two" {
+		t.Fatalf("second response = %v, %v (streamed %q)", resp, err, streamed)
+	}
+
+	if _, err := provider.Complete(context.Background(), userPrompt("more", 10, 0)); err == nil {
+		t.Error("expected error when fixture is exhausted")
+	}
+
+	mismatch := newFixtureProvider(LLMExchange{Match: "expected", Response: LLMResponse{Text: "x"}})
+	if _, err := mismatch.Complete(context.Background(), userPrompt("something else", 10, 0)); err == nil {
+		t.Error("expected error when prompt does not match")
+	}
+}
+
+func TestRecordedFixtureRoundTrip(t *testing.T) {
+	dir := chdirTemp(t)
+	clearLLMEnv(t)
+	path := filepath.Join(dir, "session.json")
+
+	recorder := &recordingProvider{
+		LLMProvider: newFixtureProvider(LLMExchange{Response: LLMResponse{Text: "recorded answer"}}),
+		path:        path,
+	}
+	if _, err := recorder.Complete(context.Background(), userPrompt("question", 10, 0)); err != nil {
+		t.Fatalf("recording Complete() error = %v", err)
+	}
+
+	t.Setenv("MEROKU_LLM_PROVIDER", "fixture")
+	t.Setenv("MEROKU_LLM_FIXTURE", path)
+	provider, err := NewLLMProvider(LLMFeatureAgent)
+	if err != nil {
+		t.Fatalf("NewLLMProvider() error = %v", err)
+	}
+	resp, err := provider.Complete(context.Background(), userPrompt("question", 10, time.Second))
+	if err != nil || resp.Text != "recorded answer" {
+		t.Fatalf("replayed response = %v, %v", resp, err)
+	}
+}
+
+func TestTimeoutProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	provider := &timeoutProvider{
+		LLMProvider: newOllamaProvider(server.URL, "llama3.1"),
+		config:      &LLMConfig{},
+	}
+
+	start := time.Now()
+	_, err := provider.Complete(context.Background(), userPrompt("hi", 10, 50*time.Millisecond))
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("request took %v, timeout was not applied", elapsed)
+	}
+}
+
+// toolConversation is a conversation where the assistant called two tools in one turn
+func toolConversation() LLMRequest {
+	calls := []LLMToolCall{
+		{ID: "call_1", Name: "aws_cli", Input: json.RawMessage(`{"command":"aws ecs list-clusters"}`)},
+		{ID: "call_2", Name: "shell", Input: json.RawMessage(`{"command":"cat dev.yaml"}`)},
+	}
+	req := userPrompt("fix it", 256, 0)
+	req.Tools = agentTools
+	req.Messages = append(req.Messages,
+		LLMMessage{Role: "assistant", Content: "Checking clusters", ToolCalls: calls},
+		toolResult(calls[0], "[]", false),
+		toolResult(calls[1], "denied", true),
+	)
+	return req
+}
+
+func TestAnthropicProviderTools(t *testing.T) {
+	var gotBody struct {
+		Tools    []map[string]interface{} `json:"tools"`
+		Messages []struct {
+			Role    string                   `json:"role"`
+			Content []map[string]interface{} `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-test","content":[{"type":"text","text":"Next"},{"type":"tool_use","id":"toolu_1","name":"web_search","input":{"query":"ecs error"}}],"stop_reason":"tool_use","usage":{"input_tokens":9,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	resp, err := newAnthropicProvider("key", server.URL, "claude-test").Complete(context.Background(), toolConversation())
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "toolu_1" || resp.ToolCalls[0].Name != "web_search" ||
+		string(resp.ToolCalls[0].Input) != `{"query":"ecs error"}` || resp.Text != "Next" {
+		t.Errorf("Complete() = %+v", resp)
+	}
+
+	if len(gotBody.Tools) != len(agentTools) || gotBody.Tools[0]["name"] != "aws_cli" || gotBody.Tools[0]["input_schema"] == nil {
+		t.Errorf("unexpected tools: %v", gotBody.Tools)
+	}
+	// user, assistant (text + 2 tool_use), user (2 tool_result)
+	if len(gotBody.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3: %+v", len(gotBody.Messages), gotBody.Messages)
+	}
+	if content := gotBody.Messages[1].Content; len(content) != 3 || content[1]["type"] != "tool_use" || content[1]["id"] != "call_1" {
+		t.Errorf("unexpected assistant turn: %v", content)
+	}
+	results := gotBody.Messages[2].Content
+	if gotBody.Messages[2].Role != "user" || len(results) != 2 || results[0]["tool_use_id"] != "call_1" || results[1]["is_error"] != true {
+		t.Errorf("unexpected tool results: %v", results)
+	}
+}
+
+func TestOpenAIProviderTools(t *testing.T) {
+	var gotBody struct {
+		Tools    []openAITool    `json:"tools"`
+		Messages []openAIMessage `json:"messages"`
+		Stream   bool            `json:"stream"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody.Stream = false
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_9\",\"type\":\"function\",\"function\":{\"name\":\"web_search\",\"arguments\":\"{\\\"query\\\":\"}}]}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"ecs\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"gpt-test","choices":[{"message":{"content":"","tool_calls":[{"id":"call_9","type":"function","function":{"name":"web_search","arguments":"{\"query\":\"ecs\"}"}}]},"finish_reason":"tool_calls"}]}`)
+	}))
+	defer server.Close()
+
+	provider := newOpenAIProvider("key", server.URL, "", "gpt-test")
+	for _, stream := range []bool{false, true} {
+		var resp *LLMResponse
+		var err error
+		if stream {
+			resp, err = provider.Stream(context.Background(), toolConversation(), func(string) {})
+		} else {
+			resp, err = provider.Complete(context.Background(), toolConversation())
+		}
+		if err != nil {
+			t.Fatalf("stream=%v: error = %v", stream, err)
+		}
+		if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_9" || string(resp.ToolCalls[0].Input) != `{"query":"ecs"}` {
+			t.Errorf("stream=%v: response = %+v", stream, resp)
+		}
+	}
+
+	if len(gotBody.Tools) != len(agentTools) || gotBody.Tools[0].Type != "function" || gotBody.Tools[0].Function.Parameters["type"] != "object" {
+		t.Errorf("unexpected tools: %+v", gotBody.Tools)
+	}
+	// user, assistant with tool_calls, one tool message per result
+	if len(gotBody.Messages) != 4 {
+		t.Fatalf("got %d messages, want 4", len(gotBody.Messages))
+	}
+	if calls := gotBody.Messages[1].ToolCalls; len(calls) != 2 || calls[1].Function.Arguments != `{"command":"cat dev.yaml"}` {
+		t.Errorf("unexpected assistant tool calls: %+v", calls)
+	}
+	if m := gotBody.Messages[3]; m.Role != "tool" || m.ToolCallID != "call_2" || m.Content != "denied" {
+		t.Errorf("unexpected tool message: %+v", m)
+	}
+}
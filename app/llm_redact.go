@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LLMRedactionLog is where redactions are recorded, relative to the project root
+const LLMRedactionLog = ".meroku/llm-redactions.jsonl"
+
+// LLMRedactionConfig configures how secrets are removed from prompts (redaction: in llm.yaml)
+type LLMRedactionConfig struct {
+	Disabled bool                  `yaml:"disabled,omitempty"`
+	Patterns []LLMRedactionPattern `yaml:"patterns,omitempty"` // Extra patterns on top of the built-in ones
+	Log      string                `yaml:"log,omitempty"`      // Defaults to .meroku/llm-redactions.jsonl
+}
+
+// LLMRedactionPattern is a custom secret pattern. If the regex has a group
+// named "secret" (or any group), only that part of the match is redacted.
+type LLMRedactionPattern struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// redactionPattern finds one kind of secret
+type redactionPattern struct {
+	kind  string
+	re    *regexp.Regexp
+	learn bool // Remember matched values and redact them wherever they appear later
+}
+
+// builtinRedactionPatterns cover AWS credentials, SecureString parameter values,
+// password-like attributes (plan JSON, yaml, tfvars, env files) and account IDs
+var builtinRedactionPatterns = []redactionPattern{
+	{kind: "private_key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{kind: "aws_access_key", re: regexp.MustCompile(`\b(?P<secret>(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16})\b`)},
+	{kind: "aws_secret_key", re: regexp.MustCompile(`(?i)(?:aws_secret_access_key|secret_?access_?key)["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`), learn: true},
+	{kind: "aws_session_token", re: regexp.MustCompile(`(?i)(?:aws_session_token|session_?token)["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{100,})`), learn: true},
+	{kind: "secure_string", re: regexp.MustCompile(`"Type":\s*"SecureString"[^{}]*?"Value":\s*"(?P<secret>(?:[^"\\]|\\.)+)"`), learn: true},
+	{kind: "secure_string", re: regexp.MustCompile(`"Value":\s*"(?P<secret>(?:[^"\\]|\\.)+)"[^{}]*?"Type":\s*"SecureString"`), learn: true},
+	{kind: "password", re: regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^:/\s@]+:(?P<secret>[^@\s/]+)@`), learn: true},
+	{kind: "password", re: regexp.MustCompile(`(?i)"[a-z0-9_]*(?:password|passwd|secret|token|api_?key|private_?key)"\s*:\s*"(?P<secret>(?:[^"\\]|\\.)+)"`), learn: true},
+	{kind: "password", re: regexp.MustCompile(`(?im)^\s*(?:export\s+)?[a-z0-9_]*(?:password|passwd|secret|api_?key)\s*[:=]\s*["']?(?P<secret>[^"'\s]+)`), learn: true},
+	{kind: "account_id", re: regexp.MustCompile(`arn:aws[a-z-]*:[a-z0-9-]*:[a-z0-9-]*:(?P<secret>\d{12}):`), learn: true},
+	{kind: "account_id", re: regexp.MustCompile(`(?i)"?(?:account_?id|account)"?\s*[:=]\s*"?(?P<secret>\d{12})\b`), learn: true},
+	// Any other 12-digit number, e.g. ECR hosts (123456789012.dkr.ecr...), but not 13-digit timestamps or decimals
+	{kind: "account_id", re: regexp.MustCompile(`(?:^|[^0-9A-Za-z_.-])(?P<secret>\d{12})\b`), learn: true},
+}
+
+// decryptedTextCommand matches commands that print decrypted values without any JSON around them
+// to match, e.g. `aws ssm get-parameter --with-decryption --query Parameter.Value --output text`
+var decryptedTextCommand = regexp.MustCompile(`--with-decryption|secretsmanager\s+get-secret-value`)
+
+var textOutputFlag = regexp.MustCompile(`--output[= ]+text\b`)
+
+var (
+	redactionPlaceholder = regexp.MustCompile(`\[\[REDACTED_[A-Z0-9_]+?_\d+\]\]`)
+	placeholderKindChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// nonSecretValues are placeholder-like values that don't need redacting
+var nonSecretValues = map[string]bool{
+	"null": true, "true": true, "false": true, "(sensitive value)": true, "(sensitive)": true,
+	"(known after apply)": true, "string": true, "SecureString": true,
+}
+
+// Redactor replaces secrets with stable placeholders such as [[REDACTED_PASSWORD_1]]
+// and restores them in LLM responses, so suggested commands work locally.
+// The brackets keep a placeholder from running into the text around it.
+// The same value always gets the same placeholder within a Redactor.
+type Redactor struct {
+	patterns []redactionPattern
+	logPath  string
+	feature  string
+
+	mu           sync.Mutex
+	placeholders map[string]string // value -> placeholder
+	values       map[string]string // placeholder -> value
+	kinds        map[string]string // placeholder -> pattern kind
+	learned      []string          // Values redacted wherever they appear, longest first
+	counts       map[string]int    // Placeholders issued per prefix
+}
+
+// RedactionEvent is one line of the redaction log. The secret itself is never logged.
+type RedactionEvent struct {
+	Time        time.Time `json:"time"`
+	Feature     string    `json:"feature"`
+	Kind        string    `json:"kind"`
+	Placeholder string    `json:"placeholder"`
+	Occurrences int       `json:"occurrences"`
+	Fingerprint string    `json:"fingerprint"` // First 8 bytes of the value's SHA-256
+}
+
+// newRedactor builds a redactor from the built-in and configured patterns
+func newRedactor(config LLMRedactionConfig, feature string) (*Redactor, error) {
+	patterns := append([]redactionPattern{}, builtinRedactionPatterns...)
+	for _, custom := range config.Patterns {
+		re, err := regexp.Compile(custom.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", custom.Name, err)
+		}
+		kind := custom.Name
+		if kind == "" {
+			kind = "custom"
+		}
+		patterns = append(patterns, redactionPattern{kind: kind, re: re, learn: true})
+	}
+
+	logPath := config.Log
+	if logPath == "" {
+		logPath = LLMRedactionLog
+	}
+
+	return &Redactor{
+		patterns:     patterns,
+		logPath:      logPath,
+		feature:      feature,
+		placeholders: map[string]string{},
+		values:       map[string]string{},
+		kinds:        map[string]string{},
+		counts:       map[string]int{},
+	}, nil
+}
+
+// redact replaces every secret in text with its placeholder. occurrences
+// counts replacements per placeholder for the redaction log.
+func (r *Redactor) redact(text string, occurrences map[string]int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.patterns {
+		text = r.redactPattern(text, p, occurrences)
+	}
+	// Values learned from earlier matches, e.g. a SecureString value echoed in logs
+	for _, value := range r.learned {
+		placeholder := r.placeholders[value]
+		var n int
+		if text, n = replaceToken(text, value, placeholder); n > 0 {
+			occurrences[placeholder] += n
+		}
+	}
+	return text
+}
+
+// redactWhole replaces all of text with one placeholder, for output that is a secret as a whole
+func (r *Redactor) redactWhole(text, kind string, occurrences map[string]int) string {
+	value := strings.TrimSpace(text)
+	if value == "" || nonSecretValues[value] || strings.Contains(value, "[[REDACTED_") {
+		return text
+	}
+	r.mu.Lock()
+	placeholder := r.placeholderFor(value, kind, true)
+	r.mu.Unlock()
+	occurrences[placeholder]++
+	return strings.Replace(text, value, placeholder, 1)
+}
+
+func isTokenByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// replaceToken replaces value where it is not part of a longer token, so a learned
+// s3cr3tvalue leaves s3cr3tvalue2 alone instead of leaking its last character
+func replaceToken(text, value, replacement string) (string, int) {
+	var b strings.Builder
+	n, last := 0, 0
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], value)
+		if i < 0 {
+			break
+		}
+		start, end := offset+i, offset+i+len(value)
+		if (start == 0 || !isTokenByte(value[0]) || !isTokenByte(text[start-1])) &&
+			(end == len(text) || !isTokenByte(value[len(value)-1]) || !isTokenByte(text[end])) {
+			b.WriteString(text[last:start])
+			b.WriteString(replacement)
+			last, n = end, n+1
+			offset = end
+			continue
+		}
+		offset = start + 1
+	}
+	if n == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+func (r *Redactor) redactPattern(text string, p redactionPattern, occurrences map[string]int) string {
+	matches := p.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	group := 0
+	if i := p.re.SubexpIndex("secret"); i > 0 {
+		group = i
+	} else if p.re.NumSubexp() > 0 {
+		group = 1
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		value := text[start:end]
+		if nonSecretValues[value] || strings.Contains(value, "[[REDACTED_") || strings.Contains(value, "${") || strings.Contains(value, "{{") {
+			continue
+		}
+		placeholder := r.placeholderFor(value, p.kind, p.learn)
+		occurrences[placeholder]++
+		b.WriteString(text[last:start])
+		b.WriteString(placeholder)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// placeholderFor returns the value's placeholder, issuing a new one if needed
+func (r *Redactor) placeholderFor(value, kind string, learn bool) string {
+	if placeholder, ok := r.placeholders[value]; ok {
+		return placeholder
+	}
+
+	prefix := "REDACTED_" + strings.ToUpper(placeholderKindChars.ReplaceAllString(kind, "_"))
+	r.counts[prefix]++
+	placeholder := fmt.Sprintf("[[%s_%d]]", prefix, r.counts[prefix])
+	r.placeholders[value] = placeholder
+	r.values[placeholder] = value
+	r.kinds[placeholder] = kind
+
+	// Short values would be replaced in unrelated places
+	if learn && len(value) >= 6 {
+		r.learned = append(r.learned, value)
+		sort.Slice(r.learned, func(i, j int) bool { return len(r.learned[i]) > len(r.learned[j]) })
+	}
+	return placeholder
+}
+
+// Rehydrate restores the secrets behind placeholders in text
+func (r *Redactor) Rehydrate(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return redactionPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := r.values[placeholder]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// hasRedactions reports whether any placeholder has been issued
+func (r *Redactor) hasRedactions() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.values) > 0
+}
+
+// mapJSONStrings applies fn to every string in a JSON document, keeping it valid JSON
+func mapJSONStrings(raw json.RawMessage, fn func(string) string) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return json.RawMessage(fn(string(raw)))
+	}
+
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return fn(v)
+		case []interface{}:
+			for i := range v {
+				v[i] = walk(v[i])
+			}
+		case map[string]interface{}:
+			for k := range v {
+				v[k] = walk(v[k])
+			}
+		}
+		return v
+	}
+
+	data, err := json.Marshal(walk(value))
+	if err != nil {
+		return raw
+	}
+	return data
+}
+
+// redactionNote tells the model how to use placeholders
+const redactionNote = "\n\nSecrets in this conversation were replaced with placeholders like [[REDACTED_PASSWORD_1]]. " +
+	"Use the placeholders verbatim in commands and file edits; they are restored locally before anything runs. " +
+	"Never ask the user to reveal them."
+
+// RedactRequest returns a copy of req with secrets replaced in the system
+// prompt, every message and every tool call input. The output of tool calls that
+// print decrypted values as text is redacted as a whole.
+func (r *Redactor) RedactRequest(req LLMRequest) LLMRequest {
+	occurrences := map[string]int{}
+	decrypted := map[string]bool{}
+	for _, msg := range req.Messages {
+		for _, call := range msg.ToolCalls {
+			if input := string(call.Input); decryptedTextCommand.MatchString(input) && textOutputFlag.MatchString(input) {
+				decrypted[call.ID] = true
+			}
+		}
+	}
+
+	out := req
+	out.System = r.redact(req.System, occurrences)
+	out.Messages = make([]LLMMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		if msg.Role == "tool" && !msg.IsError && decrypted[msg.ToolCallID] {
+			msg.Content = r.redactWhole(msg.Content, "secure_string", occurrences)
+		}
+		msg.Content = r.redact(msg.Content, occurrences)
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]LLMToolCall, len(msg.ToolCalls))
+			for j, call := range msg.ToolCalls {
+				call.Input = mapJSONStrings(call.Input, func(s string) string { return r.redact(s, occurrences) })
+				calls[j] = call
+			}
+			msg.ToolCalls = calls
+		}
+		out.Messages[i] = msg
+	}
+
+	if r.hasRedactions() {
+		out.System += redactionNote
+	}
+	r.logRedactions(occurrences)
+	return out
+}
+
+// RehydrateResponse restores secrets in the response text and tool call inputs
+func (r *Redactor) RehydrateResponse(resp *LLMResponse) *LLMResponse {
+	if resp == nil {
+		return nil
+	}
+	out := *resp
+	out.Text = r.Rehydrate(resp.Text)
+	if len(resp.ToolCalls) > 0 {
+		out.ToolCalls = make([]LLMToolCall, len(resp.ToolCalls))
+		for i, call := range resp.ToolCalls {
+			call.Input = mapJSONStrings(call.Input, r.Rehydrate)
+			out.ToolCalls[i] = call
+		}
+	}
+	return &out
+}
+
+// logRedactions appends one event per placeholder used in a request
+func (r *Redactor) logRedactions(occurrences map[string]int) {
+	if len(occurrences) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	var events []RedactionEvent
+	for placeholder, n := range occurrences {
+		sum := sha256.Sum256([]byte(r.values[placeholder]))
+		events = append(events, RedactionEvent{
+			Time:        time.Now(),
+			Feature:     r.feature,
+			Kind:        r.kinds[placeholder],
+			Placeholder: placeholder,
+			Occurrences: n,
+			Fingerprint: hex.EncodeToString(sum[:8]),
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Placeholder < events[j].Placeholder })
+
+	// Logging must never break an AI feature
+	if err := os.MkdirAll(filepath.Dir(r.logPath), 0700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(r.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	for _, event := range events {
+		if data, err := json.Marshal(event); err == nil {
+			f.Write(append(data, '\n'))
+		}
+	}
+}
+
+// redactingProvider redacts every outbound request and restores secrets in responses
+type redactingProvider struct {
+	LLMProvider
+	redactor *Redactor
+}
+
+func (p *redactingProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	response, err := p.LLMProvider.Complete(ctx, p.redactor.RedactRequest(req))
+	return p.redactor.RehydrateResponse(response), err
+}
+
+func (p *redactingProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	// Placeholders split across chunks are shown as-is; the final response is always restored
+	response, err := p.LLMProvider.Stream(ctx, p.redactor.RedactRequest(req), func(delta string) {
+		onDelta(p.redactor.Rehydrate(delta))
+	})
+	return p.redactor.RehydrateResponse(response), err
+}
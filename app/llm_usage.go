@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LLMUsageLog is where every LLM call is recorded, relative to the project root
+const LLMUsageLog = ".meroku/llm-usage.jsonl"
+
+// LLMPrice is the price of a model in USD per million tokens
+type LLMPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// defaultLLMPrices are list prices keyed by model name prefix; the longest matching prefix wins.
+// Override or extend them with prices: in llm.yaml.
+var defaultLLMPrices = map[string]LLMPrice{
+	"claude-opus-4-5":   {Input: 5, Output: 25},
+	"claude-opus-4":     {Input: 15, Output: 75},
+	"claude-sonnet-4":   {Input: 3, Output: 15},
+	"claude-3-7-sonnet": {Input: 3, Output: 15},
+	"claude-3-5-sonnet": {Input: 3, Output: 15},
+	"claude-haiku-4-5":  {Input: 1, Output: 5},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.6},
+	"gpt-4o":            {Input: 2.5, Output: 10},
+	"gpt-4.1-mini":      {Input: 0.4, Output: 1.6},
+	"gpt-4.1":           {Input: 2, Output: 8},
+}
+
+// LLMUsageRecord is one line of the usage log
+type LLMUsageRecord struct {
+	Time         time.Time `json:"time"`
+	Feature      string    `json:"feature"`
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	Session      string    `json:"session,omitempty"` // Agent session ID, if the call belongs to one
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	LatencyMS    int64     `json:"latency_ms"`
+	CostUSD      float64   `json:"cost_usd"`
+	Unpriced     bool      `json:"unpriced,omitempty"` // No price is known for the model
+	Error        string    `json:"error,omitempty"`
+}
+
+// LLMUsage is a rollup of LLM calls
+type LLMUsage struct {
+	Calls        int           `json:"calls"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	CostUSD      float64       `json:"cost_usd"`
+	Latency      time.Duration `json:"latency"` // Total time spent waiting for responses
+}
+
+// Add includes a record in the rollup
+func (u *LLMUsage) Add(record LLMUsageRecord) {
+	u.Calls++
+	u.InputTokens += record.InputTokens
+	u.OutputTokens += record.OutputTokens
+	u.CostUSD += record.CostUSD
+	u.Latency += time.Duration(record.LatencyMS) * time.Millisecond
+}
+
+// String formats the rollup for status lines, e.g. "$0.0421 · 12.3k tokens"
+func (u LLMUsage) String() string {
+	return fmt.Sprintf("$%.4f · %s tokens", u.CostUSD, formatTokenCount(u.InputTokens+u.OutputTokens))
+}
+
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// LLMBudgetError is returned instead of calling the LLM once a spend cap is reached
+type LLMBudgetError struct {
+	Scope string // "session" or "daily"
+	Limit float64
+	Spent float64
+}
+
+func (e *LLMBudgetError) Error() string {
+	return fmt.Sprintf("%s LLM spend cap reached ($%.2f spent, cap $%.2f)", e.Scope, e.Spent, e.Limit)
+}
+
+// priceFor returns the price of a model, preferring llm.yaml over the built-in table
+func (c *LLMConfig) priceFor(model string) (LLMPrice, bool) {
+	if c.Provider == LLMProviderOllama {
+		return LLMPrice{}, true // Runs locally
+	}
+	for _, prices := range []map[string]LLMPrice{c.Prices, defaultLLMPrices} {
+		best := ""
+		for prefix := range prices {
+			if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+				best = prefix
+			}
+		}
+		if best != "" {
+			return prices[best], true
+		}
+	}
+	return LLMPrice{}, false
+}
+
+// usageLogPath returns the configured usage log
+func (c *LLMConfig) usageLogPath() string {
+	if c.UsageLog != "" {
+		return c.UsageLog
+	}
+	return LLMUsageLog
+}
+
+// usageProvider records tokens, latency and cost of every call and enforces
+// the per-session and per-day spend caps before calling the LLM
+type usageProvider struct {
+	LLMProvider
+	config  *LLMConfig
+	feature string
+	model   string
+
+	mu      sync.Mutex
+	session string
+	usage   LLMUsage  // This provider's calls, i.e. one session
+	day     string    // Date today's spend was loaded for
+	logSize int64     // Size and modification time of the log when it was read
+	logMod  time.Time
+	today   *LLMUsage // Today's spend across all sessions and processes, read from the log
+}
+
+func (p *usageProvider) Complete(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	if err := p.checkBudget(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	response, err := p.LLMProvider.Complete(ctx, req)
+	p.record(response, err, time.Since(start))
+	return response, err
+}
+
+func (p *usageProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	if err := p.checkBudget(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	response, err := p.LLMProvider.Stream(ctx, req, onDelta)
+	p.record(response, err, time.Since(start))
+	return response, err
+}
+
+// Usage returns the rollup of this provider's calls
+func (p *usageProvider) Usage() LLMUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.usage
+}
+
+// SetSession tags following calls with a session ID. usage carries over the
+// spend of a resumed session so its cap still applies.
+func (p *usageProvider) SetSession(id string, usage LLMUsage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.session = id
+	p.usage = usage
+}
+
+func (p *usageProvider) checkBudget() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if limit := p.config.SessionBudgetUSD; limit > 0 && p.usage.CostUSD >= limit {
+		return &LLMBudgetError{Scope: "session", Limit: limit, Spent: p.usage.CostUSD}
+	}
+	if limit := p.config.DailyBudgetUSD; limit > 0 {
+		if today := p.todayLocked(); today.CostUSD >= limit {
+			return &LLMBudgetError{Scope: "daily", Limit: limit, Spent: today.CostUSD}
+		}
+	}
+	return nil
+}
+
+// todayLocked returns today's spend. The log is read again whenever it changed,
+// so calls from other meroku processes (e.g. the web UI next to the CLI) count too.
+func (p *usageProvider) todayLocked() *LLMUsage {
+	day := time.Now().Format(time.DateOnly)
+	var size int64
+	var mod time.Time
+	if info, err := os.Stat(p.config.usageLogPath()); err == nil {
+		size, mod = info.Size(), info.ModTime()
+	}
+	if p.today == nil || p.day != day || p.logSize != size || !p.logMod.Equal(mod) {
+		p.day, p.logSize, p.logMod = day, size, mod
+		p.today = &LLMUsage{}
+		midnight, _ := time.ParseInLocation(time.DateOnly, day, time.Local)
+		records, _ := readLLMUsage(p.config.usageLogPath(), midnight)
+		for _, record := range records {
+			p.today.Add(record)
+		}
+	}
+	return p.today
+}
+
+func (p *usageProvider) record(response *LLMResponse, callErr error, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record := LLMUsageRecord{
+		Time:      time.Now(),
+		Feature:   p.feature,
+		Provider:  p.config.Provider,
+		Model:     p.model,
+		Session:   p.session,
+		LatencyMS: latency.Milliseconds(),
+	}
+	if response != nil {
+		if response.Model != "" {
+			record.Model = response.Model
+		}
+		record.InputTokens = response.InputTokens
+		record.OutputTokens = response.OutputTokens
+	}
+	if callErr != nil {
+		record.Error = callErr.Error()
+	}
+
+	price, ok := p.config.priceFor(record.Model)
+	record.Unpriced = !ok && record.InputTokens+record.OutputTokens > 0
+	record.CostUSD = (float64(record.InputTokens)*price.Input + float64(record.OutputTokens)*price.Output) / 1_000_000
+
+	p.usage.Add(record)
+
+	// Usage accounting must never break an AI feature. The daily total picks
+	// the record up from the log on the next budget check.
+	_ = appendLLMUsage(p.config.usageLogPath(), record)
+}
+
+// appendLLMUsage adds a record to the usage log
+func appendLLMUsage(path string, record LLMUsageRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// readLLMUsage returns the records made at or after since. A missing log has no records.
+func readLLMUsage(path string, since time.Time) ([]LLMUsageRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage log: %w", err)
+	}
+	defer f.Close()
+
+	var records []LLMUsageRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record LLMUsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Skip lines cut short by a crash
+		}
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage log: %w", err)
+	}
+	return records, nil
+}
+
+// llmUsageOf returns the usage recorded by a provider created with NewLLMProvider
+func llmUsageOf(provider LLMProvider) LLMUsage {
+	if p, ok := provider.(*usageProvider); ok {
+		return p.Usage()
+	}
+	return LLMUsage{}
+}
+
+// setLLMUsageSession tags a provider's following calls with a session ID
+func setLLMUsageSession(provider LLMProvider, id string, usage LLMUsage) {
+	if p, ok := provider.(*usageProvider); ok {
+		p.SetSession(id, usage)
+	}
+}
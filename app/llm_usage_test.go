@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLLMPriceFor(t *testing.T) {
+	config := &LLMConfig{
+		Provider: LLMProviderAnthropic,
+		Prices:   map[string]LLMPrice{"claude-sonnet-4-5": {Input: 2, Output: 10}},
+	}
+
+	tests := []struct {
+		model  string
+		want   LLMPrice
+		priced bool
+	}{
+		{model: "claude-sonnet-4-5-20250929", want: LLMPrice{Input: 2, Output: 10}, priced: true}, // llm.yaml wins
+		{model: "claude-sonnet-4-20250514", want: LLMPrice{Input: 3, Output: 15}, priced: true},
+		{model: "claude-opus-4-5-20251101", want: LLMPrice{Input: 5, Output: 25}, priced: true}, // longest prefix
+		{model: "gpt-4o-mini-2024-07-18", want: LLMPrice{Input: 0.15, Output: 0.6}, priced: true},
+		{model: "my-finetune", priced: false},
+	}
+	for _, tt := range tests {
+		got, ok := config.priceFor(tt.model)
+		if got != tt.want || ok != tt.priced {
+			t.Errorf("priceFor(%q) = %+v, %v; want %+v, %v", tt.model, got, ok, tt.want, tt.priced)
+		}
+	}
+
+	if _, ok := (&LLMConfig{Provider: LLMProviderOllama}).priceFor("llama3.1"); !ok {
+		t.Error("ollama models should be priced at zero")
+	}
+}
+
+func newTestUsageProvider(t *testing.T, config *LLMConfig, exchanges ...LLMExchange) *usageProvider {
+	config.Provider = LLMProviderAnthropic
+	config.UsageLog = filepath.Join(t.TempDir(), "usage.jsonl")
+	return &usageProvider{
+		LLMProvider: newFixtureProvider(exchanges...),
+		config:      config,
+		feature:     LLMFeatureAgent,
+		model:       "claude-sonnet-4-5-20250929",
+	}
+}
+
+func usageExchange(inputTokens, outputTokens int) LLMExchange {
+	return LLMExchange{Response: LLMResponse{Text: "ok", InputTokens: inputTokens, OutputTokens: outputTokens}}
+}
+
+func TestUsageProviderRecordsCalls(t *testing.T) {
+	provider := newTestUsageProvider(t, &LLMConfig{}, usageExchange(1000, 200), usageExchange(3000, 100))
+	provider.SetSession("20261018-142501-dev", LLMUsage{})
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0)); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+	}
+	// A failed call is recorded too
+	if _, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0)); err == nil {
+		t.Fatal("expected the exhausted fixture to fail")
+	}
+
+	usage := llmUsageOf(provider)
+	wantCost := (4000*3.0 + 300*15.0) / 1_000_000
+	if usage.Calls != 3 || usage.InputTokens != 4000 || usage.OutputTokens != 300 || math.Abs(usage.CostUSD-wantCost) > 1e-9 {
+		t.Errorf("Usage() = %+v, want cost %f", usage, wantCost)
+	}
+
+	records, err := readLLMUsage(provider.config.UsageLog, time.Time{})
+	if err != nil {
+		t.Fatalf("readLLMUsage() error = %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	first := records[0]
+	if first.Feature != LLMFeatureAgent || first.Provider != LLMProviderAnthropic || first.Model != "claude-sonnet-4-5-20250929" ||
+		first.Session != "20261018-142501-dev" || first.InputTokens != 1000 || first.Unpriced {
+		t.Errorf("unexpected record: %+v", first)
+	}
+	if records[2].Error == "" {
+		t.Errorf("failed call should record its error: %+v", records[2])
+	}
+}
+
+func TestUsageProviderBudgets(t *testing.T) {
+	t.Run("session", func(t *testing.T) {
+		provider := newTestUsageProvider(t, &LLMConfig{SessionBudgetUSD: 0.01}, usageExchange(1000, 1000), usageExchange(1, 1))
+		if _, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0)); err != nil {
+			t.Fatalf("first call error = %v", err)
+		}
+
+		_, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0))
+		var budgetErr *LLMBudgetError
+		if !errors.As(err, &budgetErr) || budgetErr.Scope != "session" {
+			t.Fatalf("second call error = %v, want session budget error", err)
+		}
+
+		// A new session starts from zero
+		provider.SetSession("other", LLMUsage{})
+		if _, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0)); err != nil {
+			t.Errorf("fresh session error = %v", err)
+		}
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		provider := newTestUsageProvider(t, &LLMConfig{DailyBudgetUSD: 1}, usageExchange(1, 1))
+		for _, record := range []LLMUsageRecord{
+			{Time: time.Now().AddDate(0, 0, -2), CostUSD: 5}, // Earlier days don't count
+			{Time: time.Now(), CostUSD: 0.6},
+			{Time: time.Now(), CostUSD: 0.5},
+		} {
+			if err := appendLLMUsage(provider.config.UsageLog, record); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0))
+		var budgetErr *LLMBudgetError
+		if !errors.As(err, &budgetErr) || budgetErr.Scope != "daily" || math.Abs(budgetErr.Spent-1.1) > 1e-9 {
+			t.Fatalf("Complete() error = %v, want daily budget error", err)
+		}
+	})
+
+	t.Run("daily spend from other processes", func(t *testing.T) {
+		provider := newTestUsageProvider(t, &LLMConfig{DailyBudgetUSD: 1}, usageExchange(1, 1), usageExchange(1, 1))
+		if _, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0)); err != nil {
+			t.Fatalf("first call error = %v", err)
+		}
+
+		// Another meroku process spends the rest of today's budget
+		if err := appendLLMUsage(provider.config.UsageLog, LLMUsageRecord{Time: time.Now(), CostUSD: 2}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := provider.Complete(context.Background(), userPrompt("hi", 10, 0))
+		var budgetErr *LLMBudgetError
+		if !errors.As(err, &budgetErr) || budgetErr.Scope != "daily" {
+			t.Fatalf("Complete() error = %v, want daily budget error", err)
+		}
+	})
+}
+
+func TestReadLLMUsageSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	line, _ := json.Marshal(LLMUsageRecord{Time: time.Now(), Feature: LLMFeatureErrorHelper})
+	if err := os.WriteFile(path, append(append(line, '\n'), []byte(`{"time":"2026-`)...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := readLLMUsage(path, time.Time{})
+	if err != nil || len(records) != 1 || records[0].Feature != LLMFeatureErrorHelper {
+		t.Errorf("readLLMUsage() = %+v, %v", records, err)
+	}
+	if records, err := readLLMUsage(filepath.Join(t.TempDir(), "missing.jsonl"), time.Time{}); err != nil || records != nil {
+		t.Errorf("readLLMUsage(missing) = %+v, %v", records, err)
+	}
+}
+
+func TestAIAgentStopsAtSessionBudget(t *testing.T) {
+	agent := newPolicyTestAgent(t, AgentPolicyAutonomous)
+	agent.state.IterationLimit = 5
+	provider := newTestUsageProvider(t, &LLMConfig{SessionBudgetUSD: 0.01},
+		LLMExchange{Response: LLMResponse{InputTokens: 5000, OutputTokens: 500, ToolCalls: []LLMToolCall{
+			{ID: "call_1", Name: "web_search", Input: json.RawMessage(`{"query":"ecs"}`)},
+		}}},
+	)
+	agent.llmClient = &AgentLLMClient{provider: provider}
+	agent.executor.runner = &scenarioRunner{commands: []ScenarioCommand{{Match: "ecs", Output: "results"}}}
+
+	updates := make(chan AgentUpdate, 20)
+	agent.updateChan = updates
+	if err := agent.Start(); err != nil {
+		t.Fatalf("Start() error = %v, want a graceful stop", err)
+	}
+
+	state := agent.GetState()
+	if state.FinalOutcome != "budget_exceeded" || len(state.Iterations) != 1 || state.Usage.Calls != 1 {
+		t.Errorf("state = outcome %q, %d iterations, usage %+v", state.FinalOutcome, len(state.Iterations), state.Usage)
+	}
+
+	var last AgentUpdate
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	if last.Type != "finished" || last.Success {
+		t.Errorf("last update = %+v", last)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"gopkg.in/yaml.v2"
+)
+
+// SavedLogQueriesFile keeps the project's saved Logs Insights queries, it's meant to be committed
+const SavedLogQueriesFile = ".meroku/log-queries.yaml"
+
+// insightsMaxLogGroups is the number of log groups a Logs Insights query accepts
+const insightsMaxLogGroups = 50
+
+// insightsPollInterval is how often a running query is checked
+var insightsPollInterval = time.Second
+
+// logsInsightsAPI is the part of the CloudWatch Logs client used to run Logs Insights queries
+type logsInsightsAPI interface {
+	cloudwatchlogs.DescribeLogGroupsAPIClient
+	StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error)
+	GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error)
+	StopQuery(ctx context.Context, params *cloudwatchlogs.StopQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error)
+}
+
+// SavedLogQuery is a named Logs Insights query
+type SavedLogQuery struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Query       string   `yaml:"query" json:"query"`
+	Services    []string `yaml:"services,omitempty" json:"services,omitempty"` // Default: all services of the environment
+}
+
+type savedLogQueries struct {
+	Queries []SavedLogQuery `yaml:"queries"`
+}
+
+// InsightsQuery is a Logs Insights query over some of an environment's services
+type InsightsQuery struct {
+	Query    string
+	Services []string // Default: all services with a log group
+	Start    time.Time
+	End      time.Time
+	Limit    int32 // Default: the query's own limit, at most 10000 rows
+}
+
+// InsightsResult is the table returned by a Logs Insights query
+type InsightsResult struct {
+	QueryID        string     `json:"queryId"`
+	Status         string     `json:"status"`
+	LogGroups      []string   `json:"logGroups"`
+	Columns        []string   `json:"columns"`
+	Rows           [][]string `json:"rows"`
+	RecordsMatched float64    `json:"recordsMatched"`
+	RecordsScanned float64    `json:"recordsScanned"`
+	BytesScanned   float64    `json:"bytesScanned"`
+}
+
+var savedLogQueryName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// loadSavedLogQueries reads the project's saved queries, none if the file doesn't exist
+func loadSavedLogQueries() ([]SavedLogQuery, error) {
+	data, err := os.ReadFile(SavedLogQueriesFile)
+	if os.IsNotExist(err) {
+		return []SavedLogQuery{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SavedLogQueriesFile, err)
+	}
+	var saved savedLogQueries
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", SavedLogQueriesFile, err)
+	}
+	if saved.Queries == nil {
+		saved.Queries = []SavedLogQuery{}
+	}
+	return saved.Queries, nil
+}
+
+func writeSavedLogQueries(queries []SavedLogQuery) error {
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	data, err := yaml.Marshal(savedLogQueries{Queries: queries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(SavedLogQueriesFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(SavedLogQueriesFile), err)
+	}
+	return os.WriteFile(SavedLogQueriesFile, data, 0644)
+}
+
+// saveLogQuery adds a query or replaces the one with the same name
+func saveLogQuery(query SavedLogQuery) error {
+	if !savedLogQueryName.MatchString(query.Name) {
+		return fmt.Errorf("invalid query name %q, use letters, digits, '-', '_' and '.'", query.Name)
+	}
+	if query.Query == "" {
+		return fmt.Errorf("query is empty")
+	}
+	queries, err := loadSavedLogQueries()
+	if err != nil {
+		return err
+	}
+	queries = slices.DeleteFunc(queries, func(q SavedLogQuery) bool { return q.Name == query.Name })
+	return writeSavedLogQueries(append(queries, query))
+}
+
+// deleteLogQuery removes a saved query
+func deleteLogQuery(name string) error {
+	queries, err := loadSavedLogQueries()
+	if err != nil {
+		return err
+	}
+	remaining := slices.DeleteFunc(slices.Clone(queries), func(q SavedLogQuery) bool { return q.Name == name })
+	if len(remaining) == len(queries) {
+		return fmt.Errorf("saved query %q not found", name)
+	}
+	return writeSavedLogQueries(remaining)
+}
+
+// findSavedLogQuery returns a saved query by name
+func findSavedLogQuery(name string) (*SavedLogQuery, error) {
+	queries, err := loadSavedLogQueries()
+	if err != nil {
+		return nil, err
+	}
+	for i := range queries {
+		if queries[i].Name == name {
+			return &queries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("saved query %q not found in %s", name, SavedLogQueriesFile)
+}
+
+// environmentLogServices returns the services, scheduled and event tasks of an environment that have log groups
+func environmentLogServices(env Env) []string {
+	services := []string{"backend"}
+	for _, service := range env.Services {
+		services = append(services, service.Name)
+	}
+	for _, task := range env.ScheduledTasks {
+		services = append(services, task.Name)
+	}
+	for _, task := range env.EventProcessorTasks {
+		services = append(services, task.Name)
+	}
+	return services
+}
+
+// insightsLogGroups returns the existing log groups of the services; without services all of the
+// environment's groups are used and the ones that were not created yet are left out
+func insightsLogGroups(ctx context.Context, client logsInsightsAPI, env Env, services []string) ([]string, error) {
+	explicit := len(services) > 0
+	if !explicit {
+		services = environmentLogServices(env)
+	}
+
+	existing := map[string]bool{}
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(env.Project + "_"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list log groups: %w", err)
+		}
+		for _, group := range page.LogGroups {
+			existing[aws.ToString(group.LogGroupName)] = true
+		}
+	}
+
+	var groups []string
+	for _, service := range services {
+		group := constructLogGroupName(env, service)
+		if !existing[group] {
+			if explicit {
+				return nil, fmt.Errorf("log group %s not found, is %s deployed in %s?", group, service, env.Env)
+			}
+			continue
+		}
+		if !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no log groups found for %s", env.Env)
+	}
+	if len(groups) > insightsMaxLogGroups {
+		return nil, fmt.Errorf("%d log groups, a query can cover at most %d, pick services", len(groups), insightsMaxLogGroups)
+	}
+	return groups, nil
+}
+
+// runInsightsQuery runs a query and polls until it finishes, a cancelled context stops the query
+func runInsightsQuery(ctx context.Context, client logsInsightsAPI, env Env, q InsightsQuery) (*InsightsResult, error) {
+	if q.Query == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	if !q.End.After(q.Start) {
+		return nil, fmt.Errorf("the time range is empty")
+	}
+	groups, err := insightsLogGroups(ctx, client, env, q.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &cloudwatchlogs.StartQueryInput{
+		QueryString:   aws.String(q.Query),
+		LogGroupNames: groups,
+		StartTime:     aws.Int64(q.Start.Unix()),
+		EndTime:       aws.Int64(q.End.Unix()),
+	}
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(min(q.Limit, 10000))
+	}
+	started, err := client.StartQuery(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start query: %w", err)
+	}
+	queryID := aws.ToString(started.QueryId)
+
+	ticker := time.NewTicker(insightsPollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := client.GetQueryResults(ctx, &cloudwatchlogs.GetQueryResultsInput{QueryId: aws.String(queryID)})
+		if err != nil {
+			if ctx.Err() != nil {
+				// The query keeps running and costs money otherwise
+				client.StopQuery(context.Background(), &cloudwatchlogs.StopQueryInput{QueryId: aws.String(queryID)})
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to get query results: %w", err)
+		}
+
+		switch resp.Status {
+		case cwltypes.QueryStatusComplete:
+			result := insightsResult(resp.Results)
+			result.QueryID, result.Status, result.LogGroups = queryID, string(resp.Status), groups
+			if resp.Statistics != nil {
+				result.RecordsMatched = resp.Statistics.RecordsMatched
+				result.RecordsScanned = resp.Statistics.RecordsScanned
+				result.BytesScanned = resp.Statistics.BytesScanned
+			}
+			return result, nil
+		case cwltypes.QueryStatusFailed, cwltypes.QueryStatusCancelled, cwltypes.QueryStatusTimeout:
+			return nil, fmt.Errorf("query %s: %s", queryID, resp.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			client.StopQuery(context.Background(), &cloudwatchlogs.StopQueryInput{QueryId: aws.String(queryID)})
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// insightsResult turns result rows into a table, columns appear in the order the query returns them
+func insightsResult(results [][]cwltypes.ResultField) *InsightsResult {
+	result := &InsightsResult{Columns: []string{}, Rows: [][]string{}}
+	index := map[string]int{}
+	for _, fields := range results {
+		for _, field := range fields {
+			name := aws.ToString(field.Field)
+			if _, ok := index[name]; !ok && name != "@ptr" {
+				index[name] = len(result.Columns)
+				result.Columns = append(result.Columns, name)
+			}
+		}
+	}
+	for _, fields := range results {
+		row := make([]string, len(result.Columns))
+		for _, field := range fields {
+			if i, ok := index[aws.ToString(field.Field)]; ok {
+				row[i] = aws.ToString(field.Value)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
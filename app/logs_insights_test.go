@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// fakeLogsInsights completes a query after a number of polls
+type fakeLogsInsights struct {
+	groups  []string
+	polls   int
+	started *cloudwatchlogs.StartQueryInput
+	stopped bool
+}
+
+func (f *fakeLogsInsights) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	out := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	for _, group := range f.groups {
+		if strings.HasPrefix(group, aws.ToString(params.LogGroupNamePrefix)) {
+			out.LogGroups = append(out.LogGroups, cwltypes.LogGroup{LogGroupName: aws.String(group)})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLogsInsights) StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	f.started = params
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String("q-1")}, nil
+}
+
+func (f *fakeLogsInsights) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	if f.polls > 0 {
+		f.polls--
+		return &cloudwatchlogs.GetQueryResultsOutput{Status: cwltypes.QueryStatusRunning}, nil
+	}
+	return &cloudwatchlogs.GetQueryResultsOutput{
+		Status:     cwltypes.QueryStatusComplete,
+		Statistics: &cwltypes.QueryStatistics{RecordsMatched: 2, RecordsScanned: 40},
+		Results: [][]cwltypes.ResultField{
+			{{Field: aws.String("@timestamp"), Value: aws.String("2024-05-01 10:00:00.000")}, {Field: aws.String("@message"), Value: aws.String("ERROR db")}, {Field: aws.String("@ptr"), Value: aws.String("x")}},
+			{{Field: aws.String("@timestamp"), Value: aws.String("2024-05-01 10:01:00.000")}, {Field: aws.String("@logStream"), Value: aws.String("ecs/backend/t1")}},
+		},
+	}, nil
+}
+
+func (f *fakeLogsInsights) StopQuery(ctx context.Context, params *cloudwatchlogs.StopQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	f.stopped = true
+	return &cloudwatchlogs.StopQueryOutput{}, nil
+}
+
+func newFakeInsightsEnv() (*fakeLogsInsights, Env) {
+	env := Env{
+		Project:        "shop",
+		Env:            "dev",
+		Services:       []Service{{Name: "api"}},
+		ScheduledTasks: []ScheduledTask{{Name: "cleanup"}},
+	}
+	return &fakeLogsInsights{groups: []string{"shop_backend_dev", "shop_task_cleanup_dev", "shop_backend_prod"}}, env
+}
+
+func TestInsightsLogGroups(t *testing.T) {
+	client, env := newFakeInsightsEnv()
+	ctx := context.Background()
+
+	// The api service has no log group yet and is left out
+	groups, err := insightsLogGroups(ctx, client, env, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(groups, ",") != "shop_backend_dev,shop_task_cleanup_dev" {
+		t.Errorf("groups = %v", groups)
+	}
+
+	if _, err := insightsLogGroups(ctx, client, env, []string{"api"}); err == nil || !strings.Contains(err.Error(), "shop_service_api_dev not found") {
+		t.Errorf("missing log group error = %v", err)
+	}
+}
+
+func TestRunInsightsQuery(t *testing.T) {
+	defer func(interval time.Duration) { insightsPollInterval = interval }(insightsPollInterval)
+	insightsPollInterval = time.Millisecond
+
+	client, env := newFakeInsightsEnv()
+	client.polls = 2
+	end := time.Unix(1714557600, 0)
+	result, err := runInsightsQuery(context.Background(), client, env, InsightsQuery{
+		Query:    "fields @timestamp, @message",
+		Services: []string{"backend"},
+		Start:    end.Add(-time.Hour),
+		End:      end,
+		Limit:    50000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.polls != 0 || aws.ToInt32(client.started.Limit) != 10000 || aws.ToInt64(client.started.StartTime) != end.Unix()-3600 {
+		t.Errorf("started = %+v, polls left %d", client.started, client.polls)
+	}
+	if strings.Join(result.Columns, ",") != "@timestamp,@message,@logStream" || len(result.Rows) != 2 ||
+		result.Rows[1][2] != "ecs/backend/t1" || result.Rows[1][1] != "" || result.RecordsMatched != 2 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRunInsightsQueryCancelled(t *testing.T) {
+	client, env := newFakeInsightsEnv()
+	client.polls = 1000
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := runInsightsQuery(ctx, client, env, InsightsQuery{Query: "fields @message", Start: time.Unix(0, 0), End: time.Now()})
+	if err == nil || !client.stopped {
+		t.Errorf("err = %v, stopped %v", err, client.stopped)
+	}
+}
+
+func TestSavedLogQueries(t *testing.T) {
+	wd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	if queries, err := loadSavedLogQueries(); err != nil || len(queries) != 0 {
+		t.Fatalf("loadSavedLogQueries() = %v, %v", queries, err)
+	}
+	for _, q := range []SavedLogQuery{
+		{Name: "slow", Query: "filter duration > 1000"},
+		{Name: "errors", Query: "filter @message like /ERROR/"},
+		{Name: "slow", Query: "filter duration > 2000", Services: []string{"backend"}},
+	} {
+		if err := saveLogQuery(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveLogQuery(SavedLogQuery{Name: "bad name", Query: "x"}); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+
+	queries, err := loadSavedLogQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0].Name != "errors" || queries[1].Query != "filter duration > 2000" {
+		t.Errorf("queries = %+v", queries)
+	}
+
+	if err := deleteLogQuery("errors"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findSavedLogQuery("errors"); err == nil {
+		t.Error("deleted query was found")
+	}
+	if err := deleteLogQuery("errors"); err == nil {
+		t.Error("expected an error deleting a missing query")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logTimestampFormat keeps milliseconds so entries of the same second stay in order
+const logTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// logLevels are the levels of LogEntry
+var logLevels = []string{"debug", "info", "warning", "error"}
+
+// defaultLogFieldMapping has the field names of common JSON loggers (zap, zerolog, logrus, pino, winston, structlog)
+var defaultLogFieldMapping = LogFieldMapping{
+	Level:     []string{"level", "severity", "lvl", "log.level"},
+	Message:   []string{"message", "msg"},
+	TraceID:   []string{"trace_id", "traceId", "trace.id", "dd.trace_id"},
+	RequestID: []string{"request_id", "requestId", "req_id", "http.request_id"},
+}
+
+// LogFilter selects log entries by level and by fields
+type LogFilter struct {
+	Levels []string          // Any of these levels, all if empty
+	Fields map[string]string // Field path (or trace_id, request_id, stream, source) to value
+}
+
+// logFieldMapping returns the mapping of a service, lists not configured in log_fields use the defaults
+func logFieldMapping(env Env, service string) LogFieldMapping {
+	mapping := env.LogFields[service]
+	if len(mapping.Level) == 0 {
+		mapping.Level = defaultLogFieldMapping.Level
+	}
+	if len(mapping.Message) == 0 {
+		mapping.Message = defaultLogFieldMapping.Message
+	}
+	if len(mapping.TraceID) == 0 {
+		mapping.TraceID = defaultLogFieldMapping.TraceID
+	}
+	if len(mapping.RequestID) == 0 {
+		mapping.RequestID = defaultLogFieldMapping.RequestID
+	}
+	return mapping
+}
+
+// parseLogFilter reads ?level=error,warning&field=user_id=42&field=route=/orders
+func parseLogFilter(query url.Values) (LogFilter, error) {
+	filter := LogFilter{Fields: map[string]string{}}
+	for _, value := range query["level"] {
+		for _, level := range strings.Split(value, ",") {
+			if level = strings.TrimSpace(level); level == "" {
+				continue
+			}
+			normalized := normalizeLogLevel(level)
+			if normalized == "" {
+				return filter, fmt.Errorf("invalid level %q, use %s", level, strings.Join(logLevels, ", "))
+			}
+			filter.Levels = append(filter.Levels, normalized)
+		}
+	}
+	for _, value := range query["field"] {
+		name, fieldValue, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return filter, fmt.Errorf("invalid field filter %q, use name=value", value)
+		}
+		filter.Fields[name] = fieldValue
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter selects all entries
+func (f LogFilter) IsEmpty() bool {
+	return len(f.Levels) == 0 && len(f.Fields) == 0
+}
+
+// Matches reports whether an entry has one of the levels and all of the fields
+func (f LogFilter) Matches(entry LogEntry) bool {
+	if len(f.Levels) > 0 && !slices.Contains(f.Levels, entry.Level) {
+		return false
+	}
+	for name, want := range f.Fields {
+		var got string
+		switch name {
+		case "trace_id":
+			got = entry.TraceID
+		case "request_id":
+			got = entry.RequestID
+		case "stream":
+			got = entry.Stream
+		case "source":
+			got = entry.Source
+		default:
+			value, ok := lookupLogField(entry.Fields, name)
+			if !ok {
+				return false
+			}
+			got = logFieldString(value)
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLogEntry turns a CloudWatch event into a LogEntry. JSON lines are parsed with the mapping,
+// other lines get a level from their text. Lines from FireLens ({"log": ..., "source": "stderr"})
+// are unwrapped first so stdout and stderr are reported the same way for every service.
+func parseLogEntry(mapping LogFieldMapping, stream string, timestamp int64, message string) LogEntry {
+	entry := LogEntry{
+		Timestamp: time.UnixMilli(timestamp).UTC().Format(logTimestampFormat),
+		Message:   strings.TrimSpace(message),
+		Stream:    stream,
+	}
+
+	fields := parseJSONLogLine(entry.Message)
+	if line, ok := fields["log"].(string); ok {
+		if source, _ := fields["source"].(string); source == "stdout" || source == "stderr" {
+			entry.Source = source
+			entry.Message = strings.TrimSpace(line)
+			fields = parseJSONLogLine(entry.Message)
+		}
+	}
+
+	if fields == nil {
+		entry.Level = textLogLevel(entry.Message)
+		return entry
+	}
+	entry.Fields = fields
+	if value, ok := firstLogField(fields, mapping.Message); ok {
+		entry.Message = strings.TrimSpace(logFieldString(value))
+	}
+	if value, ok := firstLogField(fields, mapping.Level); ok {
+		entry.Level = normalizeLogLevel(value)
+	}
+	if entry.Level == "" {
+		entry.Level = textLogLevel(entry.Message)
+	}
+	if value, ok := firstLogField(fields, mapping.TraceID); ok {
+		entry.TraceID = logFieldString(value)
+	}
+	if value, ok := firstLogField(fields, mapping.RequestID); ok {
+		entry.RequestID = logFieldString(value)
+	}
+	return entry
+}
+
+// parseJSONLogLine returns the fields of a JSON object line, nil for anything else
+func parseJSONLogLine(line string) map[string]interface{} {
+	if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+		return nil
+	}
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// firstLogField returns the first of the candidate fields that is set
+func firstLogField(fields map[string]interface{}, candidates []string) (interface{}, bool) {
+	for _, name := range candidates {
+		if value, ok := lookupLogField(fields, name); ok && value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// lookupLogField finds a field by name, or by a dotted path into nested objects
+func lookupLogField(fields map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := fields[name]; ok {
+		return value, true
+	}
+	head, rest, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil, false
+	}
+	nested, ok := fields[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupLogField(nested, rest)
+}
+
+func logFieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeLogLevel maps level names and pino/bunyan numeric levels to a LogEntry level,
+// "" if the value is not a level
+func normalizeLogLevel(value interface{}) string {
+	if number, ok := value.(json.Number); ok {
+		n, err := strconv.Atoi(number.String())
+		if err != nil {
+			return ""
+		}
+		// pino and bunyan: 10 trace, 20 debug, 30 info, 40 warn, 50 error, 60 fatal
+		switch {
+		case n >= 50:
+			return "error"
+		case n >= 40:
+			return "warning"
+		case n >= 30:
+			return "info"
+		case n >= 10:
+			return "debug"
+		}
+		return ""
+	}
+
+	switch strings.ToLower(strings.TrimSpace(logFieldString(value))) {
+	case "trace", "debug", "verbose":
+		return "debug"
+	case "info", "information", "notice":
+		return "info"
+	case "warn", "warning":
+		return "warning"
+	case "error", "err", "fatal", "critical", "crit", "panic", "alert", "emergency", "dpanic":
+		return "error"
+	}
+	return ""
+}
+
+// textLogLevel guesses the level of a plain text line
+func textLogLevel(message string) string {
+	messageLower := strings.ToLower(message)
+	if strings.Contains(messageLower, "error") || strings.Contains(messageLower, "exception") {
+		return "error"
+	} else if strings.Contains(messageLower, "warn") {
+		return "warning"
+	} else if strings.Contains(messageLower, "debug") {
+		return "debug"
+	}
+	return "info"
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseLogEntry(t *testing.T) {
+	env := Env{LogFields: map[string]LogFieldMapping{
+		"worker": {Level: []string{"lvl_name"}, TraceID: []string{"ctx.trace"}},
+	}}
+	backend := logFieldMapping(env, "backend")
+	worker := logFieldMapping(env, "worker")
+
+	tests := []struct {
+		name    string
+		mapping LogFieldMapping
+		message string
+		want    LogEntry
+	}{
+		{
+			name:    "plain text",
+			mapping: backend,
+			message: "Exception in thread main\n",
+			want:    LogEntry{Message: "Exception in thread main", Level: "error"},
+		},
+		{
+			name:    "zap json",
+			mapping: backend,
+			message: `{"level":"warn","msg":"slow query","trace_id":"t-1","request_id":"r-1","ms":1200}`,
+			want:    LogEntry{Message: "slow query", Level: "warning", TraceID: "t-1", RequestID: "r-1"},
+		},
+		{
+			name:    "pino numeric level",
+			mapping: backend,
+			message: `{"level":50,"msg":"boom","reqId":7}`,
+			want:    LogEntry{Message: "boom", Level: "error"},
+		},
+		{
+			name:    "json without level uses the text",
+			mapping: backend,
+			message: `{"message":"debug: cache miss"}`,
+			want:    LogEntry{Message: "debug: cache miss", Level: "debug"},
+		},
+		{
+			name:    "custom mapping with nested trace",
+			mapping: worker,
+			message: `{"lvl_name":"ERROR","message":"job failed","ctx":{"trace":"abc"}}`,
+			want:    LogEntry{Message: "job failed", Level: "error", TraceID: "abc"},
+		},
+		{
+			name:    "firelens stderr with json",
+			mapping: backend,
+			message: `{"log":"{\"level\":\"info\",\"msg\":\"started\"}","source":"stderr","container_name":"backend"}`,
+			want:    LogEntry{Message: "started", Level: "info", Source: "stderr"},
+		},
+		{
+			name:    "firelens stdout with text",
+			mapping: backend,
+			message: `{"log":"listening on :8080","source":"stdout"}`,
+			want:    LogEntry{Message: "listening on :8080", Level: "info", Source: "stdout"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLogEntry(tt.mapping, "ecs/backend/1", 1714557600123, tt.message)
+			if got.Timestamp != "2024-05-01T10:00:00.123Z" || got.Stream != "ecs/backend/1" {
+				t.Errorf("timestamp %q, stream %q", got.Timestamp, got.Stream)
+			}
+			if got.Message != tt.want.Message || got.Level != tt.want.Level || got.Source != tt.want.Source ||
+				got.TraceID != tt.want.TraceID || got.RequestID != tt.want.RequestID {
+				t.Errorf("parseLogEntry() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogFilter(t *testing.T) {
+	if _, err := parseLogFilter(url.Values{"level": {"loud"}}); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if _, err := parseLogFilter(url.Values{"field": {"user_id"}}); err == nil {
+		t.Error("expected an error for a field without a value")
+	}
+
+	filter, err := parseLogFilter(url.Values{"level": {"warn,error"}, "field": {"user.id=42", "request_id=r-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping := logFieldMapping(Env{}, "backend")
+	for message, want := range map[string]bool{
+		`{"level":"error","request_id":"r-1","user":{"id":42}}`: true,
+		`{"level":"warn","request_id":"r-1","user":{"id":42}}`:  true,
+		`{"level":"info","request_id":"r-1","user":{"id":42}}`:  false,
+		`{"level":"error","request_id":"r-2","user":{"id":42}}`: false,
+		`{"level":"error","request_id":"r-1"}`:                  false,
+		`error: user.id=42 request_id=r-1`:                      false,
+	} {
+		if got := filter.Matches(parseLogEntry(mapping, "s", 0, message)); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", message, got, want)
+		}
+	}
+
+	empty, _ := parseLogFilter(url.Values{})
+	if !empty.IsEmpty() || !empty.Matches(LogEntry{Level: "debug"}) {
+		t.Error("an empty filter should match everything")
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// logsMaxEvents caps the events read per service without --follow
+const logsMaxEvents = 10000
+
+// logsPollInterval is how often new events are fetched when Live Tail is not available
+var logsPollInterval = 2 * time.Second
+
+// liveTailMaxLogGroups is the number of log groups a Live Tail session accepts
+const liveTailMaxLogGroups = 10
+
+// cloudWatchLogsAPI is the part of the CloudWatch Logs client used by `meroku logs`
+type cloudWatchLogsAPI interface {
+	cloudwatchlogs.FilterLogEventsAPIClient
+	cloudwatchlogs.DescribeLogGroupsAPIClient
+	cloudwatchlogs.DescribeLogStreamsAPIClient
+	StartLiveTail(ctx context.Context, params *cloudwatchlogs.StartLiveTailInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartLiveTailOutput, error)
+}
+
+// logSource is the log group of a service, optionally narrowed to the streams of one task
+type logSource struct {
+	Service string
+	Group   string
+	ARN     string
+	Streams []string
+}
+
+// serviceLogEvent is a log event with the service it belongs to
+type serviceLogEvent struct {
+	ID        string
+	Service   string
+	Stream    string
+	Timestamp int64 // Milliseconds since epoch
+	Message   string
+}
+
+// parseLogsSince parses --since as a duration before now (90s, 15m, 1h, 2d)
+// or as an absolute time (RFC 3339 or 2006-01-02)
+func parseLogsSince(value string, now time.Time) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days > 0 {
+			return now.Add(-time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, use a duration like 15m, 1h or 2d, or a time like 2024-01-02T15:04", value)
+}
+
+// ecsTaskID returns the task ID of a task ARN, IDs are returned as is
+func ecsTaskID(task string) string {
+	return task[strings.LastIndex(task, "/")+1:]
+}
+
+// resolveLogSources finds the log groups of the services, with a task only its streams are read
+func resolveLogSources(ctx context.Context, client cloudWatchLogsAPI, env Env, services []string, task string) ([]logSource, error) {
+	var sources []logSource
+	for _, service := range services {
+		group := constructLogGroupName(env, service)
+		resp, err := client.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String(group)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find log group %s: %w", group, err)
+		}
+		source := logSource{Service: service, Group: group}
+		for _, g := range resp.LogGroups {
+			if aws.ToString(g.LogGroupName) == group {
+				source.ARN = aws.ToString(g.LogGroupArn)
+				if source.ARN == "" {
+					source.ARN = strings.TrimSuffix(aws.ToString(g.Arn), ":*")
+				}
+			}
+		}
+		if source.ARN == "" {
+			return nil, fmt.Errorf("log group %s not found, is %s deployed in %s?", group, service, env.Env)
+		}
+
+		if task != "" {
+			streams, err := taskLogStreams(ctx, client, group, ecsTaskID(task))
+			if err != nil {
+				return nil, err
+			}
+			if len(streams) == 0 {
+				continue
+			}
+			source.Streams = streams
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no log streams found for task %s", ecsTaskID(task))
+	}
+	return sources, nil
+}
+
+// taskLogStreams returns the streams of a task, ECS names them <prefix>/<container>/<task id>
+func taskLogStreams(ctx context.Context, client cloudWatchLogsAPI, group, taskID string) ([]string, error) {
+	var streams []string
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(group),
+		OrderBy:      cwltypes.OrderByLastEventTime,
+		Descending:   aws.Bool(true),
+	})
+	// Recent tasks come first, older ones are not worth listing thousands of streams for
+	for pages := 0; paginator.HasMorePages() && pages < 10; pages++ {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list log streams of %s: %w", group, err)
+		}
+		for _, stream := range page.LogStreams {
+			if name := aws.ToString(stream.LogStreamName); strings.HasSuffix(name, "/"+taskID) {
+				streams = append(streams, name)
+			}
+		}
+	}
+	return streams, nil
+}
+
+// fetchLogEvents reads the events between start and end of all sources, oldest first.
+// It reports whether a source had more than logsMaxEvents events.
+func fetchLogEvents(ctx context.Context, client cloudWatchLogsAPI, sources []logSource, start, end time.Time, filter string) ([]serviceLogEvent, bool, error) {
+	var events []serviceLogEvent
+	truncated := false
+	for _, source := range sources {
+		input := &cloudwatchlogs.FilterLogEventsInput{
+			LogGroupName: aws.String(source.Group),
+			StartTime:    aws.Int64(start.UnixMilli()),
+			EndTime:      aws.Int64(end.UnixMilli()),
+		}
+		if len(source.Streams) > 0 {
+			input.LogStreamNames = source.Streams
+		}
+		if filter != "" {
+			input.FilterPattern = aws.String(filter)
+		}
+
+		count := 0
+		paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, input)
+		for paginator.HasMorePages() && count < logsMaxEvents {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to read logs of %s: %w", source.Service, err)
+			}
+			for _, e := range page.Events {
+				if count == logsMaxEvents {
+					truncated = true
+					break
+				}
+				events = append(events, serviceLogEvent{
+					ID:        aws.ToString(e.EventId),
+					Service:   source.Service,
+					Stream:    aws.ToString(e.LogStreamName),
+					Timestamp: aws.ToInt64(e.Timestamp),
+					Message:   strings.TrimRight(aws.ToString(e.Message), "\n"),
+				})
+				count++
+			}
+		}
+		if count == logsMaxEvents && paginator.HasMorePages() {
+			truncated = true
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+	return events, truncated, nil
+}
+
+// followLogs prints new events after the ones the cursor printed until the context is cancelled.
+// It uses a Live Tail session and falls back to polling if Live Tail is not available
+// (permissions, region, LocalStack). Both continue from the cursor, so nothing is printed twice.
+func followLogs(ctx context.Context, client cloudWatchLogsAPI, sources []logSource, cursor *logCursor, filter string, printEvent func(serviceLogEvent), notice func(string)) error {
+	if len(sources) <= liveTailMaxLogGroups {
+		// Events written since the last printed one, before the session started, are fetched once it did
+		backfill := func() error {
+			events, _, err := fetchLogEvents(ctx, client, sources, cursor.Since(), time.Now(), filter)
+			if err != nil {
+				return err
+			}
+			cursor.Print(events, printEvent)
+			return nil
+		}
+		err := liveTailLogs(ctx, client, sources, filter, backfill, func(event serviceLogEvent) {
+			cursor.Print([]serviceLogEvent{event}, printEvent)
+		})
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		notice(fmt.Sprintf("Live Tail is not available (%v), polling every %s", err, logsPollInterval))
+	}
+	return pollLogs(ctx, client, sources, cursor, filter, printEvent)
+}
+
+// liveTailLogs streams events from Live Tail sessions, a session ends after 3 hours and is restarted.
+// started runs whenever a session started.
+func liveTailLogs(ctx context.Context, client cloudWatchLogsAPI, sources []logSource, filter string, started func() error, printEvent func(serviceLogEvent)) error {
+	input := &cloudwatchlogs.StartLiveTailInput{}
+	services := map[string]string{}
+	streams := map[string]bool{}
+	for _, source := range sources {
+		input.LogGroupIdentifiers = append(input.LogGroupIdentifiers, source.ARN)
+		services[source.ARN], services[source.Group] = source.Service, source.Service
+		for _, stream := range source.Streams {
+			streams[stream] = true
+		}
+	}
+	// Live Tail only filters streams for a single log group, otherwise it's done here
+	if len(sources) == 1 && len(sources[0].Streams) > 0 {
+		input.LogStreamNames = sources[0].Streams
+	}
+	if filter != "" {
+		input.LogEventFilterPattern = aws.String(filter)
+	}
+
+	for {
+		resp, err := client.StartLiveTail(ctx, input)
+		if err != nil {
+			return err
+		}
+		stream := resp.GetStream()
+		err = readLiveTailSession(stream.Events(), services, streams, started, printEvent)
+		if closeErr := stream.Close(); err == nil {
+			err = closeErr
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readLiveTailSession prints the events of a session, without streams all of them
+func readLiveTailSession(events <-chan cwltypes.StartLiveTailResponseStream, services map[string]string, streams map[string]bool, started func() error, printEvent func(serviceLogEvent)) error {
+	for event := range events {
+		switch event := event.(type) {
+		case *cwltypes.StartLiveTailResponseStreamMemberSessionStart:
+			if err := started(); err != nil {
+				return err
+			}
+		case *cwltypes.StartLiveTailResponseStreamMemberSessionUpdate:
+			for _, e := range event.Value.SessionResults {
+				name := aws.ToString(e.LogStreamName)
+				if len(streams) > 0 && !streams[name] {
+					continue
+				}
+				printEvent(serviceLogEvent{
+					Service:   services[aws.ToString(e.LogGroupIdentifier)],
+					Stream:    name,
+					Timestamp: aws.ToInt64(e.Timestamp),
+					Message:   strings.TrimRight(aws.ToString(e.Message), "\n"),
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// pollLogs fetches new events every logsPollInterval and prints the ones the cursor did not print yet
+func pollLogs(ctx context.Context, client cloudWatchLogsAPI, sources []logSource, cursor *logCursor, filter string, printEvent func(serviceLogEvent)) error {
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		events, _, err := fetchLogEvents(ctx, client, sources, cursor.Since(), time.Now(), filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		cursor.Print(events, printEvent)
+	}
+}
+
+// logCursorWindow is how long printed events are remembered before the latest one, fetches
+// overlap Live Tail by a few seconds at most
+const logCursorWindow = 5 * time.Minute
+
+// logCursor prints events once. Fetches start at the millisecond of the last event and overlap
+// Live Tail, so the recently printed events are remembered.
+type logCursor struct {
+	last    int64
+	seen    map[string]int64 // Event key to timestamp
+	pruneAt int64            // Forgetting old keys waits until last passes this, once per window
+}
+
+func newLogCursor(since time.Time) *logCursor {
+	return &logCursor{last: since.UnixMilli(), seen: map[string]int64{}, pruneAt: since.Add(logCursorWindow).UnixMilli()}
+}
+
+// logEventKey identifies an event. Live Tail events have no event ID, so the stream, time
+// and message are used; identical lines in the same millisecond of a stream print once.
+func logEventKey(e serviceLogEvent) string {
+	return fmt.Sprintf("%s\x00%d\x00%s", e.Stream, e.Timestamp, e.Message)
+}
+
+// Since is the start of the next fetch
+func (c *logCursor) Since() time.Time {
+	return time.UnixMilli(c.last)
+}
+
+// Print prints the events that were not printed before
+func (c *logCursor) Print(events []serviceLogEvent, printEvent func(serviceLogEvent)) {
+	for _, e := range events {
+		key := logEventKey(e)
+		if _, ok := c.seen[key]; ok {
+			continue
+		}
+		printEvent(e)
+		c.seen[key] = e.Timestamp
+		if e.Timestamp > c.last {
+			c.last = e.Timestamp
+		}
+	}
+	if c.last < c.pruneAt {
+		return
+	}
+	for key, ts := range c.seen {
+		if ts < c.last-logCursorWindow.Milliseconds() {
+			delete(c.seen, key)
+		}
+	}
+	c.pruneAt = c.last + logCursorWindow.Milliseconds()
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// fakeCloudWatchLogs serves events per log group, two per page, and has no Live Tail
+type fakeCloudWatchLogs struct {
+	mu       sync.Mutex
+	events   map[string][]cwltypes.FilteredLogEvent
+	patterns []string
+}
+
+func (f *fakeCloudWatchLogs) add(group, stream string, ts int64, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events[group] = append(f.events[group], cwltypes.FilteredLogEvent{
+		EventId:       aws.String(fmt.Sprintf("%s-%d", group, len(f.events[group]))),
+		LogStreamName: aws.String(stream),
+		Timestamp:     aws.Int64(ts),
+		Message:       aws.String(message + "\n"),
+	})
+}
+
+func (f *fakeCloudWatchLogs) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.patterns = append(f.patterns, aws.ToString(params.FilterPattern))
+
+	var matching []cwltypes.FilteredLogEvent
+	for _, e := range f.events[aws.ToString(params.LogGroupName)] {
+		ts := aws.ToInt64(e.Timestamp)
+		if ts < aws.ToInt64(params.StartTime) || ts > aws.ToInt64(params.EndTime) {
+			continue
+		}
+		if len(params.LogStreamNames) > 0 && !slices.Contains(params.LogStreamNames, aws.ToString(e.LogStreamName)) {
+			continue
+		}
+		matching = append(matching, e)
+	}
+
+	start := len(aws.ToString(params.NextToken))
+	end := min(start+2, len(matching))
+	out := &cloudwatchlogs.FilterLogEventsOutput{Events: matching[start:end]}
+	if end < len(matching) {
+		out.NextToken = aws.String(strings.Repeat("x", end))
+	}
+	return out, nil
+}
+
+func (f *fakeCloudWatchLogs) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	out := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	for group := range f.events {
+		if strings.HasPrefix(group, aws.ToString(params.LogGroupNamePrefix)) {
+			out.LogGroups = append(out.LogGroups, cwltypes.LogGroup{
+				LogGroupName: aws.String(group),
+				Arn:          aws.String("arn:aws:logs:eu-west-1:111111111111:log-group:" + group + ":*"),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeCloudWatchLogs) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	out := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	seen := map[string]bool{}
+	for _, e := range f.events[aws.ToString(params.LogGroupName)] {
+		if name := aws.ToString(e.LogStreamName); !seen[name] {
+			seen[name] = true
+			out.LogStreams = append(out.LogStreams, cwltypes.LogStream{LogStreamName: aws.String(name)})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeCloudWatchLogs) StartLiveTail(ctx context.Context, params *cloudwatchlogs.StartLiveTailInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartLiveTailOutput, error) {
+	return nil, errors.New("AccessDeniedException")
+}
+
+func newFakeLogsEnv() (*fakeCloudWatchLogs, Env) {
+	env := Env{Project: "shop", Env: "dev", ScheduledTasks: []ScheduledTask{{Name: "cleanup"}}}
+	f := &fakeCloudWatchLogs{events: map[string][]cwltypes.FilteredLogEvent{}}
+	f.add("shop_backend_dev", "ecs/backend/task1", 1000, "backend starting")
+	f.add("shop_backend_dev", "ecs/backend/task2", 3000, "GET /health 200")
+	f.add("shop_backend_dev", "ecs/backend/task1", 5000, "GET /orders 500")
+	f.add("shop_task_cleanup_dev", "ecs/cleanup/task9", 2000, "cleanup started")
+	f.add("shop_task_cleanup_dev", "ecs/cleanup/task9", 4000, "cleanup done")
+	return f, env
+}
+
+func TestParseLogsSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	for value, want := range map[string]time.Time{
+		"15m":              now.Add(-15 * time.Minute),
+		"1h":               now.Add(-time.Hour),
+		"2d":               now.Add(-48 * time.Hour),
+		"2024-05-01":       time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+		"2024-05-01T08:30": time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local),
+	} {
+		got, err := parseLogsSince(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseLogsSince(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "yesterday", "-1h", "0d"} {
+		if _, err := parseLogsSince(value, now); err == nil {
+			t.Errorf("parseLogsSince(%q) succeeded", value)
+		}
+	}
+}
+
+func TestParseLogsArgs(t *testing.T) {
+	opts, env, err := parseLogsArgs([]string{"dev", "backend,worker", "cleanup", "--follow", "--since", "30m", "--filter", "ERROR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env != "dev" || strings.Join(opts.Services, " ") != "backend worker cleanup" || !opts.Follow || opts.Since != "30m" || opts.Filter != "ERROR" {
+		t.Errorf("parseLogsArgs() = %+v, %q", opts, env)
+	}
+	if _, _, err := parseLogsArgs([]string{"dev", "--follow"}); err == nil {
+		t.Error("expected an error without a service")
+	}
+}
+
+func TestResolveLogSources(t *testing.T) {
+	client, env := newFakeLogsEnv()
+	ctx := context.Background()
+
+	sources, err := resolveLogSources(ctx, client, env, []string{"backend", "cleanup"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[1].Group != "shop_task_cleanup_dev" ||
+		sources[0].ARN != "arn:aws:logs:eu-west-1:111111111111:log-group:shop_backend_dev" {
+		t.Errorf("sources = %+v", sources)
+	}
+
+	sources, err = resolveLogSources(ctx, client, env, []string{"backend", "cleanup"}, "arn:aws:ecs:eu-west-1:111111111111:task/shop-dev/task1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || strings.Join(sources[0].Streams, ",") != "ecs/backend/task1" {
+		t.Errorf("task sources = %+v", sources)
+	}
+
+	if _, err := resolveLogSources(ctx, client, env, []string{"api"}, ""); err == nil || !strings.Contains(err.Error(), "shop_service_api_dev not found") {
+		t.Errorf("missing log group error = %v", err)
+	}
+	if _, err := resolveLogSources(ctx, client, env, []string{"backend"}, "nope"); err == nil {
+		t.Error("expected an error for a task without streams")
+	}
+}
+
+func TestFetchLogEvents(t *testing.T) {
+	client, env := newFakeLogsEnv()
+	ctx := context.Background()
+	sources, err := resolveLogSources(ctx, client, env, []string{"backend", "cleanup"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, truncated, err := fetchLogEvents(ctx, client, sources, time.UnixMilli(0), time.UnixMilli(4500), "ERROR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, fmt.Sprintf("%d %s %s", e.Timestamp, e.Service, e.Message))
+	}
+	want := "1000 backend backend starting|2000 cleanup cleanup started|3000 backend GET /health 200|4000 cleanup cleanup done"
+	if strings.Join(got, "|") != want || truncated {
+		t.Errorf("events = %s, truncated %v", strings.Join(got, "|"), truncated)
+	}
+	if !slices.Contains(client.patterns, "ERROR") {
+		t.Errorf("filter pattern was not passed: %v", client.patterns)
+	}
+}
+
+func TestFollowLogsFallsBackToPolling(t *testing.T) {
+	defer func(interval time.Duration) { logsPollInterval = interval }(logsPollInterval)
+	logsPollInterval = 10 * time.Millisecond
+
+	client, env := newFakeLogsEnv()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sources, err := resolveLogSources(ctx, client, env, []string{"backend"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UnixMilli()
+	client.add("shop_backend_dev", "ecs/backend/task1", now+1, "first")
+	client.add("shop_backend_dev", "ecs/backend/task1", now+1, "second")
+
+	var mu sync.Mutex
+	var printed, notices []string
+	done := make(chan error)
+	go func() {
+		done <- followLogs(ctx, client, sources, newLogCursor(time.UnixMilli(now)), "", func(e serviceLogEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			printed = append(printed, e.Message)
+			if len(printed) == 3 {
+				cancel()
+			}
+		}, func(notice string) { notices = append(notices, notice) })
+	}()
+
+	// Events from later polls are printed once, including ones in the same millisecond
+	time.Sleep(50 * time.Millisecond)
+	client.add("shop_backend_dev", "ecs/backend/task2", now+1, "third")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("followLogs did not return")
+	}
+	if strings.Join(printed, ",") != "first,second,third" {
+		t.Errorf("printed = %v", printed)
+	}
+	if len(notices) != 1 || !strings.Contains(notices[0], "Live Tail is not available") {
+		t.Errorf("notices = %v", notices)
+	}
+}
+
+func TestReadLiveTailSessionBackfillsGap(t *testing.T) {
+	client, env := newFakeLogsEnv()
+	ctx := context.Background()
+	sources, err := resolveLogSources(ctx, client, env, []string{"backend"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var printed []string
+	printEvent := func(e serviceLogEvent) { printed = append(printed, e.Message) }
+	events, _, err := fetchLogEvents(ctx, client, sources, time.UnixMilli(0), time.UnixMilli(5000), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor := newLogCursor(time.UnixMilli(0))
+	cursor.Print(events, printEvent)
+
+	// Written after the initial fetch, before the session started
+	client.add("shop_backend_dev", "ecs/backend/task1", 5000, "same millisecond")
+	client.add("shop_backend_dev", "ecs/backend/task2", 6000, "in the gap")
+
+	session := make(chan cwltypes.StartLiveTailResponseStream, 2)
+	session <- &cwltypes.StartLiveTailResponseStreamMemberSessionStart{}
+	session <- &cwltypes.StartLiveTailResponseStreamMemberSessionUpdate{Value: cwltypes.LiveTailSessionUpdate{
+		SessionResults: []cwltypes.LiveTailSessionLogEvent{
+			{LogGroupIdentifier: aws.String(sources[0].ARN), LogStreamName: aws.String("ecs/backend/task2"), Timestamp: aws.Int64(6000), Message: aws.String("in the gap\n")},
+			{LogGroupIdentifier: aws.String(sources[0].ARN), LogStreamName: aws.String("ecs/backend/task1"), Timestamp: aws.Int64(7000), Message: aws.String("live\n")},
+		},
+	}}
+	close(session)
+
+	backfill := func() error {
+		events, _, err := fetchLogEvents(ctx, client, sources, cursor.Since(), time.UnixMilli(6500), "")
+		if err != nil {
+			return err
+		}
+		cursor.Print(events, printEvent)
+		return nil
+	}
+	services := map[string]string{sources[0].ARN: "backend"}
+	if err := readLiveTailSession(session, services, nil, backfill, func(e serviceLogEvent) {
+		cursor.Print([]serviceLogEvent{e}, printEvent)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A poll after Live Tail stopped starts at the last event and prints nothing again
+	client.add("shop_backend_dev", "ecs/backend/task1", 7000, "live")
+	events, _, err = fetchLogEvents(ctx, client, sources, cursor.Since(), time.UnixMilli(8000), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor.Print(events, printEvent)
+
+	want := "backend starting,GET /health 200,GET /orders 500,same millisecond,in the gap,live"
+	if strings.Join(printed, ",") != want {
+		t.Errorf("printed = %v", printed)
+	}
+}
+
+func TestLogCursorForgetsOldEvents(t *testing.T) {
+	cursor := newLogCursor(time.UnixMilli(0))
+	printed := 0
+	printEvent := func(serviceLogEvent) { printed++ }
+
+	// One event per second for 20 minutes, printed one at a time like Live Tail does
+	window := logCursorWindow.Milliseconds()
+	for ts := int64(0); ts < 4*window; ts += 1000 {
+		cursor.Print([]serviceLogEvent{{Stream: "s", Timestamp: ts, Message: "line"}}, printEvent)
+		if len(cursor.seen) > int(2*window/1000)+1 {
+			t.Fatalf("%d keys remembered at %d", len(cursor.seen), ts)
+		}
+	}
+	if printed != int(4*window/1000) {
+		t.Errorf("printed %d events", printed)
+	}
+
+	// Events within the window are still deduplicated
+	cursor.Print([]serviceLogEvent{{Stream: "s", Timestamp: 4*window - 1000, Message: "line"}}, printEvent)
+	if printed != int(4*window/1000) {
+		t.Errorf("a recent event was printed twice")
+	}
+}
@@ -142,6 +142,60 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Handle static site commands (before environment selection)
+	if len(args) > 0 && args[0] == "site" {
+		handleSiteCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle database commands (before environment selection)
+	if len(args) > 0 && args[0] == "db" {
+		handleDBCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle log commands (before environment selection)
+	if len(args) > 0 && args[0] == "logs" {
+		handleLogsCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle one-off task commands (before environment selection)
+	if len(args) > 0 && args[0] == "run" {
+		handleRunCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle scheduled task commands (before environment selection)
+	if len(args) > 0 && args[0] == "task" {
+		handleTaskCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle certificate commands (before environment selection)
+	if len(args) > 0 && args[0] == "certs" {
+		handleCertsCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle AWS SSO commands (before environment selection)
+	if len(args) > 0 && args[0] == "sso" {
+		handleSSOCommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle AI usage commands (before environment selection)
+	if len(args) > 0 && args[0] == "ai" {
+		handleAICommand(args[1:])
+		os.Exit(0)
+	}
+
+	// Handle AI agent session commands (before environment selection)
+	if len(args) > 0 && args[0] == "agent" {
+		handleAgentCommand(args[1:])
+		os.Exit(0)
+	}
+
 	registerCustomHelpers()
 
 	// Handle environment and profile selection
@@ -244,16 +298,22 @@ func main() {
 func handleDNSCommand(args []string) {
 	if len(args) == 0 {
 		fmt.Println("DNS management commands:")
-		fmt.Println("  dns setup    - Run DNS setup wizard")
+		fmt.Println("  dns setup    - Run DNS setup wizard (--dnssec to sign the root zone)")
+		fmt.Println("               --non-interactive [--from dns.yaml] [--json] runs it from a config file")
 		fmt.Println("  dns status   - Show DNS configuration status")
-		fmt.Println("  dns validate - Validate DNS configuration")
+		fmt.Println("  dns validate - Validate DNS configuration ([--dnssec] [--resolvers ip,ip])")
 		fmt.Println("  dns remove   - Remove subdomain delegation")
+		fmt.Println("  dns records  - Plan or apply extra records (plan|apply [--env name] [--yes])")
+		fmt.Println("  dns import   - Import an existing zone (<domain> --from-profile p | --zone-file f)")
 		return
 	}
 
 	switch args[0] {
 	case "setup":
-		runDNSSetupWizard()
+		if err := runDNSSetup(args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case "status":
 		if err := runDNSStatus(nil, args[1:]); err != nil {
 			fmt.Printf("Error: %v\n", err)
@@ -273,9 +333,19 @@ func handleDNSCommand(args []string) {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+	case "records":
+		if err := runDNSRecords(args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "import":
+		if err := runDNSImport(args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		fmt.Printf("Unknown DNS command: %s\n", args[0])
-		fmt.Println("Available commands: setup, status, validate, remove")
+		fmt.Println("Available commands: setup, status, validate, remove, records, import")
 		os.Exit(1)
 	}
 }
@@ -344,8 +414,8 @@ func handleDebugScreen(screenName string) {
 	case "list", "help", "":
 		fmt.Println("Debug Mode - Available Screens:")
 		fmt.Println()
-		fmt.Println("  api_missing_key, api    - Anthropic API key required screen")
-		fmt.Println("                             Shows when ANTHROPIC_API_KEY is not set")
+		fmt.Println("  api_missing_key, api    - AI provider setup screen")
+		fmt.Println("                             Shows when the AI provider is not configured")
 		fmt.Println()
 		fmt.Println("  terraform_plan, plan    - Terraform plan viewer TUI")
 		fmt.Println("                             Interactive plan review with sample data")
@@ -453,8 +523,7 @@ func performAutoSSOValidation() error {
 	fmt.Printf("\nWould you like to fix this now?\n\n")
 
 	// Check if Anthropic API key is available
-	anthropicKey := os.Getenv("ANTHROPIC_API_KEY")
-	hasAPIKey := anthropicKey != ""
+	hasAPIKey := isLLMConfigured()
 
 	// Build options based on API key availability
 	options := []huh.Option[string]{
@@ -494,10 +563,7 @@ func performAutoSSOValidation() error {
 		}
 	case "agent_disabled":
 		fmt.Println("\n❌ AI Agent Not Available")
-		fmt.Println("The AI Agent requires an Anthropic API key to function.")
-		fmt.Println("Please set the ANTHROPIC_API_KEY environment variable:")
-		fmt.Println("\n  export ANTHROPIC_API_KEY=your_key_here")
-		fmt.Println("\nGet your API key from: https://console.anthropic.com/settings/keys")
+		printLLMSetupHelp("The AI Agent")
 		fmt.Println("Returning to main menu...")
 		return nil
 	case "skip":
@@ -99,8 +99,7 @@ func mainMenu() string {
 // ssoToolsMenu shows the AWS SSO tools submenu
 func ssoToolsMenu() {
 	// Check if Anthropic API key is available
-	anthropicKey := os.Getenv("ANTHROPIC_API_KEY")
-	hasAPIKey := anthropicKey != ""
+	hasAPIKey := isLLMConfigured()
 
 	// Build options based on API key availability
 	options := []huh.Option[string]{
@@ -138,10 +137,7 @@ func ssoToolsMenu() {
 		ssoToolsMenu() // Return to SSO menu
 	case "agent_disabled":
 		fmt.Println("\n❌ AI Agent Not Available")
-		fmt.Println("The AI Agent requires an Anthropic API key to function.")
-		fmt.Println("Please set the ANTHROPIC_API_KEY environment variable:")
-		fmt.Println("\n  export ANTHROPIC_API_KEY=your_key_here")
-		fmt.Println("\nGet your API key from: https://console.anthropic.com/settings/keys")
+		printLLMSetupHelp("The AI Agent")
 		ssoToolsMenu() // Return to SSO menu
 	case "validate":
 		validateAWSFromMenu()
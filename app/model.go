@@ -21,6 +21,7 @@ type Env struct {
 	// VPC Configuration
 	UseDefaultVPC bool   `yaml:"use_default_vpc"`
 	VPCCIDR       string `yaml:"vpc_cidr,omitempty"` // Optional, VPC module has default
+	Network       Network `yaml:"network,omitempty"` // Optional private subnets + NAT (custom VPC only)
 	// ECR Configuration
 	ECRStrategy      string `yaml:"ecr_strategy,omitempty"`       // "local" or "cross_account"
 	ECRAccountID     string `yaml:"ecr_account_id,omitempty"`     // For cross-account ECR access
@@ -41,6 +42,73 @@ type Env struct {
 	Buckets             []BucketConfig       `yaml:"buckets"`
 	Services            []Service            `yaml:"services"`
 	AmplifyApps         []AmplifyApp         `yaml:"amplify_apps,omitempty"`
+	WAF                 WAF                  `yaml:"waf,omitempty"`
+	StaticSites         []StaticSite         `yaml:"static_sites,omitempty"`
+	DNSRecords          []DNSRecord          `yaml:"dns_records,omitempty"` // Extra records in the environment's zone
+	LogFields           map[string]LogFieldMapping `yaml:"log_fields,omitempty"` // JSON log fields per service, "backend" included
+	// AI troubleshooting agent
+	Agent AgentCredentialsConfig `yaml:"agent,omitempty"`
+}
+
+// Network configures private networking for the custom VPC.
+// When PrivateSubnets is enabled, ECS tasks and RDS are placed in private subnets
+// and reach the internet through NAT.
+type Network struct {
+	PrivateSubnets  bool     `yaml:"private_subnets"`
+	NATMode         string   `yaml:"nat_mode,omitempty"`          // "single" (default), "per_az" or "instance"
+	NATInstanceType string   `yaml:"nat_instance_type,omitempty"` // For nat_mode "instance", default t4g.nano
+	VPCEndpoints    []string `yaml:"vpc_endpoints,omitempty"`     // s3, dynamodb, ecr, logs, ssm, secretsmanager, sts
+}
+
+// NAT modes supported by the VPC module
+const (
+	NATModeSingle   = "single"
+	NATModePerAZ    = "per_az"
+	NATModeInstance = "instance"
+)
+
+// Gateway endpoints are free, interface endpoints are billed per AZ-hour
+var (
+	gatewayVPCEndpoints   = []string{"s3", "dynamodb"}
+	interfaceVPCEndpoints = []string{"ecr", "logs", "ssm", "secretsmanager", "sts"}
+)
+
+// WAF configures an AWS WAF web ACL in front of the environment's public entrypoint.
+// AWS WAF supports ALBs but not API Gateway HTTP APIs, so alb.enabled is required.
+type WAF struct {
+	Enabled      bool     `yaml:"enabled"`
+	ManagedRules []string `yaml:"managed_rules,omitempty"` // core_rule_set, known_bad_inputs, ip_reputation (default: all)
+	RateLimit    int      `yaml:"rate_limit,omitempty"`    // Max requests per IP per 5 minutes, 0 disables
+	AllowIPs     []string `yaml:"allow_ips,omitempty"`     // CIDRs always allowed
+	DenyIPs      []string `yaml:"deny_ips,omitempty"`      // CIDRs always blocked
+}
+
+// AgentCredentialsConfig scopes the AWS credentials the AI troubleshooting agent runs with.
+// Without a role, IAM users get a read-only federation token; SSO and role
+// sessions can't be down-scoped and fall back to the profile.
+type AgentCredentialsConfig struct {
+	RoleARN         string   `yaml:"role_arn,omitempty"`         // Role assumed for agent sessions
+	WriteRoleARN    string   `yaml:"write_role_arn,omitempty"`   // Role assumed once the user grants write access, default role_arn
+	ExternalID      string   `yaml:"external_id,omitempty"`      // For roles in another account
+	ReadPolicyARNs  []string `yaml:"read_policy_arns,omitempty"` // Session policies for reads, default ReadOnlyAccess
+	SessionDuration int      `yaml:"session_duration,omitempty"` // Seconds, 900-43200 (default 3600)
+	// Use the profile unscoped when read-only credentials can't be created (e.g. SSO without role_arn)
+	AllowUnscopedProfile bool `yaml:"allow_unscoped_profile,omitempty"`
+}
+
+// WAF managed rule groups and the AWS rule group names they map to
+var wafManagedRuleGroups = map[string]string{
+	"core_rule_set":    "AWSManagedRulesCommonRuleSet",
+	"known_bad_inputs": "AWSManagedRulesKnownBadInputsRuleSet",
+	"ip_reputation":    "AWSManagedRulesAmazonIpReputationList",
+}
+
+// enabledManagedRules returns the managed rule groups in effect (all of them when unset)
+func (w WAF) enabledManagedRules() []string {
+	if len(w.ManagedRules) > 0 {
+		return w.ManagedRules
+	}
+	return []string{"core_rule_set", "known_bad_inputs", "ip_reputation"}
 }
 
 type AppSync struct {
@@ -133,6 +201,11 @@ type Postgres struct {
 	DeletionProtection                bool   `yaml:"deletion_protection"`
 	SkipFinalSnapshot                 bool   `yaml:"skip_final_snapshot"`
 	IAMDatabaseAuthenticationEnabled  bool   `yaml:"iam_database_authentication_enabled"`
+	// Backups (both RDS and Aurora); unset keeps the AWS defaults
+	BackupRetentionPeriod *int   `yaml:"backup_retention_period,omitempty"` // Days, 0-35 (Aurora 1-35)
+	BackupWindow          string `yaml:"backup_window,omitempty"`           // UTC "hh24:mi-hh24:mi", e.g. 03:00-04:00
+	// Database host for the backend instead of the managed one, set after `meroku db restore --repoint`
+	EndpointOverride string `yaml:"endpoint_override,omitempty"`
 }
 
 type Cognito struct {
@@ -206,6 +279,42 @@ type DNSConfig struct {
 	RootDomain     string           `yaml:"root_domain"`
 	RootAccount    DNSRootAccount   `yaml:"root_account"`
 	DelegatedZones []DelegatedZone  `yaml:"delegated_zones"`
+	Records        []DNSRecord      `yaml:"records,omitempty"` // Extra records in the root zone
+	Resolvers      []DNSResolver    `yaml:"resolvers,omitempty"` // Propagation checks, default: public resolvers
+	DNSSEC         bool             `yaml:"dnssec,omitempty"`    // Sign the root zone and validate the chain of trust
+}
+
+// DNSResolver is a recursive resolver used for propagation checks,
+// e.g. an internal resolver in split-horizon setups
+type DNSResolver struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"` // host or host:port, port 53 by default
+}
+
+// LogFieldMapping names the fields of a service's JSON log lines, each a list of
+// candidates that may use dots for nested fields. Empty lists use the common names.
+type LogFieldMapping struct {
+	Level     []string `yaml:"level,omitempty"`      // Default: level, severity, lvl, log.level
+	Message   []string `yaml:"message,omitempty"`    // Default: message, msg
+	TraceID   []string `yaml:"trace_id,omitempty"`   // Default: trace_id, traceId, trace.id, dd.trace_id
+	RequestID []string `yaml:"request_id,omitempty"` // Default: request_id, requestId, req_id, http.request_id
+}
+
+// DNSRecord is a record kept in sync with Route53 by `meroku dns records apply`,
+// e.g. verification TXT, MX for a mail provider or CNAMEs to SaaS providers
+type DNSRecord struct {
+	Name   string    `yaml:"name"`             // Relative to the zone, "@" or empty for the apex
+	Type   string    `yaml:"type"`             // A, AAAA, CNAME, TXT, MX, SRV, CAA or NS
+	Values []string  `yaml:"values,omitempty"` // TXT values are quoted automatically
+	TTL    int64     `yaml:"ttl,omitempty"`    // Default: 300, ignored for aliases
+	Alias  *DNSAlias `yaml:"alias,omitempty"`  // Instead of values, for A/AAAA/CNAME
+}
+
+// DNSAlias points a record at an AWS resource (ALB, CloudFront, S3 website, ...)
+type DNSAlias struct {
+	DNSName              string `yaml:"dns_name"`
+	HostedZoneID         string `yaml:"hosted_zone_id"` // Hosted zone of the target, e.g. Z2FDTNDATAQYW2 for CloudFront
+	EvaluateTargetHealth bool   `yaml:"evaluate_target_health,omitempty"`
 }
 
 type DNSRootAccount struct {
@@ -257,6 +366,21 @@ type AmplifyBranch struct {
 	CustomSubdomains          []string          `yaml:"custom_subdomains,omitempty"`              // For branch-specific subdomains
 }
 
+// StaticSite represents a prebuilt frontend hosted on S3 behind CloudFront (alternative to Amplify)
+// Files are uploaded with `meroku site publish <env> <site> <dir>`
+type StaticSite struct {
+	Name            string `yaml:"name"`
+	SubdomainPrefix string `yaml:"subdomain_prefix,omitempty"` // Custom domain under the Domain zone (requires domain.enabled)
+	SPA             bool   `yaml:"spa,omitempty"`              // Serve index_document for unknown paths
+	IndexDocument   string `yaml:"index_document,omitempty"`   // Default: index.html
+	PriceClass      string `yaml:"price_class,omitempty"`      // PriceClass_100 (default), PriceClass_200, PriceClass_All
+}
+
+// staticSiteID matches the bucket name and distribution comment created by modules/static_site
+func staticSiteID(project, site, env string) string {
+	return fmt.Sprintf("%s-site-%s-%s", project, site, env)
+}
+
 // create function which generate random string
 func generateRandomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
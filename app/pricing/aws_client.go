@@ -172,6 +172,13 @@ func getHardcodedFallbackRates() *PriceRates {
 			DataPerGBMonth: 0.045, // $/GB processed
 		},
 
+		// WAF Pricing
+		WAF: WAFPricing{
+			WebACLPerMonth:     5.00, // $/web ACL/month
+			RulePerMonth:       1.00, // $/rule/month (managed rule groups count as one rule)
+			RequestsPerMillion: 0.60, // $/million requests
+		},
+
 		// CloudWatch Pricing
 		CloudWatch: CloudWatchPricing{
 			LogsIngestionPerGB: 0.50,  // $/GB ingested
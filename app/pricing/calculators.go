@@ -262,3 +262,44 @@ func CalculateECRPrice(storageGB float64, rates *PriceRates) float64 {
 	monthlyPrice := storageGB * rates.ECR.StoragePerGBMonth
 	return monthlyPrice
 }
+
+// CalculateNATGatewayPrice calculates monthly cost for NAT gateways
+// Includes fixed hourly price per gateway + per-GB data processing
+//
+// @param config - NAT gateway configuration (gateway count, GB processed)
+// @param rates - Current pricing rates from cache
+// @return Monthly cost in USD
+func CalculateNATGatewayPrice(config NATGatewayConfig, rates *PriceRates) float64 {
+	// Fixed hourly cost per gateway
+	hourlyCost := float64(config.Count) * rates.NATGateway.HourlyPrice * HoursPerMonth
+
+	// Data processing cost (charged once regardless of gateway count)
+	dataCost := config.DataProcessedGB * rates.NATGateway.DataPerGBMonth
+
+	totalMonthly := hourlyCost + dataCost
+
+	log.Printf("[Pricing] NAT gateway cost: count=%d, data=%.1fGB, total=%.2f/mo",
+		config.Count, config.DataProcessedGB, totalMonthly)
+
+	return totalMonthly
+}
+
+// CalculateWAFPrice calculates monthly cost for an AWS WAF web ACL
+// Includes web ACL fee + per-rule fee + per-request inspection
+//
+// @param config - WAF configuration (rule count, requests per month)
+// @param rates - Current pricing rates from cache
+// @return Monthly cost in USD
+func CalculateWAFPrice(config WAFConfig, rates *PriceRates) float64 {
+	fixedCost := rates.WAF.WebACLPerMonth + float64(config.Rules)*rates.WAF.RulePerMonth
+
+	millionRequests := float64(config.MonthlyRequests) / 1000000.0
+	requestCost := millionRequests * rates.WAF.RequestsPerMillion
+
+	totalMonthly := fixedCost + requestCost
+
+	log.Printf("[Pricing] WAF cost: rules=%d, requests=%d/mo, total=%.2f/mo",
+		config.Rules, config.MonthlyRequests, totalMonthly)
+
+	return totalMonthly
+}
@@ -35,6 +35,15 @@ func getTestRates() *PriceRates {
 		CloudWatch: CloudWatchPricing{
 			LogsIngestionPerGB: 0.50,
 		},
+		NATGateway: NATGatewayPricing{
+			HourlyPrice:    0.045,
+			DataPerGBMonth: 0.045,
+		},
+		WAF: WAFPricing{
+			WebACLPerMonth:     5.00,
+			RulePerMonth:       1.00,
+			RequestsPerMillion: 0.60,
+		},
 	}
 }
 
@@ -248,6 +257,100 @@ func TestCalculateS3Price(t *testing.T) {
 	}
 }
 
+// TestCalculateNATGatewayPrice tests NAT gateway pricing calculations
+func TestCalculateNATGatewayPrice(t *testing.T) {
+	rates := getTestRates()
+
+	tests := []struct {
+		name     string
+		config   NATGatewayConfig
+		expected float64
+	}{
+		{
+			name: "Single NAT gateway, 10GB processed",
+			config: NATGatewayConfig{
+				Count:           1,
+				DataProcessedGB: 10,
+			},
+			// Hourly: 1 * 0.045 * 730 = 32.85
+			// Data: 10 * 0.045 = 0.45
+			// Total: 32.85 + 0.45 = 33.30
+			expected: 33.30,
+		},
+		{
+			name: "Per-AZ NAT gateways (2), 1000GB processed",
+			config: NATGatewayConfig{
+				Count:           2,
+				DataProcessedGB: 1000,
+			},
+			// Hourly: 2 * 0.045 * 730 = 65.70
+			// Data: 1000 * 0.045 = 45.00
+			// Total: 65.70 + 45.00 = 110.70
+			expected: 110.70,
+		},
+		{
+			name: "No NAT gateways",
+			config: NATGatewayConfig{
+				Count:           0,
+				DataProcessedGB: 0,
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateNATGatewayPrice(tt.config, rates)
+			if !floatEquals(result, tt.expected, 0.01) {
+				t.Errorf("CalculateNATGatewayPrice() = %.2f, expected %.2f", result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCalculateWAFPrice tests AWS WAF pricing calculations
+func TestCalculateWAFPrice(t *testing.T) {
+	rates := getTestRates()
+
+	tests := []struct {
+		name     string
+		config   WAFConfig
+		expected float64
+	}{
+		{
+			name: "3 managed rule groups, 100k requests",
+			config: WAFConfig{
+				Rules:           3,
+				MonthlyRequests: 100000,
+			},
+			// Fixed: 5.00 + 3 * 1.00 = 8.00
+			// Requests: 0.1 * 0.60 = 0.06
+			// Total: 8.06
+			expected: 8.06,
+		},
+		{
+			name: "5 rules (managed + rate limit + IP lists), 10M requests",
+			config: WAFConfig{
+				Rules:           5,
+				MonthlyRequests: 10000000,
+			},
+			// Fixed: 5.00 + 5 * 1.00 = 10.00
+			// Requests: 10 * 0.60 = 6.00
+			// Total: 16.00
+			expected: 16.00,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateWAFPrice(tt.config, rates)
+			if !floatEquals(result, tt.expected, 0.01) {
+				t.Errorf("CalculateWAFPrice() = %.2f, expected %.2f", result, tt.expected)
+			}
+		})
+	}
+}
+
 // TestCalculateAverageACU tests the ACU calculation logic
 // This is critical to match between backend and frontend
 func TestCalculateAverageACU(t *testing.T) {
@@ -109,6 +109,26 @@ func (s *Service) CalculateS3(region string, config S3Config) (float64, error) {
 	return CalculateS3Price(config, rates), nil
 }
 
+// CalculateNATGateway calculates NAT gateway pricing
+// Uses cached pricing rates
+func (s *Service) CalculateNATGateway(region string, config NATGatewayConfig) (float64, error) {
+	rates, err := s.GetRates(region)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rates: %w", err)
+	}
+	return CalculateNATGatewayPrice(config, rates), nil
+}
+
+// CalculateWAF calculates AWS WAF pricing
+// Uses cached pricing rates
+func (s *Service) CalculateWAF(region string, config WAFConfig) (float64, error) {
+	rates, err := s.GetRates(region)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rates: %w", err)
+	}
+	return CalculateWAFPrice(config, rates), nil
+}
+
 // RefreshRegion forces a refresh of pricing data for a specific region
 // Useful when you know pricing has changed
 func (s *Service) RefreshRegion(region string) error {
@@ -23,6 +23,7 @@ type PriceRates struct {
 	ALB        ALBPricing        `json:"alb"`
 	APIGateway APIGatewayPricing `json:"apiGateway"`
 	NATGateway NATGatewayPricing `json:"natGateway"`
+	WAF        WAFPricing        `json:"waf"`
 
 	// Other services
 	CloudWatch CloudWatchPricing `json:"cloudWatch"`
@@ -75,6 +76,13 @@ type NATGatewayPricing struct {
 	DataPerGBMonth float64 `json:"dataPerGbMonth"` // $/GB processed
 }
 
+// WAFPricing holds AWS WAF pricing
+type WAFPricing struct {
+	WebACLPerMonth     float64 `json:"webAclPerMonth"`     // $/web ACL/month (e.g., 5.00)
+	RulePerMonth       float64 `json:"rulePerMonth"`       // $/rule or rule group/month (e.g., 1.00)
+	RequestsPerMillion float64 `json:"requestsPerMillion"` // $/million requests inspected (e.g., 0.60)
+}
+
 // CloudWatchPricing holds CloudWatch pricing
 type CloudWatchPricing struct {
 	LogsIngestionPerGB float64 `json:"logsIngestionPerGb"` // $/GB ingested (e.g., 0.50)
@@ -138,6 +146,18 @@ type S3Config struct {
 	RequestsPerDay int     `json:"requestsPerDay"`
 }
 
+// NATGatewayConfig holds NAT gateway configuration
+type NATGatewayConfig struct {
+	Count           int     `json:"count"`           // Number of NAT gateways (1 for single, one per AZ for per_az)
+	DataProcessedGB float64 `json:"dataProcessedGb"` // GB processed per month across all gateways
+}
+
+// WAFConfig holds WAF web ACL configuration
+type WAFConfig struct {
+	Rules           int `json:"rules"`           // Rules + managed rule groups in the web ACL
+	MonthlyRequests int `json:"monthlyRequests"` // Requests inspected per month
+}
+
 // EnvironmentCost represents the total cost breakdown for an environment
 type EnvironmentCost struct {
 	Region       string             `json:"region"`
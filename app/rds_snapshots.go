@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// DBSnapshotInfo describes a manual or automated RDS/Aurora snapshot
+type DBSnapshotInfo struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"` // "manual" or "automated"
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Age           string    `json:"age"`
+	SizeGB        int32     `json:"sizeGb"`
+	Engine        string    `json:"engine"`
+	EngineVersion string    `json:"engineVersion"`
+	IsAurora      bool      `json:"isAurora"`
+}
+
+// DBRestoreOptions controls restoring a snapshot into a new instance or cluster
+type DBRestoreOptions struct {
+	SnapshotID string `json:"snapshotId"`
+	TargetID   string `json:"targetId,omitempty"` // Defaults to <source>-restored-<timestamp>
+	Repoint    bool   `json:"repoint"`            // Update the backend's pg_database_host SSM parameter and redeploy
+}
+
+// DBRestoreResult is returned after a restore has been started (and optionally completed)
+type DBRestoreResult struct {
+	TargetID  string `json:"targetId"`
+	IsAurora  bool   `json:"isAurora"`
+	Status    string `json:"status"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Repointed bool   `json:"repointed"`
+	Error     string `json:"error,omitempty"` // Why waiting for the restore or repointing failed
+	Note      string `json:"note,omitempty"`  // Follow-up the user has to do, e.g. pinning the endpoint in the env YAML
+}
+
+// dbRestoreTimeout bounds how long we wait for a restored database to become available
+const dbRestoreTimeout = 60 * time.Minute
+
+// awsProfileForEnv returns the AWS profile used for an environment
+func awsProfileForEnv(env Env) string {
+	if env.AWSProfile != "" {
+		return env.AWSProfile
+	}
+	return selectedAWSProfile
+}
+
+// loadAWSConfigForEnv loads AWS config using the environment's profile and region,
+// falling back to the globally selected profile
+func loadAWSConfigForEnv(ctx context.Context, env Env) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if env.Region != "" {
+		opts = append(opts, config.WithRegion(env.Region))
+	}
+	return loadAWSConfigForProfile(ctx, awsProfileForEnv(env), opts...)
+}
+
+// dbSourceIdentifier returns the instance or cluster identifier created by modules/postgres
+func dbSourceIdentifier(env Env) string {
+	if env.Postgres.Aurora {
+		return fmt.Sprintf("%s-aurora-%s", env.Project, env.Env)
+	}
+	return fmt.Sprintf("%s-postgres-%s", env.Project, env.Env)
+}
+
+// dbEndpointSSMName is the SSM parameter the backend reads PG_DATABASE_HOST from
+func dbEndpointSSMName(env Env) string {
+	return fmt.Sprintf("/%s/%s/backend/pg_database_host", env.Env, env.Project)
+}
+
+var rdsIdentifierInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// rdsIdentifier makes a valid RDS identifier: letters, digits and single hyphens,
+// starting with a letter, at most 63 characters
+func rdsIdentifier(parts ...string) string {
+	id := rdsIdentifierInvalidChars.ReplaceAllString(strings.Join(parts, "-"), "-")
+	for strings.Contains(id, "--") {
+		id = strings.ReplaceAll(id, "--", "-")
+	}
+	id = strings.ToLower(strings.Trim(id, "-"))
+	if id == "" || id[0] < 'a' || id[0] > 'z' {
+		id = "db-" + id
+	}
+	if len(id) > 63 {
+		id = strings.TrimRight(id[:63], "-")
+	}
+	return id
+}
+
+// formatAge renders a duration as a short human-readable age (e.g. "3d 4h", "25m")
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		days := int(d.Hours()) / 24
+		return fmt.Sprintf("%dd %dh", days, int(d.Hours())%24)
+	}
+}
+
+// createDBSnapshot creates a manual snapshot of the environment's database
+func createDBSnapshot(ctx context.Context, client *rds.Client, env Env, snapshotID string) (*DBSnapshotInfo, error) {
+	source := dbSourceIdentifier(env)
+	if snapshotID == "" {
+		snapshotID = rdsIdentifier(source, "manual", time.Now().UTC().Format("20060102-150405"))
+	}
+
+	tags := []rdstypes.Tag{
+		{Key: aws.String("Environment"), Value: aws.String(env.Env)},
+		{Key: aws.String("Project"), Value: aws.String(env.Project)},
+		{Key: aws.String("ManagedBy"), Value: aws.String("meroku")},
+	}
+
+	if env.Postgres.Aurora {
+		out, err := client.CreateDBClusterSnapshot(ctx, &rds.CreateDBClusterSnapshotInput{
+			DBClusterIdentifier:         aws.String(source),
+			DBClusterSnapshotIdentifier: aws.String(snapshotID),
+			Tags:                        tags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cluster snapshot of %s: %w", source, err)
+		}
+		info := clusterSnapshotInfo(*out.DBClusterSnapshot)
+		return &info, nil
+	}
+
+	out, err := client.CreateDBSnapshot(ctx, &rds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: aws.String(source),
+		DBSnapshotIdentifier: aws.String(snapshotID),
+		Tags:                 tags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot of %s: %w", source, err)
+	}
+	info := instanceSnapshotInfo(*out.DBSnapshot)
+	return &info, nil
+}
+
+// listDBSnapshots lists automated and manual snapshots of the environment's database, newest first
+func listDBSnapshots(ctx context.Context, client *rds.Client, env Env) ([]DBSnapshotInfo, error) {
+	source := dbSourceIdentifier(env)
+	snapshots := []DBSnapshotInfo{}
+
+	if env.Postgres.Aurora {
+		paginator := rds.NewDescribeDBClusterSnapshotsPaginator(client, &rds.DescribeDBClusterSnapshotsInput{
+			DBClusterIdentifier: aws.String(source),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list cluster snapshots of %s: %w", source, err)
+			}
+			for _, s := range page.DBClusterSnapshots {
+				snapshots = append(snapshots, clusterSnapshotInfo(s))
+			}
+		}
+	} else {
+		paginator := rds.NewDescribeDBSnapshotsPaginator(client, &rds.DescribeDBSnapshotsInput{
+			DBInstanceIdentifier: aws.String(source),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list snapshots of %s: %w", source, err)
+			}
+			for _, s := range page.DBSnapshots {
+				snapshots = append(snapshots, instanceSnapshotInfo(s))
+			}
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+func instanceSnapshotInfo(s rdstypes.DBSnapshot) DBSnapshotInfo {
+	created := aws.ToTime(s.SnapshotCreateTime)
+	return DBSnapshotInfo{
+		ID:            aws.ToString(s.DBSnapshotIdentifier),
+		Type:          aws.ToString(s.SnapshotType),
+		Status:        aws.ToString(s.Status),
+		CreatedAt:     created,
+		Age:           snapshotAge(created),
+		SizeGB:        aws.ToInt32(s.AllocatedStorage),
+		Engine:        aws.ToString(s.Engine),
+		EngineVersion: aws.ToString(s.EngineVersion),
+	}
+}
+
+func clusterSnapshotInfo(s rdstypes.DBClusterSnapshot) DBSnapshotInfo {
+	created := aws.ToTime(s.SnapshotCreateTime)
+	return DBSnapshotInfo{
+		ID:            aws.ToString(s.DBClusterSnapshotIdentifier),
+		Type:          aws.ToString(s.SnapshotType),
+		Status:        aws.ToString(s.Status),
+		CreatedAt:     created,
+		Age:           snapshotAge(created),
+		SizeGB:        aws.ToInt32(s.AllocatedStorage),
+		Engine:        aws.ToString(s.Engine),
+		EngineVersion: aws.ToString(s.EngineVersion),
+		IsAurora:      true,
+	}
+}
+
+func snapshotAge(created time.Time) string {
+	if created.IsZero() {
+		return "creating"
+	}
+	return formatAge(time.Since(created))
+}
+
+// startDBRestore restores a snapshot into a new instance (RDS) or cluster + serverless
+// instance (Aurora), copying network and sizing settings from the current database.
+// It returns as soon as AWS accepts the request.
+func startDBRestore(ctx context.Context, client *rds.Client, env Env, opts DBRestoreOptions) (*DBRestoreResult, error) {
+	if opts.SnapshotID == "" {
+		return nil, fmt.Errorf("snapshot id is required")
+	}
+
+	source := dbSourceIdentifier(env)
+	target := opts.TargetID
+	if target == "" {
+		target = rdsIdentifier(source, "restored", time.Now().UTC().Format("200601021504"))
+	}
+
+	tags := []rdstypes.Tag{
+		{Key: aws.String("Environment"), Value: aws.String(env.Env)},
+		{Key: aws.String("Project"), Value: aws.String(env.Project)},
+		{Key: aws.String("ManagedBy"), Value: aws.String("meroku")},
+		{Key: aws.String("RestoredFrom"), Value: aws.String(opts.SnapshotID)},
+	}
+
+	if env.Postgres.Aurora {
+		clusters, err := client.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{DBClusterIdentifier: aws.String(source)})
+		if err != nil || len(clusters.DBClusters) == 0 {
+			return nil, fmt.Errorf("source cluster %s not found: %v", source, err)
+		}
+		src := clusters.DBClusters[0]
+
+		var securityGroups []string
+		for _, sg := range src.VpcSecurityGroups {
+			securityGroups = append(securityGroups, aws.ToString(sg.VpcSecurityGroupId))
+		}
+
+		input := &rds.RestoreDBClusterFromSnapshotInput{
+			DBClusterIdentifier:             aws.String(target),
+			SnapshotIdentifier:              aws.String(opts.SnapshotID),
+			Engine:                          src.Engine,
+			EngineVersion:                   src.EngineVersion,
+			DBSubnetGroupName:               src.DBSubnetGroup,
+			VpcSecurityGroupIds:             securityGroups,
+			EnableIAMDatabaseAuthentication: src.IAMDatabaseAuthenticationEnabled,
+			Tags:                            tags,
+		}
+		if src.ServerlessV2ScalingConfiguration != nil {
+			input.ServerlessV2ScalingConfiguration = &rdstypes.ServerlessV2ScalingConfiguration{
+				MinCapacity: src.ServerlessV2ScalingConfiguration.MinCapacity,
+				MaxCapacity: src.ServerlessV2ScalingConfiguration.MaxCapacity,
+			}
+		}
+		if _, err := client.RestoreDBClusterFromSnapshot(ctx, input); err != nil {
+			return nil, fmt.Errorf("failed to restore cluster from %s: %w", opts.SnapshotID, err)
+		}
+
+		// A restored Aurora cluster has no instances, add a serverless writer
+		_, err = client.CreateDBInstance(ctx, &rds.CreateDBInstanceInput{
+			DBInstanceIdentifier: aws.String(rdsIdentifier(target, "instance")),
+			DBClusterIdentifier:  aws.String(target),
+			DBInstanceClass:      aws.String("db.serverless"),
+			Engine:               src.Engine,
+			Tags:                 tags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s restored but failed to create its instance: %w", target, err)
+		}
+
+		return &DBRestoreResult{TargetID: target, IsAurora: true, Status: "creating"}, nil
+	}
+
+	instances, err := client.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(source)})
+	if err != nil || len(instances.DBInstances) == 0 {
+		return nil, fmt.Errorf("source instance %s not found: %v", source, err)
+	}
+	src := instances.DBInstances[0]
+
+	var securityGroups []string
+	for _, sg := range src.VpcSecurityGroups {
+		securityGroups = append(securityGroups, aws.ToString(sg.VpcSecurityGroupId))
+	}
+
+	input := &rds.RestoreDBInstanceFromDBSnapshotInput{
+		DBInstanceIdentifier:            aws.String(target),
+		DBSnapshotIdentifier:            aws.String(opts.SnapshotID),
+		DBInstanceClass:                 src.DBInstanceClass,
+		VpcSecurityGroupIds:             securityGroups,
+		PubliclyAccessible:              src.PubliclyAccessible,
+		MultiAZ:                         src.MultiAZ,
+		StorageType:                     src.StorageType,
+		EnableIAMDatabaseAuthentication: aws.Bool(src.IAMDatabaseAuthenticationEnabled != nil && *src.IAMDatabaseAuthenticationEnabled),
+		Tags:                            tags,
+	}
+	if src.DBSubnetGroup != nil {
+		input.DBSubnetGroupName = src.DBSubnetGroup.DBSubnetGroupName
+	}
+	if _, err := client.RestoreDBInstanceFromDBSnapshot(ctx, input); err != nil {
+		return nil, fmt.Errorf("failed to restore instance from %s: %w", opts.SnapshotID, err)
+	}
+
+	return &DBRestoreResult{TargetID: target, IsAurora: false, Status: "creating"}, nil
+}
+
+// waitForDBRestore waits until the restored database is available and returns its endpoint
+func waitForDBRestore(ctx context.Context, client *rds.Client, result *DBRestoreResult) error {
+	if result.IsAurora {
+		waiter := rds.NewDBClusterAvailableWaiter(client)
+		out, err := waiter.WaitForOutput(ctx, &rds.DescribeDBClustersInput{
+			DBClusterIdentifier: aws.String(result.TargetID),
+		}, dbRestoreTimeout)
+		if err != nil {
+			return fmt.Errorf("cluster %s did not become available: %w", result.TargetID, err)
+		}
+		// The cluster reports available before its writer instance is ready
+		instanceWaiter := rds.NewDBInstanceAvailableWaiter(client)
+		if err := instanceWaiter.Wait(ctx, &rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: aws.String(rdsIdentifier(result.TargetID, "instance")),
+		}, dbRestoreTimeout); err != nil {
+			return fmt.Errorf("instance of cluster %s did not become available: %w", result.TargetID, err)
+		}
+		result.Endpoint = aws.ToString(out.DBClusters[0].Endpoint)
+		result.Status = "available"
+		return nil
+	}
+
+	waiter := rds.NewDBInstanceAvailableWaiter(client)
+	out, err := waiter.WaitForOutput(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(result.TargetID),
+	}, dbRestoreTimeout)
+	if err != nil {
+		return fmt.Errorf("instance %s did not become available: %w", result.TargetID, err)
+	}
+	if endpoint := out.DBInstances[0].Endpoint; endpoint != nil {
+		result.Endpoint = aws.ToString(endpoint.Address)
+	}
+	result.Status = "available"
+	return nil
+}
+
+// dbRepointNote tells the user how to keep a repointed endpoint across Terraform applies
+func dbRepointNote(env Env, endpoint string) string {
+	return fmt.Sprintf("Set postgres.endpoint_override: %s in %s.yaml before the next apply, otherwise Terraform points the backend back at the original database. "+
+		"The restored database is not managed by Terraform.", endpoint, env.Env)
+}
+
+// repointDBEndpoint updates the backend's pg_database_host SSM parameter and
+// forces a new deployment of the backend service so tasks pick it up
+func repointDBEndpoint(ctx context.Context, cfg aws.Config, env Env, endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("restored database has no endpoint yet")
+	}
+
+	_, err := ssm.NewFromConfig(cfg).PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(dbEndpointSSMName(env)),
+		Value:     aws.String(endpoint),
+		Type:      ssmtypes.ParameterTypeString,
+		Overwrite: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", dbEndpointSSMName(env), err)
+	}
+
+	_, err = ecs.NewFromConfig(cfg).UpdateService(ctx, &ecs.UpdateServiceInput{
+		Cluster:            aws.String(fmt.Sprintf("%s_cluster_%s", env.Project, env.Env)),
+		Service:            aws.String(fmt.Sprintf("%s_service_%s", env.Project, env.Env)),
+		ForceNewDeployment: true,
+	})
+	if err != nil {
+		return fmt.Errorf("endpoint updated but failed to redeploy backend: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// scheduledTaskHistoryLimit is the default number of runs in the history
+const scheduledTaskHistoryLimit = 20
+
+// ecsTaskHistoryAPI is the part of the ECS client used to list the runs of a task
+type ecsTaskHistoryAPI interface {
+	ListTasks(ctx context.Context, params *ecs.ListTasksInput, optFns ...func(*ecs.Options)) (*ecs.ListTasksOutput, error)
+	DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error)
+}
+
+// ScheduledTaskRun is a past or running invocation of a scheduled task. ECS keeps stopped
+// tasks for about an hour, older runs are found by their log streams and have no exit code.
+// Their duration is approximate, CloudWatch updates the last event time of a stream with a delay.
+type ScheduledTaskRun struct {
+	TaskID          string     `json:"taskId"`
+	Status          string     `json:"status,omitempty"`  // ECS status, empty for runs only known from logs
+	Trigger         string     `json:"trigger,omitempty"` // manual or schedule
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	StoppedAt       *time.Time `json:"stoppedAt,omitempty"` // For runs from logs, the time of the last log line
+	DurationSeconds float64    `json:"durationSeconds,omitempty"`
+	Approximate     bool       `json:"approximate,omitempty"` // Times come from the log stream
+	ExitCode        *int       `json:"exitCode,omitempty"`
+	StoppedReason   string     `json:"stoppedReason,omitempty"`
+	LogStream       string     `json:"logStream"`
+	LogURL          string     `json:"logUrl"`
+}
+
+// checkScheduledTask returns an error if name is not one of the environment's scheduled tasks
+func checkScheduledTask(env Env, name string) error {
+	var names []string
+	for _, task := range env.ScheduledTasks {
+		if task.Name == name {
+			return nil
+		}
+		names = append(names, task.Name)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s has no scheduled tasks", env.Env)
+	}
+	return fmt.Errorf("%s is not a scheduled task of %s, use one of: %s", name, env.Env, strings.Join(names, ", "))
+}
+
+// startScheduledTask runs a scheduled task now with its configured command
+func startScheduledTask(ctx context.Context, client ecsRunTaskAPI, groups securityGroupsAPI, env Env, name string) (*OneOffTask, error) {
+	if err := checkScheduledTask(env, name); err != nil {
+		return nil, err
+	}
+	return startOneOffTask(ctx, client, groups, env, OneOffTaskRequest{Service: name})
+}
+
+// scheduledTaskHistory lists the latest runs of a scheduled task, newest first
+func scheduledTaskHistory(ctx context.Context, client ecsTaskHistoryAPI, logs cloudwatchlogs.DescribeLogStreamsAPIClient, env Env, name, region string, limit int) ([]ScheduledTaskRun, error) {
+	if err := checkScheduledTask(env, name); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = scheduledTaskHistoryLimit
+	}
+	target, err := resolveOneOffTarget(env, name)
+	if err != nil {
+		return nil, err
+	}
+	cluster := fmt.Sprintf("%s_cluster_%s", env.Project, env.Env)
+	group := constructLogGroupName(env, name)
+	streamPrefix := fmt.Sprintf("ecs/%s/", target.Container)
+
+	runs := map[string]*ScheduledTaskRun{}
+	newRun := func(taskID string) *ScheduledTaskRun {
+		stream := streamPrefix + taskID
+		return &ScheduledTaskRun{TaskID: taskID, LogStream: stream, LogURL: cloudWatchLogStreamURL(region, group, stream)}
+	}
+
+	// Older runs are only left in the logs, streams of other containers are skipped
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(logs, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(group),
+		OrderBy:      cwltypes.OrderByLastEventTime,
+		Descending:   aws.Bool(true),
+		Limit:        aws.Int32(int32(min(limit, 50))),
+	})
+	for paginator.HasMorePages() && len(runs) < limit {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			var notFound *cwltypes.ResourceNotFoundException
+			if errors.As(err, &notFound) {
+				break
+			}
+			return nil, fmt.Errorf("failed to list log streams of %s: %w", group, err)
+		}
+		for _, stream := range page.LogStreams {
+			streamName := aws.ToString(stream.LogStreamName)
+			if !strings.HasPrefix(streamName, streamPrefix) || len(runs) == limit {
+				continue
+			}
+			run := newRun(strings.TrimPrefix(streamName, streamPrefix))
+			run.Approximate = true
+			if stream.FirstEventTimestamp != nil {
+				run.StartedAt = aws.Time(time.UnixMilli(*stream.FirstEventTimestamp))
+			}
+			if stream.LastEventTimestamp != nil {
+				run.StoppedAt = aws.Time(time.UnixMilli(*stream.LastEventTimestamp))
+			}
+			runs[run.TaskID] = run
+		}
+	}
+
+	// Recent runs have their status and exit code in ECS
+	var taskArns []string
+	for _, status := range []ecstypes.DesiredStatus{ecstypes.DesiredStatusRunning, ecstypes.DesiredStatusStopped} {
+		out, err := client.ListTasks(ctx, &ecs.ListTasksInput{
+			Cluster:       aws.String(cluster),
+			Family:        aws.String(target.Family),
+			DesiredStatus: status,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks of %s: %w", name, err)
+		}
+		taskArns = append(taskArns, out.TaskArns...)
+	}
+	for batch := range slices.Chunk(taskArns, 100) {
+		out, err := client.DescribeTasks(ctx, &ecs.DescribeTasksInput{Cluster: aws.String(cluster), Tasks: batch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe tasks of %s: %w", name, err)
+		}
+		for _, task := range out.Tasks {
+			taskID := ecsTaskID(aws.ToString(task.TaskArn))
+			run := runs[taskID]
+			if run == nil {
+				run = newRun(taskID)
+				runs[taskID] = run
+			}
+			applyECSTaskToRun(run, task, target.Container)
+		}
+	}
+
+	history := make([]ScheduledTaskRun, 0, len(runs))
+	for _, run := range runs {
+		if run.StartedAt != nil && run.StoppedAt != nil {
+			run.DurationSeconds = run.StoppedAt.Sub(*run.StartedAt).Seconds()
+		}
+		history = append(history, *run)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		a, b := history[i].StartedAt, history[j].StartedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+	if len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+// applyECSTaskToRun fills a run from the ECS task, which is more accurate than its logs
+func applyECSTaskToRun(run *ScheduledTaskRun, task ecstypes.Task, container string) {
+	run.Status = aws.ToString(task.LastStatus)
+	run.Approximate = false
+	run.StoppedReason = aws.ToString(task.StoppedReason)
+	run.Trigger = "schedule"
+	if aws.ToString(task.StartedBy) == taskRunStartedBy {
+		run.Trigger = "manual"
+	}
+	if task.StartedAt != nil {
+		run.StartedAt = task.StartedAt
+	} else if task.CreatedAt != nil {
+		run.StartedAt = task.CreatedAt
+	}
+	run.StoppedAt = task.StoppedAt
+	for _, c := range task.Containers {
+		if aws.ToString(c.Name) == container && c.ExitCode != nil {
+			code := int(*c.ExitCode)
+			run.ExitCode = &code
+		}
+	}
+}
+
+// cloudWatchLogStreamURL links a log stream in the CloudWatch console,
+// which escapes the path twice with $ instead of %
+func cloudWatchLogStreamURL(region, group, stream string) string {
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "%", "$25")
+	}
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%s#logsV2:log-groups/log-group/%s/log-events/%s",
+		region, region, escape(group), escape(stream))
+}
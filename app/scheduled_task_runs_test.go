@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// fakeTaskHistory has a running manual run and a stopped scheduled run in ECS,
+// and the log streams of those and of an older run
+type fakeTaskHistory struct {
+	now time.Time
+}
+
+func (f fakeTaskHistory) ListTasks(ctx context.Context, params *ecs.ListTasksInput, optFns ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+	if aws.ToString(params.Family) != "cleanup" {
+		return &ecs.ListTasksOutput{}, nil
+	}
+	if params.DesiredStatus == ecstypes.DesiredStatusStopped {
+		return &ecs.ListTasksOutput{TaskArns: []string{"arn:aws:ecs:eu-west-1:111111111111:task/shop_cluster_dev/t2"}}, nil
+	}
+	return &ecs.ListTasksOutput{TaskArns: []string{"arn:aws:ecs:eu-west-1:111111111111:task/shop_cluster_dev/t3"}}, nil
+}
+
+func (f fakeTaskHistory) DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+	var tasks []ecstypes.Task
+	for _, arn := range params.Tasks {
+		switch ecsTaskID(arn) {
+		case "t2":
+			tasks = append(tasks, ecstypes.Task{
+				TaskArn:       aws.String(arn),
+				LastStatus:    aws.String("STOPPED"),
+				StartedBy:     aws.String("chronos-abc"),
+				StartedAt:     aws.Time(f.now.Add(-30 * time.Minute)),
+				StoppedAt:     aws.Time(f.now.Add(-28 * time.Minute)),
+				StoppedReason: aws.String("Essential container in task exited"),
+				Containers: []ecstypes.Container{
+					{Name: aws.String("shop_container_cleanup_dev"), ExitCode: aws.Int32(1)},
+				},
+			})
+		case "t3":
+			tasks = append(tasks, ecstypes.Task{
+				TaskArn:    aws.String(arn),
+				LastStatus: aws.String("RUNNING"),
+				StartedBy:  aws.String(taskRunStartedBy),
+				StartedAt:  aws.Time(f.now.Add(-time.Minute)),
+			})
+		}
+	}
+	return &ecs.DescribeTasksOutput{Tasks: tasks}, nil
+}
+
+func (f fakeTaskHistory) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	stream := func(taskID string, first, last time.Duration) cwltypes.LogStream {
+		return cwltypes.LogStream{
+			LogStreamName:       aws.String("ecs/shop_container_cleanup_dev/" + taskID),
+			FirstEventTimestamp: aws.Int64(f.now.Add(-first).UnixMilli()),
+			LastEventTimestamp:  aws.Int64(f.now.Add(-last).UnixMilli()),
+		}
+	}
+	streams := []cwltypes.LogStream{
+		stream("t3", 50*time.Second, 5*time.Second),
+		stream("t2", 29*time.Minute, 28*time.Minute),
+		{LogStreamName: aws.String("ecs/xray/t2")},
+		stream("t1", 24*time.Hour, 24*time.Hour-90*time.Second),
+	}
+
+	// One stream per page
+	i := len(aws.ToString(params.NextToken))
+	out := &cloudwatchlogs.DescribeLogStreamsOutput{LogStreams: streams[i : i+1]}
+	if i+1 < len(streams) {
+		out.NextToken = aws.String(strings.Repeat("x", i+1))
+	}
+	return out, nil
+}
+
+func TestScheduledTaskHistory(t *testing.T) {
+	env := Env{Project: "shop", Env: "dev", ScheduledTasks: []ScheduledTask{{Name: "cleanup"}}}
+	client := fakeTaskHistory{now: time.Now()}
+
+	history, err := scheduledTaskHistory(context.Background(), client, client, env, "cleanup", "eu-west-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history = %+v", history)
+	}
+
+	running, stopped, old := history[0], history[1], history[2]
+	if running.TaskID != "t3" || running.Status != "RUNNING" || running.Trigger != "manual" || running.StoppedAt != nil || running.ExitCode != nil || running.Approximate {
+		t.Errorf("running = %+v", running)
+	}
+	if stopped.TaskID != "t2" || stopped.Trigger != "schedule" || stopped.ExitCode == nil || *stopped.ExitCode != 1 || stopped.DurationSeconds != 120 || stopped.Approximate {
+		t.Errorf("stopped = %+v", stopped)
+	}
+	if old.TaskID != "t1" || old.Status != "" || old.ExitCode != nil || old.DurationSeconds != 90 || !old.Approximate {
+		t.Errorf("old = %+v", old)
+	}
+	if old.LogURL != "https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#logsV2:log-groups/log-group/shop_task_cleanup_dev/log-events/ecs$252Fshop_container_cleanup_dev$252Ft1" {
+		t.Errorf("log URL = %s", old.LogURL)
+	}
+
+	history, err = scheduledTaskHistory(context.Background(), client, client, env, "cleanup", "eu-west-1", 1)
+	if err != nil || len(history) != 1 || history[0].TaskID != "t3" {
+		t.Errorf("limited history = %+v, %v", history, err)
+	}
+
+	if _, err := scheduledTaskHistory(context.Background(), client, client, env, "backend", "eu-west-1", 0); err == nil ||
+		!strings.Contains(err.Error(), "not a scheduled task") {
+		t.Errorf("error = %v", err)
+	}
+}
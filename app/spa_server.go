@@ -66,6 +66,7 @@ func mainRouter() http.Handler {
 	mux.HandleFunc("/api/ecs/autoscaling", corsMiddleware(getServiceAutoscaling))
 	mux.HandleFunc("/api/ecs/scaling-history", corsMiddleware(getServiceScalingHistory))
 	mux.HandleFunc("/api/ecs/metrics", corsMiddleware(getServiceMetrics))
+	mux.HandleFunc("/api/ecs/run", corsMiddleware(runOneOffTask))
 
 	// API Gateway
 	mux.HandleFunc("/api/apigateway/info", corsMiddleware(getAPIGatewayInfo))
@@ -73,6 +74,11 @@ func mainRouter() http.Handler {
 	// RDS
 	mux.HandleFunc("/api/rds/endpoint", corsMiddleware(getDatabaseEndpoint))
 	mux.HandleFunc("/api/rds/info", corsMiddleware(getDatabaseInfo))
+	mux.HandleFunc("/api/rds/snapshots", corsMiddleware(listRDSSnapshots))
+	mux.HandleFunc("/api/rds/snapshots/create", corsMiddleware(createRDSSnapshot))
+	mux.HandleFunc("/api/rds/snapshots/restore", corsMiddleware(restoreRDSSnapshot))
+	mux.HandleFunc("/api/certificates", corsMiddleware(getCertificates))
+	mux.HandleFunc("/api/certificates/validation-records", corsMiddleware(createCertificateValidationRecords))
 	
 	// SSM Parameters
 	mux.HandleFunc("/api/ssm/parameter", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -115,6 +121,8 @@ func mainRouter() http.Handler {
 	// EventBridge
 	mux.HandleFunc("/api/eventbridge/send-test-event", corsMiddleware(sendTestEvent))
 	mux.HandleFunc("/api/eventbridge/event-tasks", corsMiddleware(getEventTaskInfo))
+	mux.HandleFunc("/api/scheduled-tasks/run", corsMiddleware(runScheduledTask))
+	mux.HandleFunc("/api/scheduled-tasks/history", corsMiddleware(getScheduledTaskHistory))
 	
 	// GitHub OAuth
 	mux.HandleFunc("/api/github/oauth/device", corsMiddleware(initiateGitHubDeviceFlow))
@@ -131,11 +139,16 @@ func mainRouter() http.Handler {
 	
 	// Logs
 	mux.HandleFunc("/api/logs", corsMiddleware(getServiceLogs))
+	mux.HandleFunc("/api/logs/insights", corsMiddleware(runLogsInsightsQuery))
+	mux.HandleFunc("/api/logs/insights/queries", corsMiddleware(handleSavedLogQueries))
 	
 	// Pricing
 	mux.HandleFunc("/api/pricing", corsMiddleware(getPricing))
 	mux.HandleFunc("/api/pricing/rates", corsMiddleware(getPricingRates))
 	
+	// WAF
+	mux.HandleFunc("/api/waf/blocked", corsMiddleware(getWAFBlockedRequests))
+	
 	// Buckets
 	mux.HandleFunc("/api/buckets", corsMiddleware(listBuckets))
 
@@ -146,6 +159,7 @@ func mainRouter() http.Handler {
 
 	// WebSocket endpoints (these handle their own CORS)
 	mux.HandleFunc("/ws/logs", streamServiceLogs)
+	mux.HandleFunc("/ws/ecs/run", followOneOffTaskStream)
 	mux.HandleFunc("/ws/ssh", startSSHSession)
 	mux.HandleFunc("/ws/ssh-pty", startSSHSessionPTY)
 
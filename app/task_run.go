@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// taskRunPollInterval is how often a one-off task and its logs are checked
+var taskRunPollInterval = 2 * time.Second
+
+// taskRunDrainPolls is the number of log polls after the task stopped, CloudWatch
+// receives the last lines a few seconds after the container exits
+const taskRunDrainPolls = 2
+
+// taskRunStartedBy marks one-off tasks in the ECS console and in ListTasks
+const taskRunStartedBy = "meroku-run"
+
+// ecsRunTaskAPI is the part of the ECS client used to run one-off tasks
+type ecsRunTaskAPI interface {
+	DescribeServices(ctx context.Context, params *ecs.DescribeServicesInput, optFns ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error)
+	DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error)
+	RunTask(ctx context.Context, params *ecs.RunTaskInput, optFns ...func(*ecs.Options)) (*ecs.RunTaskOutput, error)
+	DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error)
+	StopTask(ctx context.Context, params *ecs.StopTaskInput, optFns ...func(*ecs.Options)) (*ecs.StopTaskOutput, error)
+}
+
+// securityGroupsAPI looks up the security groups of scheduled and event tasks
+type securityGroupsAPI interface {
+	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+}
+
+// OneOffTaskRequest is a command to run in a copy of a service or task
+type OneOffTaskRequest struct {
+	Service     string            `json:"service"`               // backend, a service, a scheduled or an event task
+	Command     []string          `json:"command,omitempty"`     // Default: the task definition's command
+	Environment map[string]string `json:"environment,omitempty"` // Added to the container's environment
+}
+
+// OneOffTask is a started one-off task
+type OneOffTask struct {
+	Service           string `json:"service"`
+	Cluster           string `json:"cluster"`
+	TaskArn           string `json:"taskArn"`
+	TaskID            string `json:"taskId"`
+	TaskDefinitionArn string `json:"taskDefinitionArn"`
+	Container         string `json:"container"`
+	LogGroup          string `json:"logGroup"`
+	LogStream         string `json:"logStream"`
+}
+
+// OneOffTaskResult is how a one-off task ended
+type OneOffTaskResult struct {
+	ExitCode      int    `json:"exitCode"`
+	StoppedReason string `json:"stoppedReason,omitempty"`
+}
+
+// oneOffTarget is where the names of a service's ECS resources come from, see modules/workloads,
+// modules/ecs_task and modules/event_bridge_task
+type oneOffTarget struct {
+	Family        string // Task definition family
+	Container     string
+	NetworkSource string // ECS service whose subnets and security groups are used
+	SecurityGroup string // Security group name replacing the service's, for tasks
+}
+
+// resolveOneOffTarget finds the task definition, container and network of a service or task
+func resolveOneOffTarget(env Env, name string) (oneOffTarget, error) {
+	backendService := fmt.Sprintf("%s_service_%s", env.Project, env.Env)
+	if name == "backend" {
+		return oneOffTarget{Family: backendService, Container: backendService, NetworkSource: backendService}, nil
+	}
+	for _, service := range env.Services {
+		if service.Name == name {
+			serviceName := fmt.Sprintf("%s_service_%s_%s", env.Project, name, env.Env)
+			return oneOffTarget{Family: serviceName, Container: serviceName, NetworkSource: serviceName}, nil
+		}
+	}
+	isTask := slices.ContainsFunc(env.ScheduledTasks, func(t ScheduledTask) bool { return t.Name == name }) ||
+		slices.ContainsFunc(env.EventProcessorTasks, func(t EventProcessorTask) bool { return t.Name == name })
+	if isTask {
+		// Tasks run in the backend's subnets with their own security group
+		return oneOffTarget{
+			Family:        name,
+			Container:     fmt.Sprintf("%s_container_%s_%s", env.Project, name, env.Env),
+			NetworkSource: backendService,
+			SecurityGroup: fmt.Sprintf("%s_%s_%s", env.Project, name, env.Env),
+		}, nil
+	}
+	return oneOffTarget{}, fmt.Errorf("%s is not a service or task of %s, use one of: %s", name, env.Env, strings.Join(environmentLogServices(env), ", "))
+}
+
+// oneOffTaskNetwork copies the network configuration of the target's ECS service
+func oneOffTaskNetwork(ctx context.Context, client ecsRunTaskAPI, groups securityGroupsAPI, cluster string, target oneOffTarget) (*ecstypes.NetworkConfiguration, error) {
+	resp, err := client.DescribeServices(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(cluster),
+		Services: []string{target.NetworkSource},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service %s: %w", target.NetworkSource, err)
+	}
+	if len(resp.Services) == 0 || resp.Services[0].NetworkConfiguration == nil || resp.Services[0].NetworkConfiguration.AwsvpcConfiguration == nil {
+		return nil, fmt.Errorf("service %s not found in %s, deploy the environment first", target.NetworkSource, cluster)
+	}
+	vpc := *resp.Services[0].NetworkConfiguration.AwsvpcConfiguration
+
+	if target.SecurityGroup != "" {
+		out, err := groups.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+			Filters: []ec2types.Filter{{Name: aws.String("group-name"), Values: []string{target.SecurityGroup}}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find security group %s: %w", target.SecurityGroup, err)
+		}
+		if len(out.SecurityGroups) == 0 {
+			return nil, fmt.Errorf("security group %s not found, deploy the environment first", target.SecurityGroup)
+		}
+		vpc.SecurityGroups = []string{aws.ToString(out.SecurityGroups[0].GroupId)}
+	}
+	return &ecstypes.NetworkConfiguration{AwsvpcConfiguration: &vpc}, nil
+}
+
+// startOneOffTask runs the latest task definition of a service or task on Fargate with the
+// command and environment of the request
+func startOneOffTask(ctx context.Context, client ecsRunTaskAPI, groups securityGroupsAPI, env Env, req OneOffTaskRequest) (*OneOffTask, error) {
+	target, err := resolveOneOffTarget(env, req.Service)
+	if err != nil {
+		return nil, err
+	}
+	cluster := fmt.Sprintf("%s_cluster_%s", env.Project, env.Env)
+
+	// The family without a revision is its latest active revision
+	def, err := client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: aws.String(target.Family)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task definition %s: %w", target.Family, err)
+	}
+	definitionArn := aws.ToString(def.TaskDefinition.TaskDefinitionArn)
+	if !slices.ContainsFunc(def.TaskDefinition.ContainerDefinitions, func(c ecstypes.ContainerDefinition) bool {
+		return aws.ToString(c.Name) == target.Container
+	}) {
+		return nil, fmt.Errorf("task definition %s has no container %s", definitionArn, target.Container)
+	}
+
+	network, err := oneOffTaskNetwork(ctx, client, groups, cluster, target)
+	if err != nil {
+		return nil, err
+	}
+
+	override := ecstypes.ContainerOverride{Name: aws.String(target.Container), Command: req.Command}
+	names := make([]string, 0, len(req.Environment))
+	for name := range req.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		override.Environment = append(override.Environment, ecstypes.KeyValuePair{
+			Name:  aws.String(name),
+			Value: aws.String(req.Environment[name]),
+		})
+	}
+
+	resp, err := client.RunTask(ctx, &ecs.RunTaskInput{
+		Cluster:              aws.String(cluster),
+		TaskDefinition:       aws.String(definitionArn),
+		LaunchType:           ecstypes.LaunchTypeFargate,
+		Count:                aws.Int32(1),
+		StartedBy:            aws.String(taskRunStartedBy),
+		NetworkConfiguration: network,
+		Overrides:            &ecstypes.TaskOverride{ContainerOverrides: []ecstypes.ContainerOverride{override}},
+		PropagateTags:        ecstypes.PropagateTagsTaskDefinition,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run task: %w", err)
+	}
+	if len(resp.Failures) > 0 {
+		return nil, fmt.Errorf("failed to run task: %s", aws.ToString(resp.Failures[0].Reason))
+	}
+	if len(resp.Tasks) == 0 {
+		return nil, fmt.Errorf("failed to run task: no task was started")
+	}
+
+	return oneOffTaskOf(env, req.Service, target, aws.ToString(resp.Tasks[0].TaskArn), definitionArn), nil
+}
+
+// findOneOffTask describes a task started earlier by its ID or ARN
+func findOneOffTask(env Env, service, task string) (*OneOffTask, error) {
+	target, err := resolveOneOffTarget(env, service)
+	if err != nil {
+		return nil, err
+	}
+	return oneOffTaskOf(env, service, target, task, ""), nil
+}
+
+func oneOffTaskOf(env Env, service string, target oneOffTarget, taskArn, definitionArn string) *OneOffTask {
+	taskID := ecsTaskID(taskArn)
+	return &OneOffTask{
+		Service:           service,
+		Cluster:           fmt.Sprintf("%s_cluster_%s", env.Project, env.Env),
+		TaskArn:           taskArn,
+		TaskID:            taskID,
+		TaskDefinitionArn: definitionArn,
+		Container:         target.Container,
+		LogGroup:          constructLogGroupName(env, service),
+		// awslogs-stream-prefix is "ecs" in every module
+		LogStream: fmt.Sprintf("ecs/%s/%s", target.Container, taskID),
+	}
+}
+
+// followOneOffTask prints the task's logs until it stops and returns the container's exit code
+func followOneOffTask(ctx context.Context, client ecsRunTaskAPI, logs cloudWatchLogsAPI, task *OneOffTask, since time.Time, printEvent func(serviceLogEvent), onStatus func(string)) (*OneOffTaskResult, error) {
+	source := []logSource{{Service: task.Service, Group: task.LogGroup, Streams: []string{task.LogStream}}}
+	cursor := newLogCursor(since)
+	status := ""
+	var stopped *ecstypes.Task
+	drained := 0
+
+	ticker := time.NewTicker(taskRunPollInterval)
+	defer ticker.Stop()
+	for {
+		if stopped == nil {
+			resp, err := client.DescribeTasks(ctx, &ecs.DescribeTasksInput{Cluster: aws.String(task.Cluster), Tasks: []string{task.TaskArn}})
+			if err != nil && ctx.Err() == nil {
+				return nil, fmt.Errorf("failed to describe task %s: %w", task.TaskID, err)
+			}
+			if err == nil {
+				if len(resp.Tasks) == 0 {
+					return nil, fmt.Errorf("task %s not found in %s", task.TaskID, task.Cluster)
+				}
+				current := resp.Tasks[0]
+				if last := aws.ToString(current.LastStatus); last != status {
+					status = last
+					onStatus(status)
+				}
+				if status == string(ecstypes.DesiredStatusStopped) {
+					stopped = &current
+				}
+			}
+		}
+
+		// The stream exists once the container started
+		events, _, err := fetchLogEvents(ctx, logs, source, cursor.Since(), time.Now(), "")
+		var notFound *cwltypes.ResourceNotFoundException
+		if err != nil && !errors.As(err, &notFound) && ctx.Err() == nil {
+			return nil, err
+		}
+		cursor.Print(events, printEvent)
+
+		if stopped != nil {
+			if drained == taskRunDrainPolls {
+				return oneOffTaskResult(stopped, task.Container)
+			}
+			drained++
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopOneOffTask stops a one-off task that nobody follows anymore
+func stopOneOffTask(ctx context.Context, client ecsRunTaskAPI, task *OneOffTask) error {
+	_, err := client.StopTask(ctx, &ecs.StopTaskInput{
+		Cluster: aws.String(task.Cluster),
+		Task:    aws.String(task.TaskArn),
+		Reason:  aws.String("Stopped by meroku run"),
+	})
+	return err
+}
+
+// oneOffTaskResult reads the exit code of a stopped task's container
+func oneOffTaskResult(task *ecstypes.Task, container string) (*OneOffTaskResult, error) {
+	result := &OneOffTaskResult{StoppedReason: aws.ToString(task.StoppedReason)}
+	for _, c := range task.Containers {
+		if aws.ToString(c.Name) != container {
+			continue
+		}
+		if c.ExitCode == nil {
+			// e.g. CannotPullContainerError or a missing secret
+			reason := strings.TrimSpace(aws.ToString(c.Reason) + " " + result.StoppedReason)
+			return nil, fmt.Errorf("task stopped before the command ran: %s", reason)
+		}
+		result.ExitCode = int(aws.ToInt32(c.ExitCode))
+		return result, nil
+	}
+	return nil, fmt.Errorf("container %s not found in the stopped task: %s", container, result.StoppedReason)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwltypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// fakeECSRun runs a task that goes through statuses, one per DescribeTasks call
+type fakeECSRun struct {
+	statuses []string
+	exitCode *int32
+	run      *ecs.RunTaskInput
+	stopped  bool
+}
+
+func (f *fakeECSRun) DescribeServices(ctx context.Context, params *ecs.DescribeServicesInput, optFns ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
+	if params.Services[0] != "shop_service_dev" {
+		return &ecs.DescribeServicesOutput{}, nil
+	}
+	return &ecs.DescribeServicesOutput{Services: []ecstypes.Service{{
+		NetworkConfiguration: &ecstypes.NetworkConfiguration{AwsvpcConfiguration: &ecstypes.AwsVpcConfiguration{
+			Subnets:        []string{"subnet-1", "subnet-2"},
+			SecurityGroups: []string{"sg-backend"},
+			AssignPublicIp: ecstypes.AssignPublicIpEnabled,
+		}},
+	}}}, nil
+}
+
+func (f *fakeECSRun) DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error) {
+	family := aws.ToString(params.TaskDefinition)
+	container := "shop_service_dev"
+	if family == "cleanup" {
+		container = "shop_container_cleanup_dev"
+	}
+	return &ecs.DescribeTaskDefinitionOutput{TaskDefinition: &ecstypes.TaskDefinition{
+		TaskDefinitionArn:    aws.String("arn:aws:ecs:eu-west-1:111111111111:task-definition/" + family + ":7"),
+		ContainerDefinitions: []ecstypes.ContainerDefinition{{Name: aws.String(container)}},
+	}}, nil
+}
+
+func (f *fakeECSRun) RunTask(ctx context.Context, params *ecs.RunTaskInput, optFns ...func(*ecs.Options)) (*ecs.RunTaskOutput, error) {
+	f.run = params
+	return &ecs.RunTaskOutput{Tasks: []ecstypes.Task{{TaskArn: aws.String("arn:aws:ecs:eu-west-1:111111111111:task/shop_cluster_dev/abc123")}}}, nil
+}
+
+func (f *fakeECSRun) DescribeTasks(ctx context.Context, params *ecs.DescribeTasksInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+	status := f.statuses[0]
+	if len(f.statuses) > 1 {
+		f.statuses = f.statuses[1:]
+	}
+	task := ecstypes.Task{LastStatus: aws.String(status)}
+	if status == "STOPPED" {
+		task.StoppedReason = aws.String("Essential container in task exited")
+		task.Containers = []ecstypes.Container{{Name: aws.String("shop_container_cleanup_dev"), ExitCode: f.exitCode}}
+	}
+	return &ecs.DescribeTasksOutput{Tasks: []ecstypes.Task{task}}, nil
+}
+
+func (f *fakeECSRun) StopTask(ctx context.Context, params *ecs.StopTaskInput, optFns ...func(*ecs.Options)) (*ecs.StopTaskOutput, error) {
+	f.stopped = true
+	return &ecs.StopTaskOutput{}, nil
+}
+
+type fakeSecurityGroups struct{}
+
+func (fakeSecurityGroups) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	if params.Filters[0].Values[0] != "shop_cleanup_dev" {
+		return &ec2.DescribeSecurityGroupsOutput{}, nil
+	}
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-cleanup")}}}, nil
+}
+
+func TestStartOneOffTask(t *testing.T) {
+	env := Env{Project: "shop", Env: "dev", ScheduledTasks: []ScheduledTask{{Name: "cleanup"}}}
+	client := &fakeECSRun{}
+
+	task, err := startOneOffTask(context.Background(), client, fakeSecurityGroups{}, env, OneOffTaskRequest{
+		Service:     "cleanup",
+		Command:     []string{"go", "run", "./cmd/migrate"},
+		Environment: map[string]string{"VERBOSE": "1", "DRY_RUN": "0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.TaskID != "abc123" || task.LogGroup != "shop_task_cleanup_dev" || task.LogStream != "ecs/shop_container_cleanup_dev/abc123" {
+		t.Errorf("task = %+v", task)
+	}
+
+	run := client.run
+	vpc := run.NetworkConfiguration.AwsvpcConfiguration
+	if aws.ToString(run.TaskDefinition) != "arn:aws:ecs:eu-west-1:111111111111:task-definition/cleanup:7" ||
+		run.LaunchType != ecstypes.LaunchTypeFargate || aws.ToString(run.Cluster) != "shop_cluster_dev" {
+		t.Errorf("RunTask input = %+v", run)
+	}
+	if strings.Join(vpc.Subnets, ",") != "subnet-1,subnet-2" || strings.Join(vpc.SecurityGroups, ",") != "sg-cleanup" || vpc.AssignPublicIp != ecstypes.AssignPublicIpEnabled {
+		t.Errorf("network = %+v", vpc)
+	}
+	override := run.Overrides.ContainerOverrides[0]
+	if aws.ToString(override.Name) != "shop_container_cleanup_dev" || strings.Join(override.Command, " ") != "go run ./cmd/migrate" ||
+		len(override.Environment) != 2 || aws.ToString(override.Environment[0].Name) != "DRY_RUN" {
+		t.Errorf("override = %+v", override)
+	}
+
+	if _, err := startOneOffTask(context.Background(), client, fakeSecurityGroups{}, env, OneOffTaskRequest{Service: "worker"}); err == nil ||
+		!strings.Contains(err.Error(), "use one of: backend, cleanup") {
+		t.Errorf("unknown service error = %v", err)
+	}
+}
+
+func TestFollowOneOffTask(t *testing.T) {
+	defer func(interval time.Duration) { taskRunPollInterval = interval }(taskRunPollInterval)
+	taskRunPollInterval = time.Millisecond
+
+	env := Env{Project: "shop", Env: "dev", ScheduledTasks: []ScheduledTask{{Name: "cleanup"}}}
+	task, err := findOneOffTask(env, "cleanup", "arn:aws:ecs:eu-west-1:111111111111:task/shop_cluster_dev/abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := &fakeCloudWatchLogs{events: map[string][]cwltypes.FilteredLogEvent{}}
+	logs.add("shop_task_cleanup_dev", "ecs/shop_container_cleanup_dev/other", 1000, "another run")
+	logs.add("shop_task_cleanup_dev", "ecs/shop_container_cleanup_dev/abc123", 2000, "migrating")
+	logs.add("shop_task_cleanup_dev", "ecs/shop_container_cleanup_dev/abc123", 3000, "failed")
+
+	client := &fakeECSRun{statuses: []string{"PROVISIONING", "RUNNING", "RUNNING", "STOPPED"}, exitCode: aws.Int32(3)}
+	var printed, statuses []string
+	result, err := followOneOffTask(context.Background(), client, logs, task, time.UnixMilli(0),
+		func(e serviceLogEvent) { printed = append(printed, e.Message) },
+		func(status string) { statuses = append(statuses, status) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 || result.StoppedReason != "Essential container in task exited" {
+		t.Errorf("result = %+v", result)
+	}
+	if strings.Join(printed, ",") != "migrating,failed" || strings.Join(statuses, ",") != "PROVISIONING,RUNNING,STOPPED" {
+		t.Errorf("printed %v, statuses %v", printed, statuses)
+	}
+
+	// A container that never ran has no exit code
+	client = &fakeECSRun{statuses: []string{"STOPPED"}}
+	if _, err := followOneOffTask(context.Background(), client, logs, task, time.Now(), func(serviceLogEvent) {}, func(string) {}); err == nil ||
+		!strings.Contains(err.Error(), "before the command ran") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestParseRunArgs(t *testing.T) {
+	req, env, err := parseRunArgs([]string{"dev", "backend", "--env", "A=1", "--env", "B=x=y", "--", "rails", "db:migrate", "--trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env != "dev" || req.Service != "backend" || strings.Join(req.Command, " ") != "rails db:migrate --trace" ||
+		req.Environment["A"] != "1" || req.Environment["B"] != "x=y" {
+		t.Errorf("parseRunArgs() = %+v, %q", req, env)
+	}
+	if _, _, err := parseRunArgs([]string{"dev", "backend", "rails"}); err == nil {
+		t.Error("expected an error for a command without --")
+	}
+	if _, _, err := parseRunArgs([]string{"dev", "--", "ls"}); err == nil {
+		t.Error("expected an error without a service")
+	}
+}
@@ -823,13 +823,13 @@ func (m *modernPlanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			
 		case key.Matches(msg, m.keys.AskAI):
-			if os.Getenv("ANTHROPIC_API_KEY") != "" {
+			if err := llmConfigError(); err == nil {
 				m.aiLoading = true
 				m.showAIError = false
 				m.aiError = ""
 				return m, m.askAIToExplainCmd()
 			} else {
-				m.aiError = "ANTHROPIC_API_KEY environment variable not set. Set it with: export ANTHROPIC_API_KEY=your_key_here"
+				m.aiError = fmt.Sprintf("AI provider not configured: %v. Set ANTHROPIC_API_KEY or configure %s", err, LLMConfigFile)
 				m.showAIError = true
 				return m, nil
 			}
@@ -1482,7 +1482,7 @@ func (m *modernPlanModel) renderProgressBar(label string, percent float64, color
 
 func (m *modernPlanModel) renderFooter() string {
 	help := "[↑↓] Navigate  [Space/Enter] Expand  [r] Replace  [i] Import  [c] Copy  "
-	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+	if isAIHelperAvailable() {
 		help += "[e] Ask AI  "
 	}
 	help += "[a] Apply  [?] Help  [q] Quit"
@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/samber/lo"
 )
 
 // ECR repository URI pattern: <account-id>.dkr.ecr.<region>.amazonaws.com/<repo-name>
@@ -155,3 +159,182 @@ func ValidateAllECRConfigs(env *Env) error {
 
 	return nil
 }
+
+// ValidateNetworkConfig validates the optional private networking section
+func ValidateNetworkConfig(env *Env) error {
+	var errors []string
+	network := env.Network
+
+	if network.PrivateSubnets {
+		if env.UseDefaultVPC {
+			errors = append(errors, "network.private_subnets requires a custom VPC (use_default_vpc: false)")
+		}
+		if env.Postgres.Enabled && env.Postgres.PublicAccess {
+			errors = append(errors, "postgres.public_access cannot be enabled when the database is placed in private subnets")
+		}
+	}
+
+	switch network.NATMode {
+	case "", NATModeSingle, NATModePerAZ, NATModeInstance:
+	default:
+		errors = append(errors, fmt.Sprintf("network.nat_mode must be '%s', '%s' or '%s', got '%s'", NATModeSingle, NATModePerAZ, NATModeInstance, network.NATMode))
+	}
+
+	for _, endpoint := range network.VPCEndpoints {
+		if !lo.Contains(gatewayVPCEndpoints, endpoint) && !lo.Contains(interfaceVPCEndpoints, endpoint) {
+			errors = append(errors, fmt.Sprintf("network.vpc_endpoints: unknown endpoint '%s'", endpoint))
+		}
+	}
+
+	if !network.PrivateSubnets && (len(network.VPCEndpoints) > 0 || network.NATMode != "") {
+		errors = append(errors, "network.nat_mode and network.vpc_endpoints only apply when network.private_subnets is enabled")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("network configuration validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
+
+// ValidateWAFConfig validates the optional WAF section
+func ValidateWAFConfig(env *Env) error {
+	if !env.WAF.Enabled {
+		return nil
+	}
+
+	var errors []string
+	waf := env.WAF
+
+	if !env.ALB.Enabled {
+		errors = append(errors, "waf requires alb.enabled: AWS WAF cannot be attached to API Gateway HTTP APIs")
+	}
+
+	for _, rule := range waf.ManagedRules {
+		if _, ok := wafManagedRuleGroups[rule]; !ok {
+			errors = append(errors, fmt.Sprintf("waf.managed_rules: unknown rule group '%s' (expected core_rule_set, known_bad_inputs or ip_reputation)", rule))
+		}
+	}
+
+	// AWS WAF rate-based rules accept limits from 10 to 2,000,000,000 per 5 minutes
+	if waf.RateLimit != 0 && (waf.RateLimit < 10 || waf.RateLimit > 2000000000) {
+		errors = append(errors, fmt.Sprintf("waf.rate_limit must be 0 (disabled) or between 10 and 2000000000, got %d", waf.RateLimit))
+	}
+
+	for _, cidr := range append(append([]string{}, waf.AllowIPs...), waf.DenyIPs...) {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil || ip.To4() == nil {
+			errors = append(errors, fmt.Sprintf("waf: '%s' is not a valid IPv4 CIDR (e.g. 203.0.113.0/24 or 198.51.100.7/32)", cidr))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("WAF configuration validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
+
+// Static site names become part of S3 bucket names, so they must be DNS-safe
+var staticSiteNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,30}[a-z0-9]$`)
+
+// ValidateStaticSites validates the static_sites list
+func ValidateStaticSites(env *Env) error {
+	var errors []string
+	seen := map[string]bool{}
+
+	for _, site := range env.StaticSites {
+		if !staticSiteNamePattern.MatchString(site.Name) {
+			errors = append(errors, fmt.Sprintf("static site '%s': name must be 2-32 lowercase letters, digits or hyphens", site.Name))
+		}
+		if seen[site.Name] {
+			errors = append(errors, fmt.Sprintf("static site '%s': duplicate name", site.Name))
+		}
+		seen[site.Name] = true
+
+		if site.SubdomainPrefix != "" && !env.Domain.Enabled {
+			errors = append(errors, fmt.Sprintf("static site '%s': subdomain_prefix requires domain.enabled", site.Name))
+		}
+
+		switch site.PriceClass {
+		case "", "PriceClass_100", "PriceClass_200", "PriceClass_All":
+		default:
+			errors = append(errors, fmt.Sprintf("static site '%s': price_class must be PriceClass_100, PriceClass_200 or PriceClass_All, got '%s'", site.Name, site.PriceClass))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("static site validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
+
+// ValidateDNSRecords validates the dns_records list
+func ValidateDNSRecords(env *Env) error {
+	if len(env.DNSRecords) == 0 {
+		return nil
+	}
+
+	errors := validateDNSRecordList(env.DNSRecords)
+	if !env.Domain.Enabled {
+		errors = append([]string{"dns_records requires domain.enabled"}, errors...)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("DNS record validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
+
+// ValidateLogFields checks that log_fields only names services of the environment
+func ValidateLogFields(env *Env) error {
+	var errors []string
+	services := environmentLogServices(*env)
+	for service := range env.LogFields {
+		if !lo.Contains(services, service) {
+			errors = append(errors, fmt.Sprintf("log_fields: unknown service %q", service))
+		}
+	}
+	sort.Strings(errors)
+
+	if len(errors) > 0 {
+		return fmt.Errorf("log fields validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
+
+var backupWindowPattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d-([01]\d|2[0-3]):[0-5]\d$`)
+
+// ValidatePostgresBackup validates backup retention and window settings
+func ValidatePostgresBackup(env *Env) error {
+	if !env.Postgres.Enabled {
+		return nil
+	}
+
+	var errors []string
+	pg := env.Postgres
+
+	if pg.BackupRetentionPeriod != nil {
+		minDays := 0
+		if pg.Aurora {
+			// Aurora always keeps automated backups
+			minDays = 1
+		}
+		if days := *pg.BackupRetentionPeriod; days < minDays || days > 35 {
+			errors = append(errors, fmt.Sprintf("postgres.backup_retention_period must be between %d and 35 days, got %d", minDays, days))
+		}
+	}
+
+	if pg.BackupWindow != "" && !backupWindowPattern.MatchString(pg.BackupWindow) {
+		errors = append(errors, fmt.Sprintf("postgres.backup_window must be in UTC hh24:mi-hh24:mi format (e.g. 03:00-04:00), got '%s'", pg.BackupWindow))
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("postgres backup validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
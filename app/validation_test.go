@@ -293,3 +293,319 @@ func TestValidateSourceServiceExists_DefaultMode(t *testing.T) {
 		t.Errorf("Expected empty mode to be treated as create_ecr, got error: %v", err)
 	}
 }
+
+func TestValidateNetworkConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     Env
+		wantErr string
+	}{
+		{
+			name: "no network section",
+			env:  Env{},
+		},
+		{
+			name: "private subnets with single NAT and endpoints",
+			env: Env{Network: Network{
+				PrivateSubnets: true,
+				NATMode:        NATModeSingle,
+				VPCEndpoints:   []string{"s3", "ecr", "logs"},
+			}},
+		},
+		{
+			name:    "private subnets on default VPC",
+			env:     Env{UseDefaultVPC: true, Network: Network{PrivateSubnets: true}},
+			wantErr: "requires a custom VPC",
+		},
+		{
+			name: "public postgres in private subnets",
+			env: Env{
+				Network:  Network{PrivateSubnets: true},
+				Postgres: Postgres{Enabled: true, PublicAccess: true},
+			},
+			wantErr: "postgres.public_access",
+		},
+		{
+			name:    "unknown NAT mode",
+			env:     Env{Network: Network{PrivateSubnets: true, NATMode: "gateway"}},
+			wantErr: "network.nat_mode must be",
+		},
+		{
+			name:    "unknown endpoint",
+			env:     Env{Network: Network{PrivateSubnets: true, VPCEndpoints: []string{"ec2"}}},
+			wantErr: "unknown endpoint 'ec2'",
+		},
+		{
+			name:    "endpoints without private subnets",
+			env:     Env{Network: Network{VPCEndpoints: []string{"s3"}}},
+			wantErr: "only apply when network.private_subnets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNetworkConfig(&tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid config, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateWAFConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     Env
+		wantErr string
+	}{
+		{
+			name: "disabled",
+			env:  Env{WAF: WAF{RateLimit: 1}},
+		},
+		{
+			name: "ALB with all options",
+			env: Env{
+				ALB: ALB{Enabled: true},
+				WAF: WAF{
+					Enabled:      true,
+					ManagedRules: []string{"core_rule_set", "ip_reputation"},
+					RateLimit:    2000,
+					AllowIPs:     []string{"203.0.113.0/24"},
+					DenyIPs:      []string{"198.51.100.7/32"},
+				},
+			},
+		},
+		{
+			name:    "API Gateway entrypoint",
+			env:     Env{WAF: WAF{Enabled: true}},
+			wantErr: "requires alb.enabled",
+		},
+		{
+			name:    "unknown managed rule",
+			env:     Env{ALB: ALB{Enabled: true}, WAF: WAF{Enabled: true, ManagedRules: []string{"sqli"}}},
+			wantErr: "unknown rule group 'sqli'",
+		},
+		{
+			name:    "rate limit too low",
+			env:     Env{ALB: ALB{Enabled: true}, WAF: WAF{Enabled: true, RateLimit: 5}},
+			wantErr: "waf.rate_limit",
+		},
+		{
+			name:    "invalid CIDR",
+			env:     Env{ALB: ALB{Enabled: true}, WAF: WAF{Enabled: true, DenyIPs: []string{"10.0.0.1"}}},
+			wantErr: "not a valid IPv4 CIDR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWAFConfig(&tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid config, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateStaticSites(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     Env
+		wantErr string
+	}{
+		{
+			name: "valid sites",
+			env: Env{
+				Domain: Domain{Enabled: true},
+				StaticSites: []StaticSite{
+					{Name: "web", SubdomainPrefix: "app", SPA: true},
+					{Name: "docs", PriceClass: "PriceClass_All"},
+				},
+			},
+		},
+		{
+			name:    "invalid name",
+			env:     Env{StaticSites: []StaticSite{{Name: "My_Site"}}},
+			wantErr: "name must be",
+		},
+		{
+			name:    "duplicate name",
+			env:     Env{StaticSites: []StaticSite{{Name: "web"}, {Name: "web"}}},
+			wantErr: "duplicate name",
+		},
+		{
+			name:    "subdomain without domain",
+			env:     Env{StaticSites: []StaticSite{{Name: "web", SubdomainPrefix: "app"}}},
+			wantErr: "requires domain.enabled",
+		},
+		{
+			name:    "invalid price class",
+			env:     Env{StaticSites: []StaticSite{{Name: "web", PriceClass: "cheap"}}},
+			wantErr: "price_class",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStaticSites(&tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid config, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDNSRecords(t *testing.T) {
+	domain := Domain{Enabled: true, DomainName: "example.com"}
+	tests := []struct {
+		name    string
+		env     Env
+		wantErr string
+	}{
+		{
+			name: "valid records",
+			env: Env{Domain: domain, DNSRecords: []DNSRecord{
+				{Name: "@", Type: "MX", Values: []string{"10 mx1.mail.example.net", "20 mx2.mail.example.net"}},
+				{Name: "_verify", Type: "TXT", Values: []string{"token=abc"}},
+				{Name: "docs", Type: "CNAME", Values: []string{"acme.gitbook.io"}},
+				{Name: "www", Type: "A", Alias: &DNSAlias{DNSName: "d111.cloudfront.net", HostedZoneID: "Z2FDTNDATAQYW2"}},
+			}},
+		},
+		{
+			name:    "domain disabled",
+			env:     Env{DNSRecords: []DNSRecord{{Name: "x", Type: "TXT", Values: []string{"y"}}}},
+			wantErr: "requires domain.enabled",
+		},
+		{
+			name:    "unknown type",
+			env:     Env{Domain: domain, DNSRecords: []DNSRecord{{Name: "x", Type: "SPF", Values: []string{"y"}}}},
+			wantErr: "type must be one of",
+		},
+		{
+			name:    "duplicate",
+			env:     Env{Domain: domain, DNSRecords: []DNSRecord{{Type: "TXT", Values: []string{"a"}}, {Name: "@", Type: "TXT", Values: []string{"b"}}}},
+			wantErr: "duplicate record",
+		},
+		{
+			name:    "apex CNAME",
+			env:     Env{Domain: domain, DNSRecords: []DNSRecord{{Type: "CNAME", Values: []string{"x.example.net"}}}},
+			wantErr: "zone apex",
+		},
+		{
+			name:    "alias and values",
+			env:     Env{Domain: domain, DNSRecords: []DNSRecord{{Name: "www", Type: "A", Values: []string{"1.2.3.4"}, Alias: &DNSAlias{DNSName: "x", HostedZoneID: "Z"}}}},
+			wantErr: "either values or alias",
+		},
+		{
+			name:    "no values",
+			env:     Env{Domain: domain, DNSRecords: []DNSRecord{{Name: "www", Type: "A"}}},
+			wantErr: "values or alias required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDNSRecords(&tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid config, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePostgresBackup(t *testing.T) {
+	days := func(d int) *int { return &d }
+
+	tests := []struct {
+		name    string
+		env     Env
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			env:  Env{Postgres: Postgres{Enabled: true}},
+		},
+		{
+			name: "valid rds settings",
+			env:  Env{Postgres: Postgres{Enabled: true, BackupRetentionPeriod: days(0), BackupWindow: "03:00-04:00"}},
+		},
+		{
+			name:    "aurora requires retention",
+			env:     Env{Postgres: Postgres{Enabled: true, Aurora: true, BackupRetentionPeriod: days(0)}},
+			wantErr: "between 1 and 35",
+		},
+		{
+			name:    "retention too long",
+			env:     Env{Postgres: Postgres{Enabled: true, BackupRetentionPeriod: days(36)}},
+			wantErr: "between 0 and 35",
+		},
+		{
+			name:    "invalid window",
+			env:     Env{Postgres: Postgres{Enabled: true, BackupWindow: "3am-4am"}},
+			wantErr: "backup_window",
+		},
+		{
+			name: "disabled postgres is not validated",
+			env:  Env{Postgres: Postgres{BackupWindow: "nope"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePostgresBackup(&tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid config, got error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateLogFields(t *testing.T) {
+	env := Env{
+		Services:       []Service{{Name: "api"}},
+		ScheduledTasks: []ScheduledTask{{Name: "cleanup"}},
+		LogFields: map[string]LogFieldMapping{
+			"backend": {Level: []string{"severity"}},
+			"api":     {Message: []string{"text"}},
+			"cleanup": {TraceID: []string{"trace"}},
+		},
+	}
+	if err := ValidateLogFields(&env); err != nil {
+		t.Errorf("ValidateLogFields() = %v", err)
+	}
+
+	env.LogFields["worker"] = LogFieldMapping{}
+	if err := ValidateLogFields(&env); err == nil || !strings.Contains(err.Error(), `unknown service "worker"`) {
+		t.Errorf("ValidateLogFields() = %v, want unknown service error", err)
+	}
+}
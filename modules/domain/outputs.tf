@@ -19,3 +19,8 @@ output "enable_custom_domain" {
   value       = true
 }
 
+
+output "domain_name" {
+  description = "Domain name of the zone (env-prefixed when add_env_domain_prefix is set)"
+  value       = local.domain_name
+}
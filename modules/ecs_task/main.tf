@@ -32,7 +32,7 @@ resource "aws_scheduler_schedule" "scheduler" {
       launch_type            = "FARGATE"
 
       network_configuration {
-        assign_public_ip = true
+        assign_public_ip = var.assign_public_ip
         security_groups  = [aws_security_group.task.id]
         subnets          = var.subnet_ids
       }
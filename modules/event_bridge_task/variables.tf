@@ -29,6 +29,12 @@ variable "subnet_ids" {
   type = list(string)
 }
 
+variable "assign_public_ip" {
+  type        = bool
+  default     = true
+  description = "Assign public IPs to tasks (false when tasks run in private subnets behind NAT)"
+}
+
 variable "vpc_id" {
   type = string
 }
@@ -30,10 +30,13 @@ resource "aws_db_instance" "database" {
   multi_az               = var.multi_az
   deletion_protection    = var.deletion_protection
   skip_final_snapshot    = var.skip_final_snapshot
+  backup_retention_period = var.backup_retention_period
+  backup_window           = var.backup_window
   username               = local.db_username
   db_name                = local.db_name
   password                            = aws_ssm_parameter.postgres_password.value
   vpc_security_group_ids              = [aws_security_group.database.id]
+  db_subnet_group_name                = var.use_subnet_group ? aws_db_subnet_group.database[0].name : null
   publicly_accessible                 = var.public_access
   iam_database_authentication_enabled = var.iam_database_authentication_enabled
 
@@ -59,6 +62,8 @@ resource "aws_rds_cluster" "aurora" {
   skip_final_snapshot                 = true
   vpc_security_group_ids              = [aws_security_group.database.id]
   db_subnet_group_name                = aws_db_subnet_group.aurora[0].name
+  backup_retention_period             = var.backup_retention_period
+  preferred_backup_window             = var.backup_window
   iam_database_authentication_enabled = var.iam_database_authentication_enabled
 
   serverlessv2_scaling_configuration {
@@ -121,4 +126,20 @@ resource "aws_db_subnet_group" "aurora" {
     ManagedBy   = "meroku"
     Application = "${var.project}-${var.env}"
   }
-}
\ No newline at end of file
+}
+
+# DB Subnet Group for standard RDS (only when placed in custom/private subnets,
+# existing instances keep the default subnet group to avoid replacement)
+resource "aws_db_subnet_group" "database" {
+  count      = !var.aurora && var.use_subnet_group ? 1 : 0
+  name       = "${var.project}-postgres-subnet-${var.env}"
+  subnet_ids = var.subnet_ids
+
+  tags = {
+    Name        = "${var.project}-postgres-subnet-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
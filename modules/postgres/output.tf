@@ -24,4 +24,8 @@ output "db_name" {
 
 output "is_aurora" {
   value = var.aurora
-}
\ No newline at end of file
+}
+
+output "endpoint_ssm_name" {
+  value = aws_ssm_parameter.postgres_endpoint_backend.name
+}
@@ -11,6 +11,12 @@ variable "subnet_ids" {
   type = list(string)
 }
 
+variable "use_subnet_group" {
+  type        = bool
+  default     = false
+  description = "Create a DB subnet group from subnet_ids for the standard RDS instance (required for private subnets)"
+}
+
 variable "project" {
   type = string
 }
@@ -110,6 +116,24 @@ variable "skip_final_snapshot" {
   description = "Skip final snapshot when deleting (not recommended for production)"
 }
 
+variable "backup_retention_period" {
+  type        = number
+  default     = null
+  description = "Days to keep automated backups (0-35). null keeps the AWS default"
+}
+
+variable "backup_window" {
+  type        = string
+  default     = null
+  description = "Daily UTC window for automated backups, e.g. 03:00-04:00. null lets AWS choose"
+}
+
+variable "endpoint_override" {
+  type        = string
+  default     = null
+  description = "Database host published to the backend instead of the managed one, e.g. a database restored from a snapshot"
+}
+
 variable "iam_database_authentication_enabled" {
   type        = bool
   default     = false
@@ -152,4 +176,19 @@ resource "aws_ssm_parameter" "postgres_password_backend" {
   }
 }
 
+// Endpoint published for the backend (injected as PG_DATABASE_HOST secret).
+// `meroku db restore --repoint` points it at a restored database; set
+// postgres.endpoint_override to the same endpoint so the next apply keeps it.
+resource "aws_ssm_parameter" "postgres_endpoint_backend" {
+  name  = "/${var.env}/${var.project}/backend/pg_database_host"
+  type  = "String"
+  value = coalesce(var.endpoint_override, var.aurora ? aws_rds_cluster.aurora[0].endpoint : aws_db_instance.database[0].address)
 
+  tags = {
+    Name        = "${var.project}-postgres-endpoint-backend-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
@@ -0,0 +1,19 @@
+output "bucket_name" {
+  value       = aws_s3_bucket.site.bucket
+  description = "S3 bucket holding the site files"
+}
+
+output "distribution_id" {
+  value       = aws_cloudfront_distribution.site.id
+  description = "CloudFront distribution ID (used for invalidations)"
+}
+
+output "distribution_domain_name" {
+  value       = aws_cloudfront_distribution.site.domain_name
+  description = "CloudFront domain name (xxxx.cloudfront.net)"
+}
+
+output "url" {
+  value       = "https://${local.custom_domain ? var.domain_name : aws_cloudfront_distribution.site.domain_name}"
+  description = "Public URL of the site"
+}
@@ -0,0 +1,50 @@
+variable "project" {
+  type        = string
+  description = "Project name"
+}
+
+variable "env" {
+  type        = string
+  description = "Environment name"
+}
+
+variable "name" {
+  type        = string
+  description = "Static site name (used in bucket and distribution names)"
+}
+
+variable "enable_custom_domain" {
+  type        = bool
+  default     = false
+  description = "Serve the site on domain_name with an ACM certificate (known at plan time, unlike zone_id)"
+}
+
+variable "domain_name" {
+  type        = string
+  default     = ""
+  description = "Custom domain for the site (e.g. app.example.com). Empty uses the CloudFront domain only"
+}
+
+variable "zone_id" {
+  type        = string
+  default     = ""
+  description = "Route53 zone ID the custom domain belongs to"
+}
+
+variable "index_document" {
+  type        = string
+  default     = "index.html"
+  description = "Default root object"
+}
+
+variable "spa" {
+  type        = bool
+  default     = false
+  description = "Serve index_document for unknown paths (403/404) so client-side routing works"
+}
+
+variable "price_class" {
+  type        = string
+  default     = "PriceClass_100"
+  description = "CloudFront price class: PriceClass_100, PriceClass_200 or PriceClass_All"
+}
@@ -1,5 +1,6 @@
 # VPC Module - Creates a custom VPC with 2 public subnets (simplified architecture)
 # Hardcoded to 2 AZs for simplicity - covers 99% of use cases
+# Optionally adds private subnets with NAT egress (see private.tf)
 
 locals {
   az_count = 2 # Hardcoded for simplicity - 2 AZs is minimum for HA
@@ -22,3 +22,18 @@ output "internet_gateway_id" {
   description = "ID of the Internet Gateway"
   value       = aws_internet_gateway.main.id
 }
+
+output "private_subnet_ids" {
+  description = "IDs of private subnets (empty unless enable_private_subnets)"
+  value       = aws_subnet.private[*].id
+}
+
+output "workload_subnet_ids" {
+  description = "Subnets for ECS tasks and RDS (private when enabled, public otherwise)"
+  value       = var.enable_private_subnets ? aws_subnet.private[*].id : aws_subnet.public[*].id
+}
+
+output "nat_public_ips" {
+  description = "Public egress IPs of the NAT gateways or NAT instance"
+  value       = concat(aws_eip.nat[*].public_ip, aws_instance.nat[*].public_ip)
+}
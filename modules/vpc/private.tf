@@ -0,0 +1,239 @@
+# Private networking - only created when enable_private_subnets = true
+# ECS tasks and RDS are placed here; egress goes through NAT in the public subnets
+
+locals {
+  private_count = var.enable_private_subnets ? local.az_count : 0
+
+  nat_gateway_count = var.enable_private_subnets ? (
+    var.nat_mode == "per_az" ? local.az_count : (var.nat_mode == "single" ? 1 : 0)
+  ) : 0
+  nat_instance_count = var.enable_private_subnets && var.nat_mode == "instance" ? 1 : 0
+
+  # Graviton instance families (t4g, c7g, ...) need the arm64 AMI
+  nat_instance_arch = can(regex("^[a-z]+[0-9]+g[a-z]*\\.", var.nat_instance_type)) ? "arm64" : "x86_64"
+
+  gateway_endpoint_services = {
+    s3       = "s3"
+    dynamodb = "dynamodb"
+  }
+  interface_endpoint_services = {
+    ecr            = ["ecr.api", "ecr.dkr"]
+    logs           = ["logs"]
+    ssm            = ["ssm", "ssmmessages", "ec2messages"]
+    secretsmanager = ["secretsmanager"]
+    sts            = ["sts"]
+  }
+
+  gateway_endpoints = var.enable_private_subnets ? toset([
+    for e in var.vpc_endpoints : e if contains(keys(local.gateway_endpoint_services), e)
+  ]) : toset([])
+  interface_endpoints = var.enable_private_subnets ? toset(flatten([
+    for e in var.vpc_endpoints : lookup(local.interface_endpoint_services, e, [])
+  ])) : toset([])
+}
+
+data "aws_region" "current" {}
+
+# Private Subnets (2 AZs) - offset from public subnets to avoid CIDR overlap
+resource "aws_subnet" "private" {
+  count             = local.private_count
+  vpc_id            = aws_vpc.main.id
+  cidr_block        = cidrsubnet(var.vpc_cidr, 8, count.index + 10)
+  availability_zone = data.aws_availability_zones.available.names[count.index]
+
+  tags = {
+    Name        = "${var.project}-private-subnet-${count.index + 1}-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+    Type        = "private"
+  }
+}
+
+# NAT Gateways (single or one per AZ)
+resource "aws_eip" "nat" {
+  count  = local.nat_gateway_count
+  domain = "vpc"
+
+  tags = {
+    Name        = "${var.project}-nat-eip-${count.index + 1}-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_nat_gateway" "main" {
+  count         = local.nat_gateway_count
+  allocation_id = aws_eip.nat[count.index].id
+  subnet_id     = aws_subnet.public[count.index].id
+
+  tags = {
+    Name        = "${var.project}-nat-${count.index + 1}-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+
+  depends_on = [aws_internet_gateway.main]
+}
+
+# NAT Instance (cheaper alternative to NAT gateway for low-traffic environments)
+data "aws_ssm_parameter" "nat_ami" {
+  count = local.nat_instance_count
+  name  = "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-${local.nat_instance_arch}"
+}
+
+resource "aws_security_group" "nat" {
+  count       = local.nat_instance_count
+  name        = "${var.project}-nat-${var.env}"
+  description = "NAT instance - allow all traffic from the VPC"
+  vpc_id      = aws_vpc.main.id
+
+  ingress {
+    protocol    = "-1"
+    from_port   = 0
+    to_port     = 0
+    cidr_blocks = [var.vpc_cidr]
+  }
+
+  egress {
+    protocol    = "-1"
+    from_port   = 0
+    to_port     = 0
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  tags = {
+    Name        = "${var.project}-nat-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_instance" "nat" {
+  count                       = local.nat_instance_count
+  ami                         = data.aws_ssm_parameter.nat_ami[0].value
+  instance_type               = var.nat_instance_type
+  subnet_id                   = aws_subnet.public[0].id
+  vpc_security_group_ids      = [aws_security_group.nat[0].id]
+  associate_public_ip_address = true
+  source_dest_check           = false
+
+  user_data = <<-EOT
+    #!/bin/bash
+    dnf install -y iptables-services
+    systemctl enable --now iptables
+    echo "net.ipv4.ip_forward = 1" > /etc/sysctl.d/90-nat.conf
+    sysctl -p /etc/sysctl.d/90-nat.conf
+    IFACE=$(ip route show default | awk '{print $5}')
+    iptables -t nat -A POSTROUTING -o "$IFACE" -j MASQUERADE
+    iptables -F FORWARD
+    service iptables save
+  EOT
+
+  tags = {
+    Name        = "${var.project}-nat-instance-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+# Private Route Tables (one per AZ so per_az NAT gateways stay zonal)
+resource "aws_route_table" "private" {
+  count  = local.private_count
+  vpc_id = aws_vpc.main.id
+
+  tags = {
+    Name        = "${var.project}-private-rt-${count.index + 1}-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_route" "private_nat_gateway" {
+  count                  = local.nat_gateway_count > 0 ? local.private_count : 0
+  route_table_id         = aws_route_table.private[count.index].id
+  destination_cidr_block = "0.0.0.0/0"
+  nat_gateway_id         = aws_nat_gateway.main[var.nat_mode == "per_az" ? count.index : 0].id
+}
+
+resource "aws_route" "private_nat_instance" {
+  count                  = local.nat_instance_count > 0 ? local.private_count : 0
+  route_table_id         = aws_route_table.private[count.index].id
+  destination_cidr_block = "0.0.0.0/0"
+  network_interface_id   = aws_instance.nat[0].primary_network_interface_id
+}
+
+resource "aws_route_table_association" "private" {
+  count          = local.private_count
+  subnet_id      = aws_subnet.private[count.index].id
+  route_table_id = aws_route_table.private[count.index].id
+}
+
+# Gateway VPC Endpoints (free) - S3 and DynamoDB
+resource "aws_vpc_endpoint" "gateway" {
+  for_each          = local.gateway_endpoints
+  vpc_id            = aws_vpc.main.id
+  service_name      = "com.amazonaws.${data.aws_region.current.name}.${local.gateway_endpoint_services[each.key]}"
+  vpc_endpoint_type = "Gateway"
+  route_table_ids   = aws_route_table.private[*].id
+
+  tags = {
+    Name        = "${var.project}-${each.key}-endpoint-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+# Interface VPC Endpoints (billed per AZ-hour) - keep ECR pulls, logs and SSM off the NAT
+resource "aws_security_group" "endpoints" {
+  count       = length(local.interface_endpoints) > 0 ? 1 : 0
+  name        = "${var.project}-vpc-endpoints-${var.env}"
+  description = "Interface VPC endpoints - HTTPS from the VPC"
+  vpc_id      = aws_vpc.main.id
+
+  ingress {
+    protocol    = "tcp"
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = [var.vpc_cidr]
+  }
+
+  tags = {
+    Name        = "${var.project}-vpc-endpoints-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_vpc_endpoint" "interface" {
+  for_each            = local.interface_endpoints
+  vpc_id              = aws_vpc.main.id
+  service_name        = "com.amazonaws.${data.aws_region.current.name}.${each.key}"
+  vpc_endpoint_type   = "Interface"
+  subnet_ids          = aws_subnet.private[*].id
+  security_group_ids  = [aws_security_group.endpoints[0].id]
+  private_dns_enabled = true
+
+  tags = {
+    Name        = "${var.project}-${replace(each.key, ".", "-")}-endpoint-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
@@ -13,3 +13,32 @@ variable "vpc_cidr" {
   type        = string
   default     = "10.0.0.0/16"
 }
+
+variable "enable_private_subnets" {
+  description = "Create private subnets (one per AZ) with NAT egress for ECS tasks and RDS"
+  type        = bool
+  default     = false
+}
+
+variable "nat_mode" {
+  description = "NAT egress for private subnets: single (one NAT gateway), per_az (one NAT gateway per AZ), or instance (one NAT instance)"
+  type        = string
+  default     = "single"
+
+  validation {
+    condition     = contains(["single", "per_az", "instance"], var.nat_mode)
+    error_message = "nat_mode must be one of: single, per_az, instance"
+  }
+}
+
+variable "nat_instance_type" {
+  description = "EC2 instance type for the NAT instance (only used when nat_mode = instance)"
+  type        = string
+  default     = "t4g.nano"
+}
+
+variable "vpc_endpoints" {
+  description = "VPC endpoints to create for private subnets (s3, dynamodb, ecr, logs, ssm, secretsmanager, sts)"
+  type        = list(string)
+  default     = []
+}
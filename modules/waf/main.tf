@@ -0,0 +1,177 @@
+# AWS WAF v2 web ACL for the public entrypoint (regional scope, attached to the ALB)
+# Rule order: deny list -> allow list -> rate limit -> AWS managed rule groups
+
+locals {
+  managed_rule_groups = {
+    core_rule_set    = "AWSManagedRulesCommonRuleSet"
+    known_bad_inputs = "AWSManagedRulesKnownBadInputsRuleSet"
+    ip_reputation    = "AWSManagedRulesAmazonIpReputationList"
+  }
+
+  # Stable priorities so reordering the yaml list doesn't churn the web ACL
+  managed_rule_priority = {
+    ip_reputation    = 10
+    known_bad_inputs = 11
+    core_rule_set    = 12
+  }
+
+  metric_prefix = replace("${var.project}-${var.env}", "_", "-")
+}
+
+resource "aws_wafv2_ip_set" "deny" {
+  count              = length(var.deny_ips) > 0 ? 1 : 0
+  name               = "${var.project}-waf-deny-${var.env}"
+  scope              = "REGIONAL"
+  ip_address_version = "IPV4"
+  addresses          = var.deny_ips
+
+  tags = {
+    Name        = "${var.project}-waf-deny-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_wafv2_ip_set" "allow" {
+  count              = length(var.allow_ips) > 0 ? 1 : 0
+  name               = "${var.project}-waf-allow-${var.env}"
+  scope              = "REGIONAL"
+  ip_address_version = "IPV4"
+  addresses          = var.allow_ips
+
+  tags = {
+    Name        = "${var.project}-waf-allow-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_wafv2_web_acl" "main" {
+  name  = "${var.project}-waf-${var.env}"
+  scope = "REGIONAL"
+
+  default_action {
+    allow {}
+  }
+
+  dynamic "rule" {
+    for_each = length(var.deny_ips) > 0 ? [1] : []
+    content {
+      name     = "deny-list"
+      priority = 0
+
+      action {
+        block {}
+      }
+
+      statement {
+        ip_set_reference_statement {
+          arn = aws_wafv2_ip_set.deny[0].arn
+        }
+      }
+
+      visibility_config {
+        cloudwatch_metrics_enabled = true
+        metric_name                = "${local.metric_prefix}-deny-list"
+        sampled_requests_enabled   = true
+      }
+    }
+  }
+
+  dynamic "rule" {
+    for_each = length(var.allow_ips) > 0 ? [1] : []
+    content {
+      name     = "allow-list"
+      priority = 1
+
+      action {
+        allow {}
+      }
+
+      statement {
+        ip_set_reference_statement {
+          arn = aws_wafv2_ip_set.allow[0].arn
+        }
+      }
+
+      visibility_config {
+        cloudwatch_metrics_enabled = true
+        metric_name                = "${local.metric_prefix}-allow-list"
+        sampled_requests_enabled   = true
+      }
+    }
+  }
+
+  dynamic "rule" {
+    for_each = var.rate_limit > 0 ? [1] : []
+    content {
+      name     = "rate-limit"
+      priority = 2
+
+      action {
+        block {}
+      }
+
+      statement {
+        rate_based_statement {
+          limit              = var.rate_limit
+          aggregate_key_type = "IP"
+        }
+      }
+
+      visibility_config {
+        cloudwatch_metrics_enabled = true
+        metric_name                = "${local.metric_prefix}-rate-limit"
+        sampled_requests_enabled   = true
+      }
+    }
+  }
+
+  dynamic "rule" {
+    for_each = toset(var.managed_rules)
+    content {
+      name     = local.managed_rule_groups[rule.value]
+      priority = local.managed_rule_priority[rule.value]
+
+      override_action {
+        none {}
+      }
+
+      statement {
+        managed_rule_group_statement {
+          vendor_name = "AWS"
+          name        = local.managed_rule_groups[rule.value]
+        }
+      }
+
+      visibility_config {
+        cloudwatch_metrics_enabled = true
+        metric_name                = "${local.metric_prefix}-${replace(rule.value, "_", "-")}"
+        sampled_requests_enabled   = true
+      }
+    }
+  }
+
+  visibility_config {
+    cloudwatch_metrics_enabled = true
+    metric_name                = "${local.metric_prefix}-waf"
+    sampled_requests_enabled   = true
+  }
+
+  tags = {
+    Name        = "${var.project}-waf-${var.env}"
+    Environment = var.env
+    Project     = var.project
+    ManagedBy   = "meroku"
+    Application = "${var.project}-${var.env}"
+  }
+}
+
+resource "aws_wafv2_web_acl_association" "main" {
+  resource_arn = var.resource_arn
+  web_acl_arn  = aws_wafv2_web_acl.main.arn
+}
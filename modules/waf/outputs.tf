@@ -0,0 +1,9 @@
+output "web_acl_arn" {
+  value       = aws_wafv2_web_acl.main.arn
+  description = "The ARN of the WAF web ACL"
+}
+
+output "web_acl_name" {
+  value       = aws_wafv2_web_acl.main.name
+  description = "The name of the WAF web ACL (used as the CloudWatch WebACL dimension)"
+}
@@ -0,0 +1,43 @@
+variable "project" {
+  type        = string
+  description = "Project name"
+}
+
+variable "env" {
+  type        = string
+  description = "Environment name"
+}
+
+variable "resource_arn" {
+  type        = string
+  description = "ARN of the resource to protect (ALB)"
+}
+
+variable "managed_rules" {
+  type        = list(string)
+  default     = ["core_rule_set", "known_bad_inputs", "ip_reputation"]
+  description = "AWS managed rule groups to enable: core_rule_set, known_bad_inputs, ip_reputation"
+
+  validation {
+    condition     = alltrue([for r in var.managed_rules : contains(["core_rule_set", "known_bad_inputs", "ip_reputation"], r)])
+    error_message = "managed_rules entries must be one of: core_rule_set, known_bad_inputs, ip_reputation."
+  }
+}
+
+variable "rate_limit" {
+  type        = number
+  default     = 0
+  description = "Maximum requests per IP in a 5-minute window (0 disables rate limiting)"
+}
+
+variable "allow_ips" {
+  type        = list(string)
+  default     = []
+  description = "CIDR blocks that are always allowed (evaluated before managed rules and rate limiting)"
+}
+
+variable "deny_ips" {
+  type        = list(string)
+  default     = []
+  description = "CIDR blocks that are always blocked"
+}
@@ -21,7 +21,7 @@ resource "aws_ecs_service" "backend" {
   network_configuration {
     security_groups  = [aws_security_group.backend.id]
     subnets          = var.subnet_ids
-    assign_public_ip = true
+    assign_public_ip = var.assign_public_ip
   }
 
   dynamic "load_balancer" {
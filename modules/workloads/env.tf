@@ -32,20 +32,33 @@ data "aws_ssm_parameters_by_path" "backend" {
 
 
 locals {
-  backend_env_ssm = [
-    for i in range(length(data.aws_ssm_parameters_by_path.backend.names)) : {
-      name      = upper(reverse(split("/", data.aws_ssm_parameters_by_path.backend.names[i]))[0])
-      valueFrom = data.aws_ssm_parameters_by_path.backend.names[i]
-    }
-  ]
+  # The database host parameter is added explicitly below: it may be created in
+  # the same apply, after the data source has been read
+  backend_env_ssm = concat(
+    [
+      for i in range(length(data.aws_ssm_parameters_by_path.backend.names)) : {
+        name      = upper(reverse(split("/", data.aws_ssm_parameters_by_path.backend.names[i]))[0])
+        valueFrom = data.aws_ssm_parameters_by_path.backend.names[i]
+      } if data.aws_ssm_parameters_by_path.backend.names[i] != var.db_endpoint_ssm_name
+    ],
+    var.db_endpoint_ssm_name != "" ? [
+      { name = "PG_DATABASE_HOST", valueFrom = var.db_endpoint_ssm_name },
+    ] : []
+  )
 
 
 }
 
 locals {
+  # When the postgres module publishes pg_database_host to SSM it is injected as a secret
+  # (so `meroku db restore --repoint` can switch databases); ECS rejects duplicate names
+  db_host_from_ssm = contains([for p in local.backend_env_ssm : p.name], "PG_DATABASE_HOST")
+
   backend_env = concat(
-    [
+    local.db_host_from_ssm ? [] : [
       { "name" : "PG_DATABASE_HOST", "value" : var.db_endpoint },
+    ],
+    [
       { "name" : "PG_DATABASE_USERNAME", "value" : var.db_user },
       { "name" : "PORT", "value" : tostring(var.backend_image_port) },
       { "name" : "PG_DATABASE_NAME", "value" : var.db_name },
@@ -68,7 +68,7 @@ resource "aws_ecs_service" "pgadmin" {
   network_configuration {
     security_groups  = [aws_security_group.pgadmin[0].id]
     subnets          = var.subnet_ids
-    assign_public_ip = true
+    assign_public_ip = var.assign_public_ip
   }
 
   service_registries {
@@ -85,7 +85,7 @@ resource "aws_ecs_service" "services" {
   network_configuration {
     security_groups  = [aws_security_group.services[each.key].id]
     subnets          = var.subnet_ids
-    assign_public_ip = true
+    assign_public_ip = var.assign_public_ip
   }
 
   dynamic "load_balancer" {
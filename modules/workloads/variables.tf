@@ -57,6 +57,12 @@ variable "subnet_ids" {
   type = list(string)
 }
 
+variable "assign_public_ip" {
+  type        = bool
+  default     = true
+  description = "Assign public IPs to tasks (false when tasks run in private subnets behind NAT)"
+}
+
 variable "github_subjects" {
   type    = list(string)
   default = ["repo:MadAppGang/*:*"]
@@ -168,6 +174,12 @@ variable "db_endpoint" {
   default = ""
 }
 
+variable "db_endpoint_ssm_name" {
+  type        = string
+  default     = ""
+  description = "SSM parameter with the database host, injected as the PG_DATABASE_HOST secret instead of db_endpoint"
+}
+
 variable "db_user" {
   default = ""
 }
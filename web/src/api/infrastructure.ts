@@ -216,6 +216,25 @@ export interface LogEntry {
 	message: string;
 	level: "info" | "warning" | "error" | "debug";
 	stream: string;
+	source?: "stdout" | "stderr"; // when the log router records it (FireLens)
+	traceId?: string;
+	requestId?: string;
+	fields?: Record<string, unknown>; // all fields of a JSON log line
+}
+
+// Server-side log filter, shared by the REST and WebSocket endpoints
+export interface LogFilter {
+	levels?: LogEntry["level"][];
+	fields?: Record<string, string>; // field path (or trace_id, request_id, stream, source) to value
+}
+
+function appendLogFilter(params: URLSearchParams, filter?: LogFilter) {
+	if (filter?.levels?.length) {
+		params.append("level", filter.levels.join(","));
+	}
+	for (const [name, value] of Object.entries(filter?.fields ?? {})) {
+		params.append("field", `${name}=${value}`);
+	}
 }
 
 export interface LogsResponse {
@@ -494,6 +513,7 @@ export const infrastructureApi = {
 		serviceName: string,
 		limit: number = 100,
 		nextToken?: string,
+		filter?: LogFilter,
 	): Promise<LogsResponse> {
 		const params = new URLSearchParams({
 			env,
@@ -503,6 +523,7 @@ export const infrastructureApi = {
 		if (nextToken) {
 			params.append("nextToken", nextToken);
 		}
+		appendLogFilter(params, filter);
 
 		const response = await fetch(`${API_BASE_URL}/api/logs?${params}`);
 		if (!response.ok) {
@@ -518,16 +539,20 @@ export const infrastructureApi = {
 		onMessage: (logs: LogEntry[]) => void,
 		onError?: (error: Error) => void,
 		onConnect?: () => void,
+		filter?: LogFilter,
 	): WebSocket {
+		const params = new URLSearchParams({ env, service: serviceName });
+		appendLogFilter(params, filter);
+
 		// Handle both relative and absolute URLs
 		let wsUrl: string;
 		if (API_BASE_URL) {
-			wsUrl = `${API_BASE_URL.replace(/^http/, "ws")}/ws/logs?env=${encodeURIComponent(env)}&service=${encodeURIComponent(serviceName)}`;
+			wsUrl = `${API_BASE_URL.replace(/^http/, "ws")}/ws/logs?${params}`;
 		} else {
 			// If no base URL, use current host with ws protocol
 			const protocol = window.location.protocol === "https:" ? "wss:" : "ws:";
 			const host = window.location.host;
-			wsUrl = `${protocol}//${host}/ws/logs?env=${encodeURIComponent(env)}&service=${encodeURIComponent(serviceName)}`;
+			wsUrl = `${protocol}//${host}/ws/logs?${params}`;
 		}
 
 		const ws = new WebSocket(wsUrl);
@@ -25,6 +25,64 @@ export interface YamlInfrastructureConfig {
 	// VPC Configuration
 	use_default_vpc?: boolean;
 	vpc_cidr?: string;
+	network?: {
+		private_subnets: boolean; // ECS tasks and RDS in private subnets (custom VPC only)
+		nat_mode?: "single" | "per_az" | "instance";
+		nat_instance_type?: string; // For nat_mode "instance", default t4g.nano
+		vpc_endpoints?: ("s3" | "dynamodb" | "ecr" | "logs" | "ssm" | "secretsmanager" | "sts")[];
+	};
+
+	// WAF (attached to the ALB)
+	waf?: {
+		enabled: boolean;
+		managed_rules?: ("core_rule_set" | "known_bad_inputs" | "ip_reputation")[]; // default: all
+		rate_limit?: number; // requests per IP per 5 minutes, 0 disables
+		allow_ips?: string[]; // CIDRs
+		deny_ips?: string[]; // CIDRs
+	};
+
+	// Static sites (S3 + CloudFront, published with `meroku site publish`)
+	static_sites?: Array<{
+		name: string;
+		subdomain_prefix?: string; // requires domain.enabled
+		spa?: boolean; // serve index_document for unknown paths
+		index_document?: string; // default index.html
+		price_class?: "PriceClass_100" | "PriceClass_200" | "PriceClass_All";
+	}>;
+
+	// Extra Route53 records in the environment's zone (`meroku dns records apply`)
+	dns_records?: Array<{
+		name: string; // relative to the zone, "@" for the apex
+		type: "A" | "AAAA" | "CNAME" | "TXT" | "MX" | "SRV" | "CAA" | "NS";
+		values?: string[];
+		ttl?: number; // default 300
+		alias?: {
+			dns_name: string;
+			hosted_zone_id: string;
+			evaluate_target_health?: boolean;
+		};
+	}>;
+
+	// Field names of JSON log lines per service ("backend" included), defaults cover common loggers
+	log_fields?: Record<
+		string,
+		{
+			level?: string[]; // dots for nested fields, e.g. log.level
+			message?: string[];
+			trace_id?: string[];
+			request_id?: string[];
+		}
+	>;
+
+	// AWS credentials for the AI troubleshooting agent (read-only unless write access is granted)
+	agent?: {
+		role_arn?: string; // role assumed for agent sessions
+		write_role_arn?: string; // role assumed once write access is granted, default role_arn
+		external_id?: string;
+		read_policy_arns?: string[]; // session policies for reads, default ReadOnlyAccess
+		session_duration?: number; // seconds, 900-43200 (default 3600)
+		allow_unscoped_profile?: boolean; // use the profile unscoped when read-only credentials can't be created
+	};
 
 	// API Configuration
 	api_domain?: string;
@@ -130,6 +188,9 @@ export interface YamlInfrastructureConfig {
 		deletion_protection?: boolean; // Prevent accidental deletion
 		skip_final_snapshot?: boolean; // Snapshot on delete
 		iam_database_authentication_enabled?: boolean; // Enable IAM authentication
+		backup_retention_period?: number; // Days of automated backups (0-35, Aurora 1-35)
+		backup_window?: string; // UTC window, e.g. "03:00-04:00"
+		endpoint_override?: string; // Database host for the backend, set after `meroku db restore --repoint`
 	};
 
 	// Authentication Configuration